@@ -9,7 +9,21 @@
 
 package cluster
 
+import (
+	"time"
+)
+
 type Config struct {
 	Disabled bool   `config:"disabled"`
 	Address  string `config:"address"`
+
+	// Identifier 本节点在集群内的唯一标识 默认为 hostname+address
+	Identifier string `config:"identifier"`
+
+	// Peers 集群其他节点的地址 用于同步 distributed 限流器的消耗情况
+	// 与 Forward 接口一致 节点间使用不加密不认证的 gRPC 连接 仅适用于可信的内部网络
+	Peers []string `config:"peers"`
+
+	// SyncInterval 限流配额同步周期
+	SyncInterval time.Duration `config:"sync_interval"`
 }
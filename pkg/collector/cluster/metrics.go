@@ -55,6 +55,15 @@ var (
 		},
 		[]string{"record_type", "processor", "token", "code"},
 	)
+
+	quotaSyncFailedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "cluster_quota_sync_failed_total",
+			Help:      "Cluster quota sync failed total",
+		},
+		[]string{"peer"},
+	)
 )
 
 var DefaultMetricMonitor = &metricMonitor{}
@@ -76,3 +85,7 @@ func (m *metricMonitor) ObserveHandledDuration(t time.Time, token string) {
 func (m *metricMonitor) IncFailedCheckFailedCounter(processor, token string, code int) {
 	preCheckFailedTotal.WithLabelValues(define.RecordTraces.S(), processor, token, strconv.Itoa(code))
 }
+
+func (m *metricMonitor) IncQuotaSyncFailedCounter(peer string) {
+	quotaSyncFailedTotal.WithLabelValues(peer).Inc()
+}
@@ -0,0 +1,213 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package cluster
+
+import (
+	"context"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/cluster/pb"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/json"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/ratelimiter"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+const (
+	// RecordTypeQuota 节点间同步限流消耗情况的消息类型 复用 Forward 接口传输
+	RecordTypeQuota = "quota"
+
+	defaultSyncInterval = 5 * time.Second
+)
+
+type quotaMessage struct {
+	Identifier string                       `json:"identifier"`
+	Usages     map[string]ratelimiter.Usage `json:"usages"`
+}
+
+func handleQuota(body []byte) error {
+	var msg quotaMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return err
+	}
+	// peers 配置中包含本节点时会收到自己广播的消息
+	if !ratelimiter.UpdatePeerUsages(msg.Identifier, msg.Usages) {
+		logger.Debugf("ignore quota message from local node %s", msg.Identifier)
+	}
+	return nil
+}
+
+// isLocalAddress 判断 peer 是否指向本节点监听的 address
+//
+// 端口相同且 peer 的主机为监听主机 回环地址或者本机网卡地址时认为是本节点
+func isLocalAddress(peer, address string) bool {
+	if peer == address {
+		return true
+	}
+	peerHost, peerPort, err := net.SplitHostPort(peer)
+	if err != nil {
+		return false
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || peerPort != port {
+		return false
+	}
+	if peerHost == host || peerHost == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(peerHost)
+	if ip == nil {
+		return false
+	}
+	if ip.IsLoopback() {
+		return true
+	}
+
+	// 监听指定地址时只有该地址指向本节点
+	if listenIP := net.ParseIP(host); listenIP != nil && !listenIP.IsUnspecified() {
+		return listenIP.Equal(ip)
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// quotaSyncer 周期性地向集群其他节点广播本节点 distributed 限流器的消耗情况
+type quotaSyncer struct {
+	identifier string
+	interval   time.Duration
+	peers      []string
+
+	mut     sync.Mutex
+	conns   map[string]*grpc.ClientConn
+	clients map[string]pb.ClusterClient
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newQuotaSyncer(c Config) *quotaSyncer {
+	identifier := c.Identifier
+	if identifier == "" {
+		hostname, _ := os.Hostname()
+		identifier = hostname + c.Address
+	}
+	interval := c.SyncInterval
+	if interval <= 0 {
+		interval = defaultSyncInterval
+	}
+	ratelimiter.SetLocalIdentifier(identifier)
+
+	// 所有节点可能共用同一份 peers 配置 广播时跳过本节点
+	peers := make([]string, 0, len(c.Peers))
+	for _, peer := range c.Peers {
+		if isLocalAddress(peer, c.Address) {
+			logger.Infof("skip local address %s in cluster peers", peer)
+			continue
+		}
+		peers = append(peers, peer)
+	}
+
+	return &quotaSyncer{
+		identifier: identifier,
+		interval:   interval,
+		peers:      peers,
+		conns:      make(map[string]*grpc.ClientConn),
+		clients:    make(map[string]pb.ClusterClient),
+		stop:       make(chan struct{}),
+	}
+}
+
+func (qs *quotaSyncer) Start() {
+	qs.wg.Add(1)
+	go func() {
+		defer qs.wg.Done()
+
+		ticker := time.NewTicker(qs.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-qs.stop:
+				return
+			case <-ticker.C:
+				qs.sync()
+			}
+		}
+	}()
+}
+
+func (qs *quotaSyncer) Stop() {
+	close(qs.stop)
+	qs.wg.Wait()
+
+	qs.mut.Lock()
+	defer qs.mut.Unlock()
+	for _, conn := range qs.conns {
+		_ = conn.Close()
+	}
+}
+
+func (qs *quotaSyncer) getClient(peer string) (pb.ClusterClient, error) {
+	qs.mut.Lock()
+	defer qs.mut.Unlock()
+
+	if client, ok := qs.clients[peer]; ok {
+		return client, nil
+	}
+	conn, err := grpc.Dial(peer, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	client := pb.NewClusterClient(conn)
+	qs.conns[peer] = conn
+	qs.clients[peer] = client
+	return client, nil
+}
+
+func (qs *quotaSyncer) sync() {
+	usages := ratelimiter.Sync(qs.interval)
+	body, err := json.Marshal(quotaMessage{Identifier: qs.identifier, Usages: usages})
+	if err != nil {
+		logger.Errorf("failed to marshal quota message, err: %v", err)
+		return
+	}
+
+	req := &pb.ForwardRequest{RecordType: RecordTypeQuota, Body: body}
+	for _, peer := range qs.peers {
+		client, err := qs.getClient(peer)
+		if err != nil {
+			logger.Warnf("failed to dial cluster peer %s, err: %v", peer, err)
+			DefaultMetricMonitor.IncQuotaSyncFailedCounter(peer)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), qs.interval)
+		_, err = client.Forward(ctx, req)
+		cancel()
+		if err != nil {
+			logger.Warnf("failed to sync quota to cluster peer %s, err: %v", peer, err)
+			DefaultMetricMonitor.IncQuotaSyncFailedCounter(peer)
+		}
+	}
+}
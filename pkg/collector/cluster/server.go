@@ -38,6 +38,7 @@ type Server struct {
 	pb.UnimplementedClusterServer
 	address string
 	server  *grpc.Server
+	syncer  *quotaSyncer
 }
 
 func NewServer(conf *confengine.Config) (*Server, error) {
@@ -48,9 +49,15 @@ func NewServer(conf *confengine.Config) (*Server, error) {
 
 	server := grpc.NewServer()
 	pb.RegisterClusterServer(server, &Server{})
+
+	var syncer *quotaSyncer
+	if len(c.Peers) > 0 {
+		syncer = newQuotaSyncer(c)
+	}
 	return &Server{
 		address: c.Address,
 		server:  server,
+		syncer:  syncer,
 	}, nil
 }
 
@@ -78,6 +85,9 @@ func (s *Server) Start() error {
 				logger.Errorf("cluster background tasks got err: %v", err)
 			}
 		}()
+		if s.syncer != nil {
+			s.syncer.Start()
+		}
 		return nil
 	case err := <-errs:
 		return err
@@ -85,6 +95,9 @@ func (s *Server) Start() error {
 }
 
 func (s *Server) Stop() {
+	if s.syncer != nil {
+		s.syncer.Stop()
+	}
 	s.server.Stop()
 }
 
@@ -124,6 +137,12 @@ func Forward(ctx context.Context, req *pb.ForwardRequest) (*pb.ForwardReply, err
 		globalRecords.Push(r)
 		DefaultMetricMonitor.IncHandledCounter(r.Token.Original)
 		DefaultMetricMonitor.ObserveHandledDuration(start, r.Token.Original)
+
+	case RecordTypeQuota:
+		if err := handleQuota(req.Body); err != nil {
+			DefaultMetricMonitor.IncDroppedCounter()
+			return &pb.ForwardReply{Message: "FAILED"}, err
+		}
 	}
 
 	return &pb.ForwardReply{Message: "SUCCESS"}, nil
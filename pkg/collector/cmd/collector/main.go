@@ -21,6 +21,8 @@ import (
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/controller"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/explain"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/utils"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/libgse/beat"
 )
@@ -33,8 +35,20 @@ var (
 )
 
 func main() {
+	// explain 子命令仅做配置解析及试运行 无需初始化 beat
+	if len(os.Args) > 1 && os.Args[1] == "explain" {
+		if err := explain.Run(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to explain: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	settings := instance.Settings{Processing: processing.MakeDefaultSupport(false)}
-	pubConfig := beat.PublishConfig{PublishMode: libbeat.PublishMode(beat.GuaranteedSend)}
+	pubConfig := beat.PublishConfig{
+		PublishMode: libbeat.PublishMode(beat.GuaranteedSend),
+		ACKEvents:   exporter.ACKEvents, // 磁盘队列依赖输出端确认推进消费进度
+	}
 
 	config, err := beat.InitWithPublishConfig(appName, version, pubConfig, settings)
 	if err != nil {
@@ -23,6 +23,7 @@ import (
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/cleaner"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/hook"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/labelstore"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/tap"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/tracestore"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/wait"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/pingserver"
@@ -501,11 +502,14 @@ loop:
 
 			start := time.Now()
 			rtype := task.Record().RecordType
+			taps := tap.Match(task.PipelineName(), task.Record())
 			for i := 0; i < task.StageCount(); i++ {
 				// 任务执行应该事务的 一旦中间某一环执行失败那就整体失败
 				stage := task.StageAt(i)
 				logger.Debugf("process original stage: %s, recordType: %s", stage, rtype)
+				taps.Capture(stage, tap.PositionBefore, task.Record(), nil)
 				derivedRecord, err := c.pipelineMgr.GetProcessor(stage).Process(task.Record())
+				taps.Capture(stage, tap.PositionAfter, task.Record(), err)
 				if err == define.ErrSkipEmptyRecord {
 					token := task.Record().Token
 					DefaultMetricMonitor.IncSkippedCounter(task.PipelineName(), rtype, token.GetDataID(rtype), stage, token.Original)
@@ -564,12 +568,15 @@ loop:
 
 			start := time.Now()
 			rtype := task.Record().RecordType
+			taps := tap.Match(task.PipelineName(), task.Record())
 			for i := 0; i < task.StageCount(); i++ {
 				// 任务执行应该事务的 一旦中间某一环执行失败那就整体失败
 				// 无需再关注是否为 derived 类型
 				stage := task.StageAt(i)
 				logger.Debugf("process derived stage: %s, recordType: %+v", stage, rtype)
+				taps.Capture(stage, tap.PositionBefore, task.Record(), nil)
 				_, err := c.pipelineMgr.GetProcessor(stage).Process(task.Record())
+				taps.Capture(stage, tap.PositionAfter, task.Record(), err)
 				if err == define.ErrSkipEmptyRecord {
 					token := task.Record().Token
 					logger.Warnf("skip empty record '%s' at stage: %v, token: %+v, err: %v", rtype, stage, token, err)
@@ -16,24 +16,32 @@ import (
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/dbfilter"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/forwarder"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/licensechecker"
+	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/logsderiver"
+	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/logsparser"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/metricsfilter"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/pproftranslator"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/probefilter"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/proxyvalidator"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/ratelimiter"
+	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/redactor"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/resourcefilter"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/sampler"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/servicediscover"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/tokenchecker"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/tracesderiver"
+	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/transformer"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/beat"
+	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/fluentforward"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/fta"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/jaeger"
+	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/loki"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/otlp"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/pushgateway"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/pyroscope"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/remotewrite"
+	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/scrape"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/skywalking"
+	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/statsd"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/tars"
 	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/zipkin"
 )
@@ -14,6 +14,9 @@ const (
 	AttributeKeyPrefix = "attributes."
 	ConstKeyPrefix     = "const."
 
+	// AttributeSamplingAdjustedCount 采样后每条 span 代表的原始 span 数量（即采样概率的倒数）
+	AttributeSamplingAdjustedCount = "sampling.adjusted_count"
+
 	ProcessorApdexCalculator = "apdex_calculator"
 	ProcessorAttributeFilter = "attribute_filter"
 	ProcessorMetricsFilter   = "metrics_filter"
@@ -29,4 +32,8 @@ const (
 	ProcessorDbFilter        = "db_filter"
 	ProcessorProbeFilter     = "probe_filter"
 	ProcessorPprofTranslator = "pprof_translator"
+	ProcessorTransformer     = "transformer"
+	ProcessorRedactor        = "redactor"
+	ProcessorLogsParser      = "logs_parser"
+	ProcessorLogsDeriver     = "logs_deriver"
 )
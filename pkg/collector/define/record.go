@@ -33,17 +33,21 @@ const (
 	ContentTypeJson     = "application/json"
 	ContentTypeText     = "text/plain; charset=utf-8"
 
-	SourceFta         = "fta"
-	SourceJaeger      = "jaeger"
-	SourcePyroscope   = "pyroscope"
-	SourceOtlp        = "otlp"
-	SourcePushGateway = "pushgateway"
-	SourceRemoteWrite = "remotewrite"
-	SourceZipkin      = "zipkin"
-	SourceProxy       = "proxy"
-	SourceSkywalking  = "skywalking"
-	SourceBeat        = "beat"
-	SourceTars        = "tars"
+	SourceFta           = "fta"
+	SourceJaeger        = "jaeger"
+	SourcePyroscope     = "pyroscope"
+	SourceOtlp          = "otlp"
+	SourcePushGateway   = "pushgateway"
+	SourceRemoteWrite   = "remotewrite"
+	SourceZipkin        = "zipkin"
+	SourceProxy         = "proxy"
+	SourceSkywalking    = "skywalking"
+	SourceBeat          = "beat"
+	SourceTars          = "tars"
+	SourceScrape        = "scrape"
+	SourceStatsd        = "statsd"
+	SourceLoki          = "loki"
+	SourceFluentForward = "fluentforward"
 
 	KeyToken    = "X-BK-TOKEN"
 	KeyDataID   = "X-BK-DATA-ID"
@@ -123,6 +127,8 @@ const (
 	RequestICMP    RequestType = "icmp"
 	RequestDerived RequestType = "derived"
 	RequestTars    RequestType = "tars"
+	RequestUdp     RequestType = "udp"
+	RequestTcp     RequestType = "tcp"
 )
 
 type RequestClient struct {
@@ -158,6 +164,35 @@ type PushGatewayData struct {
 
 type RemoteWriteData struct {
 	Timeseries []prompb.TimeSeries
+	Histograms []RemoteWriteHistogram  // remote-write 2.0 native histograms
+	Metadata   []prompb.MetricMetadata // remote-write 2.0 中每条 series 携带的元数据
+}
+
+// RemoteWriteHistogram 同一 series 的 native histograms
+type RemoteWriteHistogram struct {
+	Labels     []prompb.Label
+	Histograms []NativeHistogram
+}
+
+// NativeHistogram prometheus native histogram 桶计数已统一还原为绝对值
+// Schema 为 -53 时表示自定义桶 边界由 CustomValues 描述
+type NativeHistogram struct {
+	Count          float64
+	Sum            float64
+	Schema         int32
+	ZeroThreshold  float64
+	ZeroCount      float64
+	PositiveSpans  []BucketSpan
+	PositiveCounts []float64
+	NegativeSpans  []BucketSpan
+	NegativeCounts []float64
+	CustomValues   []float64
+	Timestamp      int64
+}
+
+type BucketSpan struct {
+	Offset int32
+	Length uint32
 }
 
 const (
@@ -393,8 +428,11 @@ func TokenFromString(s string) (string, string) {
 }
 
 const (
-	FormatPprof = "pprof"
-	FormatJFR   = "jfr"
+	FormatPprof     = "pprof"
+	FormatJFR       = "jfr"
+	FormatCollapsed = "collapsed"
+	FormatGoTrace   = "gotrace"
+	FormatOTLP      = "otlp"
 )
 
 // ProfileMetadata Profile 元数据格式
@@ -418,16 +456,42 @@ type ProfileJfrFormatOrigin struct {
 	Labels []byte
 }
 
+// ProfileCollapsedFormatOrigin 折叠栈格式 每行为 `frame1;frame2;frame3 count`
+type ProfileCollapsedFormatOrigin []byte
+
+// ProfileGoTraceFormatOrigin Go runtime/trace 数据 仅提取其中的 CPU 采样
+type ProfileGoTraceFormatOrigin []byte
+
+// ProfileOtlpFormatOrigin OTLP profiles 在接收阶段已经完成解码
+type ProfileOtlpFormatOrigin struct {
+	Profile *profile.Profile
+}
+
 type ProfilesRawData struct {
 	Metadata ProfileMetadata
 	// Data Profile 原始数据
 	// Format = pprof -> PprofFormatOrigin
 	// Format = jfr -> JfrFormatOrigin
+	// Format = collapsed -> CollapsedFormatOrigin
+	// Format = gotrace -> GoTraceFormatOrigin
+	// Format = otlp -> OtlpFormatOrigin
 	Data any
 }
 
+// ProfileSpan 带有 span 标签的样本汇总 用于关联 Span 与 Profile
+type ProfileSpan struct {
+	TraceID  string
+	SpanID   string
+	SpanName string
+	Type     string // 样本类型 如 cpu/alloc_space
+	Unit     string
+	Value    int64
+}
+
 // ProfilesData 为 ProfilesRawData 经过处理后的数据格式
 type ProfilesData struct {
 	Profiles []*profile.Profile
 	Metadata ProfileMetadata
+	// Spans 与 Profiles 按下标一一对应 仅在开启 span 关联时填充
+	Spans [][]ProfileSpan
 }
@@ -0,0 +1,365 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package explain
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
+	_ "github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/controller" // 注册所有组件 与运行时保持一致
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter/converter"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver/otlp"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+const (
+	appName = "bk-collector"
+
+	// mainConfigName 部署目录下的主配置文件名
+	mainConfigName = "bk-collector.conf"
+)
+
+// Options explain 子命令参数
+type Options struct {
+	Config     string // 主配置文件路径或其所在目录
+	Token      string
+	DataID     int
+	RecordType string
+	Input      string // 可选 OTLP/JSON 样例文件
+	LogLevel   string
+}
+
+// ParseOptions 解析命令行参数
+func ParseOptions(args []string) (Options, error) {
+	var opts Options
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	fs.StringVar(&opts.Config, "config", ".", "main config file or the directory containing "+mainConfigName)
+	fs.StringVar(&opts.Token, "token", "", "token to explain")
+	fs.IntVar(&opts.DataID, "dataid", 0, "resolve token by dataid if token is not provided")
+	fs.StringVar(&opts.RecordType, "type", string(define.RecordTraces), "record type of the pipeline")
+	fs.StringVar(&opts.Input, "input", "", "optional OTLP/JSON sample file to run through the pipeline")
+	fs.StringVar(&opts.LogLevel, "log-level", logger.ErrorLevelDesc, "logger level")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+
+	if opts.Token == "" && opts.DataID <= 0 {
+		return opts, errors.New("either token or dataid is required")
+	}
+	return opts, nil
+}
+
+// Run 执行 explain 子命令 结果输出至 w
+func Run(args []string, w io.Writer) error {
+	opts, err := ParseOptions(args)
+	if err != nil {
+		return err
+	}
+	return Explain(opts, w)
+}
+
+// Explain 输出 token 在指定数据类型下实际生效的流水线 并可选地试运行样例数据（不发送）
+func Explain(opts Options, w io.Writer) error {
+	logger.SetLoggerLevel(opts.LogLevel)
+
+	conf, err := loadConfig(opts.Config)
+	if err != nil {
+		return err
+	}
+
+	mgr, err := pipeline.New(conf)
+	if err != nil {
+		return errors.Wrap(err, "failed to create pipeline manager")
+	}
+
+	rtype := define.RecordType(opts.RecordType)
+	pl := mgr.GetPipeline(rtype)
+	if pl == nil {
+		return errors.Errorf("no pipeline found for record type '%s'", rtype)
+	}
+
+	token := opts.Token
+	if token == "" {
+		token, err = resolveToken(mgr, pl, int32(opts.DataID))
+		if err != nil {
+			return err
+		}
+	}
+
+	printPipeline(w, mgr, pl, token)
+	if opts.Input == "" {
+		return nil
+	}
+
+	b, err := os.ReadFile(opts.Input)
+	if err != nil {
+		return err
+	}
+	var expConf exporter.Config
+	if conf.Has(define.ConfigFieldExporter) {
+		if err := conf.UnpackChild(define.ConfigFieldExporter, &expConf); err != nil {
+			return err
+		}
+	}
+	return dryRun(w, mgr, converter.NewCommonConverter(&expConf.Converter), rtype, token, b)
+}
+
+// loadConfig 加载主配置 path 为目录时读取目录下的 bk-collector.conf
+func loadConfig(path string) (*confengine.Config, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		path = filepath.Join(path, mainConfigName)
+	}
+
+	conf, err := confengine.LoadConfigPath(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load config '%s'", path)
+	}
+
+	// 完整的 beat 配置文件中 collector 配置位于 bk-collector 字段下
+	if conf.Has(appName) {
+		return conf.Child(appName)
+	}
+	return conf, nil
+}
+
+// subConfigTokens 返回流水线 Processor 子配置中出现的所有 token
+func subConfigTokens(mgr *pipeline.Manager, pl pipeline.Pipeline) []string {
+	set := make(map[string]struct{})
+	for _, name := range pl.AllProcessors() {
+		inst := mgr.GetProcessor(name)
+		if inst == nil {
+			continue
+		}
+		for _, sc := range inst.SubConfigs() {
+			set[sc.Token] = struct{}{}
+		}
+	}
+
+	tokens := make([]string, 0, len(set))
+	for k := range set {
+		tokens = append(tokens, k)
+	}
+	sort.Strings(tokens)
+	return tokens
+}
+
+// tokenChecker 返回流水线中的 token_checker 实例
+func tokenChecker(mgr *pipeline.Manager, pl pipeline.Pipeline) processor.Instance {
+	for _, name := range pl.PreCheckProcessors() {
+		inst := mgr.GetProcessor(name)
+		if inst != nil && inst.Name() == define.ProcessorTokenChecker {
+			return inst
+		}
+	}
+	return nil
+}
+
+// decodeToken 使用流水线的 token_checker 解析 token
+func decodeToken(inst processor.Instance, token string) (define.Token, error) {
+	r := &define.Record{
+		RecordType: define.RecordUndefined,
+		Token:      define.Token{Original: token},
+	}
+	if _, err := inst.Process(r); err != nil {
+		return define.Token{}, err
+	}
+	return r.Token, nil
+}
+
+// resolveToken 在子配置 token 中查找解析后 dataid 匹配的 token
+func resolveToken(mgr *pipeline.Manager, pl pipeline.Pipeline, dataID int32) (string, error) {
+	inst := tokenChecker(mgr, pl)
+	if inst == nil {
+		return "", errors.Errorf("pipeline '%s' has no %s processor", pl.Name(), define.ProcessorTokenChecker)
+	}
+
+	for _, token := range subConfigTokens(mgr, pl) {
+		decoded, err := decodeToken(inst, token)
+		if err != nil {
+			continue
+		}
+		if decoded.GetDataID(pl.RecordType()) == dataID {
+			return token, nil
+		}
+	}
+	return "", errors.Errorf("no token found for dataid %d", dataID)
+}
+
+func printPipeline(w io.Writer, mgr *pipeline.Manager, pl pipeline.Pipeline, token string) {
+	fmt.Fprintf(w, "pipeline: %s (%s)\n", pl.Name(), pl.RecordType())
+	fmt.Fprintf(w, "token: %s\n", token)
+	if inst := tokenChecker(mgr, pl); inst != nil {
+		decoded, err := decodeToken(inst, token)
+		if err != nil {
+			fmt.Fprintf(w, "decoded: <error: %v>\n", err)
+		} else {
+			fmt.Fprintf(w, "decoded: dataid=%d, biz_id=%d, app_name=%s\n", decoded.GetDataID(pl.RecordType()), decoded.BizId, decoded.AppName)
+		}
+	}
+
+	fmt.Fprintln(w, "processors:")
+	for i, name := range pl.AllProcessors() {
+		inst := mgr.GetProcessor(name)
+		if inst == nil {
+			fmt.Fprintf(w, "  %d. %s <not found>\n", i+1, name)
+			continue
+		}
+
+		var flags []string
+		if inst.IsPreCheck() {
+			flags = append(flags, "precheck")
+		}
+		if inst.IsDerived() {
+			flags = append(flags, "derived")
+		}
+		fmt.Fprintf(w, "  %d. %s", i+1, name)
+		if len(flags) > 0 {
+			fmt.Fprintf(w, " [%s]", strings.Join(flags, ","))
+		}
+		fmt.Fprintln(w)
+
+		// default 级别子配置会整体覆盖主配置 service/instance 级别则按数据中的 ID 命中
+		var subConfigs []processor.SubConfigProcessor
+		var overridden bool
+		for _, sc := range inst.SubConfigs() {
+			if sc.Token != token {
+				continue
+			}
+			subConfigs = append(subConfigs, sc)
+			if sc.Type == define.SubConfigFieldDefault {
+				overridden = true
+			}
+		}
+
+		if overridden {
+			printConfig(w, "main config (overridden)", inst.MainConfig())
+		} else {
+			printConfig(w, "main config", inst.MainConfig())
+		}
+		for _, sc := range subConfigs {
+			title := "sub config (" + sc.Type
+			if sc.ID != "" {
+				title += "=" + sc.ID
+			}
+			printConfig(w, title+")", sc.Config.Config)
+		}
+	}
+}
+
+func printConfig(w io.Writer, title string, conf map[string]interface{}) {
+	if len(conf) == 0 {
+		fmt.Fprintf(w, "     %s: {}\n", title)
+		return
+	}
+
+	b, err := yaml.Marshal(conf)
+	if err != nil {
+		fmt.Fprintf(w, "     %s: <error: %v>\n", title, err)
+		return
+	}
+	fmt.Fprintf(w, "     %s:\n", title)
+	for _, line := range strings.Split(strings.TrimRight(string(b), "\n"), "\n") {
+		fmt.Fprintf(w, "       %s\n", line)
+	}
+}
+
+// dryRun 按 controller 的调度逻辑试运行样例数据 输出转换后的事件而不发送
+func dryRun(w io.Writer, mgr *pipeline.Manager, conv converter.Converter, rtype define.RecordType, token string, b []byte) error {
+	data, err := otlp.UnmarshalRecordData(otlp.JsonEncoder(), rtype, b)
+	if err != nil {
+		return errors.Wrap(err, "failed to unmarshal input")
+	}
+
+	record := &define.Record{
+		RecordType:    rtype,
+		RequestType:   define.RequestHttp,
+		RequestClient: define.RequestClient{IP: "127.0.0.1"},
+		Token:         define.Token{Original: token},
+		Data:          data,
+	}
+
+	fmt.Fprintln(w, "dry run:")
+	code, processorName, err := pipeline.Validator{}.Validate(record)
+	if err != nil {
+		fmt.Fprintf(w, "  rejected by %s: code=%d, err=%v\n", processorName, code, err)
+		return nil
+	}
+
+	var records []*define.Record
+	derived, ok := runProcessors(w, mgr, record)
+	if ok {
+		records = append(records, record)
+	}
+	for _, r := range derived {
+		// 与 controller 一致 派生数据不再产生派生数据
+		r.Unwrap()
+		if _, ok := runProcessors(w, mgr, r); ok {
+			records = append(records, r)
+		}
+	}
+
+	var total int
+	for _, r := range records {
+		conv.Convert(r, func(events ...define.Event) {
+			for _, event := range events {
+				total++
+				b, err := json.Marshal(event.Data())
+				if err != nil {
+					fmt.Fprintf(w, "  [%s] dataid=%d <error: %v>\n", event.RecordType(), event.DataId(), err)
+					continue
+				}
+				fmt.Fprintf(w, "  [%s] dataid=%d %s\n", event.RecordType(), event.DataId(), b)
+			}
+		})
+	}
+	fmt.Fprintf(w, "total events: %d\n", total)
+	return nil
+}
+
+// runProcessors 依次执行 record 对应流水线中的调度类型 Processor
+// 返回派生数据以及 record 是否需要导出
+func runProcessors(w io.Writer, mgr *pipeline.Manager, record *define.Record) ([]*define.Record, bool) {
+	pl := mgr.GetPipeline(record.RecordType)
+	if pl == nil {
+		fmt.Fprintf(w, "  no pipeline found for record type '%s'\n", record.RecordType)
+		return nil, false
+	}
+
+	var derived []*define.Record
+	for _, stage := range pl.SchedProcessors() {
+		r, err := mgr.GetProcessor(stage).Process(record)
+		if err != nil {
+			fmt.Fprintf(w, "  [%s] stopped at %s: %v\n", record.RecordType, stage, err)
+			return derived, false
+		}
+		if r != nil {
+			derived = append(derived, r)
+		}
+	}
+	return derived, true
+}
@@ -0,0 +1,161 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package explain
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const mainContent = `
+bk-collector:
+  apm:
+    patterns:
+      - "%s/bk-collector-*.conf"
+
+  processor:
+    - name: "token_checker/fixed"
+      config:
+        type: "fixed"
+        fixed_token: "token1"
+        resource_key: "bk.data.token"
+        traces_dataid: 1000
+
+    - name: "resource_filter/add"
+      config:
+        add:
+          - label: "env"
+            value: "main"
+
+  pipeline:
+    - name: "traces_pipeline/common"
+      type: "traces"
+      processors:
+        - "token_checker/fixed"
+        - "resource_filter/add"
+`
+
+const subContent = `
+type: "subconfig"
+token: "token1"
+
+default:
+  processor:
+    - name: "token_checker/fixed"
+      config:
+        type: "fixed"
+        fixed_token: "token1"
+        resource_key: "bk.data.token"
+        traces_dataid: 1009
+
+    - name: "resource_filter/add"
+      config:
+        add:
+          - label: "env"
+            value: "sub"
+`
+
+const tracesContent = `
+{
+  "resourceSpans": [
+    {
+      "resource": {
+        "attributes": [
+          {"key": "service.name", "value": {"stringValue": "demo"}}
+        ]
+      },
+      "scopeSpans": [
+        {
+          "spans": [
+            {
+              "traceId": "056bf008474fe2c3a45aa1987de78f48",
+              "spanId": "6a8dae0c50af3614",
+              "name": "span1",
+              "kind": 2,
+              "startTimeUnixNano": "1700000000000000000",
+              "endTimeUnixNano": "1700000001000000000"
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}
+`
+
+func writeConfigDir(t *testing.T) string {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, mainConfigName), []byte(fmt.Sprintf(mainContent, dir)), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "bk-collector-token1.conf"), []byte(subContent), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "traces.json"), []byte(tracesContent), 0o644))
+	return dir
+}
+
+func TestParseOptions(t *testing.T) {
+	t.Run("Missing Token", func(t *testing.T) {
+		_, err := ParseOptions([]string{"-config", "."})
+		assert.Error(t, err)
+	})
+
+	t.Run("DataID", func(t *testing.T) {
+		opts, err := ParseOptions([]string{"-dataid", "1001", "-type", "metrics"})
+		assert.NoError(t, err)
+		assert.Equal(t, 1001, opts.DataID)
+		assert.Equal(t, "metrics", opts.RecordType)
+	})
+}
+
+func TestExplain(t *testing.T) {
+	dir := writeConfigDir(t)
+
+	t.Run("Token", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		assert.NoError(t, Run([]string{"-config", dir, "-token", "token1"}, buf))
+
+		out := buf.String()
+		assert.Contains(t, out, "pipeline: traces_pipeline/common (traces)")
+		assert.Contains(t, out, "decoded: dataid=1009")
+		assert.Contains(t, out, "1. token_checker/fixed [precheck]")
+		assert.Contains(t, out, "2. resource_filter/add")
+		assert.Contains(t, out, "main config (overridden)")
+		assert.Contains(t, out, "sub config (default)")
+		assert.NotContains(t, out, "dry run:")
+	})
+
+	t.Run("DataID", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		assert.NoError(t, Run([]string{"-config", filepath.Join(dir, mainConfigName), "-dataid", "1009"}, buf))
+		assert.Contains(t, buf.String(), "token: token1")
+	})
+
+	t.Run("DataID NotFound", func(t *testing.T) {
+		assert.Error(t, Run([]string{"-config", dir, "-dataid", "1000"}, &bytes.Buffer{}))
+	})
+
+	t.Run("Unknown RecordType", func(t *testing.T) {
+		assert.Error(t, Run([]string{"-config", dir, "-token", "token1", "-type", "metrics"}, &bytes.Buffer{}))
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		assert.NoError(t, Run([]string{"-config", dir, "-token", "token1", "-input", filepath.Join(dir, "traces.json")}, buf))
+
+		out := buf.String()
+		assert.Contains(t, out, "dry run:")
+		assert.Contains(t, out, "[traces] dataid=1009")
+		assert.Contains(t, out, `"env":"sub"`)
+		assert.Contains(t, out, "total events: 1")
+	})
+}
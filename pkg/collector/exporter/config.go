@@ -14,6 +14,8 @@ import (
 
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter/converter"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter/otlp"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter/queue"
 )
 
@@ -27,7 +29,9 @@ const (
 )
 
 type Config struct {
-	Queue queue.Config `config:"queue"`
+	Queue     queue.Config     `config:"queue"`
+	Converter converter.Config `config:"converter"`
+	Otlp      otlp.Config      `config:"otlp"`
 }
 
 func (c *Config) Validate() {
@@ -46,6 +50,7 @@ func (c *Config) Validate() {
 	if c.Queue.FlushInterval <= 0 {
 		c.Queue.FlushInterval = defaultFlushInterval
 	}
+	c.Queue.Disk.Validate()
 }
 
 type SubConfig struct {
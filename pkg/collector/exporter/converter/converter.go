@@ -62,24 +62,37 @@ type EventConverter interface {
 	ToDataID(*define.Record) int32
 }
 
-func NewCommonConverter() Converter {
-	return commonConverter{}
+// NewCommonConverter conf 为空时使用默认配置
+func NewCommonConverter(conf *Config) Converter {
+	c := Config{}
+	if conf != nil {
+		c = *conf
+	}
+	c.Validate()
+
+	return commonConverter{
+		metrics:     metricsConverter{histogram: c.Histogram},
+		remoteWrite: remoteWriteConverter{histogram: c.Histogram},
+	}
 }
 
-type commonConverter struct{}
+type commonConverter struct {
+	metrics     EventConverter
+	remoteWrite EventConverter
+}
 
 func (c commonConverter) Convert(record *define.Record, f define.GatherFunc) {
 	switch record.RecordType {
 	case define.RecordTraces:
 		TracesConverter.Convert(record, f)
 	case define.RecordMetrics:
-		MetricsConverter.Convert(record, f)
+		c.metrics.Convert(record, f)
 	case define.RecordLogs:
 		LogsConverter.Convert(record, f)
 	case define.RecordPushGateway:
 		PushGatewayConverter.Convert(record, f)
 	case define.RecordRemoteWrite:
-		RemoteWriteConverter.Convert(record, f)
+		c.remoteWrite.Convert(record, f)
 	case define.RecordProxy:
 		ProxyConverter.Convert(record, f)
 	case define.RecordPingserver:
@@ -0,0 +1,203 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package converter
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+)
+
+const (
+	// HistogramModeExponential 按照指数桶的实际边界输出 `_bucket` 不丢失精度
+	HistogramModeExponential = "exponential"
+
+	// HistogramModeFixed 降采样到固定的桶边界 便于与 explicit histogram 聚合
+	HistogramModeFixed = "fixed"
+
+	// customBucketsSchema prometheus native histogram 自定义桶边界的 schema
+	customBucketsSchema = -53
+)
+
+type HistogramConfig struct {
+	Mode    string    `config:"mode"`
+	Buckets []float64 `config:"buckets"`
+}
+
+type Config struct {
+	Histogram HistogramConfig `config:"histogram"`
+}
+
+func (c *Config) Validate() {
+	if c.Histogram.Mode != HistogramModeFixed {
+		c.Histogram.Mode = HistogramModeExponential
+	}
+	if len(c.Histogram.Buckets) == 0 {
+		c.Histogram.Buckets = prometheus.DefBuckets
+	}
+	buckets := make([]float64, len(c.Histogram.Buckets))
+	copy(buckets, c.Histogram.Buckets)
+	sort.Float64s(buckets)
+	c.Histogram.Buckets = buckets
+}
+
+// expBucket 描述指数桶的上边界以及桶内计数（非累积）
+type expBucket struct {
+	upper float64
+	count float64
+}
+
+// leBucket 描述累积后的 `_bucket` 指标
+type leBucket struct {
+	le    string
+	value float64
+}
+
+func formatLe(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// exponentialBase 返回 scale 对应的底数 base = 2^(2^-scale)
+func exponentialBase(scale int32) float64 {
+	return math.Pow(2, math.Pow(2, -float64(scale)))
+}
+
+// exponentialBuckets 将指数桶展开为按上边界升序排列的桶列表
+//
+// 正数桶 index 表示区间 (base^index, base^(index+1)]
+// 负数桶 index 表示区间 [-base^(index+1), -base^index)
+// offset 为第一个桶的 index 在 prometheus native histogram 中需要额外加 1
+func exponentialBuckets(scale int32, zeroThreshold, zeroCount float64, posOffset int32, pos []float64, negOffset int32, neg []float64) []expBucket {
+	base := exponentialBase(scale)
+	buckets := make([]expBucket, 0, len(pos)+len(neg)+1)
+
+	for i := len(neg) - 1; i >= 0; i-- {
+		index := negOffset + int32(i)
+		buckets = append(buckets, expBucket{upper: -math.Pow(base, float64(index)), count: neg[i]})
+	}
+
+	if zeroCount > 0 || len(buckets) > 0 {
+		buckets = append(buckets, expBucket{upper: zeroThreshold, count: zeroCount})
+	}
+
+	for i := 0; i < len(pos); i++ {
+		index := posOffset + int32(i)
+		buckets = append(buckets, expBucket{upper: math.Pow(base, float64(index+1)), count: pos[i]})
+	}
+	return buckets
+}
+
+func uint64sToFloat64s(values []uint64) []float64 {
+	dst := make([]float64, 0, len(values))
+	for _, v := range values {
+		dst = append(dst, float64(v))
+	}
+	return dst
+}
+
+// otlpExponentialBuckets 展开 OTLP ExponentialHistogram 数据点
+func otlpExponentialBuckets(dp pmetric.ExponentialHistogramDataPoint) []expBucket {
+	pos := dp.Positive()
+	neg := dp.Negative()
+	return exponentialBuckets(
+		dp.Scale(),
+		0,
+		float64(dp.ZeroCount()),
+		pos.Offset(),
+		uint64sToFloat64s(pos.MBucketCounts()),
+		neg.Offset(),
+		uint64sToFloat64s(neg.MBucketCounts()),
+	)
+}
+
+// spansToCounts 根据 spans 将稀疏桶展开为连续桶 空隙处补 0 返回首个桶的 index
+func spansToCounts(spans []define.BucketSpan, counts []float64) (int32, []float64) {
+	if len(spans) == 0 {
+		return 0, nil
+	}
+
+	first := spans[0].Offset
+	var dst []float64
+	var n int
+	for i, span := range spans {
+		if i > 0 {
+			for j := int32(0); j < span.Offset; j++ {
+				dst = append(dst, 0)
+			}
+		}
+		for j := uint32(0); j < span.Length && n < len(counts); j++ {
+			dst = append(dst, counts[n])
+			n++
+		}
+	}
+	return first, dst
+}
+
+// nativeBuckets 展开 prometheus native histogram
+//
+// native histogram 桶 index 表示区间 (base^(index-1), base^index] 与 OTLP 相差 1
+func nativeBuckets(h define.NativeHistogram) []expBucket {
+	posOffset, pos := spansToCounts(h.PositiveSpans, h.PositiveCounts)
+	if h.Schema == customBucketsSchema {
+		buckets := make([]expBucket, 0, len(pos))
+		for i, count := range pos {
+			index := int(posOffset) + i
+			upper := math.Inf(1)
+			if index >= 0 && index < len(h.CustomValues) {
+				upper = h.CustomValues[index]
+			}
+			buckets = append(buckets, expBucket{upper: upper, count: count})
+		}
+		return buckets
+	}
+
+	negOffset, neg := spansToCounts(h.NegativeSpans, h.NegativeCounts)
+	return exponentialBuckets(h.Schema, h.ZeroThreshold, h.ZeroCount, posOffset-1, pos, negOffset-1, neg)
+}
+
+// toLeBuckets 将桶列表转换为累积的 `_bucket` 指标 最后总会追加 +Inf 桶
+func toLeBuckets(conf HistogramConfig, buckets []expBucket, count float64) []leBucket {
+	var items []leBucket
+	switch conf.Mode {
+	case HistogramModeFixed:
+		// 桶内样本计入第一个不小于其上边界的固定桶 超出所有固定桶的样本只计入 +Inf
+		fixed := make([]float64, len(conf.Buckets))
+		for _, b := range buckets {
+			idx := sort.SearchFloat64s(conf.Buckets, b.upper)
+			if idx < len(fixed) {
+				fixed[idx] += b.count
+			}
+		}
+		var cumulative float64
+		items = make([]leBucket, 0, len(fixed)+1)
+		for i, c := range fixed {
+			cumulative += c
+			items = append(items, leBucket{le: formatLe(conf.Buckets[i]), value: cumulative})
+		}
+
+	default:
+		var cumulative float64
+		items = make([]leBucket, 0, len(buckets)+1)
+		for _, b := range buckets {
+			cumulative += b.count
+			if math.IsInf(b.upper, 1) {
+				continue
+			}
+			items = append(items, leBucket{le: formatLe(b.upper), value: cumulative})
+		}
+	}
+
+	return append(items, leBucket{le: "+Inf", value: count})
+}
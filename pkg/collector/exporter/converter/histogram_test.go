@@ -0,0 +1,154 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package converter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+)
+
+func TestConfigValidate(t *testing.T) {
+	c := Config{}
+	c.Validate()
+	assert.Equal(t, HistogramModeExponential, c.Histogram.Mode)
+	assert.NotEmpty(t, c.Histogram.Buckets)
+
+	c = Config{Histogram: HistogramConfig{Mode: HistogramModeFixed, Buckets: []float64{10, 1, 5}}}
+	c.Validate()
+	assert.Equal(t, HistogramModeFixed, c.Histogram.Mode)
+	assert.Equal(t, []float64{1, 5, 10}, c.Histogram.Buckets)
+}
+
+func TestExponentialBuckets(t *testing.T) {
+	// scale=0 base=2 正数桶 offset=1 => (2,4] (4,8] 负数桶 offset=0 => [-2,-1)
+	buckets := exponentialBuckets(0, 0, 1, 1, []float64{2, 3}, 0, []float64{4})
+	assert.Equal(t, []expBucket{
+		{upper: -1, count: 4},
+		{upper: 0, count: 1},
+		{upper: 4, count: 2},
+		{upper: 8, count: 3},
+	}, buckets)
+
+	assert.Empty(t, exponentialBuckets(0, 0, 0, 0, nil, 0, nil))
+}
+
+func TestNativeBuckets(t *testing.T) {
+	t.Run("Exponential", func(t *testing.T) {
+		// schema=0 native index=2 => (2,4] index=4 => (8,16]
+		h := define.NativeHistogram{
+			Count:          6,
+			Schema:         0,
+			PositiveSpans:  []define.BucketSpan{{Offset: 2, Length: 1}, {Offset: 1, Length: 1}},
+			PositiveCounts: []float64{2, 4},
+		}
+		assert.Equal(t, []expBucket{
+			{upper: 4, count: 2},
+			{upper: 8, count: 0},
+			{upper: 16, count: 4},
+		}, nativeBuckets(h))
+	})
+
+	t.Run("CustomBuckets", func(t *testing.T) {
+		h := define.NativeHistogram{
+			Count:          6,
+			Schema:         customBucketsSchema,
+			PositiveSpans:  []define.BucketSpan{{Offset: 0, Length: 3}},
+			PositiveCounts: []float64{1, 2, 3},
+			CustomValues:   []float64{0.1, 1},
+		}
+		buckets := nativeBuckets(h)
+		assert.Equal(t, []expBucket{{upper: 0.1, count: 1}, {upper: 1, count: 2}}, buckets[:2])
+		assert.Equal(t, []leBucket{{le: "0.1", value: 1}, {le: "1", value: 3}, {le: "+Inf", value: 6}}, toLeBuckets(HistogramConfig{}, buckets, 6))
+	})
+}
+
+func TestToLeBuckets(t *testing.T) {
+	buckets := []expBucket{
+		{upper: 0, count: 1},
+		{upper: 4, count: 2},
+		{upper: 8, count: 3},
+		{upper: 32, count: 4},
+	}
+
+	t.Run("Exponential", func(t *testing.T) {
+		assert.Equal(t, []leBucket{
+			{le: "0", value: 1},
+			{le: "4", value: 3},
+			{le: "8", value: 6},
+			{le: "32", value: 10},
+			{le: "+Inf", value: 10},
+		}, toLeBuckets(HistogramConfig{Mode: HistogramModeExponential}, buckets, 10))
+	})
+
+	t.Run("Fixed", func(t *testing.T) {
+		conf := HistogramConfig{Mode: HistogramModeFixed, Buckets: []float64{5, 10}}
+		assert.Equal(t, []leBucket{
+			{le: "5", value: 3},
+			{le: "10", value: 6},
+			{le: "+Inf", value: 10},
+		}, toLeBuckets(conf, buckets, 10))
+	})
+}
+
+func TestConvertExponentialHistogramMetrics(t *testing.T) {
+	metrics := pmetric.NewMetrics()
+	m := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName("http.duration")
+	m.SetDataType(pmetric.MetricDataTypeExponentialHistogram)
+	dp := m.ExponentialHistogram().DataPoints().AppendEmpty()
+	dp.SetTimestamp(pcommon.Timestamp(0))
+	dp.SetCount(6)
+	dp.SetSum(20)
+	dp.SetScale(0)
+	dp.SetZeroCount(1)
+	dp.Positive().SetOffset(1)
+	dp.Positive().SetMBucketCounts([]uint64{2, 3})
+	dp.Attributes().UpsertString("a1", "v1")
+
+	events := make([]define.Event, 0)
+	gather := func(evts ...define.Event) {
+		events = append(events, evts...)
+	}
+
+	t.Run("Exponential", func(t *testing.T) {
+		events = events[:0]
+		NewCommonConverter(nil).Convert(&define.Record{RecordType: define.RecordMetrics, Data: metrics}, gather)
+
+		// sum/count + 3 个桶 + +Inf
+		assert.Len(t, events, 6)
+		les := make(map[string]float64)
+		for _, evt := range events[2:] {
+			data := evt.Data()
+			le := data["dimension"].(map[string]string)["le"]
+			les[le] = data["metrics"].(map[string]float64)["http_duration_bucket"]
+		}
+		assert.Equal(t, map[string]float64{"0": 1, "4": 3, "8": 6, "+Inf": 6}, les)
+	})
+
+	t.Run("Fixed", func(t *testing.T) {
+		events = events[:0]
+		conf := &Config{Histogram: HistogramConfig{Mode: HistogramModeFixed, Buckets: []float64{5}}}
+		NewCommonConverter(conf).Convert(&define.Record{RecordType: define.RecordMetrics, Data: metrics}, gather)
+
+		assert.Len(t, events, 4)
+		les := make(map[string]float64)
+		for _, evt := range events[2:] {
+			data := evt.Data()
+			le := data["dimension"].(map[string]string)["le"]
+			les[le] = data["metrics"].(map[string]float64)["http_duration_bucket"]
+		}
+		assert.Equal(t, map[string]float64{"5": 3, "+Inf": 6}, les)
+	})
+}
@@ -49,7 +49,7 @@ func TestLogsRandom(t *testing.T) {
 		}
 	}
 
-	NewCommonConverter().Convert(&record, gather)
+	NewCommonConverter(nil).Convert(&record, gather)
 	assert.Len(t, events, 2)
 }
 
@@ -64,7 +64,7 @@ func TestLogsTime(t *testing.T) {
 			s := evts[0].Data().String()
 			assert.True(t, strings.Contains(s, "10000000"))
 		}
-		NewCommonConverter().Convert(&record, gather)
+		NewCommonConverter(nil).Convert(&record, gather)
 	}
 
 	g := makeLogsGenerator(1, 20)
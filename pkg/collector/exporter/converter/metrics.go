@@ -33,7 +33,9 @@ func (e metricsEvent) RecordType() define.RecordType {
 
 var MetricsConverter EventConverter = metricsConverter{}
 
-type metricsConverter struct{}
+type metricsConverter struct {
+	histogram HistogramConfig
+}
 
 func (c metricsConverter) ToEvent(token define.Token, dataId int32, data common.MapStr) define.Event {
 	return metricsEvent{define.NewCommonEvent(token, dataId, data)}
@@ -202,6 +204,52 @@ func (c metricsConverter) convertHistogramMetrics(dataId int32, pdMetric pmetric
 	return items
 }
 
+func (c metricsConverter) convertExponentialHistogramMetrics(dataId int32, pdMetric pmetric.Metric, rsAttrs pcommon.Map) []common.MapStr {
+	var items []common.MapStr
+	dps := pdMetric.ExponentialHistogram().DataPoints()
+	for i := 0; i < dps.Len(); i++ {
+		dp := dps.At(i)
+		dimensions := utils.MergeReplaceAttributeMaps(dp.Attributes(), rsAttrs)
+
+		if !utils.IsValidFloat64(dp.Sum()) || !utils.IsValidUint64(dp.Count()) {
+			DefaultMetricMonitor.IncConverterFailedCounter(define.RecordMetrics, dataId)
+			continue
+		}
+
+		m := otMetricMapper{
+			Metric:     pdMetric.Name() + "_sum",
+			Value:      dp.Sum(),
+			Dimensions: dimensions,
+			Time:       dp.Timestamp().AsTime(),
+		}
+		items = append(items, m.AsMapStr())
+
+		m = otMetricMapper{
+			Metric:     pdMetric.Name() + "_count",
+			Value:      float64(dp.Count()),
+			Dimensions: dimensions,
+			Time:       dp.Timestamp().AsTime(),
+		}
+		items = append(items, m.AsMapStr())
+
+		noRecorded := dp.Flags().HasFlag(pmetric.MetricDataPointFlagNoRecordedValue)
+		for _, b := range toLeBuckets(c.histogram, otlpExponentialBuckets(dp), float64(dp.Count())) {
+			val := b.value
+			if noRecorded {
+				val = math.Float64frombits(value.StaleNaN)
+			}
+			m = otMetricMapper{
+				Metric:     pdMetric.Name() + "_bucket",
+				Value:      val,
+				Dimensions: utils.MergeReplaceMaps(map[string]string{"le": b.le}, dimensions),
+				Time:       dp.Timestamp().AsTime(),
+			}
+			items = append(items, m.AsMapStr())
+		}
+	}
+	return items
+}
+
 func (c metricsConverter) convertGaugeMetrics(dataId int32, pdMetric pmetric.Metric, rsAttrs pcommon.Map) []common.MapStr {
 	dps := pdMetric.Gauge().DataPoints()
 	items := make([]common.MapStr, 0, dps.Len())
@@ -293,6 +341,9 @@ func (c metricsConverter) Extract(dataId int32, pdMetric pmetric.Metric, rsAttrs
 	case pmetric.MetricDataTypeHistogram:
 		return c.convertHistogramMetrics(dataId, pdMetric, rsAttrs)
 
+	case pmetric.MetricDataTypeExponentialHistogram:
+		return c.convertExponentialHistogramMetrics(dataId, pdMetric, rsAttrs)
+
 	case pmetric.MetricDataTypeGauge:
 		return c.convertGaugeMetrics(dataId, pdMetric, rsAttrs)
 
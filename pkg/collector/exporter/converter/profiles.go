@@ -66,13 +66,18 @@ func (c profilesConverter) Convert(record *define.Record, f define.GatherFunc) {
 			return
 		}
 
-		event := c.ToEvent(record.Token, dataId, common.MapStr{
+		data := common.MapStr{
 			"data":         protoBuf.Bytes(),
 			"type":         p.PeriodType.Type,
 			"app":          record.Token.AppName,
 			"biz_id":       record.Token.BizId,
 			"service_name": svrName,
-		})
+		}
+		if i < len(profileData.Spans) && len(profileData.Spans[i]) > 0 {
+			data["span_ids"], data["trace_ids"] = c.getSpanAndTraceIDs(profileData.Spans[i])
+		}
+
+		event := c.ToEvent(record.Token, dataId, data)
 
 		f(event)
 	}
@@ -110,6 +115,24 @@ func (c profilesConverter) getSvrNameAndTags(pd *define.ProfilesData) (string, m
 	return svrName, tags
 }
 
+// getSpanAndTraceIDs 返回去重后的 span_id 以及 trace_id 列表 作为独立字段便于检索
+func (c profilesConverter) getSpanAndTraceIDs(spans []define.ProfileSpan) ([]string, []string) {
+	spanIDs := make([]string, 0, len(spans))
+	var traceIDs []string
+	seen := make(map[string]struct{})
+	for _, span := range spans {
+		spanIDs = append(spanIDs, span.SpanID)
+		if span.TraceID == "" {
+			continue
+		}
+		if _, ok := seen[span.TraceID]; !ok {
+			seen[span.TraceID] = struct{}{}
+			traceIDs = append(traceIDs, span.TraceID)
+		}
+	}
+	return spanIDs, traceIDs
+}
+
 // mergeTagsToLabels 将 Tags 内容合并至 Sample.Label 中
 func (c profilesConverter) mergeTagsToLabels(pd *profile.Profile, tags map[string][]string) {
 	for i := 0; i < len(pd.Sample); i++ {
@@ -21,7 +21,7 @@ import (
 func TestConvertProfilesData(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		var events []define.Event
-		NewCommonConverter().Convert(&define.Record{
+		NewCommonConverter(nil).Convert(&define.Record{
 			RecordType: define.RecordProfiles,
 			Data: &define.ProfilesData{Profiles: []*profile.Profile{{
 				TimeNanos:     time.Now().UnixNano(),
@@ -50,9 +50,35 @@ func TestConvertProfilesData(t *testing.T) {
 		assert.Equal(t, "default", data["service_name"])
 	})
 
+	t.Run("With Spans", func(t *testing.T) {
+		var events []define.Event
+		NewCommonConverter(nil).Convert(&define.Record{
+			RecordType: define.RecordProfiles,
+			Data: &define.ProfilesData{
+				Profiles: []*profile.Profile{{
+					SampleType: []*profile.ValueType{{Type: "samples", Unit: "count"}},
+					Sample:     []*profile.Sample{{Value: []int64{1000}}},
+					PeriodType: &profile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+				}},
+				Spans: [][]define.ProfileSpan{{
+					{TraceID: "t1", SpanID: "s1", Value: 10},
+					{TraceID: "t1", SpanID: "s2", Value: 5},
+					{SpanID: "s3", Value: 1},
+				}},
+			},
+		}, func(evts ...define.Event) {
+			events = append(events, evts...)
+		})
+
+		assert.Len(t, events, 1)
+		data := events[0].Data()
+		assert.Equal(t, []string{"s1", "s2", "s3"}, data["span_ids"])
+		assert.Equal(t, []string{"t1"}, data["trace_ids"])
+	})
+
 	t.Run("Empty Profiles", func(t *testing.T) {
 		var hit bool
-		NewCommonConverter().Convert(&define.Record{
+		NewCommonConverter(nil).Convert(&define.Record{
 			RecordType: define.RecordProfiles,
 			Data:       &define.ProfilesData{Profiles: nil},
 			Token: define.Token{
@@ -27,7 +27,9 @@ func (e remoteWriteEvent) RecordType() define.RecordType {
 
 var RemoteWriteConverter EventConverter = remoteWriteConverter{}
 
-type remoteWriteConverter struct{}
+type remoteWriteConverter struct {
+	histogram HistogramConfig
+}
 
 func (c remoteWriteConverter) ToEvent(token define.Token, dataId int32, data common.MapStr) define.Event {
 	return remoteWriteEvent{define.NewCommonEvent(token, dataId, data)}
@@ -65,6 +67,44 @@ func (c remoteWriteConverter) Convert(record *define.Record, f define.GatherFunc
 			events = append(events, c.ToEvent(record.Token, dataId, pm.AsMapStr()))
 		}
 	}
+
+	for i := 0; i < len(rwData.Histograms); i++ {
+		rh := rwData.Histograms[i]
+		name, dims := c.extractNameDimensions(rh.Labels)
+		target, ok := dims["target"]
+		if !ok {
+			target = define.Identity()
+		}
+		for j := 0; j < len(rh.Histograms); j++ {
+			h := rh.Histograms[j]
+			if !utils.IsValidFloat64(h.Sum) || !utils.IsValidFloat64(h.Count) {
+				DefaultMetricMonitor.IncConverterFailedCounter(define.RecordRemoteWrite, dataId)
+				continue
+			}
+
+			pm := promMapper{
+				Metrics: common.MapStr{
+					name + "_count": h.Count,
+					name + "_sum":   h.Sum,
+				},
+				Target:     target,
+				Timestamp:  h.Timestamp,
+				Dimensions: utils.CloneMap(dims),
+			}
+			events = append(events, c.ToEvent(record.Token, dataId, pm.AsMapStr()))
+
+			for _, b := range toLeBuckets(c.histogram, nativeBuckets(h), h.Count) {
+				pm = promMapper{
+					Metrics:    common.MapStr{name + "_bucket": b.value},
+					Target:     target,
+					Timestamp:  h.Timestamp,
+					Dimensions: utils.MergeReplaceMaps(map[string]string{"le": b.le}, dims),
+				}
+				events = append(events, c.ToEvent(record.Token, dataId, pm.AsMapStr()))
+			}
+		}
+	}
+
 	if len(events) > 0 {
 		f(events...)
 	}
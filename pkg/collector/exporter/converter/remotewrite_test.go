@@ -11,9 +11,12 @@ package converter
 
 import (
 	"bytes"
+	"math"
 	"os"
 	"testing"
 
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/prometheus/prometheus/prompb"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
@@ -39,7 +42,7 @@ func TestConvert(t *testing.T) {
 		}
 	}
 
-	NewCommonConverter().Convert(&define.Record{
+	NewCommonConverter(nil).Convert(&define.Record{
 		RecordType:  define.RecordRemoteWrite,
 		RequestType: define.RequestHttp,
 		Data:        &define.RemoteWriteData{Timeseries: wr.Timeseries},
@@ -50,3 +53,36 @@ func TestConvert(t *testing.T) {
 		t.Logf("event(%d) = %+v", i, events[i].Data())
 	}
 }
+
+func TestConvertNativeHistograms(t *testing.T) {
+	events := make([]define.Event, 0)
+	gather := func(evts ...define.Event) {
+		events = append(events, evts...)
+	}
+
+	NewCommonConverter(nil).Convert(&define.Record{
+		RecordType:  define.RecordRemoteWrite,
+		RequestType: define.RequestHttp,
+		Data: &define.RemoteWriteData{
+			Histograms: []define.RemoteWriteHistogram{{
+				Labels: []prompb.Label{
+					{Name: "__name__", Value: "rpc_duration_seconds"},
+					{Name: "target", Value: "127.0.0.1"},
+				},
+				Histograms: []define.NativeHistogram{
+					{Count: 3, Sum: 1.5, Timestamp: 1000},
+					{Count: 4, Sum: math.NaN(), Timestamp: 2000},
+				},
+			}},
+		},
+	}, gather)
+
+	// count/sum 以及 +Inf 桶
+	assert.Len(t, events, 2)
+	data := events[0].Data()
+	assert.Equal(t, common.MapStr{
+		"rpc_duration_seconds_count": float64(3),
+		"rpc_duration_seconds_sum":   1.5,
+	}, data["metrics"])
+	assert.Equal(t, "127.0.0.1", data["target"])
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter/converter"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter/otlp"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/exporter/queue"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/json"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/wait"
@@ -34,6 +35,7 @@ type Exporter struct {
 	cancel    context.CancelFunc
 	wg        sync.WaitGroup
 	converter converter.Converter
+	otlp      *otlp.Exporter // 未启用时为 nil
 	queue     queue.Queue
 	cfg       *Config
 	batches   map[string]queue.Config // 无并发读写 无需锁保护
@@ -53,6 +55,18 @@ func PublishEvents(events ...define.Event) {
 
 var SentFunc = beat.Send
 
+// SentWithAckFunc 发送需要确认的数据 libbeat pipeline 输出成功后通过 ACKEvents 回调确认
+var SentWithAckFunc = beat.SendWithPrivate
+
+// ACKEvents 作为 libbeat pipeline 的 ACK 回调 确认已经输出的磁盘队列数据
+func ACKEvents(privates []interface{}) {
+	for _, private := range privates {
+		if item, ok := private.(queue.Item); ok {
+			item.Ack(true)
+		}
+	}
+}
+
 func New(conf *confengine.Config) (*Exporter, error) {
 	c := &Config{}
 	if err := conf.UnpackChild(define.ConfigFieldExporter, c); err != nil {
@@ -65,10 +79,19 @@ func New(conf *confengine.Config) (*Exporter, error) {
 	exp := &Exporter{
 		ctx:       ctx,
 		cancel:    cancel,
-		converter: converter.NewCommonConverter(),
+		converter: converter.NewCommonConverter(&c.Converter),
 		cfg:       c,
 		batches:   LoadConfigFrom(conf),
 	}
+	if c.Otlp.Enabled {
+		otlpExp, err := otlp.New(c.Otlp)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		exp.otlp = otlpExp
+	}
+
 	exp.queue = queue.NewBatchQueue(c.Queue, func(s string) queue.Config {
 		return exp.batches[s]
 	})
@@ -78,6 +101,10 @@ func New(conf *confengine.Config) (*Exporter, error) {
 func (e *Exporter) Start() error {
 	logger.Info("exporter start working...")
 
+	if e.otlp != nil {
+		e.otlp.Start()
+	}
+
 	for i := 0; i < define.Concurrency(); i++ {
 		go wait.Until(e.ctx, e.consumeRecords)
 		go wait.Until(e.ctx, e.consumeEvents)
@@ -117,6 +144,9 @@ func (e *Exporter) consumeRecords() {
 	for {
 		select {
 		case record := <-globalRecords.Get():
+			if e.otlp != nil {
+				e.otlp.Export(record)
+			}
 			e.converter.Convert(record, PublishEvents)
 
 		case <-e.ctx.Done():
@@ -131,9 +161,16 @@ func (e *Exporter) sendEvents() {
 
 	for {
 		select {
-		case event := <-e.queue.Pop():
+		case item := <-e.queue.Pop():
 			start := time.Now()
-			SentFunc(event)
+			if item.NeedAck() {
+				// 入队成功不代表已经输出 由 ACKEvents 回调确认
+				if !SentWithAckFunc(item.Data, item) {
+					item.Ack(false)
+				}
+			} else {
+				SentFunc(item.Data)
+			}
 			DefaultMetricMonitor.ObserveSentDuration(start)
 			DefaultMetricMonitor.IncSentCounter()
 
@@ -147,4 +184,8 @@ func (e *Exporter) sendEvents() {
 func (e *Exporter) Stop() {
 	e.cancel()
 	e.wg.Wait()
+
+	if e.otlp != nil {
+		e.otlp.Stop()
+	}
 }
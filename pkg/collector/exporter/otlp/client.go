@@ -0,0 +1,226 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	grpcgzip "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+)
+
+const (
+	routeV1Traces  = "/v1/traces"
+	routeV1Metrics = "/v1/metrics"
+	routeV1Logs    = "/v1/logs"
+)
+
+// retryableError 标识可重试的错误
+type retryableError struct {
+	err error
+}
+
+func (e retryableError) Error() string {
+	return e.err.Error()
+}
+
+func isRetryable(err error) bool {
+	var re retryableError
+	return errors.As(err, &re)
+}
+
+type client interface {
+	Export(ctx context.Context, data interface{}) error
+	Close() error
+}
+
+func newClient(conf EndpointConfig) (client, error) {
+	switch conf.Protocol {
+	case ProtocolHttp:
+		return newHttpClient(conf)
+	default:
+		return newGrpcClient(conf)
+	}
+}
+
+type grpcClient struct {
+	conf    EndpointConfig
+	conn    *grpc.ClientConn
+	traces  ptraceotlp.Client
+	metrics pmetricotlp.Client
+	logs    plogotlp.Client
+}
+
+func newGrpcClient(conf EndpointConfig) (*grpcClient, error) {
+	creds := insecure.NewCredentials()
+	if conf.Tls {
+		tlsConf, err := conf.TlsConfig.build()
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsConf)
+	}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if conf.Compression == CompressionGzip {
+		opts = append(opts, grpc.WithDefaultCallOptions(grpc.UseCompressor(grpcgzip.Name)))
+	}
+
+	conn, err := grpc.Dial(conf.Endpoint, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &grpcClient{
+		conf:    conf,
+		conn:    conn,
+		traces:  ptraceotlp.NewClient(conn),
+		metrics: pmetricotlp.NewClient(conn),
+		logs:    plogotlp.NewClient(conn),
+	}, nil
+}
+
+func (c *grpcClient) Export(ctx context.Context, data interface{}) error {
+	if len(c.conf.Headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(c.conf.Headers))
+	}
+
+	var err error
+	switch d := data.(type) {
+	case ptrace.Traces:
+		_, err = c.traces.Export(ctx, ptraceotlp.NewRequestFromTraces(d))
+	case pmetric.Metrics:
+		_, err = c.metrics.Export(ctx, pmetricotlp.NewRequestFromMetrics(d))
+	case plog.Logs:
+		_, err = c.logs.Export(ctx, plogotlp.NewRequestFromLogs(d))
+	default:
+		return errors.Errorf("unsupported data type %T", data)
+	}
+
+	if err == nil {
+		return nil
+	}
+	switch status.Code(err) {
+	case codes.Canceled, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted,
+		codes.OutOfRange, codes.Unavailable, codes.DataLoss:
+		return retryableError{err: err}
+	}
+	return err
+}
+
+func (c *grpcClient) Close() error {
+	return c.conn.Close()
+}
+
+type httpClient struct {
+	conf   EndpointConfig
+	client *http.Client
+}
+
+func newHttpClient(conf EndpointConfig) (*httpClient, error) {
+	tlsConf, err := conf.TlsConfig.build()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConf
+	return &httpClient{
+		conf:   conf,
+		client: &http.Client{Timeout: conf.Timeout, Transport: transport},
+	}, nil
+}
+
+func (c *httpClient) marshal(data interface{}) (string, []byte, error) {
+	switch d := data.(type) {
+	case ptrace.Traces:
+		b, err := ptraceotlp.NewRequestFromTraces(d).MarshalProto()
+		return routeV1Traces, b, err
+	case pmetric.Metrics:
+		b, err := pmetricotlp.NewRequestFromMetrics(d).MarshalProto()
+		return routeV1Metrics, b, err
+	case plog.Logs:
+		b, err := plogotlp.NewRequestFromLogs(d).MarshalProto()
+		return routeV1Logs, b, err
+	}
+	return "", nil, errors.Errorf("unsupported data type %T", data)
+}
+
+func (c *httpClient) Export(ctx context.Context, data interface{}) error {
+	route, b, err := c.marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if c.conf.Compression == CompressionGzip {
+		buf := &bytes.Buffer{}
+		w := gzip.NewWriter(buf)
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		b = buf.Bytes()
+	}
+
+	url := strings.TrimSuffix(c.conf.Endpoint, "/") + route
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(define.ContentType, define.ContentTypeProtobuf)
+	if c.conf.Compression == CompressionGzip {
+		req.Header.Set("Content-Encoding", CompressionGzip)
+	}
+	for k, v := range c.conf.Headers {
+		req.Header.Set(k, v)
+	}
+
+	rsp, err := c.client.Do(req)
+	if err != nil {
+		return retryableError{err: err}
+	}
+	defer rsp.Body.Close()
+	_, _ = io.Copy(io.Discard, rsp.Body)
+
+	if rsp.StatusCode >= 200 && rsp.StatusCode < 300 {
+		return nil
+	}
+
+	err = errors.Errorf("otlp http export failed, url=%s, code=%d", url, rsp.StatusCode)
+	switch rsp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return retryableError{err: err}
+	}
+	return err
+}
+
+func (c *httpClient) Close() error {
+	c.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,141 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package otlp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ProtocolGrpc = "grpc"
+	ProtocolHttp = "http"
+
+	CompressionGzip = "gzip"
+	CompressionNone = "none"
+
+	defaultQueueSize       = 1000
+	defaultWorkers         = 2
+	defaultTimeout         = 10 * time.Second
+	defaultInitialInterval = 5 * time.Second
+	defaultMaxInterval     = 30 * time.Second
+	defaultMaxElapsedTime  = 5 * time.Minute
+)
+
+// Config otlp 转发配置 数据会在写入 gse 的同时旁路转发到各个 endpoint
+type Config struct {
+	Enabled   bool             `config:"enabled"`
+	QueueSize int              `config:"queue_size"`
+	Workers   int              `config:"workers"`
+	Retry     RetryConfig      `config:"retry"`
+	Endpoints []EndpointConfig `config:"endpoints"`
+}
+
+type RetryConfig struct {
+	Enabled         bool          `config:"enabled"`
+	InitialInterval time.Duration `config:"initial_interval"`
+	MaxInterval     time.Duration `config:"max_interval"`
+	MaxElapsedTime  time.Duration `config:"max_elapsed_time"`
+}
+
+// EndpointConfig 下游 endpoint 配置
+//
+// grpc 协议 endpoint 形如 `localhost:4317`
+// http 协议 endpoint 形如 `http://localhost:4318` 请求路径会自动追加 `/v1/{traces,metrics,logs}`
+type EndpointConfig struct {
+	Name        string            `config:"name"`
+	Protocol    string            `config:"protocol"`
+	Endpoint    string            `config:"endpoint"`
+	Tls         bool              `config:"tls"` // 仅作用于 grpc 协议 http 协议按 endpoint 的 scheme 决定
+	TlsConfig   TlsConfig         `config:"tls_config"`
+	Compression string            `config:"compression"`
+	Timeout     time.Duration     `config:"timeout"`
+	Headers     map[string]string `config:"headers"`
+
+	// Tokens 仅转发指定 token 的数据 为空时转发全部
+	Tokens []string `config:"tokens"`
+
+	// RecordTypes 仅转发指定类型的数据（traces/metrics/logs）为空时转发全部
+	RecordTypes []string `config:"record_types"`
+}
+
+// TlsConfig 未配置 CaFile 时使用系统根证书校验服务端 CertFile/KeyFile 用于双向认证
+type TlsConfig struct {
+	CaFile             string `config:"ca_file"`
+	CertFile           string `config:"cert_file"`
+	KeyFile            string `config:"key_file"`
+	InsecureSkipVerify bool   `config:"insecure_skip_verify"`
+}
+
+func (c TlsConfig) build() (*tls.Config, error) {
+	conf := &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+
+	if c.CaFile != "" {
+		b, err := os.ReadFile(c.CaFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "read ca file")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, errors.Errorf("no valid certificate found in ca file '%s'", c.CaFile)
+		}
+		conf.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, errors.New("cert_file and key_file must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "load client certificate")
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	return conf, nil
+}
+
+func (c *Config) Validate() {
+	if c.QueueSize <= 0 {
+		c.QueueSize = defaultQueueSize
+	}
+	if c.Workers <= 0 {
+		c.Workers = defaultWorkers
+	}
+	if c.Retry.InitialInterval <= 0 {
+		c.Retry.InitialInterval = defaultInitialInterval
+	}
+	if c.Retry.MaxInterval <= 0 {
+		c.Retry.MaxInterval = defaultMaxInterval
+	}
+	if c.Retry.MaxElapsedTime <= 0 {
+		c.Retry.MaxElapsedTime = defaultMaxElapsedTime
+	}
+
+	for i := 0; i < len(c.Endpoints); i++ {
+		ep := &c.Endpoints[i]
+		if ep.Protocol != ProtocolHttp {
+			ep.Protocol = ProtocolGrpc
+		}
+		if ep.Compression != CompressionNone {
+			ep.Compression = CompressionGzip
+		}
+		if ep.Timeout <= 0 {
+			ep.Timeout = defaultTimeout
+		}
+		if ep.Name == "" {
+			ep.Name = ep.Endpoint
+		}
+	}
+}
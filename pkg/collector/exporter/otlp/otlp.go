@@ -0,0 +1,281 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package otlp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+const (
+	statusSuccess = "success"
+	statusFailed  = "failed"
+)
+
+var (
+	sentTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_otlp_sent_total",
+			Help:      "Exporter otlp sent total",
+		},
+		[]string{"endpoint", "record_type", "status"},
+	)
+
+	retriedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_otlp_retried_total",
+			Help:      "Exporter otlp retried total",
+		},
+		[]string{"endpoint", "record_type"},
+	)
+
+	droppedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_otlp_dropped_total",
+			Help:      "Exporter otlp dropped total",
+		},
+		[]string{"endpoint", "record_type"},
+	)
+
+	sentDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_otlp_sent_duration_seconds",
+			Help:      "Exporter otlp sent duration seconds",
+			Buckets:   define.DefObserveDuration,
+		},
+		[]string{"endpoint"},
+	)
+)
+
+var DefaultMetricMonitor = &metricMonitor{}
+
+type metricMonitor struct{}
+
+func (m *metricMonitor) IncSentCounter(endpoint string, rtype define.RecordType, status string) {
+	sentTotal.WithLabelValues(endpoint, rtype.S(), status).Inc()
+}
+
+func (m *metricMonitor) IncRetriedCounter(endpoint string, rtype define.RecordType) {
+	retriedTotal.WithLabelValues(endpoint, rtype.S()).Inc()
+}
+
+func (m *metricMonitor) IncDroppedCounter(endpoint string, rtype define.RecordType) {
+	droppedTotal.WithLabelValues(endpoint, rtype.S()).Inc()
+}
+
+func (m *metricMonitor) ObserveSentDuration(endpoint string, t time.Time) {
+	sentDuration.WithLabelValues(endpoint).Observe(time.Since(t).Seconds())
+}
+
+// baseRecordType 将 derived 类型归并到基础类型
+func baseRecordType(rtype define.RecordType) define.RecordType {
+	switch rtype {
+	case define.RecordTracesDerived:
+		return define.RecordTraces
+	case define.RecordMetricsDerived:
+		return define.RecordMetrics
+	case define.RecordLogsDerived:
+		return define.RecordLogs
+	}
+	return rtype
+}
+
+type item struct {
+	rtype define.RecordType
+	data  interface{}
+}
+
+type endpoint struct {
+	conf        EndpointConfig
+	client      client
+	tokens      map[string]struct{}
+	recordTypes map[define.RecordType]struct{}
+	ch          chan item
+}
+
+func (ep *endpoint) match(rtype define.RecordType, token string) bool {
+	if len(ep.recordTypes) > 0 {
+		if _, ok := ep.recordTypes[rtype]; !ok {
+			return false
+		}
+	}
+	if len(ep.tokens) > 0 {
+		if _, ok := ep.tokens[token]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Exporter 将 traces/metrics/logs 以 otlp 协议旁路转发到下游
+type Exporter struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	conf      Config
+	endpoints []*endpoint
+}
+
+func New(conf Config) (*Exporter, error) {
+	conf.Validate()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &Exporter{
+		ctx:    ctx,
+		cancel: cancel,
+		conf:   conf,
+	}
+
+	for _, epConf := range conf.Endpoints {
+		c, err := newClient(epConf)
+		if err != nil {
+			e.closeClients()
+			cancel()
+			return nil, err
+		}
+
+		ep := &endpoint{
+			conf:        epConf,
+			client:      c,
+			tokens:      make(map[string]struct{}),
+			recordTypes: make(map[define.RecordType]struct{}),
+			ch:          make(chan item, conf.QueueSize),
+		}
+		for _, token := range epConf.Tokens {
+			ep.tokens[token] = struct{}{}
+		}
+		for _, s := range epConf.RecordTypes {
+			ep.recordTypes[define.RecordType(s)] = struct{}{}
+		}
+		e.endpoints = append(e.endpoints, ep)
+	}
+	return e, nil
+}
+
+func (e *Exporter) Start() {
+	for _, ep := range e.endpoints {
+		logger.Infof("start otlp exporter, name=%s, protocol=%s, endpoint=%s", ep.conf.Name, ep.conf.Protocol, ep.conf.Endpoint)
+		for i := 0; i < e.conf.Workers; i++ {
+			e.wg.Add(1)
+			go e.loopSend(ep)
+		}
+	}
+}
+
+func (e *Exporter) Stop() {
+	e.cancel()
+	e.wg.Wait()
+	e.closeClients()
+}
+
+func (e *Exporter) closeClients() {
+	for _, ep := range e.endpoints {
+		if err := ep.client.Close(); err != nil {
+			logger.Warnf("failed to close otlp client, name=%s, err: %v", ep.conf.Name, err)
+		}
+	}
+}
+
+func cloneData(data interface{}) (interface{}, bool) {
+	switch d := data.(type) {
+	case ptrace.Traces:
+		return d.Clone(), true
+	case pmetric.Metrics:
+		return d.Clone(), true
+	case plog.Logs:
+		return d.Clone(), true
+	}
+	return nil, false
+}
+
+// Export 将 record 投递到匹配的 endpoint 队列中 队列满时丢弃 不阻塞主流程
+//
+// 后续 converter 会修改原始数据 因此每个 endpoint 持有独立的拷贝
+func (e *Exporter) Export(record *define.Record) {
+	rtype := baseRecordType(record.RecordType)
+	for _, ep := range e.endpoints {
+		if !ep.match(rtype, record.Token.Original) {
+			continue
+		}
+
+		data, ok := cloneData(record.Data)
+		if !ok {
+			return
+		}
+		select {
+		case ep.ch <- item{rtype: rtype, data: data}:
+		default:
+			DefaultMetricMonitor.IncDroppedCounter(ep.conf.Name, rtype)
+			logger.WarnfRate(time.Minute, ep.conf.Name, "otlp exporter queue is full, name=%s", ep.conf.Name)
+		}
+	}
+}
+
+func (e *Exporter) loopSend(ep *endpoint) {
+	defer e.wg.Done()
+
+	for {
+		select {
+		case it := <-ep.ch:
+			e.send(ep, it)
+		case <-e.ctx.Done():
+			return
+		}
+	}
+}
+
+func (e *Exporter) send(ep *endpoint, it item) {
+	start := time.Now()
+	interval := e.conf.Retry.InitialInterval
+
+	for {
+		ctx, cancel := context.WithTimeout(e.ctx, ep.conf.Timeout)
+		err := ep.client.Export(ctx, it.data)
+		cancel()
+
+		if err == nil {
+			DefaultMetricMonitor.IncSentCounter(ep.conf.Name, it.rtype, statusSuccess)
+			DefaultMetricMonitor.ObserveSentDuration(ep.conf.Name, start)
+			return
+		}
+
+		if !e.conf.Retry.Enabled || !isRetryable(err) || time.Since(start)+interval > e.conf.Retry.MaxElapsedTime {
+			DefaultMetricMonitor.IncSentCounter(ep.conf.Name, it.rtype, statusFailed)
+			logger.WarnfRate(time.Minute, ep.conf.Name, "failed to export otlp data, name=%s, err: %v", ep.conf.Name, err)
+			return
+		}
+
+		DefaultMetricMonitor.IncRetriedCounter(ep.conf.Name, it.rtype)
+		select {
+		case <-time.After(interval):
+		case <-e.ctx.Done():
+			return
+		}
+
+		interval *= 2
+		if interval > e.conf.Retry.MaxInterval {
+			interval = e.conf.Retry.MaxInterval
+		}
+	}
+}
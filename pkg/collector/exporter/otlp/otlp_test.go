@@ -0,0 +1,254 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package otlp
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	"go.uber.org/atomic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/generator"
+)
+
+func newTracesRecord(token string, spanCount int) *define.Record {
+	g := generator.NewTracesGenerator(define.TracesOptions{SpanCount: spanCount})
+	return &define.Record{
+		RecordType: define.RecordTraces,
+		Token:      define.Token{Original: token},
+		Data:       g.Generate(),
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	c := Config{Endpoints: []EndpointConfig{{Endpoint: "localhost:4317"}, {Protocol: ProtocolHttp, Compression: CompressionNone}}}
+	c.Validate()
+
+	assert.Equal(t, defaultQueueSize, c.QueueSize)
+	assert.Equal(t, ProtocolGrpc, c.Endpoints[0].Protocol)
+	assert.Equal(t, CompressionGzip, c.Endpoints[0].Compression)
+	assert.Equal(t, "localhost:4317", c.Endpoints[0].Name)
+	assert.Equal(t, ProtocolHttp, c.Endpoints[1].Protocol)
+	assert.Equal(t, CompressionNone, c.Endpoints[1].Compression)
+}
+
+func TestEndpointMatch(t *testing.T) {
+	e, err := New(Config{Endpoints: []EndpointConfig{
+		{Protocol: ProtocolHttp, Endpoint: "http://localhost"},
+		{Protocol: ProtocolHttp, Endpoint: "http://localhost", Tokens: []string{"token1"}, RecordTypes: []string{"metrics"}},
+	}})
+	assert.NoError(t, err)
+	defer e.Stop()
+
+	all, routed := e.endpoints[0], e.endpoints[1]
+	assert.True(t, all.match(define.RecordTraces, "token2"))
+	assert.True(t, routed.match(define.RecordMetrics, "token1"))
+	assert.False(t, routed.match(define.RecordMetrics, "token2"))
+	assert.False(t, routed.match(define.RecordTraces, "token1"))
+
+	assert.Equal(t, define.RecordMetrics, baseRecordType(define.RecordMetricsDerived))
+}
+
+func TestExportQueueFull(t *testing.T) {
+	e, err := New(Config{
+		QueueSize: 1,
+		Endpoints: []EndpointConfig{{Protocol: ProtocolHttp, Endpoint: "http://localhost"}},
+	})
+	assert.NoError(t, err)
+	defer e.Stop()
+
+	// 未启动 worker 队列不会被消费
+	e.Export(newTracesRecord("token1", 1))
+	e.Export(newTracesRecord("token1", 1))
+	e.Export(&define.Record{RecordType: define.RecordPushGateway, Data: &define.PushGatewayData{}})
+	assert.Len(t, e.endpoints[0].ch, 1)
+}
+
+func TestHttpExportWithRetry(t *testing.T) {
+	requests := atomic.NewInt64(0)
+	received := make(chan int, 1)
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, routeV1Traces, r.URL.Path)
+		assert.Equal(t, "v1", r.Header.Get("X-Custom"))
+
+		// 第一次请求返回 503 触发重试
+		if requests.Inc() == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		assert.Equal(t, CompressionGzip, r.Header.Get("Content-Encoding"))
+		gr, err := gzip.NewReader(r.Body)
+		assert.NoError(t, err)
+		b, err := io.ReadAll(gr)
+		assert.NoError(t, err)
+
+		req := ptraceotlp.NewRequest()
+		assert.NoError(t, req.UnmarshalProto(b))
+		received <- req.Traces().SpanCount()
+	}))
+	defer svr.Close()
+
+	e, err := New(Config{
+		Retry: RetryConfig{
+			Enabled:         true,
+			InitialInterval: 10 * time.Millisecond,
+		},
+		Endpoints: []EndpointConfig{{
+			Protocol: ProtocolHttp,
+			Endpoint: svr.URL,
+			Headers:  map[string]string{"X-Custom": "v1"},
+		}},
+	})
+	assert.NoError(t, err)
+	e.Start()
+	defer e.Stop()
+
+	e.Export(newTracesRecord("token1", 10))
+	select {
+	case n := <-received:
+		assert.Equal(t, 10, n)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+	assert.Equal(t, int64(2), requests.Load())
+}
+
+func TestHttpExportNonRetryable(t *testing.T) {
+	requests := atomic.NewInt64(0)
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Inc()
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer svr.Close()
+
+	c, err := newHttpClient(EndpointConfig{Endpoint: svr.URL, Timeout: time.Second})
+	assert.NoError(t, err)
+	err = c.Export(context.Background(), pmetric.NewMetrics())
+	assert.Error(t, err)
+	assert.False(t, isRetryable(err))
+	assert.Equal(t, int64(1), requests.Load())
+}
+
+type tracesServer struct {
+	received chan ptraceotlp.Request
+}
+
+func (s tracesServer) Export(ctx context.Context, req ptraceotlp.Request) (ptraceotlp.Response, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if len(md.Get("x-custom")) > 0 {
+		s.received <- req
+	}
+	return ptraceotlp.NewResponse(), nil
+}
+
+func TestGrpcExport(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	srv := tracesServer{received: make(chan ptraceotlp.Request, 1)}
+	s := grpc.NewServer()
+	ptraceotlp.RegisterServer(s, srv)
+	go func() { _ = s.Serve(l) }()
+	defer s.Stop()
+
+	e, err := New(Config{Endpoints: []EndpointConfig{{
+		Endpoint: l.Addr().String(),
+		Headers:  map[string]string{"x-custom": "v1"},
+	}}})
+	assert.NoError(t, err)
+	e.Start()
+	defer e.Stop()
+
+	record := newTracesRecord("token1", 5)
+	e.Export(record)
+	select {
+	case req := <-srv.received:
+		assert.Equal(t, 5, req.Traces().SpanCount())
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
+
+func writePem(t *testing.T, dir, name, typ string, b []byte) string {
+	path := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: b}), 0o600))
+	return path
+}
+
+func TestHttpExportTls(t *testing.T) {
+	svr := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer svr.Close()
+
+	dir := t.TempDir()
+	caFile := writePem(t, dir, "ca.pem", "CERTIFICATE", svr.Certificate().Raw)
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(svr.TLS.Certificates[0].PrivateKey)
+	assert.NoError(t, err)
+	keyFile := writePem(t, dir, "key.pem", "PRIVATE KEY", keyBytes)
+
+	export := func(tlsConf TlsConfig) error {
+		c, err := newHttpClient(EndpointConfig{Endpoint: svr.URL, Timeout: time.Second, TlsConfig: tlsConf})
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+		return c.Export(context.Background(), pmetric.NewMetrics())
+	}
+
+	t.Run("system roots", func(t *testing.T) {
+		assert.Error(t, export(TlsConfig{}))
+	})
+
+	t.Run("ca file", func(t *testing.T) {
+		assert.NoError(t, export(TlsConfig{CaFile: caFile}))
+	})
+
+	t.Run("insecure skip verify", func(t *testing.T) {
+		assert.NoError(t, export(TlsConfig{InsecureSkipVerify: true}))
+	})
+
+	t.Run("client certificate", func(t *testing.T) {
+		conf, err := TlsConfig{CaFile: caFile, CertFile: caFile, KeyFile: keyFile}.build()
+		assert.NoError(t, err)
+		assert.Len(t, conf.Certificates, 1)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := TlsConfig{CaFile: filepath.Join(dir, "missing.pem")}.build()
+		assert.Error(t, err)
+
+		_, err = TlsConfig{CaFile: keyFile}.build()
+		assert.Error(t, err)
+
+		_, err = TlsConfig{CertFile: caFile}.build()
+		assert.Error(t, err)
+
+		_, err = newClient(EndpointConfig{Protocol: ProtocolGrpc, Endpoint: "localhost:4317", Tls: true, TlsConfig: TlsConfig{CertFile: caFile}})
+		assert.Error(t, err)
+	})
+}
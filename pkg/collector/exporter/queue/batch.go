@@ -75,9 +75,10 @@ type BatchQueue struct {
 	wg      sync.WaitGroup
 	mut     sync.RWMutex
 	qs      map[string]chan []define.Event
-	out     chan common.MapStr
+	out     chan Item
 	conf    Config
 	getSize func(string) Config
+	spools  map[string]*diskSpool
 }
 
 // Config 不同类型的数据大小不同 因此要允许为每种类型单独设置队列批次
@@ -87,6 +88,7 @@ type Config struct {
 	TracesBatchSize  int           `config:"traces_batch_size" mapstructure:"traces_batch_size"`
 	ProxyBatchSize   int           `config:"proxy_batch_size" mapstructure:"proxy_batch_size"`
 	FlushInterval    time.Duration `config:"flush_interval" mapstructure:"flush_interval"`
+	Disk             DiskConfig    `config:"disk" mapstructure:"disk"`
 }
 
 func NewBatchQueue(conf Config, fn func(string) Config) Queue {
@@ -95,14 +97,178 @@ func NewBatchQueue(conf Config, fn func(string) Config) Queue {
 		ctx:     ctx,
 		cancel:  cancel,
 		qs:      make(map[string]chan []define.Event),
-		out:     make(chan common.MapStr, define.Concurrency()),
+		out:     make(chan Item, define.Concurrency()),
 		conf:    conf,
 		getSize: fn,
+		spools:  make(map[string]*diskSpool),
 	}
 
+	// 启用磁盘队列时优先回放上次遗留的数据
+	if conf.Disk.Enabled {
+		cq.spools = loadDiskSpools(conf.Disk)
+		for _, spool := range cq.spools {
+			cq.wg.Add(1)
+			go cq.drain(spool)
+		}
+	}
 	return cq
 }
 
+// getSpool 获取或创建 RecordType/DataID 对应的磁盘队列 未启用时返回 nil
+func (bq *BatchQueue) getSpool(rtype define.RecordType, dataID int32) *diskSpool {
+	if !bq.conf.Disk.Enabled {
+		return nil
+	}
+
+	key := spoolKey(rtype, dataID)
+	bq.mut.Lock()
+	defer bq.mut.Unlock()
+
+	if spool, ok := bq.spools[key]; ok {
+		return spool
+	}
+	spool, err := openDiskSpool(bq.conf.Disk, rtype, dataID)
+	if err != nil {
+		logger.Errorf("failed to open disk queue, rtype=%s, dataid=%d, err: %v", rtype, dataID, err)
+		return nil
+	}
+	bq.spools[key] = spool
+	bq.wg.Add(1)
+	go bq.drain(spool)
+	return spool
+}
+
+// emit 启用磁盘队列时先落盘再由 drain 异步输出 落盘失败时直接输出
+func (bq *BatchQueue) emit(dc DataIDChan, ms common.MapStr) {
+	if spool := bq.getSpool(dc.rtype, dc.dataID); spool != nil {
+		err := spool.Append(ms)
+		if err == nil {
+			return
+		}
+		logger.Warnf("failed to append disk queue, rtype=%s, dataid=%d, err: %v", dc.rtype, dc.dataID, err)
+	}
+
+	select {
+	case bq.out <- Item{Data: ms}:
+	case <-bq.ctx.Done():
+	}
+}
+
+// inflightItem 已经投递但尚未确认的磁盘队列数据
+type inflightItem struct {
+	data   common.MapStr
+	pos    spoolPosition
+	id     uint64 // 每次投递都会分配新的 id 用于忽略过期的确认
+	sent   bool
+	failed bool
+}
+
+type inflightAck struct {
+	id   uint64
+	sent bool
+}
+
+// drain 按顺序输出磁盘队列中的数据 输出端确认后才按顺序提交消费进度
+//
+// 同一个 spool 最多有 MaxInflight 条数据在途 发送失败的数据等待下一个周期重新投递
+func (bq *BatchQueue) drain(spool *diskSpool) {
+	defer bq.wg.Done()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	limit := bq.conf.Disk.MaxInflight
+	if limit <= 0 {
+		limit = defaultDiskMaxInflight
+	}
+
+	// 每次投递最多确认一次 缓冲区足够容纳所有在途数据的确认 因此确认方不会阻塞
+	acks := make(chan inflightAck, limit)
+
+	var seq uint64
+	var inflight []*inflightItem
+
+	deliver := func(it *inflightItem) bool {
+		seq++
+		it.id = seq
+		it.failed = false
+
+		var once sync.Once
+		ack := inflightAck{id: seq}
+		item := Item{Data: it.data, ack: func(sent bool) {
+			once.Do(func() {
+				ack.sent = sent
+				acks <- ack
+			})
+		}}
+
+		select {
+		case bq.out <- item:
+			return true
+		case <-bq.ctx.Done():
+			return false
+		}
+	}
+
+	handleAck := func(ack inflightAck) {
+		for _, it := range inflight {
+			if it.id != ack.id {
+				continue
+			}
+			if ack.sent {
+				it.sent = true
+			} else {
+				it.failed = true
+				logger.Warnf("failed to send disk queue item, rtype=%s, dataid=%d, retry later", spool.rtype, spool.dataId)
+			}
+			break
+		}
+
+		// 按顺序提交连续已确认的数据
+		var n int
+		for n < len(inflight) && inflight[n].sent {
+			n++
+		}
+		if n > 0 {
+			spool.Commit(inflight[n-1].pos)
+			inflight = inflight[n:]
+		}
+	}
+
+	for {
+		if len(inflight) < limit {
+			if ms, pos, ok := spool.Next(); ok {
+				it := &inflightItem{data: ms, pos: pos}
+				inflight = append(inflight, it)
+				if !deliver(it) {
+					return
+				}
+				continue
+			}
+		}
+
+		// 空闲时提交被跳过的数据并将消费进度落盘
+		if len(inflight) == 0 {
+			spool.CommitRead()
+			spool.Sync()
+		}
+
+		select {
+		case ack := <-acks:
+			handleAck(ack)
+		case <-spool.notify:
+		case <-ticker.C:
+			for _, it := range inflight {
+				if it.failed && !deliver(it) {
+					return
+				}
+			}
+		case <-bq.ctx.Done():
+			return
+		}
+	}
+}
+
 type DataIDChan struct {
 	dataID    int32
 	batchSize int
@@ -136,7 +302,6 @@ func (bq *BatchQueue) resize(rtype define.RecordType, token string, backup int)
 }
 
 func (bq *BatchQueue) compact(dc DataIDChan) {
-	bq.wg.Add(1)
 	defer bq.wg.Done()
 
 	ticker := time.NewTicker(bq.conf.FlushInterval)
@@ -151,18 +316,18 @@ func (bq *BatchQueue) compact(dc DataIDChan) {
 		DefaultMetricMonitor.ObserveQueuePopBatchSizeDistribution(len(data), dc.dataID, dc.rtype)
 		switch dc.rtype {
 		case define.RecordTraces, define.RecordLogs:
-			bq.out <- NewEventsMapStr(dc.dataID, data)
+			bq.emit(dc, NewEventsMapStr(dc.dataID, data))
 		case define.RecordMetrics, define.RecordPushGateway, define.RecordRemoteWrite, define.RecordTars:
-			bq.out <- NewMetricsMapStr(dc.dataID, data)
+			bq.emit(dc, NewMetricsMapStr(dc.dataID, data))
 		case define.RecordProfiles:
-			bq.out <- NewProfilesMapStr(dc.dataID, data)
+			bq.emit(dc, NewProfilesMapStr(dc.dataID, data))
 		case define.RecordProxy:
-			bq.out <- NewProxyMapStr(dc.dataID, data)
+			bq.emit(dc, NewProxyMapStr(dc.dataID, data))
 
 		// 数据不做聚合
 		case define.RecordPingserver, define.RecordFta, define.RecordBeat:
 			for _, item := range data {
-				bq.out <- item
+				bq.emit(dc, item)
 			}
 		}
 
@@ -199,13 +364,19 @@ func (bq *BatchQueue) compact(dc DataIDChan) {
 	}
 }
 
-func (bq *BatchQueue) Pop() <-chan common.MapStr {
+func (bq *BatchQueue) Pop() <-chan Item {
 	return bq.out
 }
 
 func (bq *BatchQueue) Close() {
 	bq.cancel()
 	bq.wg.Wait()
+
+	bq.mut.Lock()
+	defer bq.mut.Unlock()
+	for _, spool := range bq.spools {
+		spool.Close()
+	}
 }
 
 func (bq *BatchQueue) Put(events ...define.Event) {
@@ -235,6 +406,7 @@ func (bq *BatchQueue) Put(events ...define.Event) {
 		}
 
 		ch := make(chan []define.Event, define.Concurrency())
+		bq.wg.Add(1)
 		go bq.compact(DataIDChan{
 			dataID:    dataID,
 			rtype:     rtype,
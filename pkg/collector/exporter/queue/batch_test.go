@@ -73,7 +73,7 @@ func TestQueueOut(t *testing.T) {
 
 	var total int
 	for {
-		ms := <-queue.Pop()
+		ms := (<-queue.Pop()).Data
 		v, _ := ms.GetValue("data")
 		data := v.([]common.MapStr)
 		total += len(data)
@@ -114,7 +114,7 @@ func TestQueueOutWithDelta(t *testing.T) {
 
 	var total int
 	for {
-		ms := <-queue.Pop()
+		ms := (<-queue.Pop()).Data
 		v, _ := ms.GetValue("items")
 		data := v.([]common.MapStr)
 		total += len(data)
@@ -162,10 +162,10 @@ func TestQueueFull(t *testing.T) {
 	for {
 		select {
 		case e := <-queue.Pop():
-			data, err := e.GetValue("data")
+			data, err := e.Data.GetValue("data")
 			assert.NoError(t, err)
 
-			dataID, err := e.GetValue("dataid")
+			dataID, err := e.Data.GetValue("dataid")
 			assert.NoError(t, err)
 
 			actual := data.([]common.MapStr)[0]["count"]
@@ -216,10 +216,10 @@ func TestQueueFullBatch(t *testing.T) {
 	for {
 		select {
 		case e := <-queue.Pop():
-			data, err := e.GetValue("data")
+			data, err := e.Data.GetValue("data")
 			assert.NoError(t, err)
 
-			dataID, err := e.GetValue("dataid")
+			dataID, err := e.Data.GetValue("dataid")
 			assert.NoError(t, err)
 
 			actual := data.([]common.MapStr)[0]["count"]
@@ -269,10 +269,10 @@ func TestQueueTick(t *testing.T) {
 	for {
 		select {
 		case e := <-queue.Pop():
-			data, err := e.GetValue("data")
+			data, err := e.Data.GetValue("data")
 			assert.NoError(t, err)
 
-			dataID, err := e.GetValue("dataid")
+			dataID, err := e.Data.GetValue("dataid")
 			assert.NoError(t, err)
 
 			actual := data.([]common.MapStr)[0]["count"]
@@ -319,10 +319,10 @@ func TestQueueResize(t *testing.T) {
 		for {
 			select {
 			case e := <-queue.Pop():
-				_, err := e.GetValue(key)
+				_, err := e.Data.GetValue(key)
 				assert.NoError(t, err)
 
-				dataID, err := e.GetValue("dataid")
+				dataID, err := e.Data.GetValue("dataid")
 				assert.NoError(t, err)
 				assert.Equal(t, int32(1001), dataID.(int32))
 				n++
@@ -370,7 +370,7 @@ func TestQueueUniqueKey(t *testing.T) {
 
 	n := 0
 	for i := 0; i < 2; i++ {
-		item := <-queue.Pop()
+		item := (<-queue.Pop()).Data
 		t.Logf("pop item: %+v", item)
 		n += len(item)
 	}
@@ -0,0 +1,612 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package queue
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+const (
+	segmentSuffix    = ".seg"
+	cursorFilename   = "cursor"
+	cursorSyncPeriod = time.Second
+	recordHeaderSize = 16 // length(4) + crc32(4) + unix nano(8)
+
+	defaultDiskPath         = "./queue"
+	defaultDiskMaxBytes     = 1 << 30
+	defaultDiskMaxAge       = 24 * time.Hour
+	defaultDiskSegmentBytes = 64 << 20
+	defaultDiskMaxInflight  = 64
+
+	dropReasonSize      = "size"
+	dropReasonExpired   = "expired"
+	dropReasonCorrupted = "corrupted"
+)
+
+var (
+	diskBacklogBytes = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_disk_queue_backlog_bytes",
+			Help:      "Exporter disk queue backlog bytes",
+		},
+		[]string{"record_type", "id"},
+	)
+
+	diskDroppedBytes = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "exporter_disk_queue_dropped_bytes_total",
+			Help:      "Exporter disk queue dropped bytes total",
+		},
+		[]string{"record_type", "id", "reason"},
+	)
+)
+
+func (m *metricMonitor) SetDiskBacklogBytes(n int64, rtype define.RecordType, dataId int32) {
+	diskBacklogBytes.WithLabelValues(rtype.S(), strconv.Itoa(int(dataId))).Set(float64(n))
+}
+
+func (m *metricMonitor) AddDiskDroppedBytes(n int64, rtype define.RecordType, dataId int32, reason string) {
+	diskDroppedBytes.WithLabelValues(rtype.S(), strconv.Itoa(int(dataId)), reason).Add(float64(n))
+}
+
+// DiskConfig 磁盘队列配置 MaxBytes/MaxAge 均针对单个 RecordType/DataID 生效
+type DiskConfig struct {
+	Enabled      bool          `config:"enabled" mapstructure:"enabled"`
+	Path         string        `config:"path" mapstructure:"path"`
+	MaxBytes     int64         `config:"max_bytes" mapstructure:"max_bytes"`
+	MaxAge       time.Duration `config:"max_age" mapstructure:"max_age"`
+	SegmentBytes int64         `config:"segment_bytes" mapstructure:"segment_bytes"`
+	MaxInflight  int           `config:"max_inflight" mapstructure:"max_inflight"`
+}
+
+func (c *DiskConfig) Validate() {
+	if c.Path == "" {
+		c.Path = defaultDiskPath
+	}
+	if c.MaxBytes <= 0 {
+		c.MaxBytes = defaultDiskMaxBytes
+	}
+	if c.MaxAge <= 0 {
+		c.MaxAge = defaultDiskMaxAge
+	}
+	if c.SegmentBytes <= 0 {
+		c.SegmentBytes = defaultDiskSegmentBytes
+	}
+	if c.MaxInflight <= 0 {
+		c.MaxInflight = defaultDiskMaxInflight
+	}
+}
+
+func spoolDirname(rtype define.RecordType, dataId int32) string {
+	return fmt.Sprintf("%s_%d", rtype, dataId)
+}
+
+func parseSpoolDirname(name string) (define.RecordType, int32, bool) {
+	idx := strings.LastIndex(name, "_")
+	if idx <= 0 {
+		return "", 0, false
+	}
+	dataId, err := strconv.ParseInt(name[idx+1:], 10, 32)
+	if err != nil {
+		return "", 0, false
+	}
+	return define.RecordType(name[:idx]), int32(dataId), true
+}
+
+func segmentFilename(seq uint64) string {
+	return fmt.Sprintf("%020d%s", seq, segmentSuffix)
+}
+
+func encodeRecord(payload []byte, t time.Time) []byte {
+	buf := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(payload))
+	binary.BigEndian.PutUint64(buf[8:16], uint64(t.UnixNano()))
+	copy(buf[recordHeaderSize:], payload)
+	return buf
+}
+
+var errCorruptedRecord = errors.New("corrupted record")
+
+// readRecord 读取 offset 处的记录 数据不完整时返回 io.EOF
+func readRecord(f *os.File, offset int64) ([]byte, time.Time, error) {
+	header := make([]byte, recordHeaderSize)
+	if _, err := f.ReadAt(header, offset); err != nil {
+		if err == io.EOF {
+			return nil, time.Time{}, io.EOF
+		}
+		return nil, time.Time{}, err
+	}
+
+	size := binary.BigEndian.Uint32(header[0:4])
+	checksum := binary.BigEndian.Uint32(header[4:8])
+	ts := time.Unix(0, int64(binary.BigEndian.Uint64(header[8:16])))
+
+	payload := make([]byte, size)
+	if _, err := f.ReadAt(payload, offset+recordHeaderSize); err != nil {
+		if err == io.EOF {
+			return nil, time.Time{}, io.EOF
+		}
+		return nil, time.Time{}, err
+	}
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return nil, time.Time{}, errCorruptedRecord
+	}
+	return payload, ts, nil
+}
+
+// spoolPosition 记录在 spool 中的位置 用于确认消费
+type spoolPosition struct {
+	seq    uint64
+	offset int64
+	size   int64
+}
+
+// diskSpool 单个 RecordType/DataID 的磁盘队列
+//
+// 数据按顺序追加到 segment 文件中 消费进度持久化在 cursor 文件内
+// 写入总是发生在最后一个 segment 消费进度总是位于第一个 segment 消费完毕的 segment 会被删除
+// 读取进度可以领先于消费进度 从而允许多条数据同时在途
+type diskSpool struct {
+	mut    sync.Mutex
+	conf   DiskConfig
+	dir    string
+	rtype  define.RecordType
+	dataId int32
+
+	segments   []uint64
+	sizes      map[uint64]int64
+	writer     *os.File
+	readOffset int64 // 第一个 segment 中已确认消费的偏移
+	backlog    int64 // 未确认消费的字节数
+
+	// 读取进度 reader 为 peekSeq 对应的 segment 文件
+	reader     *os.File
+	peekSeq    uint64
+	peekOffset int64
+
+	// cursor 变更后不会立即落盘 而是按周期批量写入
+	dirty    bool
+	syncedAt time.Time
+
+	notify chan struct{}
+}
+
+func openDiskSpool(conf DiskConfig, rtype define.RecordType, dataId int32) (*diskSpool, error) {
+	dir := filepath.Join(conf.Path, spoolDirname(rtype, dataId))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &diskSpool{
+		conf:   conf,
+		dir:    dir,
+		rtype:  rtype,
+		dataId: dataId,
+		sizes:  make(map[uint64]int64),
+		notify: make(chan struct{}, 1),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	// 重启后总是写入新的 segment 避免追加在残缺的记录之后
+	if err := s.rotate(); err != nil {
+		return nil, err
+	}
+	s.peekSeq = s.segments[0]
+	s.peekOffset = s.readOffset
+	s.updateBacklog()
+	return s, nil
+}
+
+func (s *diskSpool) load() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		s.segments = append(s.segments, seq)
+		s.sizes[seq] = info.Size()
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i] < s.segments[j] })
+
+	seq, offset, ok := s.loadCursor()
+	for ok && len(s.segments) > 0 && s.segments[0] < seq {
+		s.removeFirstSegment()
+	}
+	if ok && len(s.segments) > 0 && s.segments[0] == seq && offset <= s.sizes[seq] {
+		s.readOffset = offset
+	}
+
+	for _, seq := range s.segments {
+		s.backlog += s.sizes[seq]
+	}
+	s.backlog -= s.readOffset
+	return nil
+}
+
+func (s *diskSpool) loadCursor() (uint64, int64, bool) {
+	b, err := os.ReadFile(filepath.Join(s.dir, cursorFilename))
+	if err != nil {
+		return 0, 0, false
+	}
+
+	var seq uint64
+	var offset int64
+	if _, err := fmt.Sscanf(string(b), "%d %d", &seq, &offset); err != nil {
+		return 0, 0, false
+	}
+	return seq, offset, true
+}
+
+// saveCursor 标记 cursor 已变更 距离上次落盘超过 cursorSyncPeriod 时才写入磁盘
+func (s *diskSpool) saveCursor() {
+	s.dirty = true
+	if time.Since(s.syncedAt) >= cursorSyncPeriod {
+		s.syncCursor()
+	}
+}
+
+// syncCursor 先写临时文件并 fsync 再 rename 保证 cursor 文件不会出现残缺内容
+//
+// 写入 cursor 前先对写入中的 segment 执行 fsync 确保落盘的进度不会领先于数据
+func (s *diskSpool) syncCursor() {
+	if !s.dirty || len(s.segments) == 0 {
+		return
+	}
+
+	if s.writer != nil {
+		if err := s.writer.Sync(); err != nil {
+			logger.Warnf("failed to sync disk queue segment, dir=%s, err: %v", s.dir, err)
+		}
+	}
+
+	content := fmt.Sprintf("%d %d", s.segments[0], s.readOffset)
+	if err := writeFileAtomic(filepath.Join(s.dir, cursorFilename), []byte(content)); err != nil {
+		logger.Warnf("failed to save disk queue cursor, dir=%s, err: %v", s.dir, err)
+		return
+	}
+	s.dirty = false
+	s.syncedAt = time.Now()
+}
+
+func writeFileAtomic(filename string, data []byte) error {
+	tmp := filename + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+func (s *diskSpool) updateBacklog() {
+	DefaultMetricMonitor.SetDiskBacklogBytes(s.backlog, s.rtype, s.dataId)
+}
+
+func (s *diskSpool) rotate() error {
+	var seq uint64 = 1
+	if n := len(s.segments); n > 0 {
+		seq = s.segments[n-1] + 1
+	}
+
+	f, err := os.OpenFile(filepath.Join(s.dir, segmentFilename(seq)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	// 切换前确保旧 segment 已经落盘
+	if s.writer != nil {
+		if err := s.writer.Sync(); err != nil {
+			logger.Warnf("failed to sync disk queue segment, dir=%s, err: %v", s.dir, err)
+		}
+		_ = s.writer.Close()
+	}
+	s.writer = f
+	s.segments = append(s.segments, seq)
+	s.sizes[seq] = 0
+	return nil
+}
+
+// removeFirstSegment 删除第一个 segment 并返回其中未被消费的字节数
+func (s *diskSpool) removeFirstSegment() int64 {
+	seq := s.segments[0]
+	remaining := s.sizes[seq] - s.readOffset
+
+	if s.reader != nil && s.peekSeq == seq {
+		_ = s.reader.Close()
+		s.reader = nil
+	}
+	if err := os.Remove(filepath.Join(s.dir, segmentFilename(seq))); err != nil && !os.IsNotExist(err) {
+		logger.Warnf("failed to remove disk queue segment, dir=%s, seq=%d, err: %v", s.dir, seq, err)
+	}
+
+	delete(s.sizes, seq)
+	s.segments = s.segments[1:]
+	s.readOffset = 0
+
+	// 读取进度所在的 segment 被删除时从下一个 segment 开始读取
+	if len(s.segments) > 0 && s.peekSeq <= seq {
+		s.peekSeq = s.segments[0]
+		s.peekOffset = 0
+	}
+	return remaining
+}
+
+// peekNextSegment 读取进度切换到下一个 segment
+func (s *diskSpool) peekNextSegment() {
+	if s.reader != nil {
+		_ = s.reader.Close()
+		s.reader = nil
+	}
+	for _, seq := range s.segments {
+		if seq > s.peekSeq {
+			s.peekSeq = seq
+			break
+		}
+	}
+	s.peekOffset = 0
+}
+
+// dropOldest 超出容量时丢弃最旧的 segment
+func (s *diskSpool) dropOldest() error {
+	if len(s.segments) == 1 {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	dropped := s.removeFirstSegment()
+	s.backlog -= dropped
+	s.saveCursor()
+	DefaultMetricMonitor.AddDiskDroppedBytes(dropped, s.rtype, s.dataId, dropReasonSize)
+	return nil
+}
+
+// Append 追加数据到队列尾部
+func (s *diskSpool) Append(ms common.MapStr) error {
+	payload, err := json.Marshal(ms)
+	if err != nil {
+		return err
+	}
+	record := encodeRecord(payload, time.Now())
+	size := int64(len(record))
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	for s.backlog > 0 && s.backlog+size > s.conf.MaxBytes {
+		if err := s.dropOldest(); err != nil {
+			return err
+		}
+	}
+
+	last := s.segments[len(s.segments)-1]
+	if s.sizes[last] >= s.conf.SegmentBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+		last = s.segments[len(s.segments)-1]
+	}
+
+	if _, err := s.writer.Write(record); err != nil {
+		return err
+	}
+	s.sizes[last] += size
+	s.backlog += size
+	s.updateBacklog()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Next 从读取进度处读取下一条数据并推进读取进度 无数据时返回 false
+//
+// 读取到的数据需要调用 Commit 确认后才会推进消费进度 过期或者损坏的数据会被跳过 随后续的确认一并提交
+func (s *diskSpool) Next() (common.MapStr, spoolPosition, bool) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	for {
+		seq := s.peekSeq
+		writing := seq == s.segments[len(s.segments)-1]
+
+		if s.reader == nil {
+			f, err := os.Open(filepath.Join(s.dir, segmentFilename(seq)))
+			if err != nil {
+				logger.Warnf("failed to open disk queue segment, dir=%s, seq=%d, err: %v", s.dir, seq, err)
+				if writing {
+					return nil, spoolPosition{}, false
+				}
+				DefaultMetricMonitor.AddDiskDroppedBytes(s.sizes[seq]-s.peekOffset, s.rtype, s.dataId, dropReasonCorrupted)
+				s.peekNextSegment()
+				continue
+			}
+			s.reader = f
+		}
+
+		payload, ts, err := readRecord(s.reader, s.peekOffset)
+		if err != nil {
+			// 写入中的 segment 读到末尾说明暂无数据
+			if writing {
+				return nil, spoolPosition{}, false
+			}
+			if remaining := s.sizes[seq] - s.peekOffset; err != io.EOF || remaining > 0 {
+				logger.Warnf("disk queue segment corrupted, dir=%s, seq=%d, err: %v", s.dir, seq, err)
+				DefaultMetricMonitor.AddDiskDroppedBytes(remaining, s.rtype, s.dataId, dropReasonCorrupted)
+			}
+			s.peekNextSegment()
+			continue
+		}
+
+		size := int64(recordHeaderSize + len(payload))
+		offset := s.peekOffset
+		s.peekOffset += size
+
+		if time.Since(ts) > s.conf.MaxAge {
+			DefaultMetricMonitor.AddDiskDroppedBytes(size, s.rtype, s.dataId, dropReasonExpired)
+			continue
+		}
+
+		var ms common.MapStr
+		decoder := json.NewDecoder(bytes.NewReader(payload))
+		decoder.UseNumber()
+		if err := decoder.Decode(&ms); err != nil {
+			DefaultMetricMonitor.AddDiskDroppedBytes(size, s.rtype, s.dataId, dropReasonCorrupted)
+			continue
+		}
+
+		// 输出端依赖 dataid 的数值类型
+		ms["dataid"] = s.dataId
+		return ms, spoolPosition{seq: seq, offset: offset, size: size}, true
+	}
+}
+
+// commit 将消费进度推进到 seq/offset 处 位置已经失效（比如被容量淘汰）时忽略
+func (s *diskSpool) commit(seq uint64, offset int64) {
+	if seq < s.segments[0] || (seq == s.segments[0] && offset <= s.readOffset) {
+		return
+	}
+
+	for s.segments[0] < seq {
+		s.backlog -= s.removeFirstSegment()
+	}
+	s.backlog -= offset - s.readOffset
+	s.readOffset = offset
+
+	// 消费完毕的 segment 直接删除
+	if len(s.segments) > 1 && s.readOffset >= s.sizes[s.segments[0]] {
+		s.backlog -= s.removeFirstSegment()
+	}
+	s.saveCursor()
+	s.updateBacklog()
+}
+
+// Commit 确认 pos 及其之前的数据均已消费 调用方须按 Next 返回的顺序提交
+func (s *diskSpool) Commit(pos spoolPosition) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.commit(pos.seq, pos.offset+pos.size)
+}
+
+// CommitRead 将消费进度推进到读取进度 仅在没有在途数据时调用 用于提交被跳过的数据
+func (s *diskSpool) CommitRead() {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.commit(s.peekSeq, s.peekOffset)
+}
+
+// Sync 将尚未落盘的 cursor 写入磁盘
+func (s *diskSpool) Sync() {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.syncCursor()
+}
+
+// Backlog 返回未消费的字节数
+func (s *diskSpool) Backlog() int64 {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.backlog
+}
+
+func (s *diskSpool) Close() {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.syncCursor()
+	if s.writer != nil {
+		_ = s.writer.Close()
+	}
+	if s.reader != nil {
+		_ = s.reader.Close()
+	}
+}
+
+// loadDiskSpools 扫描磁盘目录 恢复上次未消费完的队列
+func loadDiskSpools(conf DiskConfig) map[string]*diskSpool {
+	spools := make(map[string]*diskSpool)
+	entries, err := os.ReadDir(conf.Path)
+	if err != nil {
+		return spools
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		rtype, dataId, ok := parseSpoolDirname(entry.Name())
+		if !ok {
+			continue
+		}
+		spool, err := openDiskSpool(conf, rtype, dataId)
+		if err != nil {
+			logger.Errorf("failed to open disk queue, dir=%s, err: %v", entry.Name(), err)
+			continue
+		}
+		logger.Infof("replay disk queue, rtype=%s, dataid=%d, backlog=%d", rtype, dataId, spool.Backlog())
+		spools[spoolKey(rtype, dataId)] = spool
+	}
+	return spools
+}
+
+func spoolKey(rtype define.RecordType, dataId int32) string {
+	return strconv.Itoa(int(dataId)) + "/" + string(rtype)
+}
@@ -0,0 +1,343 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package queue
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+)
+
+func newTestDiskConfig(t *testing.T) DiskConfig {
+	conf := DiskConfig{Enabled: true, Path: t.TempDir()}
+	conf.Validate()
+	return conf
+}
+
+func nextCount(t *testing.T, spool *diskSpool) int {
+	ms, pos, ok := spool.Next()
+	assert.True(t, ok)
+	assert.Equal(t, int32(1001), ms["dataid"])
+	spool.Commit(pos)
+
+	n, err := ms["count"].(json.Number).Int64()
+	assert.NoError(t, err)
+	return int(n)
+}
+
+func TestParseSpoolDirname(t *testing.T) {
+	rtype, dataId, ok := parseSpoolDirname(spoolDirname(define.RecordRemoteWrite, 1001))
+	assert.True(t, ok)
+	assert.Equal(t, define.RecordRemoteWrite, rtype)
+	assert.Equal(t, int32(1001), dataId)
+
+	_, _, ok = parseSpoolDirname("metrics")
+	assert.False(t, ok)
+}
+
+func TestDiskSpoolAppendNext(t *testing.T) {
+	spool, err := openDiskSpool(newTestDiskConfig(t), define.RecordMetrics, 1001)
+	assert.NoError(t, err)
+	defer spool.Close()
+
+	_, _, ok := spool.Next()
+	assert.False(t, ok)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, spool.Append(common.MapStr{"dataid": 1001, "count": i}))
+	}
+	assert.True(t, spool.Backlog() > 0)
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, i, nextCount(t, spool))
+	}
+	_, _, ok = spool.Next()
+	assert.False(t, ok)
+	assert.Equal(t, int64(0), spool.Backlog())
+}
+
+func TestDiskSpoolInflight(t *testing.T) {
+	conf := newTestDiskConfig(t)
+	conf.SegmentBytes = 64
+
+	spool, err := openDiskSpool(conf, define.RecordMetrics, 1001)
+	assert.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, spool.Append(common.MapStr{"count": i}))
+	}
+
+	// 读取进度领先于消费进度 可以连续读取多条数据
+	var positions []spoolPosition
+	for i := 0; i < 3; i++ {
+		ms, pos, ok := spool.Next()
+		assert.True(t, ok)
+		n, _ := ms["count"].(json.Number).Int64()
+		assert.Equal(t, i, int(n))
+		positions = append(positions, pos)
+	}
+	assert.NotEqual(t, positions[0], positions[1])
+
+	// 仅提交前两条 未确认的数据在重启后回放
+	spool.Commit(positions[1])
+	spool.Close()
+
+	spool, err = openDiskSpool(conf, define.RecordMetrics, 1001)
+	assert.NoError(t, err)
+	defer spool.Close()
+	for i := 2; i < 5; i++ {
+		assert.Equal(t, i, nextCount(t, spool))
+	}
+	assert.Equal(t, int64(0), spool.Backlog())
+}
+
+func TestDiskSpoolReplay(t *testing.T) {
+	conf := newTestDiskConfig(t)
+	conf.SegmentBytes = 64
+
+	spool, err := openDiskSpool(conf, define.RecordMetrics, 1001)
+	assert.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, spool.Append(common.MapStr{"count": i}))
+	}
+	assert.Equal(t, 0, nextCount(t, spool))
+	assert.Equal(t, 1, nextCount(t, spool))
+	spool.Close()
+
+	spools := loadDiskSpools(conf)
+	assert.Len(t, spools, 1)
+	spool = spools[spoolKey(define.RecordMetrics, 1001)]
+	defer spool.Close()
+
+	for i := 2; i < 5; i++ {
+		assert.Equal(t, i, nextCount(t, spool))
+	}
+	_, _, ok := spool.Next()
+	assert.False(t, ok)
+}
+
+func TestDiskSpoolMaxBytes(t *testing.T) {
+	conf := newTestDiskConfig(t)
+	conf.SegmentBytes = 64
+	conf.MaxBytes = 256
+
+	spool, err := openDiskSpool(conf, define.RecordMetrics, 1001)
+	assert.NoError(t, err)
+	defer spool.Close()
+
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, spool.Append(common.MapStr{"count": i}))
+		assert.True(t, spool.Backlog() <= conf.MaxBytes)
+	}
+
+	// 最旧的数据已被淘汰 剩余数据依然有序
+	prev := nextCount(t, spool)
+	assert.True(t, prev > 0)
+	for {
+		ms, pos, ok := spool.Next()
+		if !ok {
+			break
+		}
+		spool.Commit(pos)
+		n, _ := ms["count"].(json.Number).Int64()
+		assert.Equal(t, prev+1, int(n))
+		prev = int(n)
+	}
+	assert.Equal(t, 99, prev)
+}
+
+func TestDiskSpoolMaxAge(t *testing.T) {
+	conf := newTestDiskConfig(t)
+	conf.MaxAge = time.Millisecond
+
+	spool, err := openDiskSpool(conf, define.RecordMetrics, 1001)
+	assert.NoError(t, err)
+	defer spool.Close()
+
+	assert.NoError(t, spool.Append(common.MapStr{"count": 0}))
+	time.Sleep(10 * time.Millisecond)
+
+	_, _, ok := spool.Next()
+	assert.False(t, ok)
+
+	// 过期的数据在没有在途数据时提交
+	assert.True(t, spool.Backlog() > 0)
+	spool.CommitRead()
+	assert.Equal(t, int64(0), spool.Backlog())
+}
+
+func TestDiskSpoolCorrupted(t *testing.T) {
+	conf := newTestDiskConfig(t)
+	dir := filepath.Join(conf.Path, spoolDirname(define.RecordMetrics, 1001))
+	assert.NoError(t, os.MkdirAll(dir, 0o755))
+
+	// 残缺的 segment 会被跳过
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, segmentFilename(1)), []byte("broken"), 0o644))
+
+	spool, err := openDiskSpool(conf, define.RecordMetrics, 1001)
+	assert.NoError(t, err)
+	defer spool.Close()
+
+	assert.NoError(t, spool.Append(common.MapStr{"count": 1}))
+	assert.Equal(t, 1, nextCount(t, spool))
+}
+
+func TestQueueOutWithDisk(t *testing.T) {
+	conf := Config{
+		MetricsBatchSize: 10,
+		FlushInterval:    time.Second,
+		Disk:             newTestDiskConfig(t),
+	}
+
+	// 上次遗留的数据
+	spool, err := openDiskSpool(conf.Disk, define.RecordPushGateway, 1001)
+	assert.NoError(t, err)
+	assert.NoError(t, spool.Append(NewMetricsMapStr(1001, []common.MapStr{{"count": -1}})))
+	spool.Close()
+
+	queue := NewBatchQueue(conf, func(s string) Config {
+		return Config{}
+	})
+	defer queue.Close()
+
+	for i := 0; i < 20; i++ {
+		queue.Put(&testMetricsEvent{
+			CommonEvent: define.NewCommonEvent(define.Token{}, 1001, common.MapStr{"count": i}),
+		})
+	}
+
+	var total int
+	for total < 21 {
+		item := <-queue.Pop()
+		assert.Equal(t, int32(1001), item.Data["dataid"])
+		total += len(item.Data["data"].([]interface{}))
+		item.Ack(true)
+	}
+	assert.Equal(t, 21, total)
+}
+
+func TestQueueOutWithDiskSendFailed(t *testing.T) {
+	conf := Config{
+		MetricsBatchSize: 1,
+		FlushInterval:    time.Second,
+		Disk:             newTestDiskConfig(t),
+	}
+	queue := NewBatchQueue(conf, func(s string) Config {
+		return Config{}
+	})
+
+	queue.Put(&testMetricsEvent{
+		CommonEvent: define.NewCommonEvent(define.Token{}, 1001, common.MapStr{"count": 1}),
+	})
+
+	// 发送失败的数据会被重新投递 重复确认会被忽略
+	item := <-queue.Pop()
+	item.Ack(false)
+	item.Ack(true)
+	item = <-queue.Pop()
+	assert.Equal(t, int32(1001), item.Data["dataid"])
+
+	// 未确认的数据在重启后依然存在
+	queue.Close()
+	spool, err := openDiskSpool(conf.Disk, define.RecordPushGateway, 1001)
+	assert.NoError(t, err)
+	assert.True(t, spool.Backlog() > 0)
+
+	ms, pos, ok := spool.Next()
+	assert.True(t, ok)
+	assert.Len(t, ms["data"], 1)
+	spool.Commit(pos)
+	spool.Close()
+
+	spool, err = openDiskSpool(conf.Disk, define.RecordPushGateway, 1001)
+	assert.NoError(t, err)
+	defer spool.Close()
+	assert.Equal(t, int64(0), spool.Backlog())
+}
+
+func TestQueueOutWithDiskInflight(t *testing.T) {
+	conf := Config{
+		MetricsBatchSize: 1,
+		FlushInterval:    time.Second,
+		Disk:             newTestDiskConfig(t),
+	}
+	conf.Disk.MaxInflight = 2
+	queue := NewBatchQueue(conf, func(s string) Config {
+		return Config{}
+	})
+
+	for i := 0; i < 3; i++ {
+		queue.Put(&testMetricsEvent{
+			CommonEvent: define.NewCommonEvent(define.Token{}, 1001, common.MapStr{"count": i}),
+		})
+	}
+
+	// 未确认前最多有 MaxInflight 条数据在途
+	first := <-queue.Pop()
+	second := <-queue.Pop()
+	select {
+	case <-queue.Pop():
+		assert.Fail(t, "too many inflight items")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// 乱序确认时按顺序提交 确认后继续投递
+	second.Ack(true)
+	first.Ack(true)
+	third := <-queue.Pop()
+	queue.Close()
+
+	// 第三条未确认 重启后回放
+	spool, err := openDiskSpool(conf.Disk, define.RecordPushGateway, 1001)
+	assert.NoError(t, err)
+	defer spool.Close()
+
+	ms, _, ok := spool.Next()
+	assert.True(t, ok)
+	assert.Equal(t, third.Data["data"], ms["data"])
+	_, _, ok = spool.Next()
+	assert.False(t, ok)
+}
+
+func TestDiskSpoolSyncCursor(t *testing.T) {
+	spool, err := openDiskSpool(newTestDiskConfig(t), define.RecordMetrics, 1001)
+	assert.NoError(t, err)
+	defer spool.Close()
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, spool.Append(common.MapStr{"count": i}))
+	}
+	cursor := filepath.Join(spool.dir, cursorFilename)
+
+	// 首次提交立即落盘 周期内的后续提交只标记变更
+	nextCount(t, spool)
+	b, err := os.ReadFile(cursor)
+	assert.NoError(t, err)
+	nextCount(t, spool)
+	b2, err := os.ReadFile(cursor)
+	assert.NoError(t, err)
+	assert.Equal(t, b, b2)
+	assert.True(t, spool.dirty)
+
+	spool.Sync()
+	b3, err := os.ReadFile(cursor)
+	assert.NoError(t, err)
+	assert.NotEqual(t, b, b3)
+	assert.False(t, spool.dirty)
+
+	_, err = os.Stat(cursor + ".tmp")
+	assert.True(t, os.IsNotExist(err))
+}
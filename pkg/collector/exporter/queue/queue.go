@@ -22,13 +22,33 @@ type Queue interface {
 	// Put 推送数据到队列中 调用者须保证同一批次的 Event 的 RecordType/DataID 是相同的
 	Put(events ...define.Event)
 
-	// Pop 弹出转换后的 MapStr 数据
-	Pop() <-chan common.MapStr
+	// Pop 弹出转换后的数据 发送完成后须调用 Item.Ack 确认
+	Pop() <-chan Item
 
 	// Close 队列清理并关闭
 	Close()
 }
 
+// Item 队列输出的数据
+//
+// 来自磁盘队列的数据在 Ack(true) 之后才会推进消费进度 发送失败的数据会被重新投递 未确认的数据在重启后回放
+type Item struct {
+	Data common.MapStr
+	ack  func(sent bool)
+}
+
+// Ack 确认数据发送结果
+func (i Item) Ack(sent bool) {
+	if i.ack != nil {
+		i.ack(sent)
+	}
+}
+
+// NeedAck 来自磁盘队列的数据需要等待输出端确认
+func (i Item) NeedAck() bool {
+	return i.ack != nil
+}
+
 // NewEventsMapStr 代表着事件类型数据
 func NewEventsMapStr(dataId int32, data []common.MapStr) common.MapStr {
 	now := time.Now()
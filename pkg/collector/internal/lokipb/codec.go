@@ -0,0 +1,173 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package lokipb
+
+import (
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/protowireutil"
+)
+
+// Unmarshal 解码 logproto.PushRequest
+func Unmarshal(b []byte, req *PushRequest) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		if num != 1 {
+			return 0, false, nil
+		}
+		v, n, err := protowireutil.ConsumeBytes(typ, b)
+		if err != nil {
+			return 0, false, err
+		}
+		var stream Stream
+		if err := unmarshalStream(v, &stream); err != nil {
+			return 0, false, err
+		}
+		req.Streams = append(req.Streams, stream)
+		return n, true, nil
+	})
+}
+
+func unmarshalStream(b []byte, s *Stream) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			v, n, err := protowireutil.ConsumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			s.Labels = string(v)
+			return n, true, nil
+
+		case 2:
+			v, n, err := protowireutil.ConsumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			var entry Entry
+			if err := unmarshalEntry(v, &entry); err != nil {
+				return 0, false, err
+			}
+			s.Entries = append(s.Entries, entry)
+			return n, true, nil
+		}
+		return 0, false, nil
+	})
+}
+
+func unmarshalEntry(b []byte, e *Entry) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			v, n, err := protowireutil.ConsumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			ts, err := unmarshalTimestamp(v)
+			if err != nil {
+				return 0, false, err
+			}
+			e.Timestamp = ts
+			return n, true, nil
+
+		case 2:
+			v, n, err := protowireutil.ConsumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			e.Line = string(v)
+			return n, true, nil
+
+		case 3:
+			v, n, err := protowireutil.ConsumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			var pair LabelPair
+			if err := unmarshalLabelPair(v, &pair); err != nil {
+				return 0, false, err
+			}
+			e.StructuredMetadata = append(e.StructuredMetadata, pair)
+			return n, true, nil
+		}
+		return 0, false, nil
+	})
+}
+
+// unmarshalTimestamp 解码 google.protobuf.Timestamp 并转换为纳秒时间戳
+func unmarshalTimestamp(b []byte) (int64, error) {
+	var seconds, nanos int64
+	err := protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			v, n, err := protowireutil.ConsumeVarint(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			seconds = int64(v)
+			return n, true, nil
+
+		case 2:
+			v, n, err := protowireutil.ConsumeVarint(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			nanos = int64(int32(v))
+			return n, true, nil
+		}
+		return 0, false, nil
+	})
+	return seconds*1e9 + nanos, err
+}
+
+func unmarshalLabelPair(b []byte, pair *LabelPair) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1, 2:
+			v, n, err := protowireutil.ConsumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			if num == 1 {
+				pair.Name = string(v)
+			} else {
+				pair.Value = string(v)
+			}
+			return n, true, nil
+		}
+		return 0, false, nil
+	})
+}
+
+// Marshal 编码 logproto.PushRequest
+func Marshal(req *PushRequest) []byte {
+	var b []byte
+	for _, s := range req.Streams {
+		var stream []byte
+		stream = protowireutil.AppendString(stream, 1, s.Labels)
+		for _, e := range s.Entries {
+			var ts []byte
+			ts = protowireutil.AppendVarint(ts, 1, uint64(e.Timestamp/1e9))
+			ts = protowireutil.AppendVarint(ts, 2, uint64(e.Timestamp%1e9))
+
+			var entry []byte
+			entry = protowireutil.AppendMessage(entry, 1, ts)
+			entry = protowireutil.AppendString(entry, 2, e.Line)
+			for _, pair := range e.StructuredMetadata {
+				var lp []byte
+				lp = protowireutil.AppendString(lp, 1, pair.Name)
+				lp = protowireutil.AppendString(lp, 2, pair.Value)
+				entry = protowireutil.AppendMessage(entry, 3, lp)
+			}
+			stream = protowireutil.AppendMessage(stream, 2, entry)
+		}
+		b = protowireutil.AppendMessage(b, 1, stream)
+	}
+	return b
+}
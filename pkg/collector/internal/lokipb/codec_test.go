@@ -0,0 +1,87 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package lokipb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalUnmarshal(t *testing.T) {
+	req := &PushRequest{
+		Streams: []Stream{
+			{
+				Labels: `{job="app", env="prod"}`,
+				Entries: []Entry{
+					{Timestamp: 1700000000123456789, Line: "hello"},
+					{
+						Timestamp:          1700000001000000000,
+						Line:               "world",
+						StructuredMetadata: []LabelPair{{Name: "trace_id", Value: "abc"}},
+					},
+				},
+			},
+		},
+	}
+
+	var got PushRequest
+	assert.NoError(t, Unmarshal(Marshal(req), &got))
+	assert.Equal(t, *req, got)
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	var req PushRequest
+	assert.Error(t, Unmarshal([]byte{0x0a, 0xff}, &req))
+}
+
+func TestParseLabels(t *testing.T) {
+	tests := []struct {
+		input  string
+		labels []LabelPair
+		err    bool
+	}{
+		{
+			input:  `{job="app", env="prod"}`,
+			labels: []LabelPair{{Name: "job", Value: "app"}, {Name: "env", Value: "prod"}},
+		},
+		{
+			input:  `{msg="a \"quoted\", value"}`,
+			labels: []LabelPair{{Name: "msg", Value: `a "quoted", value`}},
+		},
+		{
+			input: `{}`,
+		},
+		{
+			input: `job="app"`,
+			err:   true,
+		},
+		{
+			input: `{job=app}`,
+			err:   true,
+		},
+		{
+			input: `{job="app" env="prod"}`,
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			labels, err := ParseLabels(tt.input)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.labels, labels)
+		})
+	}
+}
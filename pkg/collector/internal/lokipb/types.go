@@ -0,0 +1,79 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+// Package lokipb 实现 loki push 协议（logproto.PushRequest）的编解码
+//
+// 引入 loki 依赖代价过高 因此基于 protowire 手动实现 仅覆盖 push 接口所需的字段
+// 参见 https://grafana.com/docs/loki/latest/reference/loki-http-api/#ingest-logs
+package lokipb
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type PushRequest struct {
+	Streams []Stream
+}
+
+type Stream struct {
+	Labels  string
+	Entries []Entry
+}
+
+type LabelPair struct {
+	Name  string
+	Value string
+}
+
+type Entry struct {
+	Timestamp          int64 // unix 纳秒时间戳
+	Line               string
+	StructuredMetadata []LabelPair
+}
+
+// ParseLabels 解析 `{job="app", env="prod"}` 格式的 labels 字符串
+func ParseLabels(s string) ([]LabelPair, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+		return nil, errors.Errorf("invalid labels '%s'", s)
+	}
+	s = strings.TrimSpace(s[1 : len(s)-1])
+
+	var pairs []LabelPair
+	for len(s) > 0 {
+		idx := strings.IndexByte(s, '=')
+		if idx <= 0 {
+			return nil, errors.Errorf("invalid label pair '%s'", s)
+		}
+		name := strings.TrimSpace(s[:idx])
+		s = strings.TrimSpace(s[idx+1:])
+
+		quoted, err := strconv.QuotedPrefix(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value of label '%s'", name)
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value of label '%s'", name)
+		}
+		pairs = append(pairs, LabelPair{Name: name, Value: value})
+
+		s = strings.TrimSpace(s[len(quoted):])
+		if len(s) > 0 {
+			if s[0] != ',' {
+				return nil, errors.Errorf("unexpected character '%c' after label '%s'", s[0], name)
+			}
+			s = strings.TrimSpace(s[1:])
+		}
+	}
+	return pairs, nil
+}
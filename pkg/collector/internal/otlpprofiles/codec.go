@@ -0,0 +1,538 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package otlpprofiles
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/protowireutil"
+)
+
+// varintField 解码单个 varint 字段并写入 dst
+func varintField(typ protowire.Type, b []byte, dst func(v uint64)) (int, bool, error) {
+	v, n, err := protowireutil.ConsumeVarint(typ, b)
+	if err != nil {
+		return 0, false, err
+	}
+	dst(v)
+	return n, true, nil
+}
+
+// messageField 解码单个嵌套消息字段
+func messageField(typ protowire.Type, b []byte, unmarshal func([]byte) error) (int, bool, error) {
+	v, n, err := protowireutil.ConsumeBytes(typ, b)
+	if err != nil {
+		return 0, false, err
+	}
+	if err := unmarshal(v); err != nil {
+		return 0, false, err
+	}
+	return n, true, nil
+}
+
+func repeatedInt32Field(typ protowire.Type, b []byte, dst *[]int32) (int, bool, error) {
+	n, err := protowireutil.ConsumeRepeatedVarint(typ, b, func(v uint64) {
+		*dst = append(*dst, int32(v))
+	})
+	return n, err == nil, err
+}
+
+// Unmarshal 解码 ExportProfilesServiceRequest
+func Unmarshal(b []byte, req *ExportProfilesServiceRequest) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		if num != 1 {
+			return 0, false, nil
+		}
+		return messageField(typ, b, func(v []byte) error {
+			var rp ResourceProfiles
+			if err := unmarshalResourceProfiles(v, &rp); err != nil {
+				return err
+			}
+			req.ResourceProfiles = append(req.ResourceProfiles, rp)
+			return nil
+		})
+	})
+}
+
+func unmarshalResourceProfiles(b []byte, rp *ResourceProfiles) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			return messageField(typ, b, func(v []byte) error {
+				return unmarshalAttributes(v, 1, &rp.Resource)
+			})
+		case 2:
+			return messageField(typ, b, func(v []byte) error {
+				var sp ScopeProfiles
+				if err := unmarshalScopeProfiles(v, &sp); err != nil {
+					return err
+				}
+				rp.ScopeProfiles = append(rp.ScopeProfiles, sp)
+				return nil
+			})
+		}
+		return 0, false, nil
+	})
+}
+
+func unmarshalScopeProfiles(b []byte, sp *ScopeProfiles) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			return messageField(typ, b, func(v []byte) error {
+				return unmarshalScope(v, sp)
+			})
+		case 2:
+			return messageField(typ, b, func(v []byte) error {
+				var p Profile
+				if err := unmarshalProfile(v, &p); err != nil {
+					return err
+				}
+				sp.Profiles = append(sp.Profiles, p)
+				return nil
+			})
+		}
+		return 0, false, nil
+	})
+}
+
+func unmarshalScope(b []byte, sp *ScopeProfiles) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1, 2:
+			v, n, err := protowireutil.ConsumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			if num == 1 {
+				sp.ScopeName = string(v)
+			} else {
+				sp.ScopeVersion = string(v)
+			}
+			return n, true, nil
+		}
+		return 0, false, nil
+	})
+}
+
+// unmarshalAttributes 解码消息中编号为 field 的 repeated KeyValue 字段
+func unmarshalAttributes(b []byte, field protowire.Number, attrs *[]KeyValue) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		if num != field {
+			return 0, false, nil
+		}
+		return messageField(typ, b, func(v []byte) error {
+			kv, ok, err := unmarshalKeyValue(v)
+			if err != nil {
+				return err
+			}
+			if ok {
+				*attrs = append(*attrs, kv)
+			}
+			return nil
+		})
+	})
+}
+
+func unmarshalKeyValue(b []byte) (KeyValue, bool, error) {
+	var kv KeyValue
+	var hasValue bool
+	err := protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			v, n, err := protowireutil.ConsumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			kv.Key = string(v)
+			return n, true, nil
+		case 2:
+			return messageField(typ, b, func(v []byte) error {
+				s, ok, err := unmarshalAnyValue(v)
+				kv.Value, hasValue = s, ok
+				return err
+			})
+		}
+		return 0, false, nil
+	})
+	return kv, hasValue, err
+}
+
+// unmarshalAnyValue 将标量类型的 AnyValue 转换为字符串
+func unmarshalAnyValue(b []byte) (string, bool, error) {
+	var s string
+	var ok bool
+	err := protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1, 7: // string_value/bytes_value
+			v, n, err := protowireutil.ConsumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			s, ok = string(v), true
+			return n, true, nil
+		case 2: // bool_value
+			return varintField(typ, b, func(v uint64) {
+				s, ok = strconv.FormatBool(v != 0), true
+			})
+		case 3: // int_value
+			return varintField(typ, b, func(v uint64) {
+				s, ok = strconv.FormatInt(int64(v), 10), true
+			})
+		case 4: // double_value
+			if typ != protowire.Fixed64Type {
+				return 0, false, errors.Errorf("unexpected wire type %d", typ)
+			}
+			v, n := protowire.ConsumeFixed64(b)
+			if n < 0 {
+				return 0, false, protowire.ParseError(n)
+			}
+			s, ok = strconv.FormatFloat(math.Float64frombits(v), 'f', -1, 64), true
+			return n, true, nil
+		}
+		return 0, false, nil
+	})
+	return s, ok, err
+}
+
+func unmarshalProfile(b []byte, p *Profile) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			return messageField(typ, b, func(v []byte) error {
+				var vt ValueType
+				if err := unmarshalValueType(v, &vt); err != nil {
+					return err
+				}
+				p.SampleType = append(p.SampleType, vt)
+				return nil
+			})
+		case 2:
+			return messageField(typ, b, func(v []byte) error {
+				var s Sample
+				if err := unmarshalSample(v, &s); err != nil {
+					return err
+				}
+				p.Sample = append(p.Sample, s)
+				return nil
+			})
+		case 3:
+			return messageField(typ, b, func(v []byte) error {
+				var m Mapping
+				if err := unmarshalMapping(v, &m); err != nil {
+					return err
+				}
+				p.MappingTable = append(p.MappingTable, m)
+				return nil
+			})
+		case 4:
+			return messageField(typ, b, func(v []byte) error {
+				var loc Location
+				if err := unmarshalLocation(v, &loc); err != nil {
+					return err
+				}
+				p.LocationTable = append(p.LocationTable, loc)
+				return nil
+			})
+		case 5:
+			return repeatedInt32Field(typ, b, &p.LocationIndices)
+		case 6:
+			return messageField(typ, b, func(v []byte) error {
+				var f Function
+				if err := unmarshalFunction(v, &f); err != nil {
+					return err
+				}
+				p.FunctionTable = append(p.FunctionTable, f)
+				return nil
+			})
+		case 7:
+			return messageField(typ, b, func(v []byte) error {
+				kv, _, err := unmarshalKeyValue(v)
+				// 属性表通过下标引用 即使值无法识别也需要占位
+				p.AttributeTable = append(p.AttributeTable, kv)
+				return err
+			})
+		case 10:
+			v, n, err := protowireutil.ConsumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			p.StringTable = append(p.StringTable, string(v))
+			return n, true, nil
+		case 11:
+			return varintField(typ, b, func(v uint64) { p.TimeNanos = int64(v) })
+		case 12:
+			return varintField(typ, b, func(v uint64) { p.DurationNanos = int64(v) })
+		case 13:
+			return messageField(typ, b, func(v []byte) error {
+				return unmarshalValueType(v, &p.PeriodType)
+			})
+		case 14:
+			return varintField(typ, b, func(v uint64) { p.Period = int64(v) })
+		case 15:
+			return repeatedInt32Field(typ, b, &p.CommentStrindices)
+		case 16:
+			return varintField(typ, b, func(v uint64) { p.DefaultSampleTypeStrindex = int32(v) })
+		case 17:
+			v, n, err := protowireutil.ConsumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			p.ProfileID = append([]byte(nil), v...)
+			return n, true, nil
+		case 18:
+			return repeatedInt32Field(typ, b, &p.AttributeIndices)
+		case 20:
+			v, n, err := protowireutil.ConsumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			p.OriginalPayloadFormat = string(v)
+			return n, true, nil
+		case 21:
+			v, n, err := protowireutil.ConsumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			p.OriginalPayload = append([]byte(nil), v...)
+			return n, true, nil
+		}
+		return 0, false, nil
+	})
+}
+
+func unmarshalValueType(b []byte, vt *ValueType) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			return varintField(typ, b, func(v uint64) { vt.TypeStrindex = int32(v) })
+		case 2:
+			return varintField(typ, b, func(v uint64) { vt.UnitStrindex = int32(v) })
+		}
+		return 0, false, nil
+	})
+}
+
+func unmarshalSample(b []byte, s *Sample) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			return varintField(typ, b, func(v uint64) { s.LocationsStartIndex = int32(v) })
+		case 2:
+			return varintField(typ, b, func(v uint64) { s.LocationsLength = int32(v) })
+		case 3:
+			n, err := protowireutil.ConsumeRepeatedVarint(typ, b, func(v uint64) {
+				s.Value = append(s.Value, int64(v))
+			})
+			return n, err == nil, err
+		case 4:
+			return repeatedInt32Field(typ, b, &s.AttributeIndices)
+		}
+		return 0, false, nil
+	})
+}
+
+func unmarshalMapping(b []byte, m *Mapping) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			return varintField(typ, b, func(v uint64) { m.MemoryStart = v })
+		case 2:
+			return varintField(typ, b, func(v uint64) { m.MemoryLimit = v })
+		case 3:
+			return varintField(typ, b, func(v uint64) { m.FileOffset = v })
+		case 4:
+			return varintField(typ, b, func(v uint64) { m.FilenameStrindex = int32(v) })
+		}
+		return 0, false, nil
+	})
+}
+
+func unmarshalLocation(b []byte, loc *Location) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			return varintField(typ, b, func(v uint64) {
+				loc.MappingIndex, loc.HasMappingIndex = int32(v), true
+			})
+		case 2:
+			return varintField(typ, b, func(v uint64) { loc.Address = v })
+		case 3:
+			return messageField(typ, b, func(v []byte) error {
+				var line Line
+				if err := unmarshalLine(v, &line); err != nil {
+					return err
+				}
+				loc.Line = append(loc.Line, line)
+				return nil
+			})
+		case 4:
+			return varintField(typ, b, func(v uint64) { loc.IsFolded = v != 0 })
+		}
+		return 0, false, nil
+	})
+}
+
+func unmarshalLine(b []byte, line *Line) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			return varintField(typ, b, func(v uint64) { line.FunctionIndex = int32(v) })
+		case 2:
+			return varintField(typ, b, func(v uint64) { line.Line = int64(v) })
+		case 3:
+			return varintField(typ, b, func(v uint64) { line.Column = int64(v) })
+		}
+		return 0, false, nil
+	})
+}
+
+func unmarshalFunction(b []byte, f *Function) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			return varintField(typ, b, func(v uint64) { f.NameStrindex = int32(v) })
+		case 2:
+			return varintField(typ, b, func(v uint64) { f.SystemNameStrindex = int32(v) })
+		case 3:
+			return varintField(typ, b, func(v uint64) { f.FilenameStrindex = int32(v) })
+		case 4:
+			return varintField(typ, b, func(v uint64) { f.StartLine = int64(v) })
+		}
+		return 0, false, nil
+	})
+}
+
+func appendPacked[T int32 | int64](b []byte, num protowire.Number, vs []T) []byte {
+	if len(vs) == 0 {
+		return b
+	}
+	var packed []byte
+	for _, v := range vs {
+		packed = protowire.AppendVarint(packed, uint64(v))
+	}
+	return protowireutil.AppendMessage(b, num, packed)
+}
+
+func appendAttributes(b []byte, num protowire.Number, attrs []KeyValue) []byte {
+	for _, attr := range attrs {
+		var kv []byte
+		kv = protowireutil.AppendString(kv, 1, attr.Key)
+		kv = protowireutil.AppendMessage(kv, 2, protowireutil.AppendString(nil, 1, attr.Value))
+		b = protowireutil.AppendMessage(b, num, kv)
+	}
+	return b
+}
+
+func appendValueType(b []byte, num protowire.Number, vt ValueType) []byte {
+	var v []byte
+	v = protowireutil.AppendVarint(v, 1, uint64(vt.TypeStrindex))
+	v = protowireutil.AppendVarint(v, 2, uint64(vt.UnitStrindex))
+	return protowireutil.AppendMessage(b, num, v)
+}
+
+// Marshal 编码 ExportProfilesServiceRequest 属性值统一编码为 string_value
+func Marshal(req *ExportProfilesServiceRequest) []byte {
+	var b []byte
+	for _, rp := range req.ResourceProfiles {
+		var resource []byte
+		resource = protowireutil.AppendMessage(resource, 1, appendAttributes(nil, 1, rp.Resource))
+		for _, sp := range rp.ScopeProfiles {
+			var scope []byte
+			scope = protowireutil.AppendString(scope, 1, sp.ScopeName)
+			scope = protowireutil.AppendString(scope, 2, sp.ScopeVersion)
+
+			var scopeProfiles []byte
+			scopeProfiles = protowireutil.AppendMessage(scopeProfiles, 1, scope)
+			for i := 0; i < len(sp.Profiles); i++ {
+				scopeProfiles = protowireutil.AppendMessage(scopeProfiles, 2, marshalProfile(&sp.Profiles[i]))
+			}
+			resource = protowireutil.AppendMessage(resource, 2, scopeProfiles)
+		}
+		b = protowireutil.AppendMessage(b, 1, resource)
+	}
+	return b
+}
+
+func marshalProfile(p *Profile) []byte {
+	var b []byte
+	for _, vt := range p.SampleType {
+		b = appendValueType(b, 1, vt)
+	}
+	for _, s := range p.Sample {
+		var sample []byte
+		sample = protowireutil.AppendVarint(sample, 1, uint64(s.LocationsStartIndex))
+		sample = protowireutil.AppendVarint(sample, 2, uint64(s.LocationsLength))
+		sample = appendPacked(sample, 3, s.Value)
+		sample = appendPacked(sample, 4, s.AttributeIndices)
+		b = protowireutil.AppendMessage(b, 2, sample)
+	}
+	for _, m := range p.MappingTable {
+		var mapping []byte
+		mapping = protowireutil.AppendVarint(mapping, 1, m.MemoryStart)
+		mapping = protowireutil.AppendVarint(mapping, 2, m.MemoryLimit)
+		mapping = protowireutil.AppendVarint(mapping, 3, m.FileOffset)
+		mapping = protowireutil.AppendVarint(mapping, 4, uint64(m.FilenameStrindex))
+		b = protowireutil.AppendMessage(b, 3, mapping)
+	}
+	for _, loc := range p.LocationTable {
+		var location []byte
+		if loc.HasMappingIndex {
+			location = protowire.AppendTag(location, 1, protowire.VarintType)
+			location = protowire.AppendVarint(location, uint64(loc.MappingIndex))
+		}
+		location = protowireutil.AppendVarint(location, 2, loc.Address)
+		for _, l := range loc.Line {
+			var line []byte
+			line = protowireutil.AppendVarint(line, 1, uint64(l.FunctionIndex))
+			line = protowireutil.AppendVarint(line, 2, uint64(l.Line))
+			line = protowireutil.AppendVarint(line, 3, uint64(l.Column))
+			location = protowireutil.AppendMessage(location, 3, line)
+		}
+		if loc.IsFolded {
+			location = protowireutil.AppendVarint(location, 4, 1)
+		}
+		b = protowireutil.AppendMessage(b, 4, location)
+	}
+	b = appendPacked(b, 5, p.LocationIndices)
+	for _, f := range p.FunctionTable {
+		var function []byte
+		function = protowireutil.AppendVarint(function, 1, uint64(f.NameStrindex))
+		function = protowireutil.AppendVarint(function, 2, uint64(f.SystemNameStrindex))
+		function = protowireutil.AppendVarint(function, 3, uint64(f.FilenameStrindex))
+		function = protowireutil.AppendVarint(function, 4, uint64(f.StartLine))
+		b = protowireutil.AppendMessage(b, 6, function)
+	}
+	b = appendAttributes(b, 7, p.AttributeTable)
+	for _, s := range p.StringTable {
+		b = protowireutil.AppendString(b, 10, s)
+	}
+	b = protowireutil.AppendVarint(b, 11, uint64(p.TimeNanos))
+	b = protowireutil.AppendVarint(b, 12, uint64(p.DurationNanos))
+	b = appendValueType(b, 13, p.PeriodType)
+	b = protowireutil.AppendVarint(b, 14, uint64(p.Period))
+	b = appendPacked(b, 15, p.CommentStrindices)
+	b = protowireutil.AppendVarint(b, 16, uint64(p.DefaultSampleTypeStrindex))
+	if len(p.ProfileID) > 0 {
+		b = protowireutil.AppendMessage(b, 17, p.ProfileID)
+	}
+	b = appendPacked(b, 18, p.AttributeIndices)
+	if p.OriginalPayloadFormat != "" {
+		b = protowireutil.AppendString(b, 20, p.OriginalPayloadFormat)
+	}
+	if len(p.OriginalPayload) > 0 {
+		b = protowireutil.AppendMessage(b, 21, p.OriginalPayload)
+	}
+	return b
+}
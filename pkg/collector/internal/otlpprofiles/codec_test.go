@@ -0,0 +1,115 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package otlpprofiles
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/protowireutil"
+)
+
+// newTestProfile 两条调用栈 main -> foo 以及 main -> bar
+func newTestProfile() Profile {
+	return Profile{
+		StringTable:   []string{"", "cpu", "nanoseconds", "samples", "count", "main", "foo", "bar", "main.go", "/bin/app", "thread"},
+		SampleType:    []ValueType{{TypeStrindex: 3, UnitStrindex: 4}, {TypeStrindex: 1, UnitStrindex: 2}},
+		PeriodType:    ValueType{TypeStrindex: 1, UnitStrindex: 2},
+		Period:        10000000,
+		TimeNanos:     1700000000000000000,
+		DurationNanos: 10000000000,
+		MappingTable:  []Mapping{{MemoryStart: 0x1000, MemoryLimit: 0x2000, FilenameStrindex: 9}},
+		FunctionTable: []Function{
+			{NameStrindex: 5, SystemNameStrindex: 5, FilenameStrindex: 8},
+			{NameStrindex: 6, SystemNameStrindex: 6, FilenameStrindex: 8, StartLine: 10},
+			{NameStrindex: 7, SystemNameStrindex: 7, FilenameStrindex: 8, StartLine: 20},
+		},
+		LocationTable: []Location{
+			{HasMappingIndex: true, Address: 0x1100, Line: []Line{{FunctionIndex: 0, Line: 5}}},
+			{HasMappingIndex: true, Address: 0x1200, Line: []Line{{FunctionIndex: 1, Line: 12}}},
+			{Address: 0x1300, Line: []Line{{FunctionIndex: 2, Line: 22}}},
+		},
+		LocationIndices:  []int32{1, 0, 2, 0},
+		AttributeTable:   []KeyValue{{Key: "thread.name", Value: "worker"}, {Key: "profile.kind", Value: "cpu"}},
+		AttributeIndices: []int32{1},
+		Sample: []Sample{
+			{LocationsStartIndex: 0, LocationsLength: 2, Value: []int64{3, 30000000}, AttributeIndices: []int32{0}},
+			{LocationsStartIndex: 2, LocationsLength: 2, Value: []int64{1, 10000000}},
+		},
+		ProfileID: []byte{1, 2, 3, 4},
+	}
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+	req := &ExportProfilesServiceRequest{
+		ResourceProfiles: []ResourceProfiles{
+			{
+				Resource: []KeyValue{{Key: "service.name", Value: "app"}},
+				ScopeProfiles: []ScopeProfiles{
+					{
+						ScopeName:    "otel-ebpf-profiler",
+						ScopeVersion: "v0.1.0",
+						Profiles:     []Profile{newTestProfile()},
+					},
+				},
+			},
+		},
+	}
+
+	var got ExportProfilesServiceRequest
+	assert.NoError(t, Unmarshal(Marshal(req), &got))
+	assert.Equal(t, *req, got)
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	var req ExportProfilesServiceRequest
+	assert.Error(t, Unmarshal([]byte{0x0a, 0xff}, &req))
+}
+
+func TestUnmarshalAnyValue(t *testing.T) {
+	anyValue := func(num protowire.Number, typ protowire.Type, v uint64) []byte {
+		b := protowire.AppendTag(nil, num, typ)
+		if typ == protowire.Fixed64Type {
+			return protowire.AppendFixed64(b, v)
+		}
+		return protowire.AppendVarint(b, v)
+	}
+
+	tests := []struct {
+		input []byte
+		value string
+		ok    bool
+	}{
+		{input: anyValue(2, protowire.VarintType, 1), value: "true", ok: true},
+		{input: anyValue(3, protowire.VarintType, uint64(1<<64-2)), value: "-2", ok: true},
+		{input: anyValue(4, protowire.Fixed64Type, 0x3ff8000000000000), value: "1.5", ok: true},
+		{input: protowireutil.AppendMessage(nil, 5, nil)}, // array_value
+	}
+	for _, tt := range tests {
+		s, ok, err := unmarshalAnyValue(tt.input)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.ok, ok)
+		assert.Equal(t, tt.value, s)
+	}
+}
+
+func TestUnmarshalUnpackedRepeated(t *testing.T) {
+	var b []byte
+	for _, v := range []uint64{3, 30} {
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, v)
+	}
+
+	var s Sample
+	assert.NoError(t, unmarshalSample(b, &s))
+	assert.Equal(t, []int64{3, 30}, s.Value)
+}
@@ -0,0 +1,161 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package otlpprofiles
+
+import (
+	"github.com/google/pprof/profile"
+	"github.com/pkg/errors"
+)
+
+// Attributes 返回 profile 级别的属性
+func (p *Profile) Attributes() []KeyValue {
+	attrs := make([]KeyValue, 0, len(p.AttributeIndices))
+	for _, idx := range p.AttributeIndices {
+		if idx >= 0 && int(idx) < len(p.AttributeTable) {
+			attrs = append(attrs, p.AttributeTable[idx])
+		}
+	}
+	return attrs
+}
+
+func (p *Profile) str(idx int32) (string, error) {
+	if idx < 0 || int(idx) >= len(p.StringTable) {
+		return "", errors.Errorf("string index %d out of range", idx)
+	}
+	return p.StringTable[idx], nil
+}
+
+func (p *Profile) valueType(vt ValueType) (*profile.ValueType, error) {
+	typ, err := p.str(vt.TypeStrindex)
+	if err != nil {
+		return nil, err
+	}
+	unit, err := p.str(vt.UnitStrindex)
+	if err != nil {
+		return nil, err
+	}
+	return &profile.ValueType{Type: typ, Unit: unit}, nil
+}
+
+// ToPprof 将 OTLP Profile 转换为 pprof 格式 下标引用会被转换为从 1 开始的 ID
+func (p *Profile) ToPprof() (*profile.Profile, error) {
+	var err error
+	pp := &profile.Profile{
+		TimeNanos:     p.TimeNanos,
+		DurationNanos: p.DurationNanos,
+		Period:        p.Period,
+	}
+
+	for _, vt := range p.SampleType {
+		st, err := p.valueType(vt)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid sample type")
+		}
+		pp.SampleType = append(pp.SampleType, st)
+	}
+	if pp.PeriodType, err = p.valueType(p.PeriodType); err != nil {
+		return nil, errors.Wrap(err, "invalid period type")
+	}
+	if p.DefaultSampleTypeStrindex > 0 {
+		if pp.DefaultSampleType, err = p.str(p.DefaultSampleTypeStrindex); err != nil {
+			return nil, errors.Wrap(err, "invalid default sample type")
+		}
+	}
+	for _, idx := range p.CommentStrindices {
+		comment, err := p.str(idx)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid comment")
+		}
+		pp.Comments = append(pp.Comments, comment)
+	}
+
+	for i, m := range p.MappingTable {
+		file, err := p.str(m.FilenameStrindex)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid mapping %d", i)
+		}
+		pp.Mapping = append(pp.Mapping, &profile.Mapping{
+			ID:     uint64(i + 1),
+			Start:  m.MemoryStart,
+			Limit:  m.MemoryLimit,
+			Offset: m.FileOffset,
+			File:   file,
+		})
+	}
+
+	for i, f := range p.FunctionTable {
+		fn := &profile.Function{ID: uint64(i + 1), StartLine: f.StartLine}
+		if fn.Name, err = p.str(f.NameStrindex); err != nil {
+			return nil, errors.Wrapf(err, "invalid function %d", i)
+		}
+		if fn.SystemName, err = p.str(f.SystemNameStrindex); err != nil {
+			return nil, errors.Wrapf(err, "invalid function %d", i)
+		}
+		if fn.Filename, err = p.str(f.FilenameStrindex); err != nil {
+			return nil, errors.Wrapf(err, "invalid function %d", i)
+		}
+		pp.Function = append(pp.Function, fn)
+	}
+
+	for i, loc := range p.LocationTable {
+		location := &profile.Location{
+			ID:       uint64(i + 1),
+			Address:  loc.Address,
+			IsFolded: loc.IsFolded,
+		}
+		if loc.HasMappingIndex {
+			if loc.MappingIndex < 0 || int(loc.MappingIndex) >= len(pp.Mapping) {
+				return nil, errors.Errorf("location %d: mapping index %d out of range", i, loc.MappingIndex)
+			}
+			location.Mapping = pp.Mapping[loc.MappingIndex]
+		}
+		for _, line := range loc.Line {
+			if line.FunctionIndex < 0 || int(line.FunctionIndex) >= len(pp.Function) {
+				return nil, errors.Errorf("location %d: function index %d out of range", i, line.FunctionIndex)
+			}
+			location.Line = append(location.Line, profile.Line{
+				Function: pp.Function[line.FunctionIndex],
+				Line:     line.Line,
+			})
+		}
+		pp.Location = append(pp.Location, location)
+	}
+
+	for i, s := range p.Sample {
+		start, end := int(s.LocationsStartIndex), int(s.LocationsStartIndex)+int(s.LocationsLength)
+		if start < 0 || end < start || end > len(p.LocationIndices) {
+			return nil, errors.Errorf("sample %d: locations range [%d, %d) out of range", i, start, end)
+		}
+
+		sample := &profile.Sample{Value: s.Value}
+		for _, idx := range p.LocationIndices[start:end] {
+			if idx < 0 || int(idx) >= len(pp.Location) {
+				return nil, errors.Errorf("sample %d: location index %d out of range", i, idx)
+			}
+			sample.Location = append(sample.Location, pp.Location[idx])
+		}
+		for _, idx := range s.AttributeIndices {
+			if idx < 0 || int(idx) >= len(p.AttributeTable) {
+				return nil, errors.Errorf("sample %d: attribute index %d out of range", i, idx)
+			}
+			if sample.Label == nil {
+				sample.Label = make(map[string][]string)
+			}
+			attr := p.AttributeTable[idx]
+			sample.Label[attr.Key] = append(sample.Label[attr.Key], attr.Value)
+		}
+		pp.Sample = append(pp.Sample, sample)
+	}
+
+	if err := pp.CheckValid(); err != nil {
+		return nil, errors.Wrap(err, "invalid profile")
+	}
+	return pp, nil
+}
@@ -0,0 +1,82 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package otlpprofiles
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToPprof(t *testing.T) {
+	p := newTestProfile()
+	pp, err := p.ToPprof()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "samples", pp.SampleType[0].Type)
+	assert.Equal(t, "nanoseconds", pp.SampleType[1].Unit)
+	assert.Equal(t, "cpu", pp.PeriodType.Type)
+	assert.Equal(t, int64(10000000), pp.Period)
+	assert.Len(t, pp.Function, 3)
+	assert.Len(t, pp.Location, 3)
+	assert.Equal(t, "/bin/app", pp.Location[0].Mapping.File)
+	assert.Nil(t, pp.Location[2].Mapping)
+
+	assert.Len(t, pp.Sample, 2)
+	s := pp.Sample[0]
+	assert.Equal(t, []int64{3, 30000000}, s.Value)
+	assert.Equal(t, "foo", s.Location[0].Line[0].Function.Name)
+	assert.Equal(t, "main", s.Location[1].Line[0].Function.Name)
+	assert.Equal(t, []string{"worker"}, s.Label["thread.name"])
+	assert.Nil(t, pp.Sample[1].Label)
+
+	assert.Equal(t, []KeyValue{{Key: "profile.kind", Value: "cpu"}}, p.Attributes())
+}
+
+func TestToPprofInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(p *Profile)
+	}{
+		{
+			name:   "string index",
+			modify: func(p *Profile) { p.FunctionTable[0].NameStrindex = 100 },
+		},
+		{
+			name:   "function index",
+			modify: func(p *Profile) { p.LocationTable[0].Line[0].FunctionIndex = 3 },
+		},
+		{
+			name:   "mapping index",
+			modify: func(p *Profile) { p.LocationTable[0].MappingIndex = 1 },
+		},
+		{
+			name:   "locations range",
+			modify: func(p *Profile) { p.Sample[1].LocationsLength = 3 },
+		},
+		{
+			name:   "location index",
+			modify: func(p *Profile) { p.LocationIndices[0] = -1 },
+		},
+		{
+			name:   "sample values",
+			modify: func(p *Profile) { p.Sample[0].Value = []int64{1} },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProfile()
+			tt.modify(&p)
+			_, err := p.ToPprof()
+			assert.Error(t, err)
+		})
+	}
+}
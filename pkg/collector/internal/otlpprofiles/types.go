@@ -0,0 +1,97 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+// Package otlpprofiles 实现 OTLP profiles 信号（ExportProfilesServiceRequest）的编解码
+//
+// profiles 信号仍处于 development 阶段 pdata 尚未提供对应模型 因此基于 protowire 手动实现
+// 字段定义参照 opentelemetry-proto v1.4.0 profiles/v1development 仅覆盖转换为 pprof 所需的字段
+package otlpprofiles
+
+type ExportProfilesServiceRequest struct {
+	ResourceProfiles []ResourceProfiles
+}
+
+type ResourceProfiles struct {
+	Resource      []KeyValue // Resource.attributes
+	ScopeProfiles []ScopeProfiles
+}
+
+type ScopeProfiles struct {
+	ScopeName    string
+	ScopeVersion string
+	Profiles     []Profile
+}
+
+// KeyValue 属性键值对 非字符串类型的值会被转换为字符串 数组及嵌套类型会被忽略
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+type ValueType struct {
+	TypeStrindex int32
+	UnitStrindex int32
+}
+
+type Sample struct {
+	LocationsStartIndex int32
+	LocationsLength     int32
+	Value               []int64
+	AttributeIndices    []int32
+}
+
+type Mapping struct {
+	MemoryStart      uint64
+	MemoryLimit      uint64
+	FileOffset       uint64
+	FilenameStrindex int32
+}
+
+type Location struct {
+	MappingIndex    int32
+	HasMappingIndex bool
+	Address         uint64
+	Line            []Line
+	IsFolded        bool
+}
+
+type Line struct {
+	FunctionIndex int32
+	Line          int64
+	Column        int64
+}
+
+type Function struct {
+	NameStrindex       int32
+	SystemNameStrindex int32
+	FilenameStrindex   int32
+	StartLine          int64
+}
+
+// Profile 结构与 pprof 基本一致 区别在于各实体通过 table 下标而非 ID 引用
+type Profile struct {
+	SampleType                []ValueType
+	Sample                    []Sample
+	MappingTable              []Mapping
+	LocationTable             []Location
+	LocationIndices           []int32
+	FunctionTable             []Function
+	AttributeTable            []KeyValue
+	StringTable               []string
+	TimeNanos                 int64
+	DurationNanos             int64
+	PeriodType                ValueType
+	Period                    int64
+	CommentStrindices         []int32
+	DefaultSampleTypeStrindex int32
+	ProfileID                 []byte
+	AttributeIndices          []int32
+	OriginalPayloadFormat     string
+	OriginalPayload           []byte
+}
@@ -0,0 +1,291 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package ottl
+
+import (
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+type Context string
+
+const (
+	ContextResource  Context = "resource"
+	ContextSpan      Context = "span"
+	ContextLog       Context = "log"
+	ContextDataPoint Context = "datapoint"
+)
+
+// ParseContext 解析上下文类型
+func ParseContext(s string) (Context, error) {
+	switch c := Context(s); c {
+	case ContextResource, ContextSpan, ContextLog, ContextDataPoint:
+		return c, nil
+	}
+	return "", errors.Errorf("unknown context '%s'", s)
+}
+
+// TransformContext 语句执行时的上下文 根据 Context 类型仅填充对应的字段
+type TransformContext struct {
+	resource   pcommon.Resource
+	span       ptrace.Span
+	logRecord  plog.LogRecord
+	metric     pmetric.Metric
+	attrs      pcommon.Map
+	number     pmetric.NumberDataPoint
+	isNumberDp bool
+}
+
+func NewResourceContext(resource pcommon.Resource) *TransformContext {
+	return &TransformContext{resource: resource, attrs: resource.Attributes()}
+}
+
+func NewSpanContext(resource pcommon.Resource, span ptrace.Span) *TransformContext {
+	return &TransformContext{resource: resource, span: span, attrs: span.Attributes()}
+}
+
+func NewLogContext(resource pcommon.Resource, logRecord plog.LogRecord) *TransformContext {
+	return &TransformContext{resource: resource, logRecord: logRecord, attrs: logRecord.Attributes()}
+}
+
+// NewDataPointContext attrs 为数据点的维度 仅 gauge/sum 类型的数据点支持访问 value
+func NewDataPointContext(resource pcommon.Resource, metric pmetric.Metric, attrs pcommon.Map) *TransformContext {
+	return &TransformContext{resource: resource, metric: metric, attrs: attrs}
+}
+
+func NewNumberDataPointContext(resource pcommon.Resource, metric pmetric.Metric, dp pmetric.NumberDataPoint) *TransformContext {
+	return &TransformContext{resource: resource, metric: metric, attrs: dp.Attributes(), number: dp, isNumberDp: true}
+}
+
+type getter func(tc *TransformContext) interface{}
+
+type setter func(tc *TransformContext, v interface{}) error
+
+// path 描述语句中对字段的引用
+//
+// 不带 key 的 attributes 引用为 map 类型 仅可作为 delete_keys/keep_keys 等函数的参数
+type path struct {
+	name    string
+	key     string
+	hasKey  bool
+	mapFunc func(tc *TransformContext) pcommon.Map
+	get     getter
+	set     setter
+}
+
+func (p *path) isMap() bool {
+	return p.mapFunc != nil && !p.hasKey
+}
+
+func (p *path) String() string {
+	if p.hasKey {
+		return p.name + "[\"" + p.key + "\"]"
+	}
+	return p.name
+}
+
+type field struct {
+	get getter
+	set setter
+}
+
+func stringSetter(f func(tc *TransformContext, s string)) setter {
+	return func(tc *TransformContext, v interface{}) error {
+		s, ok := v.(string)
+		if !ok {
+			return errors.Errorf("expected string value, got %T", v)
+		}
+		f(tc, s)
+		return nil
+	}
+}
+
+var spanFields = map[string]field{
+	"name": {
+		get: func(tc *TransformContext) interface{} { return tc.span.Name() },
+		set: stringSetter(func(tc *TransformContext, s string) { tc.span.SetName(s) }),
+	},
+	"kind": {
+		get: func(tc *TransformContext) interface{} { return tc.span.Kind().String() },
+	},
+	"status.code": {
+		get: func(tc *TransformContext) interface{} { return tc.span.Status().Code().String() },
+	},
+	"status.message": {
+		get: func(tc *TransformContext) interface{} { return tc.span.Status().Message() },
+		set: stringSetter(func(tc *TransformContext, s string) { tc.span.Status().SetMessage(s) }),
+	},
+	"trace_id": {
+		get: func(tc *TransformContext) interface{} { return tc.span.TraceID().HexString() },
+	},
+	"span_id": {
+		get: func(tc *TransformContext) interface{} { return tc.span.SpanID().HexString() },
+	},
+	"parent_span_id": {
+		get: func(tc *TransformContext) interface{} { return tc.span.ParentSpanID().HexString() },
+	},
+	"duration": {
+		get: func(tc *TransformContext) interface{} {
+			start, end := tc.span.StartTimestamp(), tc.span.EndTimestamp()
+			if start > end {
+				return int64(0)
+			}
+			return int64(end - start)
+		},
+	},
+}
+
+var logFields = map[string]field{
+	"body": {
+		get: func(tc *TransformContext) interface{} { return tc.logRecord.Body().AsString() },
+		set: stringSetter(func(tc *TransformContext, s string) { pcommon.NewValueString(s).CopyTo(tc.logRecord.Body()) }),
+	},
+	"severity_text": {
+		get: func(tc *TransformContext) interface{} { return tc.logRecord.SeverityText() },
+		set: stringSetter(func(tc *TransformContext, s string) { tc.logRecord.SetSeverityText(s) }),
+	},
+	"severity_number": {
+		get: func(tc *TransformContext) interface{} { return int64(tc.logRecord.SeverityNumber()) },
+		set: func(tc *TransformContext, v interface{}) error {
+			i, ok := v.(int64)
+			if !ok {
+				return errors.Errorf("expected int value, got %T", v)
+			}
+			tc.logRecord.SetSeverityNumber(plog.SeverityNumber(i))
+			return nil
+		},
+	},
+	"trace_id": {
+		get: func(tc *TransformContext) interface{} { return tc.logRecord.TraceID().HexString() },
+	},
+	"span_id": {
+		get: func(tc *TransformContext) interface{} { return tc.logRecord.SpanID().HexString() },
+	},
+}
+
+var dataPointFields = map[string]field{
+	"metric.name": {
+		get: func(tc *TransformContext) interface{} { return tc.metric.Name() },
+		set: stringSetter(func(tc *TransformContext, s string) { tc.metric.SetName(s) }),
+	},
+	"metric.unit": {
+		get: func(tc *TransformContext) interface{} { return tc.metric.Unit() },
+		set: stringSetter(func(tc *TransformContext, s string) { tc.metric.SetUnit(s) }),
+	},
+	"metric.type": {
+		get: func(tc *TransformContext) interface{} { return tc.metric.DataType().String() },
+	},
+	"value": {
+		get: func(tc *TransformContext) interface{} {
+			if !tc.isNumberDp {
+				return nil
+			}
+			if tc.number.ValueType() == pmetric.NumberDataPointValueTypeInt {
+				return tc.number.IntVal()
+			}
+			return tc.number.DoubleVal()
+		},
+	},
+}
+
+var contextFields = map[Context]map[string]field{
+	ContextResource:  {},
+	ContextSpan:      spanFields,
+	ContextLog:       logFields,
+	ContextDataPoint: dataPointFields,
+}
+
+const (
+	pathAttributes         = "attributes"
+	pathResourceAttributes = "resource.attributes"
+)
+
+// resolvePath 在编译期将字段名称解析为读写函数
+func resolvePath(ctx Context, name string, key string, hasKey bool) (*path, error) {
+	var mapFunc func(tc *TransformContext) pcommon.Map
+	switch name {
+	case pathAttributes:
+		mapFunc = func(tc *TransformContext) pcommon.Map { return tc.attrs }
+	case pathResourceAttributes:
+		if ctx == ContextResource {
+			return nil, errors.Errorf("path '%s' is not supported in %s context", name, ctx)
+		}
+		mapFunc = func(tc *TransformContext) pcommon.Map { return tc.resource.Attributes() }
+	}
+
+	if mapFunc != nil {
+		p := &path{name: name, key: key, hasKey: hasKey, mapFunc: mapFunc}
+		if hasKey {
+			p.get = func(tc *TransformContext) interface{} {
+				v, ok := mapFunc(tc).Get(key)
+				if !ok {
+					return nil
+				}
+				return fromValue(v)
+			}
+			p.set = func(tc *TransformContext, v interface{}) error {
+				if v == nil {
+					mapFunc(tc).Remove(key)
+					return nil
+				}
+				val, err := toValue(v)
+				if err != nil {
+					return err
+				}
+				mapFunc(tc).Upsert(key, val)
+				return nil
+			}
+		}
+		return p, nil
+	}
+
+	if hasKey {
+		return nil, errors.Errorf("path '%s' does not support key access", name)
+	}
+	f, ok := contextFields[ctx][name]
+	if !ok {
+		return nil, errors.Errorf("unknown path '%s' in %s context", name, ctx)
+	}
+	return &path{name: name, get: f.get, set: f.set}, nil
+}
+
+func fromValue(v pcommon.Value) interface{} {
+	switch v.Type() {
+	case pcommon.ValueTypeString:
+		return v.StringVal()
+	case pcommon.ValueTypeInt:
+		return v.IntVal()
+	case pcommon.ValueTypeDouble:
+		return v.DoubleVal()
+	case pcommon.ValueTypeBool:
+		return v.BoolVal()
+	case pcommon.ValueTypeEmpty:
+		return nil
+	default:
+		return v.AsString()
+	}
+}
+
+func toValue(v interface{}) (pcommon.Value, error) {
+	switch val := v.(type) {
+	case string:
+		return pcommon.NewValueString(val), nil
+	case int64:
+		return pcommon.NewValueInt(val), nil
+	case float64:
+		return pcommon.NewValueDouble(val), nil
+	case bool:
+		return pcommon.NewValueBool(val), nil
+	}
+	return pcommon.NewValueEmpty(), errors.Errorf("unsupported value type %T", v)
+}
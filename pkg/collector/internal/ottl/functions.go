@@ -0,0 +1,334 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package ottl
+
+import (
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+type editor func(tc *TransformContext) error
+
+type condition func(tc *TransformContext) bool
+
+const (
+	FuncSet            = "set"
+	FuncDelete         = "delete"
+	FuncKeepKeys       = "keep_keys"
+	FuncReplacePattern = "replace_pattern"
+	FuncTruncate       = "truncate"
+
+	FuncIsMatch = "IsMatch"
+)
+
+func checkArgs(name string, args []argument, n int) error {
+	if len(args) != n {
+		return errors.Errorf("function %s expects %d arguments, got %d", name, n, len(args))
+	}
+	return nil
+}
+
+func valueGetter(arg argument) (getter, error) {
+	if arg.isList {
+		return nil, errors.New("list is not allowed here")
+	}
+	if arg.path != nil && arg.path.get == nil {
+		return nil, errors.Errorf("path '%s' can not be used as value", arg.path)
+	}
+	return arg.getter(), nil
+}
+
+func mapPath(name string, arg argument) (*path, error) {
+	if arg.path == nil || !arg.path.isMap() {
+		return nil, errors.Errorf("function %s expects attributes as first argument", name)
+	}
+	return arg.path, nil
+}
+
+func stringLiteral(name string, arg argument) (string, error) {
+	s, ok := arg.literal.(string)
+	if arg.path != nil || arg.isList || !ok {
+		return "", errors.Errorf("function %s expects string literal", name)
+	}
+	return s, nil
+}
+
+func newEditor(name string, args []argument) (editor, error) {
+	switch name {
+	case FuncSet:
+		return newSetEditor(args)
+	case FuncDelete:
+		return newDeleteEditor(args)
+	case FuncKeepKeys:
+		return newKeepKeysEditor(args)
+	case FuncReplacePattern:
+		return newReplacePatternEditor(args)
+	case FuncTruncate:
+		return newTruncateEditor(args)
+	}
+	return nil, errors.Errorf("unknown function '%s'", name)
+}
+
+// set(target, value) value 为 nil 时等价于删除
+func newSetEditor(args []argument) (editor, error) {
+	if err := checkArgs(FuncSet, args, 2); err != nil {
+		return nil, err
+	}
+	target := args[0].path
+	if target == nil || target.set == nil {
+		return nil, errors.Errorf("function %s expects settable path as first argument", FuncSet)
+	}
+	get, err := valueGetter(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return func(tc *TransformContext) error {
+		return target.set(tc, get(tc))
+	}, nil
+}
+
+// delete(attributes["key"])
+func newDeleteEditor(args []argument) (editor, error) {
+	if err := checkArgs(FuncDelete, args, 1); err != nil {
+		return nil, err
+	}
+	target := args[0].path
+	if target == nil || target.mapFunc == nil || !target.hasKey {
+		return nil, errors.Errorf("function %s expects attributes key as argument", FuncDelete)
+	}
+
+	return func(tc *TransformContext) error {
+		target.mapFunc(tc).Remove(target.key)
+		return nil
+	}, nil
+}
+
+// keep_keys(attributes, ["key1", "key2"])
+func newKeepKeysEditor(args []argument) (editor, error) {
+	if err := checkArgs(FuncKeepKeys, args, 2); err != nil {
+		return nil, err
+	}
+	target, err := mapPath(FuncKeepKeys, args[0])
+	if err != nil {
+		return nil, err
+	}
+	if !args[1].isList {
+		return nil, errors.Errorf("function %s expects list as second argument", FuncKeepKeys)
+	}
+	keys := make(map[string]struct{}, len(args[1].list))
+	for _, v := range args[1].list {
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.Errorf("function %s expects string keys", FuncKeepKeys)
+		}
+		keys[s] = struct{}{}
+	}
+
+	return func(tc *TransformContext) error {
+		target.mapFunc(tc).RemoveIf(func(k string, _ pcommon.Value) bool {
+			_, ok := keys[k]
+			return !ok
+		})
+		return nil
+	}, nil
+}
+
+// stringEditor 对目标字段的字符串值做变换 目标为 attributes 时作用于所有字符串类型的值
+func stringEditor(name string, target *path, f func(s string) string) (editor, error) {
+	if target == nil {
+		return nil, errors.Errorf("function %s expects path as first argument", name)
+	}
+
+	if target.isMap() {
+		return func(tc *TransformContext) error {
+			target.mapFunc(tc).Range(func(_ string, v pcommon.Value) bool {
+				if v.Type() == pcommon.ValueTypeString {
+					v.SetStringVal(f(v.StringVal()))
+				}
+				return true
+			})
+			return nil
+		}, nil
+	}
+
+	if target.set == nil {
+		return nil, errors.Errorf("function %s expects settable path as first argument", name)
+	}
+	return func(tc *TransformContext) error {
+		s, ok := target.get(tc).(string)
+		if !ok {
+			return nil
+		}
+		return target.set(tc, f(s))
+	}, nil
+}
+
+// replace_pattern(target, "regex", "replacement") replacement 支持 $1 形式的分组引用
+func newReplacePatternEditor(args []argument) (editor, error) {
+	if err := checkArgs(FuncReplacePattern, args, 3); err != nil {
+		return nil, err
+	}
+	pattern, err := stringLiteral(FuncReplacePattern, args[1])
+	if err != nil {
+		return nil, err
+	}
+	replacement, err := stringLiteral(FuncReplacePattern, args[2])
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.Wrapf(err, "function %s got invalid pattern", FuncReplacePattern)
+	}
+
+	return stringEditor(FuncReplacePattern, args[0].path, func(s string) string {
+		return re.ReplaceAllString(s, replacement)
+	})
+}
+
+// truncate(target, limit) 按字符数截断
+func newTruncateEditor(args []argument) (editor, error) {
+	if err := checkArgs(FuncTruncate, args, 2); err != nil {
+		return nil, err
+	}
+	limit, ok := args[1].literal.(int64)
+	if args[1].path != nil || !ok || limit < 0 {
+		return nil, errors.Errorf("function %s expects non-negative int limit", FuncTruncate)
+	}
+
+	return stringEditor(FuncTruncate, args[0].path, func(s string) string {
+		if utf8.RuneCountInString(s) <= int(limit) {
+			return s
+		}
+		return string([]rune(s)[:limit])
+	})
+}
+
+func newBoolFunc(name string, args []argument) (condition, error) {
+	switch name {
+	case FuncIsMatch:
+		if err := checkArgs(FuncIsMatch, args, 2); err != nil {
+			return nil, err
+		}
+		get, err := valueGetter(args[0])
+		if err != nil {
+			return nil, err
+		}
+		pattern, err := stringLiteral(FuncIsMatch, args[1])
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "function %s got invalid pattern", FuncIsMatch)
+		}
+		return func(tc *TransformContext) bool {
+			s, ok := get(tc).(string)
+			return ok && re.MatchString(s)
+		}, nil
+	}
+	return nil, errors.Errorf("unknown condition function '%s'", name)
+}
+
+func andCondition(left, right condition) condition {
+	return func(tc *TransformContext) bool { return left(tc) && right(tc) }
+}
+
+func orCondition(left, right condition) condition {
+	return func(tc *TransformContext) bool { return left(tc) || right(tc) }
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func equal(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	return a == b
+}
+
+// compare 仅数值之间或者字符串之间可比较大小
+func compare(a, b interface{}) (int, bool) {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case fa < fb:
+			return -1, true
+		case fa > fb:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	sa, ok := a.(string)
+	if !ok {
+		return 0, false
+	}
+	sb, ok := b.(string)
+	if !ok {
+		return 0, false
+	}
+	switch {
+	case sa < sb:
+		return -1, true
+	case sa > sb:
+		return 1, true
+	}
+	return 0, true
+}
+
+func newComparison(op string, left, right argument) (condition, error) {
+	lget, err := valueGetter(left)
+	if err != nil {
+		return nil, err
+	}
+	rget, err := valueGetter(right)
+	if err != nil {
+		return nil, err
+	}
+
+	var f func(a, b interface{}) bool
+	switch op {
+	case "==":
+		f = equal
+	case "!=":
+		f = func(a, b interface{}) bool { return !equal(a, b) }
+	case "<":
+		f = func(a, b interface{}) bool { n, ok := compare(a, b); return ok && n < 0 }
+	case "<=":
+		f = func(a, b interface{}) bool { n, ok := compare(a, b); return ok && n <= 0 }
+	case ">":
+		f = func(a, b interface{}) bool { n, ok := compare(a, b); return ok && n > 0 }
+	case ">=":
+		f = func(a, b interface{}) bool { n, ok := compare(a, b); return ok && n >= 0 }
+	default:
+		return nil, errors.Errorf("unknown operator '%s'", op)
+	}
+
+	return func(tc *TransformContext) bool {
+		return f(lget(tc), rget(tc))
+	}, nil
+}
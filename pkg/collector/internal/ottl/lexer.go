@@ -0,0 +1,134 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package ottl
+
+import (
+	"strconv"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+	tokenComma
+	tokenOperator
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// tokenize 将语句拆分为 token 列表 字符串字面量会被反转义
+func tokenize(s string) ([]token, error) {
+	rs := []rune(s)
+	var tokens []token
+
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case r == '[':
+			tokens = append(tokens, token{kind: tokenLBracket, text: "[", pos: i})
+			i++
+		case r == ']':
+			tokens = append(tokens, token{kind: tokenRBracket, text: "]", pos: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: i})
+			i++
+
+		case r == '=' || r == '!' || r == '<' || r == '>':
+			op := string(r)
+			if i+1 < len(rs) && rs[i+1] == '=' {
+				op += "="
+			}
+			if op == "=" || op == "!" {
+				return nil, errors.Errorf("unexpected operator '%s' at %d", op, i)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+			i += len(op)
+
+		case r == '"':
+			j := i + 1
+			for ; j < len(rs); j++ {
+				if rs[j] == '\\' {
+					j++
+					continue
+				}
+				if rs[j] == '"' {
+					break
+				}
+			}
+			if j >= len(rs) {
+				return nil, errors.Errorf("unterminated string at %d", i)
+			}
+			text, err := strconv.Unquote(string(rs[i : j+1]))
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid string at %d", i)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, pos: i})
+			i = j + 1
+
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(rs) && unicode.IsDigit(rs[i+1])):
+			j := i + 1
+			for ; j < len(rs); j++ {
+				if !unicode.IsDigit(rs[j]) && rs[j] != '.' {
+					break
+				}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(rs[i:j]), pos: i})
+			i = j
+
+		case isIdentStart(r):
+			j := i + 1
+			for ; j < len(rs); j++ {
+				if !isIdentPart(rs[j]) {
+					break
+				}
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(rs[i:j]), pos: i})
+			i = j
+
+		default:
+			return nil, errors.Errorf("unexpected character '%c' at %d", r, i)
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(rs)})
+	return tokens, nil
+}
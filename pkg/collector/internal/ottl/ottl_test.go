@@ -0,0 +1,180 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package ottl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func makeSpanContext() *TransformContext {
+	resource := pcommon.NewResource()
+	resource.Attributes().UpsertString("service.name", "api")
+
+	span := ptrace.NewSpan()
+	span.SetName("GET /users/123")
+	span.SetKind(ptrace.SpanKindServer)
+	span.SetStartTimestamp(1000)
+	span.SetEndTimestamp(3000)
+	span.Attributes().UpsertString("http.url", "http://example.com/users/123?token=abc")
+	span.Attributes().UpsertInt("http.status_code", 500)
+	span.Attributes().UpsertString("db.statement", "SELECT * FROM users")
+	return NewSpanContext(resource, span)
+}
+
+func TestTokenize(t *testing.T) {
+	tokens, err := tokenize(`set(attributes["a\"b"], -1.5) where x != nil`)
+	assert.NoError(t, err)
+
+	var texts []string
+	for _, tk := range tokens {
+		texts = append(texts, tk.text)
+	}
+	assert.Equal(t, []string{"set", "(", "attributes", "[", `a"b`, "]", ",", "-1.5", ")", "where", "x", "!=", "nil", ""}, texts)
+
+	_, err = tokenize(`set(name, "abc`)
+	assert.Error(t, err)
+	_, err = tokenize(`name = "abc"`)
+	assert.Error(t, err)
+}
+
+func TestParseStatementFailed(t *testing.T) {
+	cases := []struct {
+		ctx  Context
+		stmt string
+	}{
+		{ContextSpan, `unknown(name)`},
+		{ContextSpan, `set(kind, "x")`},
+		{ContextSpan, `set(attributes, "x")`},
+		{ContextSpan, `set(name, attributes)`},
+		{ContextSpan, `set(body, "x")`},
+		{ContextResource, `set(resource.attributes["k"], "v")`},
+		{ContextSpan, `set(name, "x") where`},
+		{ContextSpan, `set(name, "x") where name`},
+		{ContextSpan, `set(name, "x") extra`},
+		{ContextSpan, `delete(attributes)`},
+		{ContextSpan, `keep_keys(attributes, "a")`},
+		{ContextSpan, `replace_pattern(name, "(", "x")`},
+		{ContextSpan, `truncate(name, "10")`},
+		{ContextSpan, `set(name, "x") where IsMatch(name, name)`},
+	}
+	for _, c := range cases {
+		_, err := ParseStatement(c.ctx, c.stmt)
+		assert.Error(t, err, c.stmt)
+	}
+}
+
+func TestSpanStatements(t *testing.T) {
+	stmts, err := ParseStatements(ContextSpan, []string{
+		`set(attributes["env"], "prod") where resource.attributes["service.name"] == "api"`,
+		`set(attributes["env"], "test") where resource.attributes["service.name"] == "other"`,
+		`set(attributes["slow"], true) where duration >= 2000 and kind == "SPAN_KIND_SERVER"`,
+		`set(attributes["failed"], true) where attributes["http.status_code"] >= 500.0 or attributes["missing"] != nil`,
+		`replace_pattern(name, "/\\d+", "/{id}")`,
+		`replace_pattern(attributes["http.url"], "token=[^&]+", "token=***")`,
+		`truncate(attributes["db.statement"], 6) where not IsMatch(name, "^POST")`,
+		`set(attributes["status"], attributes["http.status_code"])`,
+		`delete(attributes["http.status_code"])`,
+		`set(attributes["never"], 1) where (name == "x" or name == "y") and kind == "SPAN_KIND_SERVER"`,
+	})
+	assert.NoError(t, err)
+
+	tc := makeSpanContext()
+	for _, stmt := range stmts {
+		assert.NoError(t, stmt.Execute(tc))
+	}
+
+	attrs := tc.span.Attributes()
+	assert.Equal(t, "GET /users/{id}", tc.span.Name())
+	assert.Equal(t, map[string]interface{}{
+		"env":          "prod",
+		"slow":         true,
+		"failed":       true,
+		"http.url":     "http://example.com/users/123?token=***",
+		"db.statement": "SELECT",
+		"status":       int64(500),
+	}, attrs.AsRaw())
+}
+
+func TestKeepKeysAndMapEditors(t *testing.T) {
+	stmts, err := ParseStatements(ContextSpan, []string{
+		`keep_keys(attributes, ["http.url", "db.statement"])`,
+		`truncate(attributes, 10)`,
+		`replace_pattern(attributes, "^http", "https")`,
+	})
+	assert.NoError(t, err)
+
+	tc := makeSpanContext()
+	for _, stmt := range stmts {
+		assert.NoError(t, stmt.Execute(tc))
+	}
+	assert.Equal(t, map[string]interface{}{
+		"http.url":     "https://exa",
+		"db.statement": "SELECT * F",
+	}, tc.span.Attributes().AsRaw())
+}
+
+func TestLogStatements(t *testing.T) {
+	stmts, err := ParseStatements(ContextLog, []string{
+		`set(severity_text, "ERROR") where IsMatch(body, "(?i)exception")`,
+		`set(severity_number, 17) where severity_text == "ERROR"`,
+		`replace_pattern(body, "password=\\S+", "password=***")`,
+	})
+	assert.NoError(t, err)
+
+	logRecord := plog.NewLogRecord()
+	logRecord.Body().SetStringVal("got Exception, password=123456")
+	tc := NewLogContext(pcommon.NewResource(), logRecord)
+	for _, stmt := range stmts {
+		assert.NoError(t, stmt.Execute(tc))
+	}
+	assert.Equal(t, "ERROR", logRecord.SeverityText())
+	assert.Equal(t, plog.SeverityNumber(17), logRecord.SeverityNumber())
+	assert.Equal(t, "got Exception, password=***", logRecord.Body().StringVal())
+}
+
+func TestDataPointStatements(t *testing.T) {
+	stmts, err := ParseStatements(ContextDataPoint, []string{
+		`set(metric.name, "cpu_usage_high") where metric.name == "cpu_usage" and value > 0.9`,
+		`set(attributes["type"], metric.type)`,
+	})
+	assert.NoError(t, err)
+
+	metric := pmetric.NewMetric()
+	metric.SetName("cpu_usage")
+	metric.SetDataType(pmetric.MetricDataTypeGauge)
+	dp := metric.Gauge().DataPoints().AppendEmpty()
+	dp.SetDoubleVal(0.95)
+
+	tc := NewNumberDataPointContext(pcommon.NewResource(), metric, dp)
+	for _, stmt := range stmts {
+		assert.NoError(t, stmt.Execute(tc))
+	}
+	assert.Equal(t, "cpu_usage_high", metric.Name())
+	assert.Equal(t, map[string]interface{}{"type": "Gauge"}, dp.Attributes().AsRaw())
+}
+
+func TestParseCondition(t *testing.T) {
+	cond, err := ParseCondition(ContextResource, `attributes["service.name"] == "api" and attributes["missing"] == nil`)
+	assert.NoError(t, err)
+	assert.Equal(t, `attributes["service.name"] == "api" and attributes["missing"] == nil`, cond.String())
+
+	resource := pcommon.NewResource()
+	resource.Attributes().UpsertString("service.name", "api")
+	assert.True(t, cond.Match(NewResourceContext(resource)))
+
+	resource.Attributes().UpsertString("missing", "")
+	assert.False(t, cond.Match(NewResourceContext(resource)))
+}
@@ -0,0 +1,392 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+// Package ottl 实现一个简化版的 OTTL（OpenTelemetry Transformation Language）
+//
+// 语句格式为 `editor(args...) [where condition]`
+// 例如: set(attributes["env"], "prod") where resource.attributes["service.name"] == "api"
+//
+// 语句在配置加载时编译为闭包 运行时不再产生解析开销
+package ottl
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	keywordWhere = "where"
+	keywordAnd   = "and"
+	keywordOr    = "or"
+	keywordNot   = "not"
+	keywordNil   = "nil"
+	keywordTrue  = "true"
+	keywordFalse = "false"
+)
+
+// Statement 编译后的语句
+type Statement struct {
+	raw       string
+	editor    editor
+	condition condition
+}
+
+func (s *Statement) String() string {
+	return s.raw
+}
+
+// Execute 条件满足时执行语句
+func (s *Statement) Execute(tc *TransformContext) error {
+	if s.condition != nil && !s.condition(tc) {
+		return nil
+	}
+	return s.editor(tc)
+}
+
+// ParseStatement 编译语句
+func ParseStatement(ctx Context, s string) (*Statement, error) {
+	p, err := newParser(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	ed, err := p.parseEditor()
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse statement '%s'", s)
+	}
+	stmt := &Statement{raw: s, editor: ed}
+
+	if p.peekIdent(keywordWhere) {
+		p.next()
+		cond, err := p.parseOr()
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse statement '%s'", s)
+		}
+		stmt.condition = cond
+	}
+
+	if err := p.expect(tokenEOF); err != nil {
+		return nil, errors.Wrapf(err, "parse statement '%s'", s)
+	}
+	return stmt, nil
+}
+
+// ParseStatements 批量编译语句 任一语句失败即返回错误
+func ParseStatements(ctx Context, ss []string) ([]*Statement, error) {
+	stmts := make([]*Statement, 0, len(ss))
+	for _, s := range ss {
+		stmt, err := ParseStatement(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+	}
+	return stmts, nil
+}
+
+// Condition 编译后的条件表达式
+type Condition struct {
+	raw  string
+	cond condition
+}
+
+func (c *Condition) String() string {
+	return c.raw
+}
+
+func (c *Condition) Match(tc *TransformContext) bool {
+	return c.cond(tc)
+}
+
+// ParseCondition 编译单独的条件表达式 语法与 where 子句一致
+func ParseCondition(ctx Context, s string) (*Condition, error) {
+	p, err := newParser(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse condition '%s'", s)
+	}
+	if err := p.expect(tokenEOF); err != nil {
+		return nil, errors.Wrapf(err, "parse condition '%s'", s)
+	}
+	return &Condition{raw: s, cond: cond}, nil
+}
+
+type parser struct {
+	ctx    Context
+	tokens []token
+	pos    int
+}
+
+func newParser(ctx Context, s string) (*parser, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "tokenize '%s'", s)
+	}
+	return &parser{ctx: ctx, tokens: tokens}, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekN(n int) token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) peekIdent(keyword string) bool {
+	t := p.peek()
+	return t.kind == tokenIdent && t.text == keyword
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(kind tokenKind) error {
+	t := p.next()
+	if t.kind != kind {
+		if t.kind == tokenEOF {
+			return errors.New("unexpected end of input")
+		}
+		return errors.Errorf("unexpected token '%s' at %d", t.text, t.pos)
+	}
+	return nil
+}
+
+// argument 函数参数 可以是字段引用 字面量或者字面量列表
+type argument struct {
+	path    *path
+	literal interface{}
+	list    []interface{}
+	isList  bool
+}
+
+func (a argument) getter() getter {
+	if a.path != nil {
+		return a.path.get
+	}
+	lit := a.literal
+	return func(*TransformContext) interface{} { return lit }
+}
+
+func (p *parser) parseEditor() (editor, error) {
+	t := p.next()
+	if t.kind != tokenIdent {
+		return nil, errors.Errorf("expected function name at %d", t.pos)
+	}
+	args, err := p.parseArgs()
+	if err != nil {
+		return nil, err
+	}
+	return newEditor(t.text, args)
+}
+
+func (p *parser) parseArgs() ([]argument, error) {
+	if err := p.expect(tokenLParen); err != nil {
+		return nil, err
+	}
+
+	var args []argument
+	if p.peek().kind == tokenRParen {
+		p.next()
+		return args, nil
+	}
+	for {
+		arg, err := p.parseArg()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		t := p.next()
+		switch t.kind {
+		case tokenComma:
+			continue
+		case tokenRParen:
+			return args, nil
+		default:
+			return nil, errors.Errorf("unexpected token '%s' at %d", t.text, t.pos)
+		}
+	}
+}
+
+func (p *parser) parseArg() (argument, error) {
+	if p.peek().kind != tokenLBracket {
+		return p.parseValue()
+	}
+
+	p.next()
+	arg := argument{isList: true}
+	for p.peek().kind != tokenRBracket {
+		v, err := p.parseValue()
+		if err != nil {
+			return arg, err
+		}
+		if v.path != nil {
+			return arg, errors.New("list only supports literal values")
+		}
+		arg.list = append(arg.list, v.literal)
+		if p.peek().kind == tokenComma {
+			p.next()
+		}
+	}
+	p.next()
+	return arg, nil
+}
+
+func parseNumber(s string) (interface{}, error) {
+	if strings.Contains(s, ".") {
+		return strconv.ParseFloat(s, 64)
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+func (p *parser) parseValue() (argument, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return argument{literal: t.text}, nil
+
+	case tokenNumber:
+		n, err := parseNumber(t.text)
+		if err != nil {
+			return argument{}, errors.Wrapf(err, "invalid number '%s' at %d", t.text, t.pos)
+		}
+		return argument{literal: n}, nil
+
+	case tokenIdent:
+		switch t.text {
+		case keywordNil:
+			return argument{}, nil
+		case keywordTrue:
+			return argument{literal: true}, nil
+		case keywordFalse:
+			return argument{literal: false}, nil
+		}
+
+		var key string
+		var hasKey bool
+		if p.peek().kind == tokenLBracket {
+			p.next()
+			kt := p.next()
+			if kt.kind != tokenString {
+				return argument{}, errors.Errorf("expected string key at %d", kt.pos)
+			}
+			if err := p.expect(tokenRBracket); err != nil {
+				return argument{}, err
+			}
+			key, hasKey = kt.text, true
+		}
+		pt, err := resolvePath(p.ctx, t.text, key, hasKey)
+		if err != nil {
+			return argument{}, err
+		}
+		return argument{path: pt}, nil
+	}
+
+	if t.kind == tokenEOF {
+		return argument{}, errors.New("unexpected end of input")
+	}
+	return argument{}, errors.Errorf("unexpected token '%s' at %d", t.text, t.pos)
+}
+
+func (p *parser) parseOr() (condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekIdent(keywordOr) {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orCondition(left, right)
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (condition, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekIdent(keywordAnd) {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andCondition(left, right)
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (condition, error) {
+	if p.peekIdent(keywordNot) {
+		p.next()
+		cond, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(tc *TransformContext) bool { return !cond(tc) }, nil
+	}
+
+	if p.peek().kind == tokenLParen {
+		p.next()
+		cond, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenRParen); err != nil {
+			return nil, err
+		}
+		return cond, nil
+	}
+
+	// 布尔函数 如 IsMatch(name, "^GET")
+	if t := p.peek(); t.kind == tokenIdent && p.peekN(1).kind == tokenLParen {
+		p.next()
+		args, err := p.parseArgs()
+		if err != nil {
+			return nil, err
+		}
+		return newBoolFunc(t.text, args)
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (condition, error) {
+	left, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	t := p.next()
+	if t.kind != tokenOperator {
+		return nil, errors.Errorf("expected comparison operator at %d", t.pos)
+	}
+	right, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return newComparison(t.text, left, right)
+}
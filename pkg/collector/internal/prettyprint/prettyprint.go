@@ -94,6 +94,62 @@ func Logs(logs plog.Logs) {
 	})
 }
 
+// Object 将数据转换为可 JSON 序列化的对象 字段与 Pretty 输出保持一致
+// 非 OT 数据类型原样返回
+func Object(rtype define.RecordType, data interface{}) interface{} {
+	switch rtype {
+	case define.RecordTraces:
+		return tracesObject(data.(ptrace.Traces))
+	case define.RecordMetrics:
+		return metricsObject(data.(pmetric.Metrics))
+	case define.RecordLogs:
+		return logsObject(data.(plog.Logs))
+	}
+	return data
+}
+
+func tracesObject(traces ptrace.Traces) []map[string]interface{} {
+	items := make([]map[string]interface{}, 0)
+	foreach.SpansWithResourceAttrs(traces.ResourceSpans(), func(rsAttrs pcommon.Map, span ptrace.Span) {
+		items = append(items, map[string]interface{}{
+			"resource":   rsAttrs.AsRaw(),
+			"traceID":    span.TraceID().HexString(),
+			"spanID":     span.SpanID().HexString(),
+			"spanName":   span.Name(),
+			"spanKind":   span.Kind().String(),
+			"spanStatus": span.Status().Code().String(),
+			"attributes": span.Attributes().AsRaw(),
+		})
+	})
+	return items
+}
+
+func metricsObject(metrics pmetric.Metrics) []map[string]interface{} {
+	items := make([]map[string]interface{}, 0)
+	foreach.MetricsWithResourceAttrs(metrics.ResourceMetrics(), func(rsAttrs pcommon.Map, metric pmetric.Metric) {
+		items = append(items, map[string]interface{}{
+			"resource": rsAttrs.AsRaw(),
+			"metric":   metric.Name(),
+			"dataType": metric.DataType().String(),
+			"unit":     metric.Unit(),
+		})
+	})
+	return items
+}
+
+func logsObject(logs plog.Logs) []map[string]interface{} {
+	items := make([]map[string]interface{}, 0)
+	foreach.LogsWithResourceAttrs(logs.ResourceLogs(), func(rsAttrs pcommon.Map, logRecord plog.LogRecord) {
+		items = append(items, map[string]interface{}{
+			"resource":   rsAttrs.AsRaw(),
+			"body":       logRecord.Body().AsString(),
+			"attributes": logRecord.Attributes().AsRaw(),
+			"logLevel":   logRecord.SeverityText(),
+		})
+	})
+	return items
+}
+
 func bToMb(b uint64) uint64 {
 	return b / 1024 / 1024
 }
@@ -12,6 +12,8 @@ package prettyprint
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/generator"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
@@ -44,3 +46,36 @@ func TestPrettyPrint(t *testing.T) {
 		RuntimeMemStats(t.Logf)
 	})
 }
+
+func TestObject(t *testing.T) {
+	t.Run("Traces", func(t *testing.T) {
+		g := generator.NewTracesGenerator(define.TracesOptions{
+			SpanCount: 2,
+		})
+		items := Object(define.RecordTraces, g.Generate()).([]map[string]interface{})
+		assert.Len(t, items, 2)
+		assert.Contains(t, items[0], "spanID")
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		g := generator.NewMetricsGenerator(define.MetricsOptions{
+			GaugeCount: 2,
+		})
+		items := Object(define.RecordMetrics, g.Generate()).([]map[string]interface{})
+		assert.Len(t, items, 2)
+		assert.Equal(t, "Gauge", items[0]["dataType"])
+	})
+
+	t.Run("Logs", func(t *testing.T) {
+		g := generator.NewLogsGenerator(define.LogsOptions{
+			LogCount: 2,
+		})
+		items := Object(define.RecordLogs, g.Generate()).([]map[string]interface{})
+		assert.Len(t, items, 2)
+	})
+
+	t.Run("Others", func(t *testing.T) {
+		data := define.PushGatewayData{}
+		assert.Equal(t, data, Object(define.RecordPushGateway, data))
+	})
+}
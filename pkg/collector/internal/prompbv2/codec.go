@@ -0,0 +1,348 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package prompbv2
+
+import (
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/protowireutil"
+)
+
+// Unmarshal 解码 io.prometheus.write.v2.Request
+func Unmarshal(b []byte, req *Request) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 4:
+			v, n, err := protowireutil.ConsumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			req.Symbols = append(req.Symbols, string(v))
+			return n, true, nil
+		case 5:
+			v, n, err := protowireutil.ConsumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			var ts TimeSeries
+			if err := unmarshalTimeSeries(v, &ts); err != nil {
+				return 0, false, err
+			}
+			req.Timeseries = append(req.Timeseries, ts)
+			return n, true, nil
+		}
+		return 0, false, nil
+	})
+}
+
+func unmarshalTimeSeries(b []byte, ts *TimeSeries) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			n, err := protowireutil.ConsumeRepeatedVarint(typ, b, func(v uint64) { ts.LabelsRefs = append(ts.LabelsRefs, uint32(v)) })
+			return n, true, err
+		case 2:
+			v, n, err := protowireutil.ConsumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			var s Sample
+			if err := unmarshalSample(v, &s); err != nil {
+				return 0, false, err
+			}
+			ts.Samples = append(ts.Samples, s)
+			return n, true, nil
+		case 3:
+			v, n, err := protowireutil.ConsumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			var h Histogram
+			if err := unmarshalHistogram(v, &h); err != nil {
+				return 0, false, err
+			}
+			ts.Histograms = append(ts.Histograms, h)
+			return n, true, nil
+		case 4:
+			v, n, err := protowireutil.ConsumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			var e Exemplar
+			if err := unmarshalExemplar(v, &e); err != nil {
+				return 0, false, err
+			}
+			ts.Exemplars = append(ts.Exemplars, e)
+			return n, true, nil
+		case 5:
+			v, n, err := protowireutil.ConsumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			if err := unmarshalMetadata(v, &ts.Metadata); err != nil {
+				return 0, false, err
+			}
+			return n, true, nil
+		case 6:
+			v, n, err := protowireutil.ConsumeVarint(typ, b)
+			ts.CreatedTimestamp = int64(v)
+			return n, true, err
+		}
+		return 0, false, nil
+	})
+}
+
+func unmarshalSample(b []byte, s *Sample) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			v, n, err := protowireutil.ConsumeDouble(typ, b)
+			s.Value = v
+			return n, true, err
+		case 2:
+			v, n, err := protowireutil.ConsumeVarint(typ, b)
+			s.Timestamp = int64(v)
+			return n, true, err
+		}
+		return 0, false, nil
+	})
+}
+
+func unmarshalExemplar(b []byte, e *Exemplar) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			n, err := protowireutil.ConsumeRepeatedVarint(typ, b, func(v uint64) { e.LabelsRefs = append(e.LabelsRefs, uint32(v)) })
+			return n, true, err
+		case 2:
+			v, n, err := protowireutil.ConsumeDouble(typ, b)
+			e.Value = v
+			return n, true, err
+		case 3:
+			v, n, err := protowireutil.ConsumeVarint(typ, b)
+			e.Timestamp = int64(v)
+			return n, true, err
+		}
+		return 0, false, nil
+	})
+}
+
+func unmarshalMetadata(b []byte, m *Metadata) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			v, n, err := protowireutil.ConsumeVarint(typ, b)
+			m.Type = MetricType(v)
+			return n, true, err
+		case 3:
+			v, n, err := protowireutil.ConsumeVarint(typ, b)
+			m.HelpRef = uint32(v)
+			return n, true, err
+		case 4:
+			v, n, err := protowireutil.ConsumeVarint(typ, b)
+			m.UnitRef = uint32(v)
+			return n, true, err
+		}
+		return 0, false, nil
+	})
+}
+
+func unmarshalBucketSpan(b []byte, s *BucketSpan) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			v, n, err := protowireutil.ConsumeVarint(typ, b)
+			s.Offset = int32(protowire.DecodeZigZag(v))
+			return n, true, err
+		case 2:
+			v, n, err := protowireutil.ConsumeVarint(typ, b)
+			s.Length = uint32(v)
+			return n, true, err
+		}
+		return 0, false, nil
+	})
+}
+
+func consumeBucketSpan(typ protowire.Type, b []byte, spans *[]BucketSpan) (int, error) {
+	v, n, err := protowireutil.ConsumeBytes(typ, b)
+	if err != nil {
+		return 0, err
+	}
+	var span BucketSpan
+	if err := unmarshalBucketSpan(v, &span); err != nil {
+		return 0, err
+	}
+	*spans = append(*spans, span)
+	return n, nil
+}
+
+func unmarshalHistogram(b []byte, h *Histogram) error {
+	return protowireutil.ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			v, n, err := protowireutil.ConsumeVarint(typ, b)
+			h.CountInt = v
+			return n, true, err
+		case 2:
+			v, n, err := protowireutil.ConsumeDouble(typ, b)
+			h.CountFloat = v
+			h.IsFloat = true
+			return n, true, err
+		case 3:
+			v, n, err := protowireutil.ConsumeDouble(typ, b)
+			h.Sum = v
+			return n, true, err
+		case 4:
+			v, n, err := protowireutil.ConsumeVarint(typ, b)
+			h.Schema = int32(protowire.DecodeZigZag(v))
+			return n, true, err
+		case 5:
+			v, n, err := protowireutil.ConsumeDouble(typ, b)
+			h.ZeroThreshold = v
+			return n, true, err
+		case 6:
+			v, n, err := protowireutil.ConsumeVarint(typ, b)
+			h.ZeroCountInt = v
+			return n, true, err
+		case 7:
+			v, n, err := protowireutil.ConsumeDouble(typ, b)
+			h.ZeroCountFloat = v
+			return n, true, err
+		case 8:
+			n, err := consumeBucketSpan(typ, b, &h.NegativeSpans)
+			return n, true, err
+		case 9:
+			n, err := protowireutil.ConsumeRepeatedVarint(typ, b, func(v uint64) { h.NegativeDeltas = append(h.NegativeDeltas, protowire.DecodeZigZag(v)) })
+			return n, true, err
+		case 10:
+			n, err := protowireutil.ConsumeRepeatedDouble(typ, b, func(v float64) { h.NegativeCounts = append(h.NegativeCounts, v) })
+			return n, true, err
+		case 11:
+			n, err := consumeBucketSpan(typ, b, &h.PositiveSpans)
+			return n, true, err
+		case 12:
+			n, err := protowireutil.ConsumeRepeatedVarint(typ, b, func(v uint64) { h.PositiveDeltas = append(h.PositiveDeltas, protowire.DecodeZigZag(v)) })
+			return n, true, err
+		case 13:
+			n, err := protowireutil.ConsumeRepeatedDouble(typ, b, func(v float64) { h.PositiveCounts = append(h.PositiveCounts, v) })
+			return n, true, err
+		case 14:
+			v, n, err := protowireutil.ConsumeVarint(typ, b)
+			h.ResetHint = int32(v)
+			return n, true, err
+		case 15:
+			v, n, err := protowireutil.ConsumeVarint(typ, b)
+			h.Timestamp = int64(v)
+			return n, true, err
+		case 16:
+			n, err := protowireutil.ConsumeRepeatedDouble(typ, b, func(v float64) { h.CustomValues = append(h.CustomValues, v) })
+			return n, true, err
+		}
+		return 0, false, nil
+	})
+}
+
+func refsToUint64(refs []uint32) []uint64 {
+	values := make([]uint64, 0, len(refs))
+	for _, ref := range refs {
+		values = append(values, uint64(ref))
+	}
+	return values
+}
+
+func zigzag(values []int64) []uint64 {
+	encoded := make([]uint64, 0, len(values))
+	for _, v := range values {
+		encoded = append(encoded, protowire.EncodeZigZag(v))
+	}
+	return encoded
+}
+
+func marshalSpans(b []byte, num protowire.Number, spans []BucketSpan) []byte {
+	for _, span := range spans {
+		var msg []byte
+		msg = protowireutil.AppendVarint(msg, 1, protowire.EncodeZigZag(int64(span.Offset)))
+		msg = protowireutil.AppendVarint(msg, 2, uint64(span.Length))
+		b = protowireutil.AppendMessage(b, num, msg)
+	}
+	return b
+}
+
+func marshalHistogram(h Histogram) []byte {
+	var b []byte
+	if h.IsFloat {
+		b = protowire.AppendTag(b, 2, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(h.CountFloat))
+	} else {
+		b = protowire.AppendTag(b, 1, protowire.VarintType)
+		b = protowire.AppendVarint(b, h.CountInt)
+	}
+	b = protowireutil.AppendDouble(b, 3, h.Sum)
+	b = protowireutil.AppendVarint(b, 4, protowire.EncodeZigZag(int64(h.Schema)))
+	b = protowireutil.AppendDouble(b, 5, h.ZeroThreshold)
+	if h.IsFloat {
+		b = protowireutil.AppendDouble(b, 7, h.ZeroCountFloat)
+	} else {
+		b = protowireutil.AppendVarint(b, 6, h.ZeroCountInt)
+	}
+	b = marshalSpans(b, 8, h.NegativeSpans)
+	b = protowireutil.AppendPackedVarint(b, 9, zigzag(h.NegativeDeltas))
+	b = protowireutil.AppendPackedDouble(b, 10, h.NegativeCounts)
+	b = marshalSpans(b, 11, h.PositiveSpans)
+	b = protowireutil.AppendPackedVarint(b, 12, zigzag(h.PositiveDeltas))
+	b = protowireutil.AppendPackedDouble(b, 13, h.PositiveCounts)
+	b = protowireutil.AppendVarint(b, 14, uint64(h.ResetHint))
+	b = protowireutil.AppendVarint(b, 15, uint64(h.Timestamp))
+	b = protowireutil.AppendPackedDouble(b, 16, h.CustomValues)
+	return b
+}
+
+// Marshal 编码 io.prometheus.write.v2.Request
+func Marshal(req *Request) []byte {
+	var b []byte
+	for _, s := range req.Symbols {
+		b = protowire.AppendTag(b, 4, protowire.BytesType)
+		b = protowire.AppendString(b, s)
+	}
+
+	for _, ts := range req.Timeseries {
+		var msg []byte
+		msg = protowireutil.AppendPackedVarint(msg, 1, refsToUint64(ts.LabelsRefs))
+		for _, s := range ts.Samples {
+			var sample []byte
+			sample = protowireutil.AppendDouble(sample, 1, s.Value)
+			sample = protowireutil.AppendVarint(sample, 2, uint64(s.Timestamp))
+			msg = protowireutil.AppendMessage(msg, 2, sample)
+		}
+		for _, h := range ts.Histograms {
+			msg = protowireutil.AppendMessage(msg, 3, marshalHistogram(h))
+		}
+		for _, e := range ts.Exemplars {
+			var exemplar []byte
+			exemplar = protowireutil.AppendPackedVarint(exemplar, 1, refsToUint64(e.LabelsRefs))
+			exemplar = protowireutil.AppendDouble(exemplar, 2, e.Value)
+			exemplar = protowireutil.AppendVarint(exemplar, 3, uint64(e.Timestamp))
+			msg = protowireutil.AppendMessage(msg, 4, exemplar)
+		}
+
+		var md []byte
+		md = protowireutil.AppendVarint(md, 1, uint64(ts.Metadata.Type))
+		md = protowireutil.AppendVarint(md, 3, uint64(ts.Metadata.HelpRef))
+		md = protowireutil.AppendVarint(md, 4, uint64(ts.Metadata.UnitRef))
+		if len(md) > 0 {
+			msg = protowireutil.AppendMessage(msg, 5, md)
+		}
+		msg = protowireutil.AppendVarint(msg, 6, uint64(ts.CreatedTimestamp))
+		b = protowireutil.AppendMessage(b, 5, msg)
+	}
+	return b
+}
@@ -0,0 +1,110 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package prompbv2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRequest() *Request {
+	return &Request{
+		Symbols: []string{"", "__name__", "http_requests_total", "code", "200", "trace_id", "abc", "requests count", "req"},
+		Timeseries: []TimeSeries{
+			{
+				LabelsRefs: []uint32{1, 2, 3, 4},
+				Samples:    []Sample{{Value: 10, Timestamp: 1000}, {Value: 12, Timestamp: 2000}},
+				Exemplars:  []Exemplar{{LabelsRefs: []uint32{5, 6}, Value: 1, Timestamp: 1500}},
+				Metadata:   Metadata{Type: MetricTypeCounter, HelpRef: 7, UnitRef: 8},
+
+				CreatedTimestamp: 500,
+			},
+			{
+				LabelsRefs: []uint32{1, 2},
+				Histograms: []Histogram{
+					{
+						CountInt:       6,
+						Sum:            -1.5,
+						Schema:         -1,
+						ZeroThreshold:  0.001,
+						ZeroCountInt:   1,
+						PositiveSpans:  []BucketSpan{{Offset: -2, Length: 3}},
+						PositiveDeltas: []int64{1, 2, -2},
+						NegativeSpans:  []BucketSpan{{Offset: 0, Length: 1}},
+						NegativeDeltas: []int64{2},
+						Timestamp:      2000,
+					},
+					{
+						IsFloat:        true,
+						CountFloat:     3.5,
+						Sum:            7,
+						ZeroCountFloat: 0.5,
+						PositiveSpans:  []BucketSpan{{Offset: 0, Length: 2}},
+						PositiveCounts: []float64{1, 2},
+						Timestamp:      3000,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+	req := newTestRequest()
+
+	var got Request
+	assert.NoError(t, Unmarshal(Marshal(req), &got))
+	assert.Equal(t, req.Symbols, got.Symbols)
+	assert.Len(t, got.Timeseries, 2)
+
+	ts := got.Timeseries[0]
+	assert.Equal(t, []uint32{1, 2, 3, 4}, ts.LabelsRefs)
+	assert.Equal(t, req.Timeseries[0].Samples, ts.Samples)
+	assert.Equal(t, req.Timeseries[0].Exemplars, ts.Exemplars)
+	assert.Equal(t, req.Timeseries[0].Metadata, ts.Metadata)
+	assert.Equal(t, int64(500), ts.CreatedTimestamp)
+
+	h := got.Timeseries[1].Histograms[0]
+	assert.False(t, h.IsFloat)
+	assert.Equal(t, float64(6), h.Count())
+	assert.Equal(t, float64(1), h.ZeroCount())
+	assert.Equal(t, int32(-1), h.Schema)
+	assert.Equal(t, -1.5, h.Sum)
+	assert.Equal(t, []BucketSpan{{Offset: -2, Length: 3}}, h.PositiveSpans)
+	assert.Equal(t, []float64{1, 3, 1}, h.PositiveBuckets())
+	assert.Equal(t, []float64{2}, h.NegativeBuckets())
+	assert.Equal(t, int64(2000), h.Timestamp)
+
+	h = got.Timeseries[1].Histograms[1]
+	assert.True(t, h.IsFloat)
+	assert.Equal(t, 3.5, h.Count())
+	assert.Equal(t, 0.5, h.ZeroCount())
+	assert.Equal(t, []float64{1, 2}, h.PositiveBuckets())
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	var req Request
+	assert.Error(t, Unmarshal([]byte{0x2a, 0x05, 0x01}, &req))
+}
+
+func TestRequestLabels(t *testing.T) {
+	req := newTestRequest()
+
+	lbs, err := req.Labels([]uint32{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"__name__", "http_requests_total"}, lbs)
+
+	_, err = req.Labels([]uint32{1})
+	assert.Error(t, err)
+
+	_, err = req.Labels([]uint32{1, 100})
+	assert.Error(t, err)
+}
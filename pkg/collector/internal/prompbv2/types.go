@@ -0,0 +1,158 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+// Package prompbv2 实现 prometheus remote-write 2.0 协议（io.prometheus.write.v2.Request）的编解码
+//
+// 依赖的 prometheus 版本尚未提供 v2 的 protobuf 定义 因此基于 protowire 手动实现
+// 参见 https://prometheus.io/docs/specs/prw/remote_write_spec_2_0/
+package prompbv2
+
+import (
+	"github.com/pkg/errors"
+)
+
+type MetricType int32
+
+const (
+	MetricTypeUnspecified    MetricType = 0
+	MetricTypeCounter        MetricType = 1
+	MetricTypeGauge          MetricType = 2
+	MetricTypeHistogram      MetricType = 3
+	MetricTypeGaugeHistogram MetricType = 4
+	MetricTypeSummary        MetricType = 5
+	MetricTypeInfo           MetricType = 6
+	MetricTypeStateSet       MetricType = 7
+)
+
+type Request struct {
+	Symbols    []string
+	Timeseries []TimeSeries
+}
+
+type TimeSeries struct {
+	LabelsRefs       []uint32
+	Samples          []Sample
+	Histograms       []Histogram
+	Exemplars        []Exemplar
+	Metadata         Metadata
+	CreatedTimestamp int64
+}
+
+type Sample struct {
+	Value     float64
+	Timestamp int64
+}
+
+type Exemplar struct {
+	LabelsRefs []uint32
+	Value      float64
+	Timestamp  int64
+}
+
+type Metadata struct {
+	Type    MetricType
+	HelpRef uint32
+	UnitRef uint32
+}
+
+type BucketSpan struct {
+	Offset int32
+	Length uint32
+}
+
+// Histogram native histogram
+// 整数类型的 histogram 使用 deltas 描述桶计数 浮点类型使用 counts 描述桶计数
+type Histogram struct {
+	CountInt       uint64
+	CountFloat     float64
+	IsFloat        bool
+	Sum            float64
+	Schema         int32
+	ZeroThreshold  float64
+	ZeroCountInt   uint64
+	ZeroCountFloat float64
+	NegativeSpans  []BucketSpan
+	NegativeDeltas []int64
+	NegativeCounts []float64
+	PositiveSpans  []BucketSpan
+	PositiveDeltas []int64
+	PositiveCounts []float64
+	ResetHint      int32
+	Timestamp      int64
+	CustomValues   []float64
+}
+
+// Count 返回样本总数
+func (h Histogram) Count() float64 {
+	if h.IsFloat {
+		return h.CountFloat
+	}
+	return float64(h.CountInt)
+}
+
+// ZeroCount 返回零值桶计数
+func (h Histogram) ZeroCount() float64 {
+	if h.IsFloat {
+		return h.ZeroCountFloat
+	}
+	return float64(h.ZeroCountInt)
+}
+
+// PositiveBuckets 返回正数桶的绝对计数
+func (h Histogram) PositiveBuckets() []float64 {
+	if h.IsFloat {
+		return h.PositiveCounts
+	}
+	return deltasToCounts(h.PositiveDeltas)
+}
+
+// NegativeBuckets 返回负数桶的绝对计数
+func (h Histogram) NegativeBuckets() []float64 {
+	if h.IsFloat {
+		return h.NegativeCounts
+	}
+	return deltasToCounts(h.NegativeDeltas)
+}
+
+func deltasToCounts(deltas []int64) []float64 {
+	if len(deltas) == 0 {
+		return nil
+	}
+	counts := make([]float64, 0, len(deltas))
+	var cur int64
+	for _, d := range deltas {
+		cur += d
+		counts = append(counts, float64(cur))
+	}
+	return counts
+}
+
+// Symbol 从符号表中获取字符串 引用越界时返回错误
+func (r *Request) Symbol(ref uint32) (string, error) {
+	if int(ref) >= len(r.Symbols) {
+		return "", errors.Errorf("symbol ref %d out of range (%d)", ref, len(r.Symbols))
+	}
+	return r.Symbols[ref], nil
+}
+
+// Labels 将 labels_refs 解析为 name/value 对
+func (r *Request) Labels(refs []uint32) ([]string, error) {
+	if len(refs)%2 != 0 {
+		return nil, errors.Errorf("invalid labels refs length %d", len(refs))
+	}
+	lbs := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		s, err := r.Symbol(ref)
+		if err != nil {
+			return nil, err
+		}
+		lbs = append(lbs, s)
+	}
+	return lbs, nil
+}
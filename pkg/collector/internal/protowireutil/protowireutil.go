@@ -0,0 +1,180 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+// Package protowireutil 基于 protowire 的轻量编解码工具 供手写的 protobuf 编解码器复用
+package protowireutil
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// FieldFunc 处理单个字段 返回 false 表示字段未被识别 需要跳过
+type FieldFunc func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error)
+
+// ConsumeMessage 遍历消息中的所有字段 未识别的字段会被跳过
+func ConsumeMessage(b []byte, f FieldFunc) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		n, ok, err := f(num, typ, b)
+		if err != nil {
+			return errors.Wrapf(err, "field %d", num)
+		}
+		if !ok {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+func ConsumeVarint(typ protowire.Type, b []byte) (uint64, int, error) {
+	if typ != protowire.VarintType {
+		return 0, 0, errors.Errorf("unexpected wire type %d", typ)
+	}
+	v, n := protowire.ConsumeVarint(b)
+	if n < 0 {
+		return 0, 0, protowire.ParseError(n)
+	}
+	return v, n, nil
+}
+
+func ConsumeDouble(typ protowire.Type, b []byte) (float64, int, error) {
+	if typ != protowire.Fixed64Type {
+		return 0, 0, errors.Errorf("unexpected wire type %d", typ)
+	}
+	v, n := protowire.ConsumeFixed64(b)
+	if n < 0 {
+		return 0, 0, protowire.ParseError(n)
+	}
+	return math.Float64frombits(v), n, nil
+}
+
+func ConsumeBytes(typ protowire.Type, b []byte) ([]byte, int, error) {
+	if typ != protowire.BytesType {
+		return nil, 0, errors.Errorf("unexpected wire type %d", typ)
+	}
+	v, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return nil, 0, protowire.ParseError(n)
+	}
+	return v, n, nil
+}
+
+// ConsumeRepeatedVarint 同时兼容 packed 以及非 packed 编码
+func ConsumeRepeatedVarint(typ protowire.Type, b []byte, f func(v uint64)) (int, error) {
+	if typ == protowire.VarintType {
+		v, n, err := ConsumeVarint(typ, b)
+		if err != nil {
+			return 0, err
+		}
+		f(v)
+		return n, nil
+	}
+
+	packed, n, err := ConsumeBytes(typ, b)
+	if err != nil {
+		return 0, err
+	}
+	for len(packed) > 0 {
+		v, m := protowire.ConsumeVarint(packed)
+		if m < 0 {
+			return 0, protowire.ParseError(m)
+		}
+		f(v)
+		packed = packed[m:]
+	}
+	return n, nil
+}
+
+// ConsumeRepeatedDouble 同时兼容 packed 以及非 packed 编码
+func ConsumeRepeatedDouble(typ protowire.Type, b []byte, f func(v float64)) (int, error) {
+	if typ == protowire.Fixed64Type {
+		v, n, err := ConsumeDouble(typ, b)
+		if err != nil {
+			return 0, err
+		}
+		f(v)
+		return n, nil
+	}
+
+	packed, n, err := ConsumeBytes(typ, b)
+	if err != nil {
+		return 0, err
+	}
+	for len(packed) > 0 {
+		v, m := protowire.ConsumeFixed64(packed)
+		if m < 0 {
+			return 0, protowire.ParseError(m)
+		}
+		f(math.Float64frombits(v))
+		packed = packed[m:]
+	}
+	return n, nil
+}
+
+// AppendVarint 零值按 proto3 语义省略
+func AppendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// AppendDouble 零值按 proto3 语义省略
+func AppendDouble(b []byte, num protowire.Number, v float64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(v))
+}
+
+func AppendString(b []byte, num protowire.Number, s string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func AppendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func AppendPackedVarint(b []byte, num protowire.Number, values []uint64) []byte {
+	if len(values) == 0 {
+		return b
+	}
+	var packed []byte
+	for _, v := range values {
+		packed = protowire.AppendVarint(packed, v)
+	}
+	return AppendMessage(b, num, packed)
+}
+
+func AppendPackedDouble(b []byte, num protowire.Number, values []float64) []byte {
+	if len(values) == 0 {
+		return b
+	}
+	var packed []byte
+	for _, v := range values {
+		packed = protowire.AppendFixed64(packed, math.Float64bits(v))
+	}
+	return AppendMessage(b, num, packed)
+}
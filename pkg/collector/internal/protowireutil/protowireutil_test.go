@@ -0,0 +1,79 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package protowireutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestConsumeMessage(t *testing.T) {
+	var b []byte
+	b = AppendVarint(b, 1, 10)
+	b = AppendDouble(b, 2, 1.5)
+	b = AppendString(b, 3, "foo")
+	b = AppendPackedVarint(b, 4, []uint64{1, 2})
+	b = AppendPackedDouble(b, 5, []float64{0.5})
+	b = protowire.AppendTag(b, 4, protowire.VarintType) // 非 packed 编码
+	b = protowire.AppendVarint(b, 3)
+	b = AppendString(b, 99, "unknown")
+
+	var (
+		varint  uint64
+		double  float64
+		str     string
+		varints []uint64
+		doubles []float64
+	)
+	err := ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			v, n, err := ConsumeVarint(typ, b)
+			varint = v
+			return n, true, err
+		case 2:
+			v, n, err := ConsumeDouble(typ, b)
+			double = v
+			return n, true, err
+		case 3:
+			v, n, err := ConsumeBytes(typ, b)
+			str = string(v)
+			return n, true, err
+		case 4:
+			n, err := ConsumeRepeatedVarint(typ, b, func(v uint64) { varints = append(varints, v) })
+			return n, true, err
+		case 5:
+			n, err := ConsumeRepeatedDouble(typ, b, func(v float64) { doubles = append(doubles, v) })
+			return n, true, err
+		}
+		return 0, false, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), varint)
+	assert.Equal(t, 1.5, double)
+	assert.Equal(t, "foo", str)
+	assert.Equal(t, []uint64{1, 2, 3}, varints)
+	assert.Equal(t, []float64{0.5}, doubles)
+}
+
+func TestConsumeMessageInvalid(t *testing.T) {
+	b := AppendString(nil, 1, "foo")
+	err := ConsumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		_, n, err := ConsumeVarint(typ, b)
+		return n, true, err
+	})
+	assert.Error(t, err)
+
+	assert.Error(t, ConsumeMessage([]byte{0xff}, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		return 0, false, nil
+	}))
+}
@@ -0,0 +1,123 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package ratelimiter
+
+import (
+	"sync"
+	"time"
+)
+
+// bucket 支持透支的令牌桶
+//
+// 请求的字节数只有在数据解析完成后才能确定 因此 bytes 限流采用先放行后扣减的方式
+// 令牌透支后拒绝后续请求 直至令牌重新补充为正数 透支额度最多为一个 burst
+type bucket struct {
+	mut    sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate float64, burst int) *bucket {
+	b := &bucket{last: time.Now()}
+	b.setRate(rate, burst)
+	b.tokens = b.burst
+	return b
+}
+
+// setRate 调整速率以及容量 已有令牌超出新容量部分将被丢弃
+func (b *bucket) setRate(rate float64, burst int) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	b.refill(time.Now())
+	b.rate = rate
+	b.burst = float64(burst)
+	if b.burst < rate {
+		b.burst = rate
+	}
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens += elapsed * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// allow 判断令牌是否处于透支状态
+func (b *bucket) allow() bool {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	b.refill(time.Now())
+	return b.tokens > 0
+}
+
+// take 令牌足够时扣减 n 个令牌
+func (b *bucket) take(n float64) bool {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	b.refill(time.Now())
+	if b.tokens < n {
+		return false
+	}
+	b.tokens -= n
+	return true
+}
+
+// charge 扣减 n 个令牌 允许透支
+func (b *bucket) charge(n float64) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	b.refill(time.Now())
+	b.tokens -= n
+	if b.tokens < -b.burst {
+		b.tokens = -b.burst
+	}
+}
+
+// bytesRateLimiter 在已有限流器的基础上增加 bytes 限制
+type bytesRateLimiter struct {
+	RateLimiter
+	bytes *bucket
+}
+
+func withBytesLimit(rl RateLimiter, bytesPerSecond float64, bytesBurst int) RateLimiter {
+	if bytesPerSecond <= 0 {
+		return rl
+	}
+	return &bytesRateLimiter{
+		RateLimiter: rl,
+		bytes:       newBucket(bytesPerSecond, bytesBurst),
+	}
+}
+
+func (rl *bytesRateLimiter) TryAccept() bool {
+	if !rl.bytes.allow() {
+		return false
+	}
+	return rl.RateLimiter.TryAccept()
+}
+
+func (rl *bytesRateLimiter) ConsumeBytes(n int) {
+	rl.bytes.charge(float64(n))
+}
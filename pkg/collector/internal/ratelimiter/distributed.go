@@ -0,0 +1,270 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package ratelimiter
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+const (
+	// reservedShare 按节点数平均分配的配额比例 保证无流量的节点在流量突增时仍有可用配额
+	reservedShare = 0.1
+
+	// peerExpiredFactor 超过 N 个同步周期未上报的节点视为已下线
+	peerExpiredFactor = 3
+)
+
+// Usage 同步周期内的平均消耗速率
+type Usage struct {
+	Records float64 `json:"records"`
+	Bytes   float64 `json:"bytes"`
+}
+
+// distributedRateLimiter 集群共享配额的限流器
+//
+// 各节点周期性地交换每个 key 的消耗速率 按本节点的消耗占比分配全局配额
+// 本节点配额 = 全局配额 * (reservedShare / 节点数 + (1 - reservedShare) * 本节点消耗 / 集群总消耗)
+// 各节点视图一致时 所有节点配额之和等于全局配额
+type distributedRateLimiter struct {
+	key  string
+	conf Config
+
+	unlimited bool
+	rejected  bool
+	records   *bucket
+	bytes     *bucket
+
+	qps      *atomic.Float64
+	requests *atomic.Int64
+	consumed *atomic.Int64
+}
+
+func newDistributedRateLimiter(c Config, key string) RateLimiter {
+	rl := &distributedRateLimiter{
+		key:       key,
+		conf:      c,
+		unlimited: c.Qps == 0,
+		rejected:  c.Qps < 0,
+		qps:       atomic.NewFloat64(0),
+		requests:  atomic.NewInt64(0),
+		consumed:  atomic.NewInt64(0),
+	}
+
+	share := defaultRegistry.initialShare()
+	if !rl.unlimited && !rl.rejected {
+		rl.records = newBucket(float64(c.Qps)*share, scaleBurst(c.Burst, share))
+		rl.qps.Store(float64(c.Qps) * share)
+	}
+	if c.BytesPerSecond > 0 {
+		rl.bytes = newBucket(c.BytesPerSecond*share, scaleBurst(c.BytesBurst, share))
+	}
+
+	defaultRegistry.register(rl)
+	return rl
+}
+
+func scaleBurst(burst int, share float64) int {
+	n := int(math.Ceil(float64(burst) * share))
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+func (rl *distributedRateLimiter) Type() string {
+	return TypeDistributed
+}
+
+func (rl *distributedRateLimiter) Stop() {
+	defaultRegistry.unregister(rl)
+}
+
+func (rl *distributedRateLimiter) TryAccept() bool {
+	rl.requests.Inc()
+	if rl.rejected {
+		return false
+	}
+	if rl.bytes != nil && !rl.bytes.allow() {
+		return false
+	}
+	if rl.unlimited {
+		return true
+	}
+	return rl.records.take(1)
+}
+
+func (rl *distributedRateLimiter) ConsumeBytes(n int) {
+	rl.consumed.Add(int64(n))
+	if rl.bytes != nil {
+		rl.bytes.charge(float64(n))
+	}
+}
+
+func (rl *distributedRateLimiter) QPS() float32 {
+	if rl.unlimited {
+		return math.MaxFloat32
+	}
+	if rl.rejected {
+		return 0
+	}
+	return float32(rl.qps.Load())
+}
+
+// snapshot 返回周期内的消耗速率并重置计数
+func (rl *distributedRateLimiter) snapshot(elapsed time.Duration) Usage {
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		seconds = 1
+	}
+	return Usage{
+		Records: float64(rl.requests.Swap(0)) / seconds,
+		Bytes:   float64(rl.consumed.Swap(0)) / seconds,
+	}
+}
+
+func (rl *distributedRateLimiter) apply(recordsShare, bytesShare float64) {
+	if rl.records != nil {
+		qps := float64(rl.conf.Qps) * recordsShare
+		rl.records.setRate(qps, scaleBurst(rl.conf.Burst, recordsShare))
+		rl.qps.Store(qps)
+	}
+	if rl.bytes != nil {
+		rl.bytes.setRate(rl.conf.BytesPerSecond*bytesShare, scaleBurst(rl.conf.BytesBurst, bytesShare))
+	}
+}
+
+type peerUsages struct {
+	usages  map[string]Usage
+	updated time.Time
+}
+
+// quotaRegistry 记录本节点的 distributed 限流器以及其他节点上报的消耗情况
+type quotaRegistry struct {
+	mut      sync.Mutex
+	local    string // 本节点标识 忽略来自本节点的消息
+	limiters map[string]*distributedRateLimiter
+	peers    map[string]peerUsages
+	last     time.Time
+}
+
+func newQuotaRegistry() *quotaRegistry {
+	return &quotaRegistry{
+		limiters: make(map[string]*distributedRateLimiter),
+		peers:    make(map[string]peerUsages),
+		last:     time.Now(),
+	}
+}
+
+var defaultRegistry = newQuotaRegistry()
+
+// initialShare 新建的限流器在首次同步前按节点数平均分配配额
+func (qr *quotaRegistry) initialShare() float64 {
+	qr.mut.Lock()
+	defer qr.mut.Unlock()
+
+	return 1 / float64(len(qr.peers)+1)
+}
+
+// register 相同 key 的限流器（如配置重载）会替换旧的实例 未指定 key 的限流器不参与集群同步
+func (qr *quotaRegistry) register(rl *distributedRateLimiter) {
+	if rl.key == "" {
+		return
+	}
+
+	qr.mut.Lock()
+	defer qr.mut.Unlock()
+
+	qr.limiters[rl.key] = rl
+}
+
+func (qr *quotaRegistry) unregister(rl *distributedRateLimiter) {
+	qr.mut.Lock()
+	defer qr.mut.Unlock()
+
+	if qr.limiters[rl.key] == rl {
+		delete(qr.limiters, rl.key)
+	}
+}
+
+func (qr *quotaRegistry) setLocal(identifier string) {
+	qr.mut.Lock()
+	defer qr.mut.Unlock()
+
+	qr.local = identifier
+}
+
+// updatePeer 返回 false 表示消息来自本节点 本节点的消耗不能重复计入集群总消耗
+func (qr *quotaRegistry) updatePeer(peer string, usages map[string]Usage, now time.Time) bool {
+	qr.mut.Lock()
+	defer qr.mut.Unlock()
+
+	if peer == qr.local {
+		return false
+	}
+	qr.peers[peer] = peerUsages{usages: usages, updated: now}
+	return true
+}
+
+func share(local, total float64, nodes int) float64 {
+	if total <= 0 {
+		return 1 / float64(nodes)
+	}
+	return reservedShare/float64(nodes) + (1-reservedShare)*local/total
+}
+
+func (qr *quotaRegistry) sync(interval time.Duration, now time.Time) map[string]Usage {
+	qr.mut.Lock()
+	defer qr.mut.Unlock()
+
+	elapsed := now.Sub(qr.last)
+	qr.last = now
+
+	for peer, pu := range qr.peers {
+		if now.Sub(pu.updated) > peerExpiredFactor*interval {
+			delete(qr.peers, peer)
+		}
+	}
+
+	nodes := len(qr.peers) + 1
+	usages := make(map[string]Usage, len(qr.limiters))
+	for key, rl := range qr.limiters {
+		local := rl.snapshot(elapsed)
+		usages[key] = local
+
+		total := local
+		for _, pu := range qr.peers {
+			u := pu.usages[key]
+			total.Records += u.Records
+			total.Bytes += u.Bytes
+		}
+		rl.apply(share(local.Records, total.Records, nodes), share(local.Bytes, total.Bytes, nodes))
+	}
+	return usages
+}
+
+// SetLocalIdentifier 设置本节点在集群内的标识
+func SetLocalIdentifier(identifier string) {
+	defaultRegistry.setLocal(identifier)
+}
+
+// UpdatePeerUsages 记录集群内其他节点上报的消耗速率 来自本节点的消息会被忽略并返回 false
+func UpdatePeerUsages(peer string, usages map[string]Usage) bool {
+	return defaultRegistry.updatePeer(peer, usages, time.Now())
+}
+
+// Sync 根据集群消耗情况重新分配本节点的配额 并返回本节点在周期内的消耗速率用于广播
+// 调用方需按 interval 周期性调用
+func Sync(interval time.Duration) map[string]Usage {
+	return defaultRegistry.sync(interval, time.Now())
+}
@@ -17,6 +17,7 @@ import (
 
 const (
 	TypeTokenBucket = "token_bucket"
+	TypeDistributed = "distributed"
 	TypeNoop        = "noop"
 )
 
@@ -30,6 +31,12 @@ type Config struct {
 
 	// The maximum number of tokens in the bucket is capped at 'burst'
 	Burst int `config:"burst" mapstructure:"burst"`
+
+	// BytesPerSecond limits the bytes received per second, 0 means unlimited
+	BytesPerSecond float64 `config:"bytes_per_second" mapstructure:"bytes_per_second"`
+
+	// BytesBurst is the maximum bytes allowed in a burst, default to 'bytes_per_second'
+	BytesBurst int `config:"bytes_burst" mapstructure:"bytes_burst"`
 }
 
 // RateLimiter 限流器接口定义
@@ -41,6 +48,10 @@ type RateLimiter interface {
 	// it returns false.
 	TryAccept() bool
 
+	// ConsumeBytes records the bytes consumed by an accepted request, requests
+	// will be rejected by TryAccept until the bytes debt is paid off
+	ConsumeBytes(n int)
+
 	// Stop stops the rate limiter, subsequent calls to CanAccept will return false
 	Stop()
 
@@ -50,9 +61,16 @@ type RateLimiter interface {
 
 // New 根据配置生成限流器
 func New(c Config) RateLimiter {
+	return NewWithKey(c, "")
+}
+
+// NewWithKey key 用于在集群内标识 distributed 类型的限流器 一般为 token
+func NewWithKey(c Config, key string) RateLimiter {
 	switch c.Type {
 	case TypeTokenBucket:
-		return newTokenBucketRateLimiter(c.Qps, c.Burst)
+		return withBytesLimit(newTokenBucketRateLimiter(c.Qps, c.Burst), c.BytesPerSecond, c.BytesBurst)
+	case TypeDistributed:
+		return newDistributedRateLimiter(c, key)
 	default:
 		return newNoopRateLimiter()
 	}
@@ -78,6 +96,8 @@ func (noopRateLimiter) TryAccept() bool {
 	return true
 }
 
+func (noopRateLimiter) ConsumeBytes(int) {}
+
 // QPS 实现 RateLimiter QPS 方法
 func (noopRateLimiter) QPS() float32 {
 	return 0
@@ -136,6 +156,8 @@ func (rl *tokenBucketRateLimiter) TryAccept() bool {
 }
 
 // QPS 实现 RateLimiter QPS 方法
+func (rl *tokenBucketRateLimiter) ConsumeBytes(int) {}
+
 func (rl *tokenBucketRateLimiter) QPS() float32 {
 	if rl.unlimited {
 		return math.MaxFloat32
@@ -10,6 +10,7 @@
 package ratelimiter
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -126,3 +127,101 @@ func TestIncrementThrottle(t *testing.T) {
 		t.Error("unexpected false accept")
 	}
 }
+
+func TestBytesRateLimiter(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		rl := New(Config{Type: TypeTokenBucket, Qps: 10, Burst: 10})
+		_, ok := rl.(*bytesRateLimiter)
+		assert.False(t, ok)
+	})
+
+	t.Run("Debt", func(t *testing.T) {
+		rl := New(Config{Type: TypeTokenBucket, Qps: 100, Burst: 100, BytesPerSecond: 100})
+		assert.True(t, rl.TryAccept())
+		rl.ConsumeBytes(150)
+
+		// 令牌透支 拒绝后续请求
+		assert.False(t, rl.TryAccept())
+
+		time.Sleep(600 * time.Millisecond)
+		assert.True(t, rl.TryAccept())
+		rl.Stop()
+	})
+}
+
+func TestBucket(t *testing.T) {
+	b := newBucket(10, 5)
+	assert.Equal(t, float64(10), b.burst)
+	assert.True(t, b.take(10))
+	assert.False(t, b.take(1))
+
+	b.charge(100)
+	assert.Equal(t, float64(-10), b.tokens)
+	assert.False(t, b.allow())
+
+	b.setRate(1000, 1000)
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, b.allow())
+}
+
+func TestDistributedRateLimiter(t *testing.T) {
+	defaultRegistry = newQuotaRegistry()
+	defer func() {
+		defaultRegistry = newQuotaRegistry()
+	}()
+
+	rl := NewWithKey(Config{Type: TypeDistributed, Qps: 100, Burst: 100, BytesPerSecond: 1000}, "token1").(*distributedRateLimiter)
+	defer rl.Stop()
+
+	assert.Equal(t, TypeDistributed, rl.Type())
+	assert.Equal(t, float32(100), rl.QPS())
+
+	for i := 0; i < 30; i++ {
+		assert.True(t, rl.TryAccept())
+	}
+	rl.ConsumeBytes(100)
+
+	// 其他节点消耗是本节点的两倍
+	now := time.Now()
+	defaultRegistry.last = now.Add(-time.Second)
+	assert.True(t, defaultRegistry.updatePeer("peer1", map[string]Usage{"token1": {Records: 60, Bytes: 200}}, now))
+
+	// 来自本节点的消息被忽略
+	defaultRegistry.setLocal("local")
+	assert.False(t, defaultRegistry.updatePeer("local", map[string]Usage{"token1": {Records: 30, Bytes: 100}}, now))
+	assert.Len(t, defaultRegistry.peers, 1)
+
+	usages := defaultRegistry.sync(time.Second, now)
+	assert.Equal(t, map[string]Usage{"token1": {Records: 30, Bytes: 100}}, usages)
+	assert.InDelta(t, 100*(0.05+0.9/3), rl.QPS(), 0.01)
+	assert.InDelta(t, 1000*(0.05+0.9/3), rl.bytes.rate, 0.01)
+
+	// 节点过期后独享全部配额
+	defaultRegistry.sync(time.Second, now.Add(10*time.Second))
+	assert.Equal(t, float32(100), rl.QPS())
+}
+
+func TestDistributedRateLimiterQps(t *testing.T) {
+	t.Run("Unlimited", func(t *testing.T) {
+		rl := NewWithKey(Config{Type: TypeDistributed}, "token1")
+		defer rl.Stop()
+		assert.True(t, rl.TryAccept())
+		assert.Equal(t, float32(math.MaxFloat32), rl.QPS())
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		rl := NewWithKey(Config{Type: TypeDistributed, Qps: -1}, "token1")
+		defer rl.Stop()
+		assert.False(t, rl.TryAccept())
+		assert.Equal(t, float32(0), rl.QPS())
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		rl1 := NewWithKey(Config{Type: TypeDistributed, Qps: 1}, "token2")
+		rl2 := NewWithKey(Config{Type: TypeDistributed, Qps: 1}, "token2")
+		rl1.Stop()
+		assert.Equal(t, rl2, defaultRegistry.limiters["token2"])
+		rl2.Stop()
+		assert.NotContains(t, defaultRegistry.limiters, "token2")
+	})
+}
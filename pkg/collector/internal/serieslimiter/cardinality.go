@@ -0,0 +1,194 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package serieslimiter
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	metricNameKey = "__name__"
+
+	maxTopMetrics      = 128
+	maxLabelKeys       = 256
+	maxRejectedSamples = 20
+
+	// rejectedSampleInterval 被拒绝的 series 采样间隔 避免超限时频繁拷贝 labels
+	rejectedSampleInterval = 100 * time.Millisecond
+)
+
+// Cardinality 指标名或者 label 的近似基数
+type Cardinality struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// RejectedSeries 因超出上限而被拒绝的 series
+type RejectedSeries struct {
+	Time   time.Time         `json:"time"`
+	Labels map[string]string `json:"labels"`
+}
+
+// Stats 单个 dataid 在当前统计周期内的 series 基数情况
+type Stats struct {
+	DataID     int32            `json:"dataid"`
+	MaxSeries  int              `json:"max_series"`
+	Series     int              `json:"series"`
+	TopMetrics []Cardinality    `json:"top_metrics"`
+	TopLabels  []Cardinality    `json:"top_labels"`
+	Rejected   []RejectedSeries `json:"rejected"`
+}
+
+// cardinality 记录新增 series 的指标名以及 label 取值分布 随 bloomFilter 一同按周期重置
+type cardinality struct {
+	mut     sync.Mutex
+	metrics *spaceSaving
+	labels  map[string]*hyperLogLog
+}
+
+func newCardinality() *cardinality {
+	return &cardinality{
+		metrics: newSpaceSaving(maxTopMetrics),
+		labels:  make(map[string]*hyperLogLog),
+	}
+}
+
+// Observe 记录新增的 series 指标名由调用方显式传入 dims 中不一定包含 __name__
+func (c *cardinality) Observe(name string, dims map[string]string) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if name != "" {
+		c.metrics.Add(name)
+	}
+	for k, v := range dims {
+		if k == metricNameKey {
+			continue
+		}
+		hll, ok := c.labels[k]
+		if !ok {
+			if len(c.labels) >= maxLabelKeys {
+				continue
+			}
+			hll = &hyperLogLog{}
+			c.labels[k] = hll
+		}
+		hll.Add(v)
+	}
+}
+
+func (c *cardinality) Top(n int) ([]Cardinality, []Cardinality) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	labels := make([]Cardinality, 0, len(c.labels))
+	for k, hll := range c.labels {
+		labels = append(labels, Cardinality{Name: k, Count: hll.Count()})
+	}
+	return c.metrics.Top(n), topCardinality(labels, n)
+}
+
+// rejectedRing 保留最近被拒绝的 series 样本
+type rejectedRing struct {
+	mut     sync.Mutex
+	last    int64
+	samples []RejectedSeries
+	next    int
+}
+
+func newRejectedRing() *rejectedRing {
+	return &rejectedRing{
+		samples: make([]RejectedSeries, 0, maxRejectedSamples),
+	}
+}
+
+func (rr *rejectedRing) Add(name string, dims map[string]string) {
+	now := time.Now()
+	last := atomic.LoadInt64(&rr.last)
+	if now.UnixNano()-last < int64(rejectedSampleInterval) || !atomic.CompareAndSwapInt64(&rr.last, last, now.UnixNano()) {
+		return
+	}
+
+	labels := make(map[string]string, len(dims)+1)
+	for k, v := range dims {
+		labels[k] = v
+	}
+	if name != "" {
+		labels[metricNameKey] = name
+	}
+
+	rr.mut.Lock()
+	defer rr.mut.Unlock()
+
+	sample := RejectedSeries{Time: now, Labels: labels}
+	if len(rr.samples) < maxRejectedSamples {
+		rr.samples = append(rr.samples, sample)
+		return
+	}
+	rr.samples[rr.next] = sample
+	rr.next = (rr.next + 1) % maxRejectedSamples
+}
+
+// List 按时间倒序返回样本
+func (rr *rejectedRing) List() []RejectedSeries {
+	rr.mut.Lock()
+	defer rr.mut.Unlock()
+
+	ret := make([]RejectedSeries, len(rr.samples))
+	copy(ret, rr.samples)
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Time.After(ret[j].Time)
+	})
+	return ret
+}
+
+var registry = struct {
+	mut      sync.Mutex
+	limiters map[*Limiter]struct{}
+}{
+	limiters: make(map[*Limiter]struct{}),
+}
+
+func register(l *Limiter) {
+	registry.mut.Lock()
+	defer registry.mut.Unlock()
+
+	registry.limiters[l] = struct{}{}
+}
+
+func unregister(l *Limiter) {
+	registry.mut.Lock()
+	defer registry.mut.Unlock()
+
+	delete(registry.limiters, l)
+}
+
+// Snapshot 返回所有运行中的 Limiter 的基数统计 dataID 为 0 时返回全部 dataid
+// 同一 dataid 可能被多个 Limiter 使用（如不同处理器） 此时会返回多条记录
+func Snapshot(dataID int32, top int) []Stats {
+	registry.mut.Lock()
+	limiters := make([]*Limiter, 0, len(registry.limiters))
+	for l := range registry.limiters {
+		limiters = append(limiters, l)
+	}
+	registry.mut.Unlock()
+
+	var ret []Stats
+	for _, l := range limiters {
+		ret = append(ret, l.Stats(dataID, top)...)
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].DataID < ret[j].DataID
+	})
+	return ret
+}
@@ -74,14 +74,18 @@ type Limiter struct {
 }
 
 func New(maxSeries int, gcInterval time.Duration) *Limiter {
-	return &Limiter{
+	l := &Limiter{
 		recorders:  map[int32]*recorder{},
 		maxSeries:  maxSeries,
 		gcInterval: gcInterval,
 	}
+	register(l)
+	return l
 }
 
 func (l *Limiter) Stop() {
+	unregister(l)
+
 	l.mut.Lock()
 	defer l.mut.Unlock()
 
@@ -90,7 +94,8 @@ func (l *Limiter) Stop() {
 	}
 }
 
-func (l *Limiter) Set(dataID int32, dims map[string]string) bool {
+// Set 记录 series 并返回是否允许写入 name 为 series 所属的指标名 仅用于基数统计
+func (l *Limiter) Set(dataID int32, name string, dims map[string]string) bool {
 	var r *recorder
 	h := labels.HashFromMap(dims)
 
@@ -101,7 +106,7 @@ func (l *Limiter) Set(dataID int32, dims map[string]string) bool {
 	}
 	l.mut.RUnlock()
 	if r != nil {
-		return r.Set(h)
+		return r.Set(h, name, dims)
 	}
 
 	// 写锁保护 确保执行流一致性
@@ -114,5 +119,20 @@ func (l *Limiter) Set(dataID int32, dims map[string]string) bool {
 	}
 	l.mut.Unlock()
 
-	return r.Set(h)
+	return r.Set(h, name, dims)
+}
+
+// Stats 返回各 dataid 的基数统计 dataID 为 0 时返回全部
+func (l *Limiter) Stats(dataID int32, top int) []Stats {
+	l.mut.RLock()
+	defer l.mut.RUnlock()
+
+	var ret []Stats
+	for id, r := range l.recorders {
+		if dataID != 0 && id != dataID {
+			continue
+		}
+		ret = append(ret, r.Stats(top))
+	}
+	return ret
 }
@@ -10,6 +10,7 @@
 package serieslimiter
 
 import (
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -27,7 +28,7 @@ func TestLimiterExceeded(t *testing.T) {
 	exceeded := 0
 	for i := 0; i < 100; i++ {
 		for _, id := range ids {
-			ok := limiter.Set(id, random.Dimensions(6))
+			ok := limiter.Set(id, "", random.Dimensions(6))
 			if !ok {
 				exceeded++
 			}
@@ -44,7 +45,7 @@ func TestLimiterNotExceeded(t *testing.T) {
 	exceeded := 0
 	for i := 0; i < 100; i++ {
 		for _, id := range ids {
-			ok := limiter.Set(id, random.Dimensions(6))
+			ok := limiter.Set(id, "", random.Dimensions(6))
 			if !ok {
 				exceeded++
 			}
@@ -61,7 +62,7 @@ func TestLimiterGcOk(t *testing.T) {
 	exceeded := 0
 	for i := 0; i < 20; i++ {
 		for _, id := range ids {
-			ok := limiter.Set(id, random.Dimensions(6))
+			ok := limiter.Set(id, "", random.Dimensions(6))
 			if !ok {
 				exceeded++
 			}
@@ -81,7 +82,7 @@ func TestLimiterGcNotYet(t *testing.T) {
 	exceeded := 0
 	for i := 0; i < 20; i++ {
 		for _, id := range ids {
-			ok := limiter.Set(id, random.Dimensions(6))
+			ok := limiter.Set(id, "", random.Dimensions(6))
 			if !ok {
 				exceeded++
 			}
@@ -93,6 +94,36 @@ func TestLimiterGcNotYet(t *testing.T) {
 	assert.True(t, exceeded > 8)
 }
 
+func TestLimiterStats(t *testing.T) {
+	limiter := New(50, time.Hour)
+	defer limiter.Stop()
+
+	for i := 0; i < 100; i++ {
+		limiter.Set(1001, "metric_"+strconv.Itoa(i%2), map[string]string{
+			"pod":     "pod_" + strconv.Itoa(i),
+			"cluster": "cluster_" + strconv.Itoa(i%3),
+		})
+	}
+	limiter.Set(1002, "up", map[string]string{"__name__": "up"})
+
+	stats := Snapshot(1001, 2)
+	assert.Len(t, stats, 1)
+
+	s := stats[0]
+	assert.Equal(t, int32(1001), s.DataID)
+	assert.Equal(t, 50, s.MaxSeries)
+	assert.Equal(t, 50, s.Series)
+	assert.Equal(t, []Cardinality{{Name: "metric_0", Count: 25}, {Name: "metric_1", Count: 25}}, s.TopMetrics)
+	assert.Len(t, s.TopLabels, 2)
+	assert.Equal(t, "pod", s.TopLabels[0].Name)
+	assert.Equal(t, "cluster", s.TopLabels[1].Name)
+	assert.Len(t, s.Rejected, 1) // 采样间隔内仅记录一条
+	assert.Equal(t, "pod_50", s.Rejected[0].Labels["pod"])
+	assert.Equal(t, "metric_0", s.Rejected[0].Labels["__name__"])
+
+	assert.Len(t, Snapshot(0, 10), 2)
+}
+
 func BenchmarkLimiterSet(b *testing.B) {
 	const n = 1000000
 	limiter := New(n, time.Minute)
@@ -105,7 +136,7 @@ func BenchmarkLimiterSet(b *testing.B) {
 		go func() {
 			defer wg.Done()
 			for j := 0; j < n; j++ {
-				limiter.Set(1, random.FastDimensions(1))
+				limiter.Set(1, "", random.FastDimensions(1))
 			}
 		}()
 	}
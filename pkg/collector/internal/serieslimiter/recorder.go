@@ -20,6 +20,7 @@ type recorder struct {
 	dataID   int32
 	maxItems int
 	v        atomic.Value
+	rejected *rejectedRing
 	done     chan struct{}
 }
 
@@ -27,6 +28,7 @@ func newRecorder(dataID int32, maxItems int, refreshInterval time.Duration) *rec
 	l := &recorder{
 		dataID:   dataID,
 		maxItems: maxItems,
+		rejected: newRejectedRing(),
 		done:     make(chan struct{}, 1),
 	}
 	l.v.Store(newBloomLimiter(dataID, maxItems))
@@ -71,9 +73,26 @@ func (l *recorder) CurrentItems() int {
 	return int(n)
 }
 
-func (l *recorder) Set(h uint64) bool {
+func (l *recorder) Set(h uint64, name string, dims map[string]string) bool {
 	lm := l.v.Load().(*bloomFilter)
-	return lm.Set(h)
+	ok := lm.Set(h, name, dims)
+	if !ok {
+		l.rejected.Add(name, dims)
+	}
+	return ok
+}
+
+func (l *recorder) Stats(top int) Stats {
+	lm := l.v.Load().(*bloomFilter)
+	metrics, labels := lm.stats.Top(top)
+	return Stats{
+		DataID:     l.dataID,
+		MaxSeries:  l.maxItems,
+		Series:     int(atomic.LoadUint64(&lm.currentItems)),
+		TopMetrics: metrics,
+		TopLabels:  labels,
+		Rejected:   l.rejected.List(),
+	}
 }
 
 func (l *recorder) Stop() {
@@ -86,17 +105,19 @@ type bloomFilter struct {
 	dataID       int32
 	currentItems uint64
 	f            *filter
+	stats        *cardinality
 }
 
 func newBloomLimiter(dataID int32, maxItems int) *bloomFilter {
 	return &bloomFilter{
 		dataID: dataID,
 		f:      newFilter(maxItems),
+		stats:  newCardinality(),
 	}
 }
 
 // Set 返回 true 如果 series 已经存在或者被成功添加
-func (l *bloomFilter) Set(h uint64) bool {
+func (l *bloomFilter) Set(h uint64, name string, dims map[string]string) bool {
 	currentItems := atomic.LoadUint64(&l.currentItems)
 	if currentItems >= uint64(l.f.maxItems) {
 		has := l.f.Has(h)
@@ -107,6 +128,7 @@ func (l *bloomFilter) Set(h uint64) bool {
 	}
 	if l.f.Add(h) {
 		atomic.AddUint64(&l.currentItems, 1)
+		l.stats.Observe(name, dims)
 		DefaultMetricMonitor.IncAddedSeriesCounter(l.dataID)
 	}
 	return true
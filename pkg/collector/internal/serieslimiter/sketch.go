@@ -0,0 +1,116 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package serieslimiter
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+const hllPrecision = 8 // 256 个寄存器 标准误差约 6.5%
+
+// hyperLogLog 基数估算 用于统计 label 取值的近似个数
+type hyperLogLog struct {
+	registers [1 << hllPrecision]uint8
+}
+
+func (h *hyperLogLog) Add(s string) {
+	x := xxhash.Sum64String(s)
+	idx := x >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(x<<hllPrecision|1<<(hllPrecision-1)) + 1)
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+	}
+}
+
+func (h *hyperLogLog) Count() int {
+	const m = float64(1 << hllPrecision)
+	alpha := 0.7213 / (1 + 1.079/m)
+
+	var sum float64
+	var zeros int
+	for _, r := range h.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	estimate := alpha * m * m / sum
+	// 小基数时使用 linear counting 修正
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int(estimate + 0.5)
+}
+
+type counter struct {
+	name  string
+	count int
+}
+
+// spaceSaving top-k 近似统计 仅保留 capacity 个计数器
+// 计数器已满时替换计数最小的项 新项的计数在最小值基础上累加 因此结果只会高估
+type spaceSaving struct {
+	capacity int
+	counters map[string]*counter
+}
+
+func newSpaceSaving(capacity int) *spaceSaving {
+	return &spaceSaving{
+		capacity: capacity,
+		counters: make(map[string]*counter, capacity),
+	}
+}
+
+func (ss *spaceSaving) Add(name string) {
+	if c, ok := ss.counters[name]; ok {
+		c.count++
+		return
+	}
+
+	if len(ss.counters) < ss.capacity {
+		ss.counters[name] = &counter{name: name, count: 1}
+		return
+	}
+
+	var min *counter
+	for _, c := range ss.counters {
+		if min == nil || c.count < min.count {
+			min = c
+		}
+	}
+	delete(ss.counters, min.name)
+	ss.counters[name] = &counter{name: name, count: min.count + 1}
+}
+
+func (ss *spaceSaving) Top(n int) []Cardinality {
+	ret := make([]Cardinality, 0, len(ss.counters))
+	for _, c := range ss.counters {
+		ret = append(ret, Cardinality{Name: c.name, Count: c.count})
+	}
+	return topCardinality(ret, n)
+}
+
+func topCardinality(items []Cardinality, n int) []Cardinality {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].Name < items[j].Name
+	})
+	if n > 0 && len(items) > n {
+		items = items[:n]
+	}
+	return items
+}
@@ -0,0 +1,51 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package serieslimiter
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHyperLogLog(t *testing.T) {
+	cases := []int{0, 10, 100, 1000, 10000}
+	for _, n := range cases {
+		t.Run(strconv.Itoa(n), func(t *testing.T) {
+			var hll hyperLogLog
+			for i := 0; i < n; i++ {
+				hll.Add(strconv.Itoa(i))
+				hll.Add(strconv.Itoa(i)) // 重复值不影响结果
+			}
+			assert.InDelta(t, n, hll.Count(), float64(n)*0.2+1)
+		})
+	}
+}
+
+func TestSpaceSaving(t *testing.T) {
+	ss := newSpaceSaving(3)
+	for i := 0; i < 100; i++ {
+		ss.Add("heavy")
+	}
+	for i := 0; i < 50; i++ {
+		ss.Add("medium")
+	}
+	for i := 0; i < 20; i++ {
+		ss.Add("light" + strconv.Itoa(i))
+	}
+
+	top := ss.Top(2)
+	assert.Equal(t, []Cardinality{
+		{Name: "heavy", Count: 100},
+		{Name: "medium", Count: 50},
+	}, top)
+	assert.Len(t, ss.Top(0), 3)
+}
@@ -0,0 +1,276 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package tap
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/prettyprint"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/ratelimiter"
+)
+
+const (
+	PositionBefore = "before"
+	PositionAfter  = "after"
+)
+
+const (
+	// MaxSessions 同时存在的订阅会话上限
+	MaxSessions = 4
+
+	// MaxQps 单个会话每秒采样的 record 数量上限
+	MaxQps = 10
+
+	// MaxDuration 单个会话的最长存活时间 到期自动关闭
+	MaxDuration = 5 * time.Minute
+
+	// 会话缓冲区满时直接丢弃 避免阻塞流水线
+	entriesBuffer = 256
+)
+
+var ErrTooManySessions = errors.New("too many tap sessions")
+
+// Filter 订阅条件 Token 与 DataID 至少指定其一
+type Filter struct {
+	Token      string
+	DataID     int32
+	RecordType define.RecordType
+}
+
+func (f Filter) match(record *define.Record) bool {
+	if f.RecordType != "" && f.RecordType != record.RecordType {
+		return false
+	}
+	if f.Token != "" && f.Token != record.Token.Original {
+		return false
+	}
+	if f.DataID > 0 && f.DataID != record.Token.GetDataID(record.RecordType) {
+		return false
+	}
+	return true
+}
+
+// Entry 记录某条 record 在某个 processor 执行前/后的快照
+type Entry struct {
+	Time       time.Time         `json:"time"`
+	ID         uint64            `json:"id"` // 同一条 record 的所有快照共享 ID
+	Pipeline   string            `json:"pipeline"`
+	Processor  string            `json:"processor"`
+	Position   string            `json:"position"`
+	RecordType define.RecordType `json:"record_type"`
+	Token      string            `json:"token"`
+	DataID     int32             `json:"dataid"`
+	Error      string            `json:"error,omitempty"`
+	Data       json.RawMessage   `json:"data"`
+}
+
+// Session 订阅会话 会话到期或调用 Close 后 C 将被关闭
+type Session struct {
+	filter   Filter
+	limiter  ratelimiter.RateLimiter
+	entries  chan Entry
+	done     chan struct{}
+	once     sync.Once
+	mut      sync.RWMutex
+	closed   bool
+	dropped  atomic.Int64
+	expireAt time.Time
+}
+
+// C 返回快照管道
+func (s *Session) C() <-chan Entry {
+	return s.entries
+}
+
+// Dropped 返回因缓冲区已满而被丢弃的快照数量
+func (s *Session) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// ExpireAt 返回会话过期时间
+func (s *Session) ExpireAt() time.Time {
+	return s.expireAt
+}
+
+// Close 关闭会话 可重复调用
+func (s *Session) Close() {
+	s.once.Do(func() {
+		unregister(s)
+		close(s.done)
+
+		s.mut.Lock()
+		s.closed = true
+		close(s.entries)
+		s.mut.Unlock()
+		s.limiter.Stop()
+	})
+}
+
+func (s *Session) push(entry Entry) {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	if s.closed {
+		return
+	}
+	select {
+	case s.entries <- entry:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Subscribe 创建订阅会话 qps/duration 超出上限时会被截断
+func Subscribe(filter Filter, qps int, duration time.Duration) (*Session, error) {
+	if filter.Token == "" && filter.DataID <= 0 {
+		return nil, errors.New("either token or dataid is required")
+	}
+	if qps <= 0 || qps > MaxQps {
+		qps = MaxQps
+	}
+	if duration <= 0 || duration > MaxDuration {
+		duration = MaxDuration
+	}
+
+	s := &Session{
+		filter: filter,
+		limiter: ratelimiter.New(ratelimiter.Config{
+			Type:  ratelimiter.TypeTokenBucket,
+			Qps:   float32(qps),
+			Burst: qps,
+		}),
+		entries:  make(chan Entry, entriesBuffer),
+		done:     make(chan struct{}),
+		expireAt: time.Now().Add(duration),
+	}
+	if err := register(s); err != nil {
+		s.limiter.Stop()
+		return nil, err
+	}
+
+	go func() {
+		timer := time.NewTimer(duration)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+			s.Close()
+		case <-s.done:
+		}
+	}()
+	return s, nil
+}
+
+var registry = struct {
+	mut      sync.RWMutex
+	sessions map[*Session]struct{}
+	count    atomic.Int32 // 无订阅时快速返回 避免加锁
+	seq      atomic.Uint64
+}{
+	sessions: make(map[*Session]struct{}),
+}
+
+func register(s *Session) error {
+	registry.mut.Lock()
+	defer registry.mut.Unlock()
+
+	if len(registry.sessions) >= MaxSessions {
+		return ErrTooManySessions
+	}
+	registry.sessions[s] = struct{}{}
+	registry.count.Store(int32(len(registry.sessions)))
+	return nil
+}
+
+func unregister(s *Session) {
+	registry.mut.Lock()
+	defer registry.mut.Unlock()
+
+	delete(registry.sessions, s)
+	registry.count.Store(int32(len(registry.sessions)))
+}
+
+// Taps 命中采样的会话集合 为空时所有操作均为空操作
+type Taps struct {
+	id       uint64
+	pipeline string
+	sessions []*Session
+}
+
+// Match 返回命中 record 且通过限流采样的会话
+// 采样以 record 为粒度 保证被采样的 record 能观察到完整的处理过程
+func Match(pipeline string, record *define.Record) *Taps {
+	if registry.count.Load() == 0 {
+		return nil
+	}
+
+	registry.mut.RLock()
+	defer registry.mut.RUnlock()
+
+	var sessions []*Session
+	for s := range registry.sessions {
+		if s.filter.match(record) && s.limiter.TryAccept() {
+			sessions = append(sessions, s)
+		}
+	}
+	if len(sessions) == 0 {
+		return nil
+	}
+	return &Taps{
+		id:       registry.seq.Add(1),
+		pipeline: pipeline,
+		sessions: sessions,
+	}
+}
+
+// Capture 记录 record 在 processor 执行前/后的快照
+// processor 会原地修改数据 因此需要在此刻完成序列化
+func (t *Taps) Capture(processor, position string, record *define.Record, err error) {
+	if t == nil {
+		return
+	}
+
+	entry := Entry{
+		Time:       time.Now(),
+		ID:         t.id,
+		Pipeline:   t.pipeline,
+		Processor:  processor,
+		Position:   position,
+		RecordType: record.RecordType,
+		Token:      record.Token.Original,
+		DataID:     record.Token.GetDataID(record.RecordType),
+		Data:       marshalData(record),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	for _, s := range t.sessions {
+		s.push(entry)
+	}
+}
+
+func marshalData(record *define.Record) json.RawMessage {
+	if record.Data == nil {
+		return json.RawMessage("null")
+	}
+
+	b, err := json.Marshal(prettyprint.Object(record.RecordType, record.Data))
+	if err != nil {
+		b, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
+	return b
+}
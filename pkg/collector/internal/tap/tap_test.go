@@ -0,0 +1,94 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package tap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/generator"
+)
+
+func newTracesRecord(token string, dataID int32) *define.Record {
+	g := generator.NewTracesGenerator(define.TracesOptions{SpanCount: 1})
+	return &define.Record{
+		RecordType: define.RecordTraces,
+		Token:      define.Token{Original: token, TracesDataId: dataID},
+		Data:       g.Generate(),
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	t.Run("Invalid Filter", func(t *testing.T) {
+		_, err := Subscribe(Filter{}, 1, time.Second)
+		assert.Error(t, err)
+	})
+
+	t.Run("Too Many Sessions", func(t *testing.T) {
+		var sessions []*Session
+		for i := 0; i < MaxSessions; i++ {
+			s, err := Subscribe(Filter{Token: "token1"}, 1, time.Minute)
+			assert.NoError(t, err)
+			sessions = append(sessions, s)
+		}
+		_, err := Subscribe(Filter{Token: "token1"}, 1, time.Minute)
+		assert.Equal(t, ErrTooManySessions, err)
+
+		for _, s := range sessions {
+			s.Close()
+			s.Close()
+		}
+		assert.Nil(t, Match("traces_pipeline", newTracesRecord("token1", 1001)))
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		s, err := Subscribe(Filter{DataID: 1001}, 1, 100*time.Millisecond)
+		assert.NoError(t, err)
+
+		_, ok := <-s.C()
+		assert.False(t, ok)
+		assert.Nil(t, Match("traces_pipeline", newTracesRecord("token1", 1001)))
+	})
+}
+
+func TestMatchAndCapture(t *testing.T) {
+	s, err := Subscribe(Filter{DataID: 1001, RecordType: define.RecordTraces}, 1, time.Minute)
+	assert.NoError(t, err)
+	defer s.Close()
+
+	assert.Nil(t, Match("traces_pipeline", newTracesRecord("token1", 1002)))
+
+	record := newTracesRecord("token1", 1001)
+	taps := Match("traces_pipeline", record)
+	assert.NotNil(t, taps)
+	taps.Capture("resource_filter/add", PositionBefore, record, nil)
+	taps.Capture("resource_filter/add", PositionAfter, record, define.ErrSkipEmptyRecord)
+
+	before := <-s.C()
+	assert.Equal(t, "traces_pipeline", before.Pipeline)
+	assert.Equal(t, PositionBefore, before.Position)
+	assert.Equal(t, int32(1001), before.DataID)
+	assert.Contains(t, string(before.Data), "spanID")
+
+	after := <-s.C()
+	assert.Equal(t, before.ID, after.ID)
+	assert.Equal(t, PositionAfter, after.Position)
+	assert.NotEmpty(t, after.Error)
+
+	// 超出采样限制
+	assert.Nil(t, Match("traces_pipeline", newTracesRecord("token1", 1001)))
+
+	// 空 Taps 不做任何操作
+	var nilTaps *Taps
+	nilTaps.Capture("resource_filter/add", PositionBefore, record, nil)
+}
@@ -36,3 +36,17 @@ func DecodeWriteRequest(r io.Reader) (*prompb.WriteRequest, int, error) {
 
 	return &req, size, nil
 }
+
+// DecodeReadRequest 解码 snappy 压缩的 remote-read 请求
+func DecodeReadRequest(compressed []byte) (*prompb.ReadRequest, error) {
+	reqBuf, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, err
+	}
+
+	var req prompb.ReadRequest
+	if err := proto.Unmarshal(reqBuf, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
 )
 
@@ -65,3 +66,33 @@ func validatePreCheckProcessors(r *define.Record, getter Getter) (define.StatusC
 
 	return define.StatusCodeOK, "", nil
 }
+
+type bytesConsumer interface {
+	ConsumeBytes(token string, n int)
+}
+
+// ConsumeBytes 数据解析完成后记录 token 实际接收的字节数 供限流器按字节数限流
+func ConsumeBytes(rtype define.RecordType, token string, n int) {
+	consumeBytes(rtype, token, n, GetDefaultGetter())
+}
+
+func consumeBytes(rtype define.RecordType, token string, n int, getter Getter) {
+	if getter == nil || n <= 0 {
+		return
+	}
+
+	pl := getter.GetPipeline(rtype)
+	if pl == nil {
+		return
+	}
+
+	for _, name := range pl.PreCheckProcessors() {
+		inst := getter.GetProcessor(name)
+		if inst.Name() != define.ProcessorRateLimiter {
+			continue
+		}
+		if consumer, ok := processor.Unwrap(inst).(bytesConsumer); ok {
+			consumer.ConsumeBytes(token, n)
+		}
+	}
+}
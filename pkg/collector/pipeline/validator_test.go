@@ -55,3 +55,11 @@ func TestValidatePreCheckProcessors(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestConsumeBytes(t *testing.T) {
+	assert.NotPanics(t, func() {
+		consumeBytes(define.RecordTraces, "token1", 100, nil)
+		consumeBytes(define.RecordTraces, "token1", 100, noneValidator{})
+		ConsumeBytes(define.RecordTraces, "token1", 100)
+	})
+}
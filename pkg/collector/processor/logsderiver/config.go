@@ -0,0 +1,45 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package logsderiver
+
+import (
+	"time"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/tracesderiver/accumulator"
+)
+
+type Config struct {
+	Operations []OperationConfig `config:"operations" mapstructure:"operations"`
+}
+
+type OperationConfig struct {
+	MetricName string `config:"metric_name" mapstructure:"metric_name"`
+	// Condition 日志匹配条件 语法同 transformer 的 where 子句 为空时匹配所有日志
+	Condition string `config:"condition" mapstructure:"condition"`
+	// Dimensions 支持 resource.${key}/attributes.${key}/severity_text
+	Dimensions      []string `config:"dimensions" mapstructure:"dimensions"`
+	MaxSeries       int      `config:"max_series" mapstructure:"max_series"`
+	GcInterval      string   `config:"gc_interval" mapstructure:"gc_interval"`
+	PublishInterval string   `config:"publish_interval" mapstructure:"publish_interval"`
+}
+
+func (c OperationConfig) accumulatorConfig() *accumulator.Config {
+	gcInterval, _ := time.ParseDuration(c.GcInterval)
+	publishInterval, _ := time.ParseDuration(c.PublishInterval)
+	conf := &accumulator.Config{
+		MetricName:      c.MetricName,
+		MaxSeries:       c.MaxSeries,
+		GcInterval:      gcInterval,
+		PublishInterval: publishInterval,
+		Type:            accumulator.TypeCount,
+	}
+	conf.Validate()
+	return conf
+}
@@ -0,0 +1,32 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+/*
+# LogsDeriver: logs 数据衍生器
+
+按照条件匹配日志并累计条数 以 counter 指标的形式周期性上报（同 traces_deriver 的 count 类型）
+condition 语法同 transformer 的 where 子句（log 上下文）为空时匹配所有日志
+metric_name 为空或 condition 编译失败时 整个处理器配置加载失败
+
+processor:
+    - name: "logs_deriver/common"
+      config:
+        operations:
+          - metric_name: "bk_log_error_total"
+            condition: 'severity_number >= 17 or IsMatch(body, "(?i)exception")'
+            publish_interval: "60s"
+            gc_interval: "1h"
+            max_series: 10000
+            dimensions:
+              - "resource.service.name"
+              - "attributes.logger.name"
+              - "severity_text"
+*/
+
+package logsderiver
@@ -0,0 +1,130 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package logsderiver
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/mapstructure"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+func init() {
+	processor.Register(define.ProcessorLogsDeriver, NewFactory)
+}
+
+func NewFactory(conf map[string]interface{}, customized []processor.SubConfigProcessor) (processor.Processor, error) {
+	return newFactory(conf, customized)
+}
+
+func newFactory(conf map[string]interface{}, customized []processor.SubConfigProcessor) (*logsDeriver, error) {
+	operators := confengine.NewTierConfig()
+
+	var c Config
+	if err := mapstructure.Decode(conf, &c); err != nil {
+		return nil, err
+	}
+	operator, err := NewLogsOperator(c, processor.PublishNonSchedRecords)
+	if err != nil {
+		return nil, err
+	}
+	operators.SetGlobal(operator)
+
+	for _, custom := range customized {
+		var cfg Config
+		if err := mapstructure.Decode(custom.Config.Config, &cfg); err != nil {
+			logger.Errorf("failed to decode config: %v", err)
+			continue
+		}
+		operator, err := NewLogsOperator(cfg, processor.PublishNonSchedRecords)
+		if err != nil {
+			for _, obj := range operators.All() {
+				obj.(Operator).Clean()
+			}
+			return nil, errors.Wrapf(err, "create operator of token '%s'", custom.Token)
+		}
+		operators.Set(custom.Token, custom.Type, custom.ID, operator)
+	}
+
+	return &logsDeriver{
+		CommonProcessor: processor.NewCommonProcessor(conf, customized),
+		operators:       operators,
+	}, nil
+}
+
+type logsDeriver struct {
+	processor.CommonProcessor
+	operators *confengine.TierConfig // type: Operator
+}
+
+func (p *logsDeriver) Name() string {
+	return define.ProcessorLogsDeriver
+}
+
+func (p *logsDeriver) IsDerived() bool {
+	return true
+}
+
+func (p *logsDeriver) IsPreCheck() bool {
+	return false
+}
+
+func (p *logsDeriver) Reload(config map[string]interface{}, customized []processor.SubConfigProcessor) {
+	f, err := newFactory(config, customized)
+	if err != nil {
+		logger.Errorf("failed to reload processor: %v", err)
+		return
+	}
+
+	equal := processor.DiffMainConfig(p.MainConfig(), config)
+	if equal {
+		f.operators.GetGlobal().(Operator).Clean()
+	} else {
+		p.operators.GetGlobal().(Operator).Clean()
+		p.operators.SetGlobal(f.operators.GetGlobal())
+	}
+
+	diffRet := processor.DiffCustomizedConfig(p.SubConfigs(), customized)
+	for _, obj := range diffRet.Keep {
+		f.operators.Get(obj.Token, obj.Type, obj.ID).(Operator).Clean()
+	}
+
+	for _, obj := range diffRet.Updated {
+		p.operators.Get(obj.Token, obj.Type, obj.ID).(Operator).Clean()
+		newOperator := f.operators.Get(obj.Token, obj.Type, obj.ID)
+		p.operators.Set(obj.Token, obj.Type, obj.ID, newOperator)
+	}
+
+	for _, obj := range diffRet.Deleted {
+		p.operators.Get(obj.Token, obj.Type, obj.ID).(Operator).Clean()
+		p.operators.Del(obj.Token, obj.Type, obj.ID)
+	}
+
+	p.CommonProcessor = f.CommonProcessor
+}
+
+func (p *logsDeriver) Clean() {
+	for _, obj := range p.operators.All() {
+		obj.(Operator).Clean()
+	}
+}
+
+// Process 派生指标由 accumulator 周期性地提交 不经过 derived pipeline
+func (p *logsDeriver) Process(record *define.Record) (*define.Record, error) {
+	switch record.RecordType {
+	case define.RecordLogs:
+		operator := p.operators.GetByToken(record.Token.Original).(Operator)
+		operator.Operate(record)
+	}
+	return nil, nil
+}
@@ -0,0 +1,199 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package logsderiver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor"
+)
+
+func TestFactory(t *testing.T) {
+	content := `
+processor:
+  - name: "logs_deriver/common"
+    config:
+      operations:
+        - metric_name: "bk_log_total"
+          dimensions:
+            - "resource.service.name"
+`
+	mainConf := processor.MustLoadConfigs(content)[0].Config
+
+	customContent := `
+processor:
+  - name: "logs_deriver/common"
+    config:
+      operations:
+        - metric_name: "bk_log_error_total"
+          condition: 'severity_number >= 17'
+`
+	customConf := processor.MustLoadConfigs(customContent)[0].Config
+
+	obj, err := NewFactory(mainConf, []processor.SubConfigProcessor{
+		{
+			Token: "token1",
+			Type:  define.SubConfigFieldDefault,
+			Config: processor.Config{
+				Config: customConf,
+			},
+		},
+	})
+	factory := obj.(*logsDeriver)
+	assert.NoError(t, err)
+	assert.Equal(t, mainConf, factory.MainConfig())
+	defer factory.Clean()
+
+	global := factory.operators.GetGlobal().(logsOperator)
+	assert.Len(t, global.operations, 1)
+	assert.Nil(t, global.operations[0].condition)
+
+	custom := factory.operators.GetByToken("token1").(logsOperator)
+	assert.Len(t, custom.operations, 1)
+	assert.NotNil(t, custom.operations[0].condition)
+
+	assert.Equal(t, define.ProcessorLogsDeriver, factory.Name())
+	assert.True(t, factory.IsDerived())
+	assert.False(t, factory.IsPreCheck())
+
+	factory.Reload(mainConf, nil)
+	assert.Equal(t, mainConf, factory.MainConfig())
+}
+
+func TestFactoryInvalidOperation(t *testing.T) {
+	mainConf := processor.MustLoadConfigs(`
+processor:
+  - name: "logs_deriver/common"
+    config:
+      operations:
+        - metric_name: "bk_log_total"
+`)[0].Config
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name: "invalid condition",
+			content: `
+processor:
+  - name: "logs_deriver/common"
+    config:
+      operations:
+        - metric_name: "bk_log_invalid_total"
+          condition: 'severity_number >='
+`,
+		},
+		{
+			name: "empty metric name",
+			content: `
+processor:
+  - name: "logs_deriver/common"
+    config:
+      operations:
+        - condition: 'severity_number >= 17'
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customConf := processor.MustLoadConfigs(tt.content)[0].Config
+
+			_, err := NewFactory(customConf, nil)
+			assert.Error(t, err)
+
+			_, err = NewFactory(mainConf, []processor.SubConfigProcessor{
+				{
+					Token: "token1",
+					Type:  define.SubConfigFieldDefault,
+					Config: processor.Config{
+						Config: customConf,
+					},
+				},
+			})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseDimensions(t *testing.T) {
+	dims := parseDimensions([]string{"resource.service.name", "attributes.logger", "severity_text", "body"})
+	assert.Equal(t, []dimension{
+		{name: "service.name", resource: true},
+		{name: "logger"},
+		{name: "severity_text", severity: true},
+	}, dims)
+}
+
+func makeLogs() plog.Logs {
+	logs := plog.NewLogs()
+	rl := logs.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().UpsertString("service.name", "api")
+	logRecords := rl.ScopeLogs().AppendEmpty().LogRecords()
+
+	for i, sev := range []plog.SeverityNumber{plog.SeverityNumberERROR, plog.SeverityNumberINFO, plog.SeverityNumberFATAL} {
+		lr := logRecords.AppendEmpty()
+		lr.SetSeverityNumber(sev)
+		lr.SetSeverityText(sev.String())
+		lr.Attributes().UpsertInt("idx", int64(i))
+	}
+	return logs
+}
+
+func TestExtractDimensions(t *testing.T) {
+	logs := makeLogs()
+	rl := logs.ResourceLogs().At(0)
+	op := operation{dimensions: parseDimensions([]string{"resource.service.name", "attributes.idx", "attributes.missing", "severity_text"})}
+
+	dims := op.extractDimensions(rl.Resource().Attributes(), rl.ScopeLogs().At(0).LogRecords().At(0))
+	assert.Equal(t, map[string]string{
+		"service.name":  "api",
+		"idx":           "0",
+		"severity_text": "SEVERITY_NUMBER_ERROR",
+	}, dims)
+}
+
+func TestOperate(t *testing.T) {
+	conf := Config{
+		Operations: []OperationConfig{
+			{
+				MetricName: "bk_log_error_total",
+				Condition:  `severity_number >= 17`,
+				Dimensions: []string{"attributes.idx"},
+				MaxSeries:  1,
+			},
+			{
+				MetricName: "bk_log_total",
+				Dimensions: []string{"resource.service.name"},
+				MaxSeries:  2,
+			},
+		},
+	}
+	obj, err := NewLogsOperator(conf, nil)
+	assert.NoError(t, err)
+	operator := obj.(logsOperator)
+	defer operator.Clean()
+
+	operator.Operate(&define.Record{
+		RecordType: define.RecordLogs,
+		Data:       makeLogs(),
+		Token:      define.Token{MetricsDataId: 1001},
+	})
+
+	// 仅 error/fatal 两条日志命中条件 且维度不同 超出 max_series 限制一次
+	assert.Equal(t, map[int32]int{1001: 1}, operator.operations[0].accumulator.Exceeded())
+	// 所有日志维度相同 只会产生一个 series
+	assert.Equal(t, map[int32]int{1001: 0}, operator.operations[1].accumulator.Exceeded())
+}
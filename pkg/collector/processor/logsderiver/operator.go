@@ -0,0 +1,146 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package logsderiver
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/ottl"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/tracesderiver/accumulator"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+const dimensionSeverityText = "severity_text"
+
+type Operator interface {
+	Operate(*define.Record)
+	Clean()
+}
+
+type dimension struct {
+	name     string
+	resource bool
+	severity bool
+}
+
+type operation struct {
+	condition   *ottl.Condition
+	dimensions  []dimension
+	accumulator *accumulator.Accumulator
+}
+
+func parseDimensions(dims []string) []dimension {
+	ret := make([]dimension, 0, len(dims))
+	for _, dim := range dims {
+		switch {
+		case strings.HasPrefix(dim, define.ResourceKeyPrefix):
+			ret = append(ret, dimension{name: dim[len(define.ResourceKeyPrefix):], resource: true})
+		case strings.HasPrefix(dim, define.AttributeKeyPrefix):
+			ret = append(ret, dimension{name: dim[len(define.AttributeKeyPrefix):]})
+		case dim == dimensionSeverityText:
+			ret = append(ret, dimension{name: dim, severity: true})
+		default:
+			logger.Errorf("unsupported logs deriver dimension: %s", dim)
+		}
+	}
+	return ret
+}
+
+func (op operation) extractDimensions(rsAttrs pcommon.Map, logRecord plog.LogRecord) map[string]string {
+	dims := make(map[string]string, len(op.dimensions))
+	for _, dim := range op.dimensions {
+		switch {
+		case dim.severity:
+			dims[dim.name] = logRecord.SeverityText()
+		case dim.resource:
+			if v, ok := rsAttrs.Get(dim.name); ok {
+				dims[dim.name] = v.AsString()
+			}
+		default:
+			if v, ok := logRecord.Attributes().Get(dim.name); ok {
+				dims[dim.name] = v.AsString()
+			}
+		}
+	}
+	return dims
+}
+
+// NewLogsOperator 每个 operation 对应一个独立的 accumulator 指标名为空或条件编译失败时返回错误
+func NewLogsOperator(conf Config, publishFunc func(r *define.Record)) (Operator, error) {
+	conds := make([]*ottl.Condition, 0, len(conf.Operations))
+	for _, oc := range conf.Operations {
+		if oc.MetricName == "" {
+			return nil, errors.New("empty metric name")
+		}
+
+		var cond *ottl.Condition
+		if oc.Condition != "" {
+			c, err := ottl.ParseCondition(ottl.ContextLog, oc.Condition)
+			if err != nil {
+				return nil, errors.Wrapf(err, "parse condition of metric '%s'", oc.MetricName)
+			}
+			cond = c
+		}
+		conds = append(conds, cond)
+	}
+
+	// 全部 operation 校验通过后再创建 accumulator 避免出错时遗留后台任务
+	ops := make([]operation, 0, len(conf.Operations))
+	for i, oc := range conf.Operations {
+		ops = append(ops, operation{
+			condition:   conds[i],
+			dimensions:  parseDimensions(oc.Dimensions),
+			accumulator: accumulator.New(oc.accumulatorConfig(), publishFunc),
+		})
+	}
+	return logsOperator{operations: ops}, nil
+}
+
+type logsOperator struct {
+	operations []operation
+}
+
+func (lo logsOperator) Clean() {
+	for _, op := range lo.operations {
+		op.accumulator.Stop()
+	}
+}
+
+func (lo logsOperator) Operate(record *define.Record) {
+	if len(lo.operations) == 0 {
+		return
+	}
+
+	dataID := record.Token.MetricsDataId
+	resourceLogsSlice := record.Data.(plog.Logs).ResourceLogs()
+	for i := 0; i < resourceLogsSlice.Len(); i++ {
+		resourceLogs := resourceLogsSlice.At(i)
+		resource := resourceLogs.Resource()
+		scopeLogsSlice := resourceLogs.ScopeLogs()
+		for j := 0; j < scopeLogsSlice.Len(); j++ {
+			logs := scopeLogsSlice.At(j).LogRecords()
+			for k := 0; k < logs.Len(); k++ {
+				logRecord := logs.At(k)
+				tc := ottl.NewLogContext(resource, logRecord)
+				for _, op := range lo.operations {
+					if op.condition != nil && !op.condition.Match(tc) {
+						continue
+					}
+					op.accumulator.Accumulate(dataID, op.extractDimensions(resource.Attributes(), logRecord), 1)
+				}
+			}
+		}
+	}
+}
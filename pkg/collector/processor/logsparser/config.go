@@ -0,0 +1,81 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package logsparser
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type Config struct {
+	Json     JsonConfig     `config:"json" mapstructure:"json"`
+	Regex    []RegexConfig  `config:"regex" mapstructure:"regex"`
+	Severity SeverityConfig `config:"severity" mapstructure:"severity"`
+
+	// Overwrite 解析出的字段与已有 attributes 冲突时是否覆盖
+	Overwrite bool `config:"overwrite" mapstructure:"overwrite"`
+
+	regexps []*regexp.Regexp
+}
+
+type JsonConfig struct {
+	Enabled bool   `config:"enabled" mapstructure:"enabled"`
+	Prefix  string `config:"prefix" mapstructure:"prefix"`
+	// BodyKey 解析成功后使用该字段的值替换 body 为空时保留原始 body
+	BodyKey string `config:"body_key" mapstructure:"body_key"`
+}
+
+// RegexConfig 使用命名分组提取字段 例如 `^(?P<level>\w+) (?P<message>.*)$`
+type RegexConfig struct {
+	Pattern string `config:"pattern" mapstructure:"pattern"`
+}
+
+type SeverityConfig struct {
+	Enabled bool `config:"enabled" mapstructure:"enabled"`
+	// Keys severity_text 为空时 按顺序从 attributes 中查找日志级别
+	Keys []string `config:"keys" mapstructure:"keys"`
+	// Mapping 自定义日志级别映射 如 {"E": "ERROR"}
+	Mapping map[string]string `config:"mapping" mapstructure:"mapping"`
+
+	mapping map[string]severity
+}
+
+var defaultSeverityKeys = []string{"level", "severity", "log.level", "loglevel"}
+
+// Setup 编译正则并构建日志级别映射 任一配置非法时返回错误
+func (c *Config) Setup() error {
+	c.regexps = make([]*regexp.Regexp, 0, len(c.Regex))
+	for _, rc := range c.Regex {
+		re, err := regexp.Compile(rc.Pattern)
+		if err != nil {
+			return errors.Wrapf(err, "compile logs parser pattern '%s'", rc.Pattern)
+		}
+		c.regexps = append(c.regexps, re)
+	}
+
+	if len(c.Severity.Keys) == 0 {
+		c.Severity.Keys = defaultSeverityKeys
+	}
+	c.Severity.mapping = make(map[string]severity)
+	for k, v := range c.Severity.Mapping {
+		s, ok := lookupSeverity(v)
+		if !ok {
+			return errors.Errorf("unknown severity '%s' in mapping", v)
+		}
+		c.Severity.mapping[strings.ToLower(k)] = s
+	}
+	return nil
+}
+
+func (c *Config) Enabled() bool {
+	return c.Json.Enabled || len(c.regexps) > 0 || c.Severity.Enabled
+}
@@ -0,0 +1,36 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+/*
+# LogsParser: logs 解析器
+
+处理顺序: json -> regex -> severity
+
+processor:
+    - name: "logs_parser/common"
+      config:
+        overwrite: false # 解析出的字段与已有 attributes 冲突时是否覆盖
+        json:
+          enabled: true
+          prefix: "" # 写入 attributes 时的 key 前缀 嵌套对象使用 `.` 展开
+          body_key: "message" # 可选 使用该字段替换 body
+        regex:
+          # 命名分组提取为 attributes
+          - pattern: '^(?P<time>\S+) (?P<level>\w+) (?P<logger>[\w.]+): '
+        severity:
+          enabled: true
+          # severity_text 为空时 按顺序从 attributes 中查找日志级别
+          keys: ["level", "severity", "log.level", "loglevel"]
+          # 自定义映射 取值为 TRACE/DEBUG/INFO/WARN/ERROR/FATAL
+          mapping:
+            E: "ERROR"
+            W: "WARN"
+*/
+
+package logsparser
@@ -0,0 +1,213 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package logsparser
+
+import (
+	"bytes"
+	"math"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/foreach"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/json"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/mapstructure"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+func init() {
+	processor.Register(define.ProcessorLogsParser, NewFactory)
+}
+
+func NewFactory(conf map[string]interface{}, customized []processor.SubConfigProcessor) (processor.Processor, error) {
+	return newFactory(conf, customized)
+}
+
+func newFactory(conf map[string]interface{}, customized []processor.SubConfigProcessor) (*logsParser, error) {
+	configs := confengine.NewTierConfig()
+
+	c := &Config{}
+	if err := mapstructure.Decode(conf, c); err != nil {
+		return nil, err
+	}
+	if err := c.Setup(); err != nil {
+		return nil, err
+	}
+	configs.SetGlobal(*c)
+
+	for _, custom := range customized {
+		cfg := &Config{}
+		if err := mapstructure.Decode(custom.Config.Config, cfg); err != nil {
+			logger.Errorf("failed to decode config: %v", err)
+			continue
+		}
+		if err := cfg.Setup(); err != nil {
+			return nil, errors.Wrapf(err, "setup config of token '%s'", custom.Token)
+		}
+		configs.Set(custom.Token, custom.Type, custom.ID, *cfg)
+	}
+
+	return &logsParser{
+		CommonProcessor: processor.NewCommonProcessor(conf, customized),
+		configs:         configs,
+	}, nil
+}
+
+type logsParser struct {
+	processor.CommonProcessor
+	configs *confengine.TierConfig // type: Config
+}
+
+func (p *logsParser) Name() string {
+	return define.ProcessorLogsParser
+}
+
+func (p *logsParser) IsDerived() bool {
+	return false
+}
+
+func (p *logsParser) IsPreCheck() bool {
+	return false
+}
+
+func (p *logsParser) Reload(config map[string]interface{}, customized []processor.SubConfigProcessor) {
+	f, err := newFactory(config, customized)
+	if err != nil {
+		logger.Errorf("failed to reload processor: %v", err)
+		return
+	}
+
+	p.CommonProcessor = f.CommonProcessor
+	p.configs = f.configs
+}
+
+func (p *logsParser) Process(record *define.Record) (*define.Record, error) {
+	config := p.configs.GetByToken(record.Token.Original).(Config)
+	if !config.Enabled() {
+		return nil, nil
+	}
+
+	switch record.RecordType {
+	case define.RecordLogs:
+		pdLogs := record.Data.(plog.Logs)
+		foreach.Logs(pdLogs.ResourceLogs(), func(logRecord plog.LogRecord) {
+			if config.Json.Enabled {
+				parseJsonBody(config, logRecord)
+			}
+			if len(config.regexps) > 0 {
+				parseRegexBody(config, logRecord)
+			}
+			// 级别标准化放在最后 以便使用解析出来的字段
+			if config.Severity.Enabled {
+				config.Severity.normalize(logRecord)
+			}
+		})
+	}
+	return nil, nil
+}
+
+func putAttr(attrs pcommon.Map, overwrite bool, key string, v pcommon.Value) {
+	if overwrite {
+		attrs.Upsert(key, v)
+		return
+	}
+	attrs.Insert(key, v)
+}
+
+// parseJsonBody 解析 json 格式的 body 嵌套对象使用 `.` 展开 数组保留为 json 字符串
+func parseJsonBody(config Config, logRecord plog.LogRecord) {
+	body := logRecord.Body()
+	if body.Type() != pcommon.ValueTypeString {
+		return
+	}
+	b := bytes.TrimSpace([]byte(body.StringVal()))
+	if len(b) == 0 || b[0] != '{' {
+		return
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		logger.Debugf("failed to unmarshal log body, err: %v", err)
+		return
+	}
+
+	attrs := logRecord.Attributes()
+	flattenJson(config.Json.Prefix, m, func(k string, v pcommon.Value) {
+		putAttr(attrs, config.Overwrite, k, v)
+	})
+
+	if config.Json.BodyKey != "" {
+		if v, ok := m[config.Json.BodyKey]; ok {
+			toValue(v).CopyTo(body)
+		}
+	}
+}
+
+func flattenJson(prefix string, m map[string]interface{}, f func(k string, v pcommon.Value)) {
+	for k, v := range m {
+		key := prefix + k
+		switch val := v.(type) {
+		case nil:
+			continue
+		case map[string]interface{}:
+			flattenJson(key+".", val, f)
+		default:
+			f(key, toValue(val))
+		}
+	}
+}
+
+func toValue(v interface{}) pcommon.Value {
+	switch val := v.(type) {
+	case string:
+		return pcommon.NewValueString(val)
+	case bool:
+		return pcommon.NewValueBool(val)
+	case float64:
+		// json 数字统一解析为 float64 整数值还原为 int 类型
+		if val == math.Trunc(val) && math.Abs(val) < 1<<53 {
+			return pcommon.NewValueInt(int64(val))
+		}
+		return pcommon.NewValueDouble(val)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return pcommon.NewValueString("")
+	}
+	return pcommon.NewValueString(string(b))
+}
+
+// parseRegexBody 命名分组提取为 attributes 未命名的分组忽略
+func parseRegexBody(config Config, logRecord plog.LogRecord) {
+	body := logRecord.Body()
+	if body.Type() != pcommon.ValueTypeString {
+		return
+	}
+	s := body.StringVal()
+	attrs := logRecord.Attributes()
+
+	for _, re := range config.regexps {
+		matches := re.FindStringSubmatch(s)
+		if matches == nil {
+			continue
+		}
+		for i, name := range re.SubexpNames() {
+			if i == 0 || name == "" {
+				continue
+			}
+			putAttr(attrs, config.Overwrite, name, pcommon.NewValueString(matches[i]))
+		}
+	}
+}
@@ -0,0 +1,243 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package logsparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor"
+)
+
+func TestFactory(t *testing.T) {
+	content := `
+processor:
+  - name: "logs_parser/common"
+    config:
+      json:
+        enabled: true
+      regex:
+        - pattern: "^(?P<level>\\w+) "
+`
+	mainConf := processor.MustLoadConfigs(content)[0].Config
+
+	customContent := `
+processor:
+  - name: "logs_parser/common"
+    config:
+      severity:
+        enabled: true
+        mapping:
+          E: "ERROR"
+`
+	customConf := processor.MustLoadConfigs(customContent)[0].Config
+
+	obj, err := NewFactory(mainConf, []processor.SubConfigProcessor{
+		{
+			Token: "token1",
+			Type:  define.SubConfigFieldDefault,
+			Config: processor.Config{
+				Config: customConf,
+			},
+		},
+	})
+	factory := obj.(*logsParser)
+	assert.NoError(t, err)
+	assert.Equal(t, mainConf, factory.MainConfig())
+
+	c1 := factory.configs.GetGlobal().(Config)
+	assert.True(t, c1.Json.Enabled)
+	assert.Len(t, c1.regexps, 1)
+
+	c2 := factory.configs.GetByToken("token1").(Config)
+	assert.True(t, c2.Severity.Enabled)
+	assert.Equal(t, defaultSeverityKeys, c2.Severity.Keys)
+	assert.Equal(t, map[string]severity{"e": severityError}, c2.Severity.mapping)
+
+	assert.Equal(t, define.ProcessorLogsParser, factory.Name())
+	assert.False(t, factory.IsDerived())
+	assert.False(t, factory.IsPreCheck())
+
+	factory.Reload(mainConf, nil)
+	assert.Equal(t, mainConf, factory.MainConfig())
+
+	// 非法配置重载失败时保留原有配置
+	invalidConf := processor.MustLoadConfigs(`
+processor:
+  - name: "logs_parser/common"
+    config:
+      regex:
+        - pattern: "("
+`)[0].Config
+	factory.Reload(invalidConf, nil)
+	assert.Equal(t, mainConf, factory.MainConfig())
+	assert.Len(t, factory.configs.GetGlobal().(Config).regexps, 1)
+}
+
+func TestFactoryInvalidConfig(t *testing.T) {
+	t.Run("Pattern", func(t *testing.T) {
+		content := `
+processor:
+  - name: "logs_parser/common"
+    config:
+      regex:
+        - pattern: "("
+`
+		_, err := NewFactory(processor.MustLoadConfigs(content)[0].Config, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("CustomizedSeverity", func(t *testing.T) {
+		content := `
+processor:
+  - name: "logs_parser/common"
+    config:
+      severity:
+        enabled: true
+        mapping:
+          X: "UNKNOWN"
+`
+		conf := processor.MustLoadConfigs(content)[0].Config
+		_, err := NewFactory(map[string]interface{}{}, []processor.SubConfigProcessor{
+			{
+				Token: "token1",
+				Type:  define.SubConfigFieldDefault,
+				Config: processor.Config{
+					Config: conf,
+				},
+			},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func makeLogsRecord(bodies ...string) *define.Record {
+	logs := plog.NewLogs()
+	logRecords := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for _, body := range bodies {
+		logRecords.AppendEmpty().Body().SetStringVal(body)
+	}
+	return &define.Record{
+		RecordType: define.RecordLogs,
+		Data:       logs,
+	}
+}
+
+func logRecordAt(record *define.Record, idx int) plog.LogRecord {
+	return record.Data.(plog.Logs).ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(idx)
+}
+
+func TestJsonBody(t *testing.T) {
+	content := `
+processor:
+  - name: "logs_parser/common"
+    config:
+      json:
+        enabled: true
+        body_key: "msg"
+      severity:
+        enabled: true
+`
+	factory := processor.MustCreateFactory(content, NewFactory)
+
+	record := makeLogsRecord(
+		`{"msg": "user login", "level": "warning", "user": {"id": 100, "name": "alice"}, "tags": ["a", "b"], "cost": 1.5, "ok": true}`,
+		`not a json`,
+		`{"broken": `,
+	)
+	logRecordAt(record, 0).Attributes().UpsertString("user.name", "bob")
+
+	_, err := factory.Process(record)
+	assert.NoError(t, err)
+
+	lr := logRecordAt(record, 0)
+	assert.Equal(t, "user login", lr.Body().StringVal())
+	assert.Equal(t, "WARN", lr.SeverityText())
+	assert.Equal(t, plog.SeverityNumberWARN, lr.SeverityNumber())
+	assert.Equal(t, map[string]interface{}{
+		"msg":       "user login",
+		"level":     "warning",
+		"user.id":   int64(100),
+		"user.name": "bob", // overwrite 未开启时保留原有值
+		"tags":      `["a","b"]`,
+		"cost":      1.5,
+		"ok":        true,
+	}, lr.Attributes().AsRaw())
+
+	assert.Equal(t, "not a json", logRecordAt(record, 1).Body().StringVal())
+	assert.Equal(t, 0, logRecordAt(record, 1).Attributes().Len())
+	assert.Equal(t, 0, logRecordAt(record, 2).Attributes().Len())
+}
+
+func TestRegexBody(t *testing.T) {
+	content := `
+processor:
+  - name: "logs_parser/common"
+    config:
+      overwrite: true
+      regex:
+        - pattern: "^(?P<level>\\w) (?P<logger>[\\w.]+): (.*)$"
+      severity:
+        enabled: true
+        mapping:
+          E: "ERROR"
+`
+	factory := processor.MustCreateFactory(content, NewFactory)
+
+	record := makeLogsRecord("E app.db: connection refused", "no match")
+	logRecordAt(record, 0).Attributes().UpsertString("logger", "old")
+
+	_, err := factory.Process(record)
+	assert.NoError(t, err)
+
+	lr := logRecordAt(record, 0)
+	assert.Equal(t, map[string]interface{}{"level": "E", "logger": "app.db"}, lr.Attributes().AsRaw())
+	assert.Equal(t, "ERROR", lr.SeverityText())
+	assert.Equal(t, plog.SeverityNumberERROR, lr.SeverityNumber())
+
+	assert.Equal(t, 0, logRecordAt(record, 1).Attributes().Len())
+	assert.Equal(t, plog.SeverityNumberUNDEFINED, logRecordAt(record, 1).SeverityNumber())
+}
+
+func TestSeverityNormalize(t *testing.T) {
+	c := Config{Severity: SeverityConfig{Enabled: true}}
+	assert.NoError(t, c.Setup())
+
+	cases := []struct {
+		text       string
+		number     plog.SeverityNumber
+		wantText   string
+		wantNumber plog.SeverityNumber
+	}{
+		{text: "", number: plog.SeverityNumberERROR3, wantText: "ERROR", wantNumber: plog.SeverityNumberERROR3},
+		{text: "Custom", number: plog.SeverityNumberINFO, wantText: "Custom", wantNumber: plog.SeverityNumberINFO},
+		{text: "critical", wantText: "FATAL", wantNumber: plog.SeverityNumberFATAL},
+		{text: " Debug ", wantText: "DEBUG", wantNumber: plog.SeverityNumberDEBUG},
+		{text: "", number: plog.SeverityNumberTRACE2, wantText: "TRACE", wantNumber: plog.SeverityNumberTRACE2},
+		{text: "unknown", wantText: "unknown", wantNumber: plog.SeverityNumberUNDEFINED},
+	}
+
+	for _, tc := range cases {
+		lr := plog.NewLogRecord()
+		lr.SetSeverityText(tc.text)
+		lr.SetSeverityNumber(tc.number)
+		c.Severity.normalize(lr)
+		assert.Equal(t, tc.wantText, lr.SeverityText())
+		assert.Equal(t, tc.wantNumber, lr.SeverityNumber())
+	}
+
+	lr := plog.NewLogRecord()
+	lr.Attributes().UpsertString("log.level", "INFO")
+	assert.True(t, c.Severity.normalize(lr))
+	assert.Equal(t, "INFO", lr.SeverityText())
+}
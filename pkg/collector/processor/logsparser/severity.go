@@ -0,0 +1,118 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package logsparser
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// severity 标准化后的日志级别 number 取 OpenTelemetry 定义的每个区间的起始值
+type severity struct {
+	text   string
+	number plog.SeverityNumber
+}
+
+var (
+	severityTrace = severity{text: "TRACE", number: plog.SeverityNumberTRACE}
+	severityDebug = severity{text: "DEBUG", number: plog.SeverityNumberDEBUG}
+	severityInfo  = severity{text: "INFO", number: plog.SeverityNumberINFO}
+	severityWarn  = severity{text: "WARN", number: plog.SeverityNumberWARN}
+	severityError = severity{text: "ERROR", number: plog.SeverityNumberERROR}
+	severityFatal = severity{text: "FATAL", number: plog.SeverityNumberFATAL}
+)
+
+var severityAliases = map[string]severity{
+	"trace":       severityTrace,
+	"debug":       severityDebug,
+	"dbg":         severityDebug,
+	"info":        severityInfo,
+	"information": severityInfo,
+	"notice":      severityInfo,
+	"warn":        severityWarn,
+	"warning":     severityWarn,
+	"error":       severityError,
+	"err":         severityError,
+	"severe":      severityError,
+	"fatal":       severityFatal,
+	"critical":    severityFatal,
+	"crit":        severityFatal,
+	"alert":       severityFatal,
+	"emerg":       severityFatal,
+	"emergency":   severityFatal,
+	"panic":       severityFatal,
+}
+
+func lookupSeverity(s string) (severity, bool) {
+	sev, ok := severityAliases[strings.ToLower(strings.TrimSpace(s))]
+	return sev, ok
+}
+
+// severityFromNumber 按照 number 所在区间确定日志级别
+func severityFromNumber(n plog.SeverityNumber) (severity, bool) {
+	switch {
+	case n <= plog.SeverityNumberUNDEFINED:
+		return severity{}, false
+	case n < plog.SeverityNumberDEBUG:
+		return severityTrace, true
+	case n < plog.SeverityNumberINFO:
+		return severityDebug, true
+	case n < plog.SeverityNumberWARN:
+		return severityInfo, true
+	case n < plog.SeverityNumberERROR:
+		return severityWarn, true
+	case n < plog.SeverityNumberFATAL:
+		return severityError, true
+	}
+	return severityFatal, true
+}
+
+func (c *SeverityConfig) lookup(s string) (severity, bool) {
+	if sev, ok := c.mapping[strings.ToLower(s)]; ok {
+		return sev, true
+	}
+	return lookupSeverity(s)
+}
+
+// normalize 标准化日志级别
+// 1) 已有 severity_number 时以其为准 补齐 severity_text
+// 2) 否则依次从 severity_text 以及 attributes 中识别日志级别
+func (c *SeverityConfig) normalize(logRecord plog.LogRecord) bool {
+	if sev, ok := severityFromNumber(logRecord.SeverityNumber()); ok {
+		if logRecord.SeverityText() == "" {
+			logRecord.SetSeverityText(sev.text)
+			return true
+		}
+		return false
+	}
+
+	if text := logRecord.SeverityText(); text != "" {
+		if sev, ok := c.lookup(text); ok {
+			logRecord.SetSeverityText(sev.text)
+			logRecord.SetSeverityNumber(sev.number)
+			return true
+		}
+	}
+
+	attrs := logRecord.Attributes()
+	for _, key := range c.Keys {
+		v, ok := attrs.Get(key)
+		if !ok {
+			continue
+		}
+		if sev, ok := c.lookup(v.AsString()); ok {
+			logRecord.SetSeverityText(sev.text)
+			logRecord.SetSeverityNumber(sev.number)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package builder
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+// Frame 调用栈帧
+type Frame struct {
+	Function string
+	File     string
+	Line     int64
+	Address  uint64
+}
+
+// StackBuilder 根据调用栈构建 Profile 相同的函数、栈帧以及调用栈会被合并
+type StackBuilder struct {
+	profile   *profile.Profile
+	functions map[string]*profile.Function
+	locations map[Frame]*profile.Location
+	samples   map[string]*profile.Sample
+}
+
+func NewStackBuilder(sampleTypes []*profile.ValueType, periodType *profile.ValueType, period int64) *StackBuilder {
+	return &StackBuilder{
+		profile: &profile.Profile{
+			SampleType: sampleTypes,
+			PeriodType: periodType,
+			Period:     period,
+		},
+		functions: make(map[string]*profile.Function),
+		locations: make(map[Frame]*profile.Location),
+		samples:   make(map[string]*profile.Sample),
+	}
+}
+
+func (b *StackBuilder) function(frame Frame) *profile.Function {
+	key := frame.Function + "\x00" + frame.File
+	if fn, ok := b.functions[key]; ok {
+		return fn
+	}
+
+	fn := &profile.Function{
+		ID:         uint64(len(b.profile.Function) + 1),
+		Name:       frame.Function,
+		SystemName: frame.Function,
+		Filename:   frame.File,
+	}
+	b.profile.Function = append(b.profile.Function, fn)
+	b.functions[key] = fn
+	return fn
+}
+
+func (b *StackBuilder) location(frame Frame) *profile.Location {
+	if loc, ok := b.locations[frame]; ok {
+		return loc
+	}
+
+	loc := &profile.Location{
+		ID:      uint64(len(b.profile.Location) + 1),
+		Address: frame.Address,
+		Line:    []profile.Line{{Function: b.function(frame), Line: frame.Line}},
+	}
+	b.profile.Location = append(b.profile.Location, loc)
+	b.locations[frame] = loc
+	return loc
+}
+
+// AddStack 添加调用栈 frames 需按叶子节点在前的顺序排列 values 与 SampleType 一一对应
+func (b *StackBuilder) AddStack(frames []Frame, values []int64) {
+	locations := make([]*profile.Location, 0, len(frames))
+	ids := make([]string, 0, len(frames))
+	for _, frame := range frames {
+		loc := b.location(frame)
+		locations = append(locations, loc)
+		ids = append(ids, strconv.FormatUint(loc.ID, 10))
+	}
+
+	key := strings.Join(ids, ",")
+	if sample, ok := b.samples[key]; ok {
+		for i := range sample.Value {
+			sample.Value[i] += values[i]
+		}
+		return
+	}
+
+	sample := &profile.Sample{
+		Location: locations,
+		Value:    append([]int64(nil), values...),
+	}
+	b.profile.Sample = append(b.profile.Sample, sample)
+	b.samples[key] = sample
+}
+
+// Build 返回构建完成的 Profile
+func (b *StackBuilder) Build(start, end time.Time) *profile.Profile {
+	if !start.IsZero() {
+		b.profile.TimeNanos = start.UnixNano()
+		if end.After(start) {
+			b.profile.DurationNanos = end.Sub(start).Nanoseconds()
+		}
+	}
+	return b.profile
+}
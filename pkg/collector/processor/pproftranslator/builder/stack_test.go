@@ -0,0 +1,46 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package builder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStackBuilder(t *testing.T) {
+	sampleType := &profile.ValueType{Type: "samples", Unit: "count"}
+	b := NewStackBuilder([]*profile.ValueType{sampleType}, sampleType, 1)
+
+	foo := Frame{Function: "foo", File: "main.go", Line: 10}
+	bar := Frame{Function: "bar", File: "main.go", Line: 20}
+	main := Frame{Function: "main", File: "main.go", Line: 5}
+	b.AddStack([]Frame{foo, main}, []int64{1})
+	b.AddStack([]Frame{bar, main}, []int64{2})
+	b.AddStack([]Frame{foo, main}, []int64{3})
+	// 同一函数的不同行号共享 Function
+	b.AddStack([]Frame{{Function: "foo", File: "main.go", Line: 11}, main}, []int64{1})
+
+	start := time.Unix(1700000000, 0)
+	p := b.Build(start, start.Add(10*time.Second))
+	assert.NoError(t, p.CheckValid())
+	assert.Equal(t, start.UnixNano(), p.TimeNanos)
+	assert.Equal(t, int64(10*time.Second), p.DurationNanos)
+
+	assert.Len(t, p.Function, 3)
+	assert.Len(t, p.Location, 4)
+	assert.Len(t, p.Sample, 3)
+	assert.Equal(t, []int64{4}, p.Sample[0].Value)
+	assert.Equal(t, "foo", p.Sample[0].Location[0].Line[0].Function.Name)
+	assert.Equal(t, "main", p.Sample[0].Location[1].Line[0].Function.Name)
+	assert.Equal(t, []int64{2}, p.Sample[1].Value)
+}
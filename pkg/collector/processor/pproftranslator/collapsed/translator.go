@@ -0,0 +1,110 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package collapsed
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/google/pprof/profile"
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/pproftranslator/builder"
+)
+
+const maxLineSize = 1024 * 1024
+
+// Translator 折叠栈数据解析器 兼容 FlameGraph stackcollapse 以及 pyroscope folded 格式
+//
+// 每行格式为 `root;caller;callee count` 栈帧自根节点开始以分号分隔
+type Translator struct{}
+
+// sampleTypes 根据上报的 units 推断样本类型
+// CPU 采样在指定了采样频率时额外补充以纳秒为单位的 cpu 耗时
+func sampleTypes(meta define.ProfileMetadata) ([]*profile.ValueType, *profile.ValueType, int64) {
+	switch meta.Units {
+	case "bytes":
+		vt := &profile.ValueType{Type: "alloc_space", Unit: "bytes"}
+		return []*profile.ValueType{vt}, vt, 1
+	case "objects":
+		vt := &profile.ValueType{Type: "alloc_objects", Unit: "count"}
+		return []*profile.ValueType{vt}, vt, 1
+	case "goroutines":
+		vt := &profile.ValueType{Type: "goroutines", Unit: "count"}
+		return []*profile.ValueType{vt}, vt, 1
+	}
+
+	samples := &profile.ValueType{Type: "samples", Unit: "count"}
+	if meta.SampleRate == 0 {
+		return []*profile.ValueType{samples}, samples, 1
+	}
+	cpu := &profile.ValueType{Type: "cpu", Unit: "nanoseconds"}
+	return []*profile.ValueType{samples, cpu}, cpu, int64(1e9 / meta.SampleRate)
+}
+
+// Translate 折叠栈数据格式解析主方法
+func (t *Translator) Translate(pd define.ProfilesRawData) (*define.ProfilesData, error) {
+	data, ok := pd.Data.(define.ProfileCollapsedFormatOrigin)
+	if !ok {
+		return nil, errors.Errorf("excepted CollapsedFormatOrigin type, but got %T", pd.Data)
+	}
+
+	types, periodType, period := sampleTypes(pd.Metadata)
+	b := builder.NewStackBuilder(types, periodType, period)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+
+	var lineNum, samples int
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		idx := strings.LastIndexByte(line, ' ')
+		if idx <= 0 {
+			return nil, errors.Errorf("line %d: missing sample count", lineNum)
+		}
+		count, err := strconv.ParseInt(line[idx+1:], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d: invalid sample count", lineNum)
+		}
+
+		names := strings.Split(strings.TrimSpace(line[:idx]), ";")
+		frames := make([]builder.Frame, 0, len(names))
+		for i := len(names) - 1; i >= 0; i-- {
+			if names[i] == "" {
+				continue
+			}
+			frames = append(frames, builder.Frame{Function: names[i]})
+		}
+
+		values := []int64{count}
+		if len(types) > 1 {
+			values = append(values, count*period)
+		}
+		b.AddStack(frames, values)
+		samples++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to scan collapsed data")
+	}
+	if samples == 0 {
+		return nil, errors.Errorf("skip empty profile data, app: %d-%s", pd.Metadata.BkBizID, pd.Metadata.AppName)
+	}
+
+	p := b.Build(pd.Metadata.StartTime, pd.Metadata.EndTime)
+	return &define.ProfilesData{Metadata: pd.Metadata, Profiles: []*profile.Profile{p}}, nil
+}
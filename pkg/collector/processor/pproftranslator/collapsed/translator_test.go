@@ -0,0 +1,93 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package collapsed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+)
+
+func TestTranslate(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	data := `
+main;foo;runtime.mallocgc 3
+main;bar 2
+main;foo;runtime.mallocgc 1
+main;(*Server).Serve handler 4
+`
+
+	t.Run("Samples", func(t *testing.T) {
+		var tr Translator
+		pd, err := tr.Translate(define.ProfilesRawData{
+			Metadata: define.ProfileMetadata{StartTime: start, EndTime: start.Add(10 * time.Second)},
+			Data:     define.ProfileCollapsedFormatOrigin(data),
+		})
+		assert.NoError(t, err)
+		assert.Len(t, pd.Profiles, 1)
+
+		p := pd.Profiles[0]
+		assert.NoError(t, p.CheckValid())
+		assert.Equal(t, "samples", p.PeriodType.Type)
+		assert.Equal(t, int64(10*time.Second), p.DurationNanos)
+		assert.Len(t, p.Sample, 3)
+		assert.Equal(t, []int64{4}, p.Sample[0].Value)
+		assert.Equal(t, "runtime.mallocgc", p.Sample[0].Location[0].Line[0].Function.Name)
+		assert.Equal(t, "main", p.Sample[0].Location[2].Line[0].Function.Name)
+		assert.Equal(t, "(*Server).Serve handler", p.Sample[2].Location[0].Line[0].Function.Name)
+	})
+
+	t.Run("CPU", func(t *testing.T) {
+		var tr Translator
+		pd, err := tr.Translate(define.ProfilesRawData{
+			Metadata: define.ProfileMetadata{SampleRate: 100},
+			Data:     define.ProfileCollapsedFormatOrigin(data),
+		})
+		assert.NoError(t, err)
+
+		p := pd.Profiles[0]
+		assert.Equal(t, "cpu", p.PeriodType.Type)
+		assert.Equal(t, int64(10000000), p.Period)
+		assert.Equal(t, []int64{2, 20000000}, p.Sample[1].Value)
+	})
+
+	t.Run("Bytes", func(t *testing.T) {
+		var tr Translator
+		pd, err := tr.Translate(define.ProfilesRawData{
+			Metadata: define.ProfileMetadata{Units: "bytes"},
+			Data:     define.ProfileCollapsedFormatOrigin(data),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "alloc_space", pd.Profiles[0].SampleType[0].Type)
+	})
+}
+
+func TestTranslateInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data any
+	}{
+		{name: "DataType", data: define.ProfilePprofFormatOrigin("main 1")},
+		{name: "Empty", data: define.ProfileCollapsedFormatOrigin("\n\n")},
+		{name: "MissingCount", data: define.ProfileCollapsedFormatOrigin("main;foo")},
+		{name: "InvalidCount", data: define.ProfileCollapsedFormatOrigin("main;foo x")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tr Translator
+			_, err := tr.Translate(define.ProfilesRawData{Data: tt.data})
+			assert.Error(t, err)
+		})
+	}
+}
@@ -10,5 +10,20 @@
 package pproftranslator
 
 type Config struct {
-	Type string `config:"type" mapstructure:"type"`
+	Type     string         `config:"type" mapstructure:"type"`
+	SpanLink SpanLinkConfig `config:"span_link" mapstructure:"span_link"`
+}
+
+// SpanLinkConfig 根据 pprof 样本中的 trace_id/span_id/span_name 标签关联 Span 与 Profile
+type SpanLinkConfig struct {
+	Enabled    bool   `config:"enabled" mapstructure:"enabled"`
+	MetricName string `config:"metric_name" mapstructure:"metric_name"`
+}
+
+const defaultSpanMetricName = "bk_apm_profile_span_value"
+
+func (c *SpanLinkConfig) Validate() {
+	if c.MetricName == "" {
+		c.MetricName = defaultSpanMetricName
+	}
 }
@@ -10,10 +10,19 @@
 /*
 # PprofTranslator: Pprof 数据协议转换器
 
+根据 Profile 元数据中的 format 选择解析器 支持 pprof/jfr/collapsed（折叠栈）/gotrace（Go trace CPU 采样）/otlp
+采集器只负责接入及转换 不提供 pprof 转火焰图的查询接口 火焰图由下游 profiling 存储服务查询生成
+
 processor:
     - name: "pprof_translator/common"
       config:
         type: "spy"
+        # 提取样本中的 trace_id/span_id/span_name 标签 写入 span_ids/trace_ids 字段
+        # 并派生指标（metrics.derived）按 service_name/span_name 汇总样本值 trace_id/span_id 不作为指标维度
+        # 每个 span 的样本值另以日志形式写入 token 的 logs dataid（携带 trace_id/span_id） 用于从 Span 跳转至火焰图
+        span_link:
+          enabled: true
+          metric_name: "bk_apm_profile_span_value"
 */
 
 package pproftranslator
@@ -29,6 +29,7 @@ func NewFactory(conf map[string]interface{}, customized []processor.SubConfigPro
 
 func newFactory(conf map[string]interface{}, customized []processor.SubConfigProcessor) (*pprofTranslator, error) {
 	configs := confengine.NewTierConfig()
+	spanLinks := confengine.NewTierConfig()
 
 	var c Config
 	if err := mapstructure.Decode(conf, &c); err != nil {
@@ -36,6 +37,8 @@ func newFactory(conf map[string]interface{}, customized []processor.SubConfigPro
 	}
 
 	configs.SetGlobal(NewPprofTranslator(c))
+	c.SpanLink.Validate()
+	spanLinks.SetGlobal(c.SpanLink)
 
 	for _, custom := range customized {
 		var cfg Config
@@ -44,17 +47,23 @@ func newFactory(conf map[string]interface{}, customized []processor.SubConfigPro
 			continue
 		}
 		configs.Set(custom.Token, custom.Type, custom.ID, NewPprofTranslator(cfg))
+		cfg.SpanLink.Validate()
+		spanLinks.Set(custom.Token, custom.Type, custom.ID, cfg.SpanLink)
 	}
 
 	return &pprofTranslator{
 		CommonProcessor: processor.NewCommonProcessor(conf, customized),
 		configs:         configs,
+		spanLinks:       spanLinks,
+		publish:         processor.PublishNonSchedRecords,
 	}, nil
 }
 
 type pprofTranslator struct {
 	processor.CommonProcessor
-	configs *confengine.TierConfig
+	configs   *confengine.TierConfig
+	spanLinks *confengine.TierConfig
+	publish   func(r *define.Record) // 输出 span 关联明细日志
 }
 
 func (p *pprofTranslator) Name() string {
@@ -62,7 +71,7 @@ func (p *pprofTranslator) Name() string {
 }
 
 func (p *pprofTranslator) IsDerived() bool {
-	return false
+	return true
 }
 
 func (p *pprofTranslator) IsPreCheck() bool {
@@ -78,6 +87,7 @@ func (p *pprofTranslator) Reload(config map[string]interface{}, customized []pro
 
 	p.CommonProcessor = f.CommonProcessor
 	p.configs = f.configs
+	p.spanLinks = f.spanLinks
 }
 
 func (p *pprofTranslator) Process(record *define.Record) (*define.Record, error) {
@@ -94,5 +104,18 @@ func (p *pprofTranslator) Process(record *define.Record) (*define.Record, error)
 	}
 
 	record.Data = profileData
-	return nil, nil
+
+	spanLink := p.spanLinks.GetByToken(record.Token.Original).(SpanLinkConfig)
+	if !spanLink.Enabled {
+		return nil, nil
+	}
+
+	derived := linkSpans(record, profileData, spanLink.MetricName)
+	// 明细日志写入 token 对应的 logs dataid 未配置时不输出
+	if record.Token.LogsDataId > 0 {
+		if r := spanLogs(record, profileData); r != nil {
+			p.publish(r)
+		}
+	}
+	return derived, nil
 }
@@ -57,7 +57,7 @@ processor:
 	assert.NoError(t, mapstructure.Decode(mainConf, &c))
 
 	assert.Equal(t, define.ProcessorPprofTranslator, factory.Name())
-	assert.False(t, factory.IsDerived())
+	assert.True(t, factory.IsDerived())
 	assert.False(t, factory.IsPreCheck())
 
 	factory.Reload(mainConf, nil)
@@ -151,3 +151,83 @@ processor:
 		assert.NotEqual(t, *record, copyRecord)
 	})
 }
+
+func TestFactoryProcessSpanLink(t *testing.T) {
+	content := `
+processor:
+  - name: "pprof_translator/common"
+    config:
+      type: "spy"
+      span_link:
+        enabled: true
+`
+	mainConf := processor.MustLoadConfigs(content)[0].Config
+	obj, err := NewFactory(mainConf, []processor.SubConfigProcessor{
+		{
+			Token: "token1",
+			Type:  define.SubConfigFieldDefault,
+			Config: processor.Config{
+				Config: map[string]interface{}{"type": "spy"},
+			},
+		},
+	})
+	factory := obj.(*pprofTranslator)
+	assert.NoError(t, err)
+
+	newRecord := func(token string) *define.Record {
+		return &define.Record{
+			RecordType: define.RecordProfiles,
+			Data: define.ProfilesRawData{
+				Data:     define.ProfileCollapsedFormatOrigin("main;foo 1"),
+				Metadata: define.ProfileMetadata{Format: define.FormatCollapsed},
+			},
+			Token: define.Token{Original: token},
+		}
+	}
+
+	t.Run("NoSpans", func(t *testing.T) {
+		record := newRecord("token")
+		r, err := factory.Process(record)
+		assert.NoError(t, err)
+		assert.Nil(t, r)
+		spans := record.Data.(*define.ProfilesData).Spans
+		assert.Len(t, spans, 1)
+		assert.Empty(t, spans[0])
+	})
+
+	t.Run("Logs", func(t *testing.T) {
+		var published []*define.Record
+		factory.publish = func(r *define.Record) { published = append(published, r) }
+
+		var buf bytes.Buffer
+		assert.NoError(t, newSpanProfile().Write(&buf))
+
+		record := newRecord("token")
+		record.Data = define.ProfilesRawData{
+			Data:     define.ProfilePprofFormatOrigin(buf.Bytes()),
+			Metadata: define.ProfileMetadata{AppName: "app"},
+		}
+		r, err := factory.Process(record)
+		assert.NoError(t, err)
+		assert.NotNil(t, r)
+		assert.Len(t, published, 0) // 未配置 logs dataid 时不输出明细日志
+
+		record.Data = define.ProfilesRawData{
+			Data:     define.ProfilePprofFormatOrigin(buf.Bytes()),
+			Metadata: define.ProfileMetadata{AppName: "app"},
+		}
+		record.Token.LogsDataId = 1001
+		_, err = factory.Process(record)
+		assert.NoError(t, err)
+		assert.Len(t, published, 1)
+		assert.Equal(t, define.RecordLogs, published[0].RecordType)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		record := newRecord("token1")
+		r, err := factory.Process(record)
+		assert.NoError(t, err)
+		assert.Nil(t, r)
+		assert.Nil(t, record.Data.(*define.ProfilesData).Spans)
+	})
+}
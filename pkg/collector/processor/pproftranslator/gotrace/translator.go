@@ -0,0 +1,96 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package gotrace
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/trace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/pproftranslator/builder"
+)
+
+// defaultSampleRate runtime/pprof 默认的 CPU 采样频率
+const defaultSampleRate = 100
+
+// Translator Go runtime/trace 数据解析器
+//
+// 仅提取 trace 中的 CPU 采样事件 要求采集 trace 期间同时开启了 CPU profiling
+// 依赖 golang.org/x/exp/trace 解析 目前支持 Go 1.22 的 trace 格式
+type Translator struct{}
+
+type stackFrames func(yield func(f trace.StackFrame) bool) bool
+
+func newBuilder(meta define.ProfileMetadata) (*builder.StackBuilder, int64) {
+	rate := meta.SampleRate
+	if rate == 0 {
+		rate = defaultSampleRate
+	}
+	period := int64(time.Second) / int64(rate)
+	cpu := &profile.ValueType{Type: "cpu", Unit: "nanoseconds"}
+	types := []*profile.ValueType{{Type: "samples", Unit: "count"}, cpu}
+	return builder.NewStackBuilder(types, cpu, period), period
+}
+
+// addSample 栈帧按叶子节点在前的顺序迭代 与 pprof 保持一致
+func addSample(b *builder.StackBuilder, period int64, frames stackFrames) {
+	var stack []builder.Frame
+	frames(func(f trace.StackFrame) bool {
+		stack = append(stack, builder.Frame{
+			Function: f.Func,
+			File:     f.File,
+			Line:     int64(f.Line),
+			Address:  f.PC,
+		})
+		return true
+	})
+	b.AddStack(stack, []int64{1, period})
+}
+
+// Translate Go trace 数据格式解析主方法
+func (t *Translator) Translate(pd define.ProfilesRawData) (*define.ProfilesData, error) {
+	data, ok := pd.Data.(define.ProfileGoTraceFormatOrigin)
+	if !ok {
+		return nil, errors.Errorf("excepted GoTraceFormatOrigin type, but got %T", pd.Data)
+	}
+
+	r, err := trace.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read trace header")
+	}
+
+	b, period := newBuilder(pd.Metadata)
+	var samples int
+	for {
+		ev, err := r.ReadEvent()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, errors.Wrap(err, "failed to read trace event")
+		}
+		if ev.Kind() != trace.EventStackSample {
+			continue
+		}
+		addSample(b, period, ev.Stack().Frames)
+		samples++
+	}
+	if samples == 0 {
+		return nil, errors.Errorf("no cpu samples found in trace, app: %d-%s", pd.Metadata.BkBizID, pd.Metadata.AppName)
+	}
+
+	p := b.Build(pd.Metadata.StartTime, pd.Metadata.EndTime)
+	return &define.ProfilesData{Metadata: pd.Metadata, Profiles: []*profile.Profile{p}}, nil
+}
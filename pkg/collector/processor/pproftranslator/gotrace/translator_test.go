@@ -0,0 +1,75 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package gotrace
+
+import (
+	"bytes"
+	"runtime/trace"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	exptrace "golang.org/x/exp/trace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+)
+
+func newFrames(names ...string) stackFrames {
+	return func(yield func(f exptrace.StackFrame) bool) bool {
+		for i, name := range names {
+			if !yield(exptrace.StackFrame{PC: uint64(i + 1), Func: name, File: "main.go", Line: uint64(i + 1)}) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func TestAddSample(t *testing.T) {
+	b, period := newBuilder(define.ProfileMetadata{})
+	assert.Equal(t, int64(10000000), period)
+
+	addSample(b, period, newFrames("foo", "main"))
+	addSample(b, period, newFrames("foo", "main"))
+	addSample(b, period, newFrames("bar", "main"))
+
+	p := b.Build(time.Time{}, time.Time{})
+	assert.NoError(t, p.CheckValid())
+	assert.Equal(t, "cpu", p.PeriodType.Type)
+	assert.Len(t, p.Sample, 2)
+	assert.Equal(t, []int64{2, 20000000}, p.Sample[0].Value)
+	assert.Equal(t, "foo", p.Sample[0].Location[0].Line[0].Function.Name)
+	assert.Equal(t, "main", p.Sample[0].Location[1].Line[0].Function.Name)
+}
+
+func TestTranslateInvalid(t *testing.T) {
+	t.Run("DataType", func(t *testing.T) {
+		var tr Translator
+		_, err := tr.Translate(define.ProfilesRawData{Data: define.ProfilePprofFormatOrigin("")})
+		assert.Error(t, err)
+	})
+
+	t.Run("Header", func(t *testing.T) {
+		var tr Translator
+		_, err := tr.Translate(define.ProfilesRawData{Data: define.ProfileGoTraceFormatOrigin("invalid")})
+		assert.Error(t, err)
+	})
+
+	// 未开启 CPU profiling 时不存在采样数据 高于 Go 1.22 的 trace 格式则无法解析
+	t.Run("NoSamples", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, trace.Start(&buf))
+		trace.Stop()
+
+		var tr Translator
+		_, err := tr.Translate(define.ProfilesRawData{Data: define.ProfileGoTraceFormatOrigin(buf.Bytes())})
+		assert.Error(t, err)
+	})
+}
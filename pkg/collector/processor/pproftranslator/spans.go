@@ -0,0 +1,230 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package pproftranslator
+
+import (
+	"encoding/hex"
+	"sort"
+
+	"github.com/google/pprof/profile"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/metricsbuilder"
+)
+
+const (
+	labelTraceID  = "trace_id"
+	labelSpanID   = "span_id"
+	labelSpanName = "span_name"
+
+	keyServiceName = "service_name"
+	keyType        = "type"
+	keyUnit        = "unit"
+	keyValue       = "value"
+)
+
+// sampleIndex 与 pprof 工具保持一致 优先使用 DefaultSampleType 否则使用最后一个样本类型
+func sampleIndex(p *profile.Profile) int {
+	for i, st := range p.SampleType {
+		if st.Type == p.DefaultSampleType {
+			return i
+		}
+	}
+	return len(p.SampleType) - 1
+}
+
+func firstLabel(s *profile.Sample, key string) string {
+	if values := s.Label[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// extractSpans 按 span 汇总带有 span_id 标签的样本值
+func extractSpans(p *profile.Profile) []define.ProfileSpan {
+	idx := sampleIndex(p)
+	if idx < 0 {
+		return nil
+	}
+
+	type spanKey struct {
+		traceID  string
+		spanID   string
+		spanName string
+	}
+	values := make(map[spanKey]int64)
+	for _, s := range p.Sample {
+		spanID := firstLabel(s, labelSpanID)
+		if spanID == "" || idx >= len(s.Value) {
+			continue
+		}
+		k := spanKey{
+			traceID:  firstLabel(s, labelTraceID),
+			spanID:   spanID,
+			spanName: firstLabel(s, labelSpanName),
+		}
+		values[k] += s.Value[idx]
+	}
+
+	st := p.SampleType[idx]
+	spans := make([]define.ProfileSpan, 0, len(values))
+	for k, v := range values {
+		spans = append(spans, define.ProfileSpan{
+			TraceID:  k.traceID,
+			SpanID:   k.spanID,
+			SpanName: k.spanName,
+			Type:     st.Type,
+			Unit:     st.Unit,
+			Value:    v,
+		})
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].Value != spans[j].Value {
+			return spans[i].Value > spans[j].Value
+		}
+		return spans[i].SpanID < spans[j].SpanID
+	})
+	return spans
+}
+
+func serviceName(meta define.ProfileMetadata) string {
+	if name := meta.Tags[keyServiceName]; name != "" {
+		return name
+	}
+	return meta.AppName
+}
+
+// linkSpans 提取各 profile 中的 span 信息 并派生出按 span_name 汇总样本值的指标
+// trace_id/span_id 不作为指标维度 避免维度基数无限增长 明细由 spanLogs 以日志形式输出
+// 没有任何 span 信息时返回 nil
+func linkSpans(record *define.Record, pd *define.ProfilesData, metricName string) *define.Record {
+	pd.Spans = make([][]define.ProfileSpan, len(pd.Profiles))
+	svc := serviceName(pd.Metadata)
+
+	type metricKey struct {
+		spanName string
+		typ      string
+		unit     string
+	}
+
+	var metrics []metricsbuilder.Metric
+	for i, p := range pd.Profiles {
+		spans := extractSpans(p)
+		pd.Spans[i] = spans
+
+		var keys []metricKey
+		values := make(map[metricKey]int64)
+		for _, span := range spans {
+			k := metricKey{spanName: span.SpanName, typ: span.Type, unit: span.Unit}
+			if _, ok := values[k]; !ok {
+				keys = append(keys, k)
+			}
+			values[k] += span.Value
+		}
+
+		ts := pcommon.Timestamp(p.TimeNanos)
+		for _, k := range keys {
+			dims := map[string]string{
+				keyType: k.typ,
+				keyUnit: k.unit,
+			}
+			if k.spanName != "" {
+				dims[labelSpanName] = k.spanName
+			}
+			if svc != "" {
+				dims[keyServiceName] = svc
+			}
+			metrics = append(metrics, metricsbuilder.Metric{
+				Val:        float64(values[k]),
+				Ts:         ts,
+				Dimensions: dims,
+			})
+		}
+	}
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	mb := metricsbuilder.New()
+	mb.Build(metricName, metrics...)
+	return &define.Record{
+		RecordType:  define.RecordMetricsDerived,
+		RequestType: define.RequestDerived,
+		Token:       record.Token,
+		Data:        mb.Get(),
+	}
+}
+
+// spanLogs 将各 span 的样本值输出为日志 每个 span 一条记录
+// trace_id/span_id 写入 attributes 合法时同时写入日志的 TraceID/SpanID 字段 用于从 Span 定位火焰图
+// 没有任何 span 信息时返回 nil
+func spanLogs(record *define.Record, pd *define.ProfilesData) *define.Record {
+	logs := plog.NewLogs()
+	rl := logs.ResourceLogs().AppendEmpty()
+	if svc := serviceName(pd.Metadata); svc != "" {
+		rl.Resource().Attributes().UpsertString(keyServiceName, svc)
+	}
+	lrs := rl.ScopeLogs().AppendEmpty().LogRecords()
+
+	for i, spans := range pd.Spans {
+		ts := pcommon.Timestamp(pd.Profiles[i].TimeNanos)
+		for _, span := range spans {
+			lr := lrs.AppendEmpty()
+			lr.SetTimestamp(ts)
+			lr.Body().SetStringVal(span.SpanName)
+			if traceID, ok := decodeTraceID(span.TraceID); ok {
+				lr.SetTraceID(traceID)
+			}
+			if spanID, ok := decodeSpanID(span.SpanID); ok {
+				lr.SetSpanID(spanID)
+			}
+
+			attrs := lr.Attributes()
+			attrs.UpsertString(labelTraceID, span.TraceID)
+			attrs.UpsertString(labelSpanID, span.SpanID)
+			attrs.UpsertString(labelSpanName, span.SpanName)
+			attrs.UpsertString(keyType, span.Type)
+			attrs.UpsertString(keyUnit, span.Unit)
+			attrs.UpsertInt(keyValue, span.Value)
+		}
+	}
+	if lrs.Len() == 0 {
+		return nil
+	}
+
+	return &define.Record{
+		RecordType:  define.RecordLogs,
+		RequestType: define.RequestDerived,
+		Token:       record.Token,
+		Data:        logs,
+	}
+}
+
+func decodeTraceID(s string) (pcommon.TraceID, bool) {
+	var id [16]byte
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(id) {
+		return pcommon.NewTraceID(id), false
+	}
+	copy(id[:], b)
+	return pcommon.NewTraceID(id), true
+}
+
+func decodeSpanID(s string) (pcommon.SpanID, bool) {
+	var id [8]byte
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(id) {
+		return pcommon.NewSpanID(id), false
+	}
+	copy(id[:], b)
+	return pcommon.NewSpanID(id), true
+}
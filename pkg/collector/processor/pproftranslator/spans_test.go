@@ -0,0 +1,160 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package pproftranslator
+
+import (
+	"testing"
+
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+)
+
+func newSpanProfile() *profile.Profile {
+	label := func(traceID, spanID, spanName string) map[string][]string {
+		m := map[string][]string{"thread": {"main"}}
+		if spanID != "" {
+			m[labelTraceID] = []string{traceID}
+			m[labelSpanID] = []string{spanID}
+			m[labelSpanName] = []string{spanName}
+		}
+		return m
+	}
+
+	return &profile.Profile{
+		TimeNanos: 1700000000000000000,
+		SampleType: []*profile.ValueType{
+			{Type: "samples", Unit: "count"},
+			{Type: "cpu", Unit: "nanoseconds"},
+		},
+		Sample: []*profile.Sample{
+			{Value: []int64{1, 10}, Label: label("t1", "s1", "GET /api")},
+			{Value: []int64{2, 20}, Label: label("t1", "s2", "SELECT")},
+			{Value: []int64{3, 30}, Label: label("t1", "s1", "GET /api")},
+			{Value: []int64{4, 40}, Label: label("", "", "")},
+		},
+	}
+}
+
+func TestExtractSpans(t *testing.T) {
+	p := newSpanProfile()
+	assert.Equal(t, []define.ProfileSpan{
+		{TraceID: "t1", SpanID: "s1", SpanName: "GET /api", Type: "cpu", Unit: "nanoseconds", Value: 40},
+		{TraceID: "t1", SpanID: "s2", SpanName: "SELECT", Type: "cpu", Unit: "nanoseconds", Value: 20},
+	}, extractSpans(p))
+
+	p.DefaultSampleType = "samples"
+	spans := extractSpans(p)
+	assert.Equal(t, "samples", spans[0].Type)
+	assert.Equal(t, int64(4), spans[0].Value)
+
+	assert.Nil(t, extractSpans(&profile.Profile{}))
+}
+
+func TestLinkSpans(t *testing.T) {
+	pd := &define.ProfilesData{
+		Profiles: []*profile.Profile{newSpanProfile(), {SampleType: []*profile.ValueType{{Type: "samples", Unit: "count"}}}},
+		Metadata: define.ProfileMetadata{AppName: "app", Tags: map[string]string{"service_name": "svc"}},
+	}
+	record := &define.Record{RecordType: define.RecordProfiles, Token: define.Token{Original: "token1"}}
+
+	r := linkSpans(record, pd, defaultSpanMetricName)
+	assert.Len(t, pd.Spans, 2)
+	assert.Len(t, pd.Spans[0], 2)
+	assert.Empty(t, pd.Spans[1])
+
+	assert.Equal(t, define.RecordMetricsDerived, r.RecordType)
+	assert.Equal(t, define.RequestDerived, r.RequestType)
+	assert.Equal(t, "token1", r.Token.Original)
+
+	metrics := r.Data.(pmetric.Metrics).ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	assert.Equal(t, 1, metrics.Len())
+	assert.Equal(t, defaultSpanMetricName, metrics.At(0).Name())
+
+	// 同名 span 的样本值汇总到同一个数据点 不携带 trace_id/span_id 维度
+	dps := metrics.At(0).Gauge().DataPoints()
+	assert.Equal(t, 2, dps.Len())
+	values := make(map[string]float64)
+	for i := 0; i < dps.Len(); i++ {
+		attrs := dps.At(i).Attributes().AsRaw()
+		assert.NotContains(t, attrs, labelTraceID)
+		assert.NotContains(t, attrs, labelSpanID)
+		assert.Equal(t, "svc", attrs["service_name"])
+		assert.Equal(t, "cpu", attrs["type"])
+		assert.Equal(t, "nanoseconds", attrs["unit"])
+		values[attrs["span_name"].(string)] = dps.At(i).DoubleVal()
+	}
+	assert.Equal(t, map[string]float64{"GET /api": 40, "SELECT": 20}, values)
+}
+
+func TestLinkSpansAggregate(t *testing.T) {
+	p := newSpanProfile()
+	p.Sample = append(p.Sample, &profile.Sample{
+		Value: []int64{5, 50},
+		Label: map[string][]string{labelTraceID: {"t2"}, labelSpanID: {"s3"}, labelSpanName: {"SELECT"}},
+	})
+	pd := &define.ProfilesData{Profiles: []*profile.Profile{p}}
+
+	r := linkSpans(&define.Record{}, pd, defaultSpanMetricName)
+	assert.Len(t, pd.Spans[0], 3)
+
+	dps := r.Data.(pmetric.Metrics).ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Gauge().DataPoints()
+	assert.Equal(t, 2, dps.Len())
+	for i := 0; i < dps.Len(); i++ {
+		if dps.At(i).Attributes().AsRaw()["span_name"] == "SELECT" {
+			assert.Equal(t, float64(70), dps.At(i).DoubleVal())
+		}
+	}
+}
+
+func TestSpanLogs(t *testing.T) {
+	p := newSpanProfile()
+	p.Sample[0].Label[labelTraceID] = []string{"0102030405060708090a0b0c0d0e0f10"}
+	p.Sample[0].Label[labelSpanID] = []string{"0102030405060708"}
+	pd := &define.ProfilesData{
+		Profiles: []*profile.Profile{p},
+		Metadata: define.ProfileMetadata{AppName: "app"},
+	}
+	record := &define.Record{RecordType: define.RecordProfiles, Token: define.Token{Original: "token1", LogsDataId: 1001}}
+	linkSpans(record, pd, defaultSpanMetricName)
+
+	r := spanLogs(record, pd)
+	assert.Equal(t, define.RecordLogs, r.RecordType)
+	assert.Equal(t, int32(1001), r.Token.LogsDataId)
+
+	rl := r.Data.(plog.Logs).ResourceLogs().At(0)
+	assert.Equal(t, map[string]interface{}{"service_name": "app"}, rl.Resource().Attributes().AsRaw())
+
+	// 每个 span 一条日志 保留 trace_id/span_id 与样本值的对应关系
+	lrs := rl.ScopeLogs().At(0).LogRecords()
+	assert.Equal(t, 3, lrs.Len())
+	values := make(map[string]interface{})
+	for i := 0; i < lrs.Len(); i++ {
+		lr := lrs.At(i)
+		attrs := lr.Attributes().AsRaw()
+		assert.Equal(t, "cpu", attrs["type"])
+		assert.Equal(t, pcommon.Timestamp(p.TimeNanos), lr.Timestamp())
+		values[attrs["span_id"].(string)] = attrs["value"]
+
+		if attrs["span_id"] == "0102030405060708" {
+			assert.Equal(t, "0102030405060708090a0b0c0d0e0f10", lr.TraceID().HexString())
+			assert.Equal(t, "0102030405060708", lr.SpanID().HexString())
+		} else {
+			assert.True(t, lr.SpanID().IsEmpty())
+		}
+	}
+	assert.Equal(t, map[string]interface{}{"0102030405060708": int64(10), "s1": int64(30), "s2": int64(20)}, values)
+
+	assert.Nil(t, spanLogs(record, &define.ProfilesData{}))
+}
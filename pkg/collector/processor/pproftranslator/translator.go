@@ -16,6 +16,8 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/pproftranslator/collapsed"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/pproftranslator/gotrace"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/pproftranslator/jfr"
 )
 
@@ -55,6 +57,22 @@ func (d *DefaultTranslator) Translate(pd define.ProfilesRawData) (*define.Profil
 	return &define.ProfilesData{Metadata: meta, Profiles: []*profile.Profile{pp}}, nil
 }
 
+// OtlpTranslator OTLP profiles 在接收时已经转换为 pprof 格式 此处仅做校验
+type OtlpTranslator struct{}
+
+func (o *OtlpTranslator) Translate(pd define.ProfilesRawData) (*define.ProfilesData, error) {
+	meta := pd.Metadata
+	origin, ok := pd.Data.(define.ProfileOtlpFormatOrigin)
+	if !ok {
+		return nil, errors.Errorf("skip invalid profile dataType(%T), app: %d-%s", pd.Data, meta.BkBizID, meta.AppName)
+	}
+	if origin.Profile == nil {
+		return nil, errors.Errorf("skip empty profile data, app: %d-%s", meta.BkBizID, meta.AppName)
+	}
+
+	return &define.ProfilesData{Metadata: meta, Profiles: []*profile.Profile{origin.Profile}}, nil
+}
+
 type spyNameTranslator struct{}
 
 func (s *spyNameTranslator) Translate(r define.ProfilesRawData) (*define.ProfilesData, error) {
@@ -62,6 +80,15 @@ func (s *spyNameTranslator) Translate(r define.ProfilesRawData) (*define.Profile
 	case define.FormatJFR:
 		translator := jfr.Translator{}
 		return translator.Translate(r)
+	case define.FormatCollapsed:
+		translator := collapsed.Translator{}
+		return translator.Translate(r)
+	case define.FormatGoTrace:
+		translator := gotrace.Translator{}
+		return translator.Translate(r)
+	case define.FormatOTLP:
+		translator := OtlpTranslator{}
+		return translator.Translate(r)
 	default:
 		translator := DefaultTranslator{}
 		return translator.Translate(r)
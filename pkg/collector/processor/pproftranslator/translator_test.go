@@ -95,6 +95,49 @@ func TestDefaultTranslator(t *testing.T) {
 	})
 }
 
+func TestOtlpTranslator(t *testing.T) {
+	o := &OtlpTranslator{}
+
+	_, err := o.Translate(define.ProfilesRawData{Data: define.ProfilePprofFormatOrigin("any")})
+	assert.Error(t, err)
+
+	_, err = o.Translate(define.ProfilesRawData{Data: define.ProfileOtlpFormatOrigin{}})
+	assert.Error(t, err)
+
+	p := &profile.Profile{SampleType: []*profile.ValueType{{Type: "samples", Unit: "count"}}}
+	profilesData, err := o.Translate(define.ProfilesRawData{Data: define.ProfileOtlpFormatOrigin{Profile: p}})
+	assert.NoError(t, err)
+	assert.Equal(t, []*profile.Profile{p}, profilesData.Profiles)
+}
+
+func TestSpyNameTranslatorFormats(t *testing.T) {
+	s := &spyNameTranslator{}
+	tests := []struct {
+		format string
+		data   any
+	}{
+		{format: define.FormatCollapsed, data: define.ProfileCollapsedFormatOrigin("main;foo 1")},
+		{format: define.FormatOTLP, data: define.ProfileOtlpFormatOrigin{Profile: &profile.Profile{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			pd, err := s.Translate(define.ProfilesRawData{
+				Metadata: define.ProfileMetadata{Format: tt.format},
+				Data:     tt.data,
+			})
+			assert.NoError(t, err)
+			assert.Len(t, pd.Profiles, 1)
+		})
+	}
+
+	_, err := s.Translate(define.ProfilesRawData{
+		Metadata: define.ProfileMetadata{Format: define.FormatGoTrace},
+		Data:     define.ProfileGoTraceFormatOrigin("invalid"),
+	})
+	assert.Error(t, err)
+}
+
 func TestSpyNameTranslator(t *testing.T) {
 	c := Config{Type: "spy"}
 	entry := NewPprofTranslator(c)
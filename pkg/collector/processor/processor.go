@@ -165,6 +165,14 @@ func NewInstance(id string, processor Processor) Instance {
 	return instance{id: id, Processor: processor}
 }
 
+// Unwrap 返回实例包装的 Processor 用于访问具体 Processor 的扩展方法
+func Unwrap(inst Instance) Processor {
+	if v, ok := inst.(instance); ok {
+		return v.Processor
+	}
+	return inst
+}
+
 var processorsMap = map[string]CreateFunc{}
 
 func register(name string, createFunc CreateFunc) error {
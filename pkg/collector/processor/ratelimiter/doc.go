@@ -18,6 +18,25 @@ processor:
       qps: 5
       burst: 10
 
+  # 令牌桶限流器 同时限制每秒接收的字节数
+  - name: "rate_limiter/token_bucket_bytes"
+    config:
+      type: token_bucket
+      qps: 5
+      burst: 10
+      bytes_per_second: 10485760
+      bytes_burst: 20971520
+
+  # 集群限流器 每个 token 独立限流 qps/bytes_per_second 为集群总配额
+  # 需要在 cluster 配置中指定 peers 各节点按消耗占比分配配额
+  # 配额通过 cluster 的 gRPC Forward 接口以明文交换且不做认证 cluster 端口只能在可信网络内访问
+  - name: "rate_limiter/distributed"
+    config:
+      type: distributed
+      qps: 1000
+      burst: 2000
+      bytes_per_second: 104857600
+
   # 不做限制
   - name: "rate_limiter/noop"
     config:
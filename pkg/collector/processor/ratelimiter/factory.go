@@ -10,7 +10,11 @@
 package ratelimiter
 
 import (
+	"sync"
+	"time"
+
 	"github.com/pkg/errors"
+	"go.uber.org/atomic"
 
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
@@ -43,18 +47,35 @@ func newFactory(conf map[string]interface{}, customized []processor.SubConfigPro
 			logger.Errorf("failed to decode config: %v", err)
 			continue
 		}
-		rateLimiters.Set(custom.Token, custom.Type, custom.ID, ratelimiter.New(cfg))
+		rateLimiters.Set(custom.Token, custom.Type, custom.ID, ratelimiter.NewWithKey(cfg, custom.Token))
 	}
 
 	return &rateLimiter{
 		CommonProcessor: processor.NewCommonProcessor(conf, customized),
 		rateLimiters:    rateLimiters,
+		globalConfig:    c,
+		tokenLimiters:   make(map[string]*tokenLimiter),
+		evictedAt:       time.Now(),
 	}, nil
 }
 
+// tokenIdleTimeout 超过该时长没有请求的 token 限流器会被清理
+const tokenIdleTimeout = 10 * time.Minute
+
+type tokenLimiter struct {
+	ratelimiter.RateLimiter
+	accessed *atomic.Int64 // unix 秒
+}
+
 type rateLimiter struct {
 	processor.CommonProcessor
 	rateLimiters *confengine.TierConfig // type ratelimiter.RateLimiter
+
+	// distributed 类型的全局配置按 token 独立限流 其余类型所有 token 共享同一个限流器
+	globalConfig  ratelimiter.Config
+	mut           sync.RWMutex
+	tokenLimiters map[string]*tokenLimiter
+	evictedAt     time.Time
 }
 
 func (p *rateLimiter) Name() string {
@@ -76,13 +97,78 @@ func (p *rateLimiter) Reload(config map[string]interface{}, customized []process
 		return
 	}
 
+	p.mut.Lock()
+	for _, rl := range p.tokenLimiters {
+		rl.Stop()
+	}
+	p.tokenLimiters = f.tokenLimiters
+	p.globalConfig = f.globalConfig
+	p.mut.Unlock()
+
+	// newFactory 总是创建新的限流器 旧限流器需要停止 否则 distributed 限流器会一直残留在配额同步中
+	prev := p.rateLimiters
 	p.CommonProcessor = f.CommonProcessor
 	p.rateLimiters = f.rateLimiters
+	for _, obj := range prev.All() {
+		obj.(ratelimiter.RateLimiter).Stop()
+	}
+}
+
+func (p *rateLimiter) getRateLimiter(token string) ratelimiter.RateLimiter {
+	rl := p.rateLimiters.GetByToken(token).(ratelimiter.RateLimiter)
+	if rl != p.rateLimiters.GetGlobal() || rl.Type() != ratelimiter.TypeDistributed {
+		return rl
+	}
+
+	now := time.Now()
+	p.mut.RLock()
+	tokenRl, ok := p.tokenLimiters[token]
+	p.mut.RUnlock()
+	if ok {
+		tokenRl.accessed.Store(now.Unix())
+		return tokenRl.RateLimiter
+	}
+
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	if tokenRl, ok = p.tokenLimiters[token]; ok {
+		tokenRl.accessed.Store(now.Unix())
+		return tokenRl.RateLimiter
+	}
+
+	// 仅在新增 token 时清理 避免 token 不断变化时 map 无限增长
+	if now.Sub(p.evictedAt) >= tokenIdleTimeout {
+		p.evictIdle(now)
+	}
+	tokenRl = &tokenLimiter{
+		RateLimiter: ratelimiter.NewWithKey(p.globalConfig, token),
+		accessed:    atomic.NewInt64(now.Unix()),
+	}
+	p.tokenLimiters[token] = tokenRl
+	return tokenRl.RateLimiter
+}
+
+// evictIdle 停止并清理空闲的 token 限流器 调用方需持有写锁
+func (p *rateLimiter) evictIdle(now time.Time) {
+	p.evictedAt = now
+	for token, tokenRl := range p.tokenLimiters {
+		if now.Sub(time.Unix(tokenRl.accessed.Load(), 0)) < tokenIdleTimeout {
+			continue
+		}
+		tokenRl.Stop()
+		delete(p.tokenLimiters, token)
+		logger.Debugf("ratelimiter: evict idle token [%s]", token)
+	}
+}
+
+// ConsumeBytes 记录 token 已接收的字节数 用于 bytes 限流
+func (p *rateLimiter) ConsumeBytes(token string, n int) {
+	p.getRateLimiter(token).ConsumeBytes(n)
 }
 
 func (p *rateLimiter) Process(record *define.Record) (*define.Record, error) {
 	token := record.Token.Original
-	rl := p.rateLimiters.GetByToken(token).(ratelimiter.RateLimiter)
+	rl := p.getRateLimiter(token)
 	logger.Debugf("ratelimiter: token [%s] max qps allowed: %f", token, rl.QPS())
 	if !rl.TryAccept() {
 		return nil, errors.Errorf("ratelimiter rejected the request, token [%s] max qps allowed: %f", token, rl.QPS())
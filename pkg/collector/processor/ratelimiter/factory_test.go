@@ -11,6 +11,7 @@ package ratelimiter
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -60,8 +61,24 @@ processor:
 	assert.False(t, factory.IsDerived())
 	assert.True(t, factory.IsPreCheck())
 
+	prev := &stopRecorder{RateLimiter: factory.rateLimiters.GetByToken("token1").(ratelimiter.RateLimiter)}
+	factory.rateLimiters.Set("token1", define.SubConfigFieldDefault, "", prev)
+
 	factory.Reload(mainConf, nil)
 	assert.Equal(t, mainConf, factory.MainConfig())
+
+	// 重载后旧的自定义限流器需要被停止
+	assert.True(t, prev.stopped)
+}
+
+type stopRecorder struct {
+	ratelimiter.RateLimiter
+	stopped bool
+}
+
+func (r *stopRecorder) Stop() {
+	r.stopped = true
+	r.RateLimiter.Stop()
 }
 
 func TestNormalProcess(t *testing.T) {
@@ -106,3 +123,45 @@ processor:
 	_, err := factory.Process(&define.Record{Token: define.Token{Original: "fortest"}})
 	assert.Error(t, err)
 }
+
+func TestDistributedProcess(t *testing.T) {
+	content := `
+processor:
+  - name: "rate_limiter/distributed"
+    config:
+      type: distributed
+      qps: 2
+      burst: 2
+      bytes_per_second: 100
+`
+	obj := processor.MustCreateFactory(content, NewFactory)
+	factory := obj.(*rateLimiter)
+
+	// 每个 token 独立限流
+	for _, token := range []string{"token1", "token2"} {
+		for i := 0; i < 2; i++ {
+			_, err := factory.Process(&define.Record{Token: define.Token{Original: token}})
+			assert.NoError(t, err)
+		}
+		_, err := factory.Process(&define.Record{Token: define.Token{Original: token}})
+		assert.Error(t, err)
+	}
+	assert.Len(t, factory.tokenLimiters, 2)
+	assert.Equal(t, ratelimiter.TypeDistributed, factory.getRateLimiter("token1").Type())
+
+	// bytes 透支后拒绝请求
+	factory.ConsumeBytes("token3", 1000)
+	_, err := factory.Process(&define.Record{Token: define.Token{Original: "token3"}})
+	assert.Error(t, err)
+
+	// 空闲的 token 在新增 token 时被清理
+	factory.tokenLimiters["token1"].accessed.Store(time.Now().Add(-tokenIdleTimeout).Unix())
+	factory.evictedAt = time.Now().Add(-tokenIdleTimeout)
+	factory.getRateLimiter("token4")
+	assert.NotContains(t, factory.tokenLimiters, "token1")
+	assert.Contains(t, factory.tokenLimiters, "token2")
+	assert.Contains(t, factory.tokenLimiters, "token4")
+
+	factory.Reload(factory.MainConfig(), nil)
+	assert.Len(t, factory.tokenLimiters, 0)
+}
@@ -0,0 +1,190 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package redactor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+const (
+	ActionMask = "mask"
+	ActionHash = "hash"
+
+	defaultPlaceholder = "***"
+	hashPrefix         = "sha256:"
+	hashLength         = 16
+
+	ruleDenyKey = "deny_key"
+	ruleKeyword = "keyword"
+)
+
+type Config struct {
+	// AllowKeys 白名单 命中的 key 不做任何处理
+	AllowKeys []string `config:"allow_keys" mapstructure:"allow_keys"`
+	// DenyKeys 黑名单 命中的 key 整体替换
+	DenyKeys []string `config:"deny_keys" mapstructure:"deny_keys"`
+	// Keywords key 包含关键字（忽略大小写）时整体替换
+	Keywords []string `config:"keywords" mapstructure:"keywords"`
+	// Patterns 替换字符串值中匹配的部分
+	Patterns []PatternConfig `config:"patterns" mapstructure:"patterns"`
+
+	Action      string `config:"action" mapstructure:"action"`
+	Placeholder string `config:"placeholder" mapstructure:"placeholder"`
+	HashSalt    string `config:"hash_salt" mapstructure:"hash_salt"`
+
+	allowKeys map[string]struct{}
+	denyKeys  map[string]struct{}
+	keywords  []string
+	patterns  []pattern
+}
+
+type PatternConfig struct {
+	Name   string `config:"name" mapstructure:"name"`
+	Regex  string `config:"regex" mapstructure:"regex"`
+	Action string `config:"action" mapstructure:"action"` // 为空时使用全局 action
+}
+
+type pattern struct {
+	name   string
+	action string
+	re     *regexp.Regexp
+}
+
+func normalizeAction(action string) string {
+	if action == ActionHash {
+		return ActionHash
+	}
+	return ActionMask
+}
+
+// Setup 补全默认动作及占位符并编译正则 正则编译失败时返回错误
+func (c *Config) Setup() error {
+	c.Action = normalizeAction(c.Action)
+	if c.Placeholder == "" {
+		c.Placeholder = defaultPlaceholder
+	}
+
+	c.allowKeys = make(map[string]struct{})
+	for _, k := range c.AllowKeys {
+		c.allowKeys[k] = struct{}{}
+	}
+	c.denyKeys = make(map[string]struct{})
+	for _, k := range c.DenyKeys {
+		c.denyKeys[k] = struct{}{}
+	}
+	c.keywords = make([]string, 0, len(c.Keywords))
+	for _, k := range c.Keywords {
+		if k != "" {
+			c.keywords = append(c.keywords, strings.ToLower(k))
+		}
+	}
+
+	c.patterns = make([]pattern, 0, len(c.Patterns))
+	for i, p := range c.Patterns {
+		re, err := regexp.Compile(p.Regex)
+		if err != nil {
+			return errors.Wrapf(err, "compile redactor pattern '%s'", p.Regex)
+		}
+		name := p.Name
+		if name == "" {
+			name = "pattern_" + strconv.Itoa(i)
+		}
+		action := c.Action
+		if p.Action != "" {
+			action = normalizeAction(p.Action)
+		}
+		c.patterns = append(c.patterns, pattern{name: name, action: action, re: re})
+	}
+	return nil
+}
+
+func (c *Config) Enabled() bool {
+	return len(c.denyKeys) > 0 || len(c.keywords) > 0 || len(c.patterns) > 0
+}
+
+func (c *Config) replace(s, action string) string {
+	if action == ActionHash {
+		sum := sha256.Sum256([]byte(c.HashSalt + s))
+		return hashPrefix + hex.EncodeToString(sum[:])[:hashLength]
+	}
+	return c.Placeholder
+}
+
+// matchKey 判断 key 是否需要整体替换 返回命中的规则
+func (c *Config) matchKey(key string) (string, bool) {
+	if _, ok := c.denyKeys[key]; ok {
+		return ruleDenyKey, true
+	}
+	if len(c.keywords) == 0 {
+		return "", false
+	}
+	lower := strings.ToLower(key)
+	for _, kw := range c.keywords {
+		if strings.Contains(lower, kw) {
+			return ruleKeyword, true
+		}
+	}
+	return "", false
+}
+
+// redactString 使用 patterns 替换字符串中的敏感内容 每个命中的规则回调一次 report
+func (c *Config) redactString(s string, report func(rule string)) (string, bool) {
+	var changed bool
+	for _, p := range c.patterns {
+		if !p.re.MatchString(s) {
+			continue
+		}
+		s = p.re.ReplaceAllStringFunc(s, func(m string) string {
+			return c.replace(m, p.action)
+		})
+		changed = true
+		report(p.name)
+	}
+	return s, changed
+}
+
+// redactValue 处理字符串值以及嵌套的 map/slice
+func (c *Config) redactValue(v pcommon.Value, report func(rule string)) {
+	switch v.Type() {
+	case pcommon.ValueTypeString:
+		if s, ok := c.redactString(v.StringVal(), report); ok {
+			v.SetStringVal(s)
+		}
+	case pcommon.ValueTypeMap:
+		c.redactAttrs(v.MapVal(), report)
+	case pcommon.ValueTypeSlice:
+		slice := v.SliceVal()
+		for i := 0; i < slice.Len(); i++ {
+			c.redactValue(slice.At(i), report)
+		}
+	}
+}
+
+func (c *Config) redactAttrs(attrs pcommon.Map, report func(rule string)) {
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		if _, ok := c.allowKeys[k]; ok {
+			return true
+		}
+		if rule, ok := c.matchKey(k); ok {
+			v.SetStringVal(c.replace(v.AsString(), c.Action))
+			report(rule)
+			return true
+		}
+		c.redactValue(v, report)
+		return true
+	})
+}
@@ -0,0 +1,41 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+/*
+# Redactor: 敏感数据脱敏处理器
+
+作用于 resource attributes、span（含 events/links）的 attributes 以及日志的 attributes/body
+任一 pattern 编译失败时加载（或重载）配置失败 重载时保留旧配置
+处理优先级: allow_keys > deny_keys/keywords（整体替换）> patterns（替换匹配部分）
+替换方式:
+- mask: 使用 placeholder 替换
+- hash: 使用加盐后的 sha256 摘要替换 保留可关联性 格式为 `sha256:<16位hex>`
+
+processor:
+    - name: "redactor/common"
+      config:
+        action: "mask" # mask|hash
+        placeholder: "***"
+        hash_salt: ""
+        allow_keys:
+          - "http.method"
+        deny_keys:
+          - "http.request.header.authorization"
+        keywords:
+          - "password"
+          - "token"
+        patterns:
+          - name: "card_number"
+            regex: "\\b\\d{13,19}\\b"
+          - name: "phone_number"
+            regex: "\\b1[3-9]\\d{9}\\b"
+            action: "hash" # 可选 覆盖全局 action
+*/
+
+package redactor
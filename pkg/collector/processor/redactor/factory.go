@@ -0,0 +1,154 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package redactor
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/foreach"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/mapstructure"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+var redactedFieldsTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: define.MonitoringNamespace,
+		Name:      "redactor_redacted_fields_total",
+		Help:      "Redactor redacted fields total",
+	},
+	[]string{"record_type", "id", "rule"},
+)
+
+func init() {
+	processor.Register(define.ProcessorRedactor, NewFactory)
+}
+
+func NewFactory(conf map[string]interface{}, customized []processor.SubConfigProcessor) (processor.Processor, error) {
+	return newFactory(conf, customized)
+}
+
+func newFactory(conf map[string]interface{}, customized []processor.SubConfigProcessor) (*redactor, error) {
+	configs := confengine.NewTierConfig()
+
+	c := &Config{}
+	if err := mapstructure.Decode(conf, c); err != nil {
+		return nil, err
+	}
+	if err := c.Setup(); err != nil {
+		return nil, err
+	}
+	configs.SetGlobal(*c)
+
+	for _, custom := range customized {
+		cfg := &Config{}
+		if err := mapstructure.Decode(custom.Config.Config, cfg); err != nil {
+			logger.Errorf("failed to decode config: %v", err)
+			continue
+		}
+		if err := cfg.Setup(); err != nil {
+			return nil, errors.Wrapf(err, "setup config of token '%s'", custom.Token)
+		}
+		configs.Set(custom.Token, custom.Type, custom.ID, *cfg)
+	}
+
+	return &redactor{
+		CommonProcessor: processor.NewCommonProcessor(conf, customized),
+		configs:         configs,
+	}, nil
+}
+
+type redactor struct {
+	processor.CommonProcessor
+	configs *confengine.TierConfig // type: Config
+}
+
+func (p *redactor) Name() string {
+	return define.ProcessorRedactor
+}
+
+func (p *redactor) IsDerived() bool {
+	return false
+}
+
+func (p *redactor) IsPreCheck() bool {
+	return false
+}
+
+func (p *redactor) Reload(config map[string]interface{}, customized []processor.SubConfigProcessor) {
+	f, err := newFactory(config, customized)
+	if err != nil {
+		logger.Errorf("failed to reload processor: %v", err)
+		return
+	}
+
+	p.CommonProcessor = f.CommonProcessor
+	p.configs = f.configs
+}
+
+func (p *redactor) Process(record *define.Record) (*define.Record, error) {
+	config := p.configs.GetByToken(record.Token.Original).(Config)
+	if !config.Enabled() {
+		return nil, nil
+	}
+
+	// 同一条数据中按规则汇总后再上报 减少指标操作开销
+	counts := make(map[string]int)
+	report := func(rule string) {
+		counts[rule]++
+	}
+
+	switch record.RecordType {
+	case define.RecordTraces:
+		pdTraces := record.Data.(ptrace.Traces)
+		resourceSpansSlice := pdTraces.ResourceSpans()
+		for i := 0; i < resourceSpansSlice.Len(); i++ {
+			config.redactAttrs(resourceSpansSlice.At(i).Resource().Attributes(), report)
+		}
+		foreach.Spans(pdTraces.ResourceSpans(), func(span ptrace.Span) {
+			config.redactAttrs(span.Attributes(), report)
+			events := span.Events()
+			for i := 0; i < events.Len(); i++ {
+				config.redactAttrs(events.At(i).Attributes(), report)
+			}
+			links := span.Links()
+			for i := 0; i < links.Len(); i++ {
+				config.redactAttrs(links.At(i).Attributes(), report)
+			}
+		})
+
+	case define.RecordLogs:
+		pdLogs := record.Data.(plog.Logs)
+		resourceLogsSlice := pdLogs.ResourceLogs()
+		for i := 0; i < resourceLogsSlice.Len(); i++ {
+			config.redactAttrs(resourceLogsSlice.At(i).Resource().Attributes(), report)
+		}
+		foreach.Logs(pdLogs.ResourceLogs(), func(logRecord plog.LogRecord) {
+			config.redactAttrs(logRecord.Attributes(), report)
+			config.redactValue(logRecord.Body(), report)
+		})
+	}
+
+	if len(counts) > 0 {
+		dataID := strconv.Itoa(int(record.Token.GetDataID(record.RecordType)))
+		for rule, n := range counts {
+			redactedFieldsTotal.WithLabelValues(record.RecordType.S(), dataID, rule).Add(float64(n))
+		}
+	}
+	return nil, nil
+}
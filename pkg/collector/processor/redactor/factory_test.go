@@ -0,0 +1,226 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package redactor
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/generator"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/testkits"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor"
+)
+
+const testContent = `
+processor:
+  - name: "redactor/common"
+    config:
+      allow_keys:
+        - "http.method"
+      deny_keys:
+        - "http.request.header.authorization"
+      keywords:
+        - "Password"
+      patterns:
+        - name: "card_number"
+          regex: "\\b\\d{16}\\b"
+        - name: "phone_number"
+          regex: "\\b1[3-9]\\d{9}\\b"
+          action: "hash"
+`
+
+func TestFactory(t *testing.T) {
+	mainConf := processor.MustLoadConfigs(testContent)[0].Config
+
+	customContent := `
+processor:
+  - name: "redactor/common"
+    config:
+      action: "hash"
+      deny_keys:
+        - "user.id"
+`
+	customConf := processor.MustLoadConfigs(customContent)[0].Config
+
+	obj, err := NewFactory(mainConf, []processor.SubConfigProcessor{
+		{
+			Token: "token1",
+			Type:  define.SubConfigFieldDefault,
+			Config: processor.Config{
+				Config: customConf,
+			},
+		},
+	})
+	factory := obj.(*redactor)
+	assert.NoError(t, err)
+	assert.Equal(t, mainConf, factory.MainConfig())
+
+	c1 := factory.configs.GetGlobal().(Config)
+	assert.Equal(t, ActionMask, c1.Action)
+	assert.Equal(t, defaultPlaceholder, c1.Placeholder)
+	assert.Len(t, c1.patterns, 2)
+	assert.Equal(t, ActionHash, c1.patterns[1].action)
+
+	c2 := factory.configs.GetByToken("token1").(Config)
+	assert.Equal(t, ActionHash, c2.Action)
+	assert.Equal(t, []string{"user.id"}, c2.DenyKeys)
+
+	assert.Equal(t, define.ProcessorRedactor, factory.Name())
+	assert.False(t, factory.IsDerived())
+	assert.False(t, factory.IsPreCheck())
+
+	factory.Reload(mainConf, nil)
+	assert.Equal(t, mainConf, factory.MainConfig())
+}
+
+func TestInvalidPattern(t *testing.T) {
+	c := Config{Patterns: []PatternConfig{{Regex: "("}, {Regex: "\\d+"}}}
+	assert.Error(t, c.Setup())
+
+	c = Config{Patterns: []PatternConfig{{Regex: "\\d+"}}}
+	assert.NoError(t, c.Setup())
+	assert.Equal(t, "pattern_0", c.patterns[0].name)
+	assert.True(t, c.Enabled())
+
+	empty := Config{AllowKeys: []string{"a"}}
+	assert.NoError(t, empty.Setup())
+	assert.False(t, empty.Enabled())
+
+	invalidContent := `
+processor:
+  - name: "redactor/common"
+    config:
+      patterns:
+        - regex: "("
+`
+	invalidConf := processor.MustLoadConfigs(invalidContent)[0].Config
+	_, err := NewFactory(invalidConf, nil)
+	assert.Error(t, err)
+
+	_, err = NewFactory(nil, []processor.SubConfigProcessor{
+		{
+			Token:  "token1",
+			Type:   define.SubConfigFieldDefault,
+			Config: processor.Config{Config: invalidConf},
+		},
+	})
+	assert.Error(t, err)
+
+	// 重载失败时保留旧配置
+	factory := processor.MustCreateFactory(testContent, NewFactory).(*redactor)
+	factory.Reload(invalidConf, nil)
+	assert.Len(t, factory.configs.GetGlobal().(Config).patterns, 2)
+}
+
+func TestHashReplace(t *testing.T) {
+	c := Config{Action: ActionHash, HashSalt: "salt"}
+	assert.NoError(t, c.Setup())
+
+	h1 := c.replace("13800000000", ActionHash)
+	h2 := c.replace("13800000000", ActionHash)
+	assert.Equal(t, h1, h2)
+	assert.Len(t, h1, len(hashPrefix)+hashLength)
+	assert.NotEqual(t, h1, c.replace("13900000000", ActionHash))
+}
+
+func TestTracesRedact(t *testing.T) {
+	factory := processor.MustCreateFactory(testContent, NewFactory)
+
+	g := generator.NewTracesGenerator(define.TracesOptions{
+		GeneratorOptions: define.GeneratorOptions{
+			Attributes: map[string]string{
+				"http.method":                       "GET 1234567812345678",
+				"http.request.header.authorization": "Bearer abc",
+				"db.password":                       "123456",
+				"db.statement":                      "SELECT * FROM card WHERE no='1234567812345678' AND phone='13800000000'",
+			},
+		},
+		SpanCount: 1,
+	})
+	record := &define.Record{
+		RecordType: define.RecordTraces,
+		Data:       g.Generate(),
+		Token:      define.Token{TracesDataId: 1001},
+	}
+
+	_, err := factory.Process(record)
+	assert.NoError(t, err)
+
+	attrs := testkits.FirstSpan(record.Data.(ptrace.Traces)).Attributes()
+	testkits.AssertAttrsFoundStringVal(t, attrs, "http.method", "GET 1234567812345678")
+	testkits.AssertAttrsFoundStringVal(t, attrs, "http.request.header.authorization", "***")
+	testkits.AssertAttrsFoundStringVal(t, attrs, "db.password", "***")
+
+	v, ok := attrs.Get("db.statement")
+	assert.True(t, ok)
+	assert.Regexp(t, `^SELECT \* FROM card WHERE no='\*\*\*' AND phone='sha256:[0-9a-f]{16}'$`, v.StringVal())
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(redactedFieldsTotal.WithLabelValues("traces", "1001", "card_number")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(redactedFieldsTotal.WithLabelValues("traces", "1001", "keyword")))
+}
+
+func TestTracesEventsAndLinksRedact(t *testing.T) {
+	factory := processor.MustCreateFactory(testContent, NewFactory)
+
+	traces := ptrace.NewTraces()
+	span := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.Events().AppendEmpty().Attributes().UpsertString("db.password", "123456")
+	span.Links().AppendEmpty().Attributes().UpsertString("http.request.header.authorization", "Bearer abc")
+
+	record := &define.Record{
+		RecordType: define.RecordTraces,
+		Data:       traces,
+		Token:      define.Token{TracesDataId: 1002},
+	}
+
+	_, err := factory.Process(record)
+	assert.NoError(t, err)
+
+	span = testkits.FirstSpan(record.Data.(ptrace.Traces))
+	testkits.AssertAttrsFoundStringVal(t, span.Events().At(0).Attributes(), "db.password", "***")
+	testkits.AssertAttrsFoundStringVal(t, span.Links().At(0).Attributes(), "http.request.header.authorization", "***")
+	assert.Equal(t, float64(1), testutil.ToFloat64(redactedFieldsTotal.WithLabelValues("traces", "1002", ruleKeyword)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(redactedFieldsTotal.WithLabelValues("traces", "1002", ruleDenyKey)))
+}
+
+func TestLogsRedact(t *testing.T) {
+	factory := processor.MustCreateFactory(testContent, NewFactory)
+
+	logs := plog.NewLogs()
+	resourceLogs := logs.ResourceLogs().AppendEmpty()
+	resourceLogs.Resource().Attributes().UpsertString("db.password", "123456")
+	logRecord := resourceLogs.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	logRecord.Body().SetStringVal("pay with card 1234567812345678")
+	logRecord.Attributes().UpsertInt("user_password", 123456)
+	nested := pcommon.NewValueMap()
+	nested.MapVal().UpsertString("phone", "13800000000")
+	logRecord.Attributes().Upsert("user", nested)
+
+	record := &define.Record{
+		RecordType: define.RecordLogs,
+		Data:       logs,
+	}
+	_, err := factory.Process(record)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "pay with card ***", logRecord.Body().StringVal())
+	testkits.AssertAttrsFoundStringVal(t, resourceLogs.Resource().Attributes(), "db.password", "***")
+	testkits.AssertAttrsFoundStringVal(t, logRecord.Attributes(), "user_password", "***")
+	user, ok := logRecord.Attributes().Get("user")
+	assert.True(t, ok)
+	phone, _ := user.MapVal().Get("phone")
+	assert.Regexp(t, `^sha256:[0-9a-f]{16}$`, phone.StringVal())
+}
@@ -17,6 +17,13 @@ processor:
       type: "random"
       sampling_percentage: 100 # 采样率 [0, 100]
 
+  # 一致性概率采样（OpenTelemetry tracestate p/r 值）
+  # 兼容上游采样决策 并写入 sampling.adjusted_count 属性供 traces_deriver 还原指标
+  - name: "sampler/consistent"
+    config:
+      type: "consistent"
+      sampling_percentage: 25 # 采样率 [0, 100]
+
   # 永远采样
   - name: "sampler/always"
     config:
@@ -38,6 +45,35 @@ processor:
       max_spans: 100 # 每个 traces 最多允许的 spans 数量
       status_code: # ERROR|OK|UNSET
       - "ERROR"
+
+  # 尾部采样：缓存完整 trace 等待 decision_wait 后执行策略 任意策略命中即采样
+  # 采样的 traces 直接提交至 exporter 因此需要配置在 traces 流水线末尾
+  - name: "sampler/tail"
+    config:
+      type: "tail"
+      decision_wait: "30s" # 决策等待窗口
+      decision_ttl: "1m" # 决策结果保留时长 期间迟到的 spans 沿用原决策
+      max_traces: 50000 # 最多缓存的 traces 数量
+      policies: # 未配置策略时全部采样
+        - name: "slow"
+          type: "latency"
+          threshold: "2s"
+        - name: "errors"
+          type: "status_code"
+          status_code:
+          - "ERROR"
+        - name: "vip"
+          type: "attribute"
+          key: "user.level"
+          values: ["vip"]
+        # and/or 组合策略 子策略按顺序短路求值 rate_limiting 应放置在最后
+        - name: "baseline"
+          type: "and"
+          sub_policies:
+            - type: "attribute"
+              key: "http.method"
+            - type: "rate_limiting"
+              traces_per_second: 10 # 每个服务每秒最多采样的 traces 数量
 */
 
 package sampler
@@ -20,7 +20,7 @@ import (
 )
 
 func TestAlwaysEvaluator(t *testing.T) {
-	evaluator := New(Config{})
+	evaluator, _ := New(Config{})
 
 	g := generator.NewTracesGenerator(define.TracesOptions{
 		SpanCount: 10,
@@ -0,0 +1,197 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package evaluator
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/foreach"
+)
+
+// OpenTelemetry 一致性概率采样
+// 参见 https://opentelemetry.io/docs/specs/otel/trace/tracestate-probability-sampling/
+//
+// tracestate 中 `ot` 条目携带 p/r 两个值
+// p-value: 采样概率为 2^-p 取值 [0, 62] 63 表示概率为 0
+// r-value: 随机数中前导零的个数 取值 [0, 62] 当且仅当 p <= r 时采样
+
+const (
+	otelTraceStateKey = "ot"
+
+	maxPValue  = 62
+	zeroPValue = 63
+)
+
+type otelTraceState struct {
+	p     int // -1 表示不存在
+	r     int // -1 表示不存在
+	extra []string
+}
+
+// parseTraceState 解析 W3C tracestate 返回 ot 条目以及其余条目
+func parseTraceState(ts string) (otelTraceState, []string) {
+	ots := otelTraceState{p: -1, r: -1}
+	var members []string
+	for _, member := range strings.Split(ts, ",") {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			continue
+		}
+		kv := strings.SplitN(member, "=", 2)
+		if len(kv) != 2 || kv[0] != otelTraceStateKey {
+			members = append(members, member)
+			continue
+		}
+
+		for _, field := range strings.Split(kv[1], ";") {
+			fkv := strings.SplitN(field, ":", 2)
+			if len(fkv) != 2 {
+				continue
+			}
+			switch fkv[0] {
+			case "p":
+				if v, err := strconv.Atoi(fkv[1]); err == nil && v >= 0 && v <= zeroPValue {
+					ots.p = v
+				}
+			case "r":
+				if v, err := strconv.Atoi(fkv[1]); err == nil && v >= 0 && v <= maxPValue {
+					ots.r = v
+				}
+			default:
+				ots.extra = append(ots.extra, field)
+			}
+		}
+	}
+	return ots, members
+}
+
+// encodeTraceState 按 W3C 规范将更新过的 ot 条目放置于首位
+func encodeTraceState(ots otelTraceState, members []string) string {
+	var fields []string
+	if ots.p >= 0 {
+		fields = append(fields, "p:"+strconv.Itoa(ots.p))
+	}
+	if ots.r >= 0 {
+		fields = append(fields, "r:"+strconv.Itoa(ots.r))
+	}
+	fields = append(fields, ots.extra...)
+
+	result := make([]string, 0, len(members)+1)
+	if len(fields) > 0 {
+		result = append(result, otelTraceStateKey+"="+strings.Join(fields, ";"))
+	}
+	result = append(result, members...)
+	return strings.Join(result, ",")
+}
+
+// rValueFromTraceID 当上游未携带 r-value 时使用 traceID 低 62 位随机数计算
+func rValueFromTraceID(traceID pcommon.TraceID) int {
+	b := traceID.Bytes()
+	x := binary.BigEndian.Uint64(b[8:]) & (1<<62 - 1)
+	return bits.LeadingZeros64(x) - 2
+}
+
+// uniformFromTraceID 使用 traceID 高 64 位生成 [0, 1) 的均匀分布数值
+func uniformFromTraceID(traceID pcommon.TraceID) float64 {
+	b := traceID.Bytes()
+	return float64(binary.BigEndian.Uint64(b[:8])>>11) / (1 << 53)
+}
+
+func newConsistentEvaluator(c Config) Evaluator {
+	e := consistentEvaluator{}
+	probability := c.SamplingPercentage / 100
+	switch {
+	case probability <= 0:
+		e.pLow, e.pHigh = zeroPValue, zeroPValue
+	case probability >= 1:
+		e.pLow, e.pHigh = 0, 0
+	default:
+		// 非 2 的整数次幂概率 通过在相邻的两个 p-value 间按比例选择逼近
+		e.pLow = int(math.Floor(-math.Log2(probability)))
+		if e.pLow >= maxPValue {
+			e.pLow, e.pHigh = maxPValue, maxPValue
+			break
+		}
+		e.pHigh = e.pLow + 1
+		e.lowRatio = probability*math.Pow(2, float64(e.pHigh)) - 1
+	}
+	return e
+}
+
+// consistentEvaluator 一致性概率采样
+//
+// 与上游的 p/r 值保持一致 上游已采样的 traces 只能进一步降低采样概率
+// 采样后更新 tracestate 中的 p-value 并写入 sampling.adjusted_count 属性
+// 以便 traces_deriver 对派生指标进行还原
+type consistentEvaluator struct {
+	pLow     int
+	pHigh    int
+	lowRatio float64 // 选择 pLow 的比例
+}
+
+func (e consistentEvaluator) Type() string {
+	return evaluatorTypeConsistent
+}
+
+func (e consistentEvaluator) Stop() {}
+
+func (e consistentEvaluator) Evaluate(record *define.Record) error {
+	switch record.RecordType {
+	case define.RecordTraces:
+		e.processTraces(record.Data.(ptrace.Traces))
+	}
+	return nil
+}
+
+func (e consistentEvaluator) pValue(traceID pcommon.TraceID) int {
+	if e.pLow == e.pHigh {
+		return e.pLow
+	}
+	if uniformFromTraceID(traceID) < e.lowRatio {
+		return e.pLow
+	}
+	return e.pHigh
+}
+
+func (e consistentEvaluator) processTraces(pdTraces ptrace.Traces) {
+	foreach.SpansRemoveIf(pdTraces.ResourceSpans(), func(span ptrace.Span) bool {
+		ots, members := parseTraceState(string(span.TraceState()))
+
+		r := ots.r
+		if r < 0 {
+			r = rValueFromTraceID(span.TraceID())
+		}
+
+		p := e.pValue(span.TraceID())
+		if ots.p >= 0 {
+			// 上游 p > r 属于不一致的状态 需要擦除上游 p-value
+			if ots.p <= r && ots.p > p {
+				p = ots.p
+			}
+		}
+
+		if p > r {
+			return true
+		}
+
+		ots.p, ots.r = p, r
+		span.SetTraceState(ptrace.TraceState(encodeTraceState(ots, members)))
+		span.Attributes().UpsertDouble(define.AttributeSamplingAdjustedCount, math.Pow(2, float64(p)))
+		return false
+	})
+}
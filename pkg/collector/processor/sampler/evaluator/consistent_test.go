@@ -0,0 +1,133 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/random"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/testkits"
+)
+
+func TestTraceState(t *testing.T) {
+	tests := []struct {
+		input  string
+		p      int
+		r      int
+		output string
+	}{
+		{input: "", p: -1, r: -1, output: ""},
+		{input: "ot=p:2;r:10", p: 2, r: 10, output: "ot=p:2;r:10"},
+		{input: "vendor=foo,ot=r:3;x:y", p: -1, r: 3, output: "ot=r:3;x:y,vendor=foo"},
+		{input: "ot=p:64;r:63", p: -1, r: -1, output: ""},
+		{input: "a=1, b=2", p: -1, r: -1, output: "a=1,b=2"},
+	}
+
+	for _, tt := range tests {
+		ots, members := parseTraceState(tt.input)
+		assert.Equal(t, tt.p, ots.p)
+		assert.Equal(t, tt.r, ots.r)
+		assert.Equal(t, tt.output, encodeTraceState(ots, members))
+	}
+}
+
+func TestRValueFromTraceID(t *testing.T) {
+	assert.Equal(t, 62, rValueFromTraceID(pcommon.NewTraceID([16]byte{})))
+	assert.Equal(t, 0, rValueFromTraceID(pcommon.NewTraceID([16]byte{8: 0x3f, 9: 0xff})))
+	assert.Equal(t, 2, rValueFromTraceID(pcommon.NewTraceID([16]byte{8: 0x0f})))
+}
+
+func makeConsistentTraces(traceState string) ptrace.Traces {
+	traces := ptrace.NewTraces()
+	span := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetTraceID(random.TraceID())
+	span.SetSpanID(random.SpanID())
+	span.SetTraceState(ptrace.TraceState(traceState))
+	return traces
+}
+
+func TestConsistentEvaluator(t *testing.T) {
+	t.Run("keep all", func(t *testing.T) {
+		eval, _ := New(Config{Type: evaluatorTypeConsistent, SamplingPercentage: 100})
+		assert.Equal(t, evaluatorTypeConsistent, eval.Type())
+
+		traces := makeConsistentTraces("ot=r:5")
+		assert.NoError(t, eval.Evaluate(&define.Record{RecordType: define.RecordTraces, Data: traces}))
+		assert.Equal(t, 1, traces.SpanCount())
+
+		span := testkits.FirstSpan(traces)
+		assert.Equal(t, "ot=p:0;r:5", string(span.TraceState()))
+		v, ok := span.Attributes().Get(define.AttributeSamplingAdjustedCount)
+		assert.True(t, ok)
+		assert.Equal(t, float64(1), v.DoubleVal())
+	})
+
+	t.Run("drop all", func(t *testing.T) {
+		eval, _ := New(Config{Type: evaluatorTypeConsistent, SamplingPercentage: 0})
+		traces := makeConsistentTraces("ot=r:62")
+		assert.NoError(t, eval.Evaluate(&define.Record{RecordType: define.RecordTraces, Data: traces}))
+		assert.Equal(t, 0, traces.SpanCount())
+	})
+
+	t.Run("power of two", func(t *testing.T) {
+		eval, _ := New(Config{Type: evaluatorTypeConsistent, SamplingPercentage: 25})
+
+		traces := makeConsistentTraces("ot=r:2")
+		assert.NoError(t, eval.Evaluate(&define.Record{RecordType: define.RecordTraces, Data: traces}))
+		assert.Equal(t, 1, traces.SpanCount())
+		span := testkits.FirstSpan(traces)
+		assert.Equal(t, "ot=p:2;r:2", string(span.TraceState()))
+		v, _ := span.Attributes().Get(define.AttributeSamplingAdjustedCount)
+		assert.Equal(t, float64(4), v.DoubleVal())
+
+		traces = makeConsistentTraces("ot=r:1")
+		assert.NoError(t, eval.Evaluate(&define.Record{RecordType: define.RecordTraces, Data: traces}))
+		assert.Equal(t, 0, traces.SpanCount())
+	})
+
+	t.Run("honor upstream", func(t *testing.T) {
+		eval, _ := New(Config{Type: evaluatorTypeConsistent, SamplingPercentage: 50})
+
+		// 上游概率更低 沿用上游 p-value
+		traces := makeConsistentTraces("ot=p:3;r:4,vendor=x")
+		assert.NoError(t, eval.Evaluate(&define.Record{RecordType: define.RecordTraces, Data: traces}))
+		span := testkits.FirstSpan(traces)
+		assert.Equal(t, "ot=p:3;r:4,vendor=x", string(span.TraceState()))
+		v, _ := span.Attributes().Get(define.AttributeSamplingAdjustedCount)
+		assert.Equal(t, float64(8), v.DoubleVal())
+
+		// 上游 p > r 不一致 擦除上游 p-value
+		traces = makeConsistentTraces("ot=p:5;r:1")
+		assert.NoError(t, eval.Evaluate(&define.Record{RecordType: define.RecordTraces, Data: traces}))
+		span = testkits.FirstSpan(traces)
+		assert.Equal(t, "ot=p:1;r:1", string(span.TraceState()))
+	})
+
+	t.Run("interpolation", func(t *testing.T) {
+		eval := newConsistentEvaluator(Config{SamplingPercentage: 30}).(consistentEvaluator)
+		assert.Equal(t, 1, eval.pLow)
+		assert.Equal(t, 2, eval.pHigh)
+		assert.InDelta(t, 0.2, eval.lowRatio, 1e-9)
+
+		var kept int
+		total := 20000
+		for i := 0; i < total; i++ {
+			traces := makeConsistentTraces("")
+			_ = eval.Evaluate(&define.Record{RecordType: define.RecordTraces, Data: traces})
+			kept += traces.SpanCount()
+		}
+		assert.InDelta(t, 0.3, float64(kept)/float64(total), 0.03)
+	})
+}
@@ -19,7 +19,7 @@ import (
 
 func TestDropEvaluator(t *testing.T) {
 	t.Run("Disabled", func(t *testing.T) {
-		evaluator := New(Config{
+		evaluator, _ := New(Config{
 			Type: evaluatorTypeDrop,
 		})
 		record := &define.Record{
@@ -30,7 +30,7 @@ func TestDropEvaluator(t *testing.T) {
 	})
 
 	t.Run("Enabled", func(t *testing.T) {
-		evaluator := New(Config{
+		evaluator, _ := New(Config{
 			Type:    evaluatorTypeDrop,
 			Enabled: true,
 		})
@@ -20,13 +20,19 @@ type Config struct {
 	MaxSpan       int    `config:"max_span" mapstructure:"max_span"`
 	StoragePolicy string `config:"storage_policy" mapstructure:"storage_policy"`
 
-	// random evaluator
+	// random/consistent evaluator
 	SamplingPercentage float64 `config:"sampling_percentage" mapstructure:"sampling_percentage"`
 
 	// status_code evaluator
 	MaxDuration time.Duration `config:"max_duration" mapstructure:"max_duration"`
 	StatusCode  []string      `config:"status_code" mapstructure:"status_code"`
 
+	// tail evaluator
+	DecisionWait time.Duration  `config:"decision_wait" mapstructure:"decision_wait"`
+	DecisionTTL  time.Duration  `config:"decision_ttl" mapstructure:"decision_ttl"`
+	MaxTraces    int            `config:"max_traces" mapstructure:"max_traces"`
+	Policies     []PolicyConfig `config:"policies" mapstructure:"policies"`
+
 	// drop evaluator
 	// 目前 enabled 字段只对 drop evaluator 生效
 	Enabled bool `config:"enabled" mapstructure:"enabled"`
@@ -37,6 +43,8 @@ const (
 	evaluatorTypeDrop       = "drop"
 	evaluatorTypeRandom     = "random"
 	evaluatorTypeStatusCode = "status_code"
+	evaluatorTypeTail       = "tail"
+	evaluatorTypeConsistent = "consistent"
 )
 
 type Evaluator interface {
@@ -45,14 +53,18 @@ type Evaluator interface {
 	Evaluate(record *define.Record) error
 }
 
-func New(c Config) Evaluator {
+func New(c Config) (Evaluator, error) {
 	switch c.Type {
 	case evaluatorTypeRandom:
-		return newRandomEvaluator(c)
+		return newRandomEvaluator(c), nil
 	case evaluatorTypeStatusCode:
-		return newStatusCodeEvaluator(c)
+		return newStatusCodeEvaluator(c), nil
 	case evaluatorTypeDrop:
-		return newDropEvaluator(c)
+		return newDropEvaluator(c), nil
+	case evaluatorTypeConsistent:
+		return newConsistentEvaluator(c), nil
+	case evaluatorTypeTail:
+		return newTailEvaluator(c)
 	}
-	return newAlwaysEvaluator() // evaluatorTypeAlways
+	return newAlwaysEvaluator(), nil // evaluatorTypeAlways
 }
@@ -0,0 +1,290 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package evaluator
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	semconv "go.opentelemetry.io/collector/semconv/v1.8.0"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/foreach"
+)
+
+// PolicyConfig 尾部采样策略配置
+type PolicyConfig struct {
+	Name string `config:"name" mapstructure:"name"`
+	Type string `config:"type" mapstructure:"type"`
+
+	// latency policy
+	Threshold time.Duration `config:"threshold" mapstructure:"threshold"`
+
+	// attribute policy
+	Key    string   `config:"key" mapstructure:"key"`
+	Values []string `config:"values" mapstructure:"values"`
+
+	// status_code policy
+	StatusCode []string `config:"status_code" mapstructure:"status_code"`
+
+	// rate_limiting policy
+	TracesPerSecond int `config:"traces_per_second" mapstructure:"traces_per_second"`
+
+	// and/or policy
+	SubPolicies []PolicyConfig `config:"sub_policies" mapstructure:"sub_policies"`
+}
+
+const (
+	policyTypeAlways       = "always"
+	policyTypeLatency      = "latency"
+	policyTypeAttribute    = "attribute"
+	policyTypeStatusCode   = "status_code"
+	policyTypeRateLimiting = "rate_limiting"
+	policyTypeAnd          = "and"
+	policyTypeOr           = "or"
+)
+
+// policy 对一条完整的 trace 做出采样决策
+type policy interface {
+	Name() string
+	Sampled(traces ptrace.Traces) bool
+}
+
+// newPolicy 创建采样策略 未知的策略类型返回错误 避免误配置导致全部采样
+func newPolicy(c PolicyConfig) (policy, error) {
+	name := c.Name
+	if name == "" {
+		name = c.Type
+	}
+
+	switch c.Type {
+	case policyTypeAlways:
+		return alwaysPolicy{name: name}, nil
+	case policyTypeLatency:
+		return latencyPolicy{name: name, threshold: c.Threshold}, nil
+	case policyTypeAttribute:
+		values := make(map[string]struct{})
+		for _, v := range c.Values {
+			values[v] = struct{}{}
+		}
+		return attributePolicy{name: name, key: c.Key, values: values}, nil
+	case policyTypeStatusCode:
+		status := make(map[string]struct{})
+		for _, s := range c.StatusCode {
+			status[s] = struct{}{}
+		}
+		return statusCodePolicy{name: name, status: status}, nil
+	case policyTypeRateLimiting:
+		return &rateLimitingPolicy{
+			name:            name,
+			tracesPerSecond: c.TracesPerSecond,
+			services:        map[string]*serviceQuota{},
+		}, nil
+	case policyTypeAnd, policyTypeOr:
+		subs := make([]policy, 0, len(c.SubPolicies))
+		for _, sub := range c.SubPolicies {
+			p, err := newPolicy(sub)
+			if err != nil {
+				return nil, errors.Wrapf(err, "policy '%s'", name)
+			}
+			subs = append(subs, p)
+		}
+		return compositePolicy{name: name, and: c.Type == policyTypeAnd, policies: subs}, nil
+	}
+	return nil, errors.Errorf("unknown policy type '%s'", c.Type)
+}
+
+// alwaysPolicy 永远采样
+type alwaysPolicy struct {
+	name string
+}
+
+func (p alwaysPolicy) Name() string { return p.name }
+
+func (p alwaysPolicy) Sampled(_ ptrace.Traces) bool { return true }
+
+// latencyPolicy trace 整体耗时（最早开始时间到最晚结束时间）超过阈值则采样
+type latencyPolicy struct {
+	name      string
+	threshold time.Duration
+}
+
+func (p latencyPolicy) Name() string { return p.name }
+
+func (p latencyPolicy) Sampled(traces ptrace.Traces) bool {
+	var minStart, maxEnd pcommon.Timestamp
+	foreach.Spans(traces.ResourceSpans(), func(span ptrace.Span) {
+		if minStart == 0 || span.StartTimestamp() < minStart {
+			minStart = span.StartTimestamp()
+		}
+		if span.EndTimestamp() > maxEnd {
+			maxEnd = span.EndTimestamp()
+		}
+	})
+	if maxEnd <= minStart {
+		return false
+	}
+	return time.Duration(maxEnd-minStart) >= p.threshold
+}
+
+// attributePolicy 任意 span 的 attributes 或 resource 中 key 对应的值命中 values 则采样
+// values 为空时仅判断 key 是否存在
+type attributePolicy struct {
+	name   string
+	key    string
+	values map[string]struct{}
+}
+
+func (p attributePolicy) Name() string { return p.name }
+
+func (p attributePolicy) match(attrs pcommon.Map) bool {
+	v, ok := attrs.Get(p.key)
+	if !ok {
+		return false
+	}
+	if len(p.values) == 0 {
+		return true
+	}
+	_, ok = p.values[v.AsString()]
+	return ok
+}
+
+func (p attributePolicy) Sampled(traces ptrace.Traces) bool {
+	var matched bool
+	foreach.SpansWithResourceAttrs(traces.ResourceSpans(), func(rsAttrs pcommon.Map, span ptrace.Span) {
+		if matched {
+			return
+		}
+		matched = p.match(span.Attributes()) || p.match(rsAttrs)
+	})
+	return matched
+}
+
+// statusCodePolicy 任意 span 状态码命中则采样
+type statusCodePolicy struct {
+	name   string
+	status map[string]struct{}
+}
+
+func (p statusCodePolicy) Name() string { return p.name }
+
+func (p statusCodePolicy) Sampled(traces ptrace.Traces) bool {
+	var matched bool
+	foreach.Spans(traces.ResourceSpans(), func(span ptrace.Span) {
+		if matched {
+			return
+		}
+		_, matched = p.status[statusMap[span.Status().Code().String()]]
+	})
+	return matched
+}
+
+type serviceQuota struct {
+	second int64
+	count  int
+}
+
+// rateLimitingPolicy 按服务（service.name）限制每秒采样的 traces 数量
+// 组合策略中应放置在最后 避免配额被其他策略未命中的 traces 消耗
+type rateLimitingPolicy struct {
+	name            string
+	tracesPerSecond int
+
+	mut      sync.Mutex
+	services map[string]*serviceQuota
+}
+
+func (p *rateLimitingPolicy) Name() string { return p.name }
+
+func serviceNameFromTraces(traces ptrace.Traces) string {
+	for i := 0; i < traces.ResourceSpans().Len(); i++ {
+		v, ok := traces.ResourceSpans().At(i).Resource().Attributes().Get(semconv.AttributeServiceName)
+		if ok {
+			return v.AsString()
+		}
+	}
+	return ""
+}
+
+func (p *rateLimitingPolicy) Sampled(traces ptrace.Traces) bool {
+	service := serviceNameFromTraces(traces)
+	now := time.Now().Unix()
+
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	quota, ok := p.services[service]
+	if !ok {
+		quota = &serviceQuota{}
+		p.services[service] = quota
+	}
+	if quota.second != now {
+		quota.second = now
+		quota.count = 0
+	}
+	if quota.count >= p.tracesPerSecond {
+		return false
+	}
+	quota.count++
+	return true
+}
+
+// gc 清理过期的服务配额记录
+func (p *rateLimitingPolicy) gc(now int64) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	for service, quota := range p.services {
+		if quota.second < now {
+			delete(p.services, service)
+		}
+	}
+}
+
+// compositePolicy 组合策略 and 要求所有子策略采样 or 要求任意子策略采样
+// 子策略按顺序短路求值
+type compositePolicy struct {
+	name     string
+	and      bool
+	policies []policy
+}
+
+func (p compositePolicy) Name() string { return p.name }
+
+func (p compositePolicy) Sampled(traces ptrace.Traces) bool {
+	if len(p.policies) == 0 {
+		return false
+	}
+	for _, sub := range p.policies {
+		sampled := sub.Sampled(traces)
+		if p.and && !sampled {
+			return false
+		}
+		if !p.and && sampled {
+			return true
+		}
+	}
+	return p.and
+}
+
+// gcPolicies 递归清理策略内部状态
+func gcPolicies(policies []policy, now int64) {
+	for _, p := range policies {
+		switch v := p.(type) {
+		case *rateLimitingPolicy:
+			v.gc(now)
+		case compositePolicy:
+			gcPolicies(v.policies, now)
+		}
+	}
+}
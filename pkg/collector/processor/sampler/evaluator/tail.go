@@ -0,0 +1,332 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package evaluator
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/batchspliter"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/foreach"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/tracestore"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/sampler/queue"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+var (
+	tailBufferedTraces = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "sampler_tail_buffered_traces",
+			Help:      "Sampler tail evaluator buffered traces count",
+		},
+		[]string{"id"},
+	)
+
+	tailDecisionTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "sampler_tail_decision_total",
+			Help:      "Sampler tail evaluator decision total",
+		},
+		[]string{"id", "policy", "sampled"},
+	)
+
+	tailDroppedSpansTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "sampler_tail_dropped_spans_total",
+			Help:      "Sampler tail evaluator dropped spans total",
+		},
+		[]string{"id", "reason"},
+	)
+)
+
+const (
+	droppedReasonBufferFull = "buffer_full"
+	droppedReasonLate       = "late"
+)
+
+type tailKey struct {
+	dataID  int32
+	traceID pcommon.TraceID
+}
+
+type tailTrace struct {
+	token       define.Token
+	requestType define.RequestType
+	arrival     time.Time
+	spanIDs     []pcommon.SpanID
+}
+
+type tailDecision struct {
+	sampled bool
+	ts      time.Time
+}
+
+// tailEvaluator 尾部采样
+//
+// 按 traceID 将 spans 缓存至 tracestore 中 等待 decisionWait 后对完整的 trace 执行所有策略
+// 任意策略命中即采样 采样的 trace 通过 publish 直接提交给 exporter 因此 tail 采样应配置在 traces 流水线末尾
+// 已决策的 traceID 会保留 decisionTTL 时长 期间迟到的 spans 直接沿用原决策
+type tailEvaluator struct {
+	decisionWait time.Duration
+	decisionTTL  time.Duration
+	maxTraces    int
+	policies     []policy
+	publish      func(r *define.Record)
+	tickInterval time.Duration
+
+	mut       sync.Mutex
+	storages  map[int32]tracestore.Storage
+	traces    map[tailKey]*tailTrace
+	decisions map[tailKey]tailDecision
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newTailEvaluator(c Config) (*tailEvaluator, error) {
+	decisionWait := c.DecisionWait
+	if decisionWait <= 0 {
+		decisionWait = 30 * time.Second
+	}
+	decisionTTL := c.DecisionTTL
+	if decisionTTL <= 0 {
+		decisionTTL = 2 * decisionWait
+	}
+	maxTraces := c.MaxTraces
+	if maxTraces <= 0 {
+		maxTraces = 50000
+	}
+
+	policies := make([]policy, 0, len(c.Policies))
+	for _, pc := range c.Policies {
+		p, err := newPolicy(pc)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+	// 未配置策略时默认全部采样 避免所有 trace 被静默丢弃
+	if len(policies) == 0 {
+		logger.Warnf("tail evaluator got no policies, all traces will be sampled")
+		policies = append(policies, alwaysPolicy{name: policyTypeAlways})
+	}
+
+	eval := &tailEvaluator{
+		decisionWait: decisionWait,
+		decisionTTL:  decisionTTL,
+		maxTraces:    maxTraces,
+		policies:     policies,
+		publish:      processor.PublishNonSchedRecords,
+		tickInterval: time.Second,
+		storages:     map[int32]tracestore.Storage{},
+		traces:       map[tailKey]*tailTrace{},
+		decisions:    map[tailKey]tailDecision{},
+		stop:         make(chan struct{}),
+	}
+
+	eval.wg.Add(1)
+	go eval.loopDecide()
+	return eval, nil
+}
+
+func (e *tailEvaluator) Type() string {
+	return evaluatorTypeTail
+}
+
+func (e *tailEvaluator) Stop() {
+	close(e.stop)
+	e.wg.Wait()
+
+	// 尚未到达决策窗口的 traces 立即决策 避免 Reload 时丢失缓存的数据
+	e.decide(time.Now().Add(e.decisionWait))
+}
+
+func (e *tailEvaluator) Evaluate(record *define.Record) error {
+	switch record.RecordType {
+	case define.RecordTraces:
+		return e.processTraces(record)
+	}
+	return nil
+}
+
+func (e *tailEvaluator) getOrCreateStorage(dataID int32) tracestore.Storage {
+	stor, ok := e.storages[dataID]
+	if !ok {
+		stor = tracestore.GetOrCreateStorage(dataID)
+		e.storages[dataID] = stor
+	}
+	return stor
+}
+
+func (e *tailEvaluator) processTraces(record *define.Record) error {
+	dataID := record.Token.TracesDataId
+	id := strconv.Itoa(int(dataID))
+	pdTraces := record.Data.(ptrace.Traces)
+
+	now := time.Now()
+	keep := make(map[tracestore.TraceKey]struct{})
+
+	e.mut.Lock()
+	stor := e.getOrCreateStorage(dataID)
+	for _, t := range batchspliter.SplitEachSpans(pdTraces) {
+		traceID, spanID, ok := queue.IdFromTraces(t)
+		if !ok {
+			continue
+		}
+		key := tailKey{dataID: dataID, traceID: traceID}
+		tk := tracestore.TraceKey{TraceID: traceID, SpanID: spanID}
+
+		// 迟到的 spans 沿用已有决策
+		if decision, ok := e.decisions[key]; ok {
+			if decision.sampled {
+				keep[tk] = struct{}{}
+			} else {
+				tailDroppedSpansTotal.WithLabelValues(id, droppedReasonLate).Inc()
+			}
+			continue
+		}
+
+		tt, ok := e.traces[key]
+		if !ok {
+			if len(e.traces) >= e.maxTraces {
+				tailDroppedSpansTotal.WithLabelValues(id, droppedReasonBufferFull).Inc()
+				continue
+			}
+			tt = &tailTrace{token: record.Token, requestType: record.RequestType, arrival: now}
+			e.traces[key] = tt
+		}
+
+		if err := stor.Set(tk, t); err != nil {
+			logger.Warnf("tail evaluator failed to buffer span, dataID=%v, err: %v", dataID, err)
+			continue
+		}
+		tt.spanIDs = append(tt.spanIDs, spanID)
+	}
+	e.mut.Unlock()
+
+	foreach.SpansRemoveIf(pdTraces.ResourceSpans(), func(span ptrace.Span) bool {
+		_, ok := keep[tracestore.TraceKey{TraceID: span.TraceID(), SpanID: span.SpanID()}]
+		return !ok
+	})
+
+	if pdTraces.SpanCount() == 0 {
+		return define.ErrEndOfPipeline
+	}
+	return nil
+}
+
+func (e *tailEvaluator) loopDecide() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.tickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.stop:
+			return
+
+		case <-ticker.C:
+			e.decide(time.Now())
+		}
+	}
+}
+
+type readyTrace struct {
+	key  tailKey
+	tt   *tailTrace
+	stor tracestore.Storage
+}
+
+// decide 对已经超过等待窗口的 traces 进行决策 并清理过期的决策缓存
+func (e *tailEvaluator) decide(now time.Time) {
+	var ready []readyTrace
+	counts := make(map[int32]int)
+
+	e.mut.Lock()
+	for key, tt := range e.traces {
+		if now.Sub(tt.arrival) < e.decisionWait {
+			counts[key.dataID]++
+			continue
+		}
+		ready = append(ready, readyTrace{key: key, tt: tt, stor: e.storages[key.dataID]})
+		delete(e.traces, key)
+	}
+	for key, decision := range e.decisions {
+		if now.Sub(decision.ts) > e.decisionTTL {
+			delete(e.decisions, key)
+		}
+	}
+	for dataID := range e.storages {
+		tailBufferedTraces.WithLabelValues(strconv.Itoa(int(dataID))).Set(float64(counts[dataID]))
+	}
+	e.mut.Unlock()
+
+	gcPolicies(e.policies, now.Unix())
+
+	for _, rt := range ready {
+		traces := e.collect(rt)
+		sampled, name := e.evaluatePolicies(traces)
+		tailDecisionTotal.WithLabelValues(strconv.Itoa(int(rt.key.dataID)), name, strconv.FormatBool(sampled)).Inc()
+
+		e.mut.Lock()
+		e.decisions[rt.key] = tailDecision{sampled: sampled, ts: now}
+		e.mut.Unlock()
+
+		if !sampled || traces.SpanCount() == 0 {
+			continue
+		}
+		e.publish(&define.Record{
+			RecordType:  define.RecordTraces,
+			RequestType: rt.tt.requestType,
+			Token:       rt.tt.token,
+			Data:        traces,
+		})
+	}
+}
+
+// collect 从 tracestore 中取出并合并 trace 的所有 spans
+func (e *tailEvaluator) collect(rt readyTrace) ptrace.Traces {
+	traces := ptrace.NewTraces()
+	for _, spanID := range rt.tt.spanIDs {
+		tk := tracestore.TraceKey{TraceID: rt.key.traceID, SpanID: spanID}
+		t, err := rt.stor.Get(tk)
+		if err != nil {
+			logger.Errorf("failed to get tk=%v, err: %v", tk, err)
+			continue
+		}
+		if err := rt.stor.Del(tk); err != nil {
+			logger.Errorf("failed to delete tk=%v, err: %v", tk, err)
+		}
+		t.ResourceSpans().MoveAndAppendTo(traces.ResourceSpans())
+	}
+	return traces
+}
+
+// evaluatePolicies 任意策略命中即采样 返回命中的策略名称
+func (e *tailEvaluator) evaluatePolicies(traces ptrace.Traces) (bool, string) {
+	for _, p := range e.policies {
+		if p.Sampled(traces) {
+			return true, p.Name()
+		}
+	}
+	return false, ""
+}
@@ -0,0 +1,240 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package evaluator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/random"
+)
+
+type spanOptions struct {
+	traceID  pcommon.TraceID
+	service  string
+	status   ptrace.StatusCode
+	duration time.Duration
+	attrs    map[string]string
+}
+
+func makeTraces(opts ...spanOptions) ptrace.Traces {
+	traces := ptrace.NewTraces()
+	start := time.Now()
+	for _, opt := range opts {
+		rs := traces.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().UpsertString("service.name", opt.service)
+		span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+		span.SetTraceID(opt.traceID)
+		span.SetSpanID(random.SpanID())
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(opt.duration)))
+		span.Status().SetCode(opt.status)
+		for k, v := range opt.attrs {
+			span.Attributes().UpsertString(k, v)
+		}
+	}
+	return traces
+}
+
+func mustNewPolicy(c PolicyConfig) policy {
+	p, err := newPolicy(c)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func TestPolicies(t *testing.T) {
+	t1 := random.TraceID()
+
+	t.Run("latency", func(t *testing.T) {
+		p := mustNewPolicy(PolicyConfig{Type: policyTypeLatency, Threshold: time.Second})
+		assert.True(t, p.Sampled(makeTraces(spanOptions{traceID: t1, duration: 2 * time.Second})))
+		assert.False(t, p.Sampled(makeTraces(spanOptions{traceID: t1, duration: time.Millisecond})))
+	})
+
+	t.Run("attribute", func(t *testing.T) {
+		p := mustNewPolicy(PolicyConfig{Type: policyTypeAttribute, Key: "http.method", Values: []string{"POST"}})
+		assert.True(t, p.Sampled(makeTraces(spanOptions{traceID: t1, attrs: map[string]string{"http.method": "POST"}})))
+		assert.False(t, p.Sampled(makeTraces(spanOptions{traceID: t1, attrs: map[string]string{"http.method": "GET"}})))
+
+		p = mustNewPolicy(PolicyConfig{Type: policyTypeAttribute, Key: "service.name"})
+		assert.True(t, p.Sampled(makeTraces(spanOptions{traceID: t1, service: "svc"})))
+	})
+
+	t.Run("status_code", func(t *testing.T) {
+		p := mustNewPolicy(PolicyConfig{Type: policyTypeStatusCode, StatusCode: []string{"ERROR"}})
+		assert.True(t, p.Sampled(makeTraces(
+			spanOptions{traceID: t1, status: ptrace.StatusCodeOk},
+			spanOptions{traceID: t1, status: ptrace.StatusCodeError},
+		)))
+		assert.False(t, p.Sampled(makeTraces(spanOptions{traceID: t1, status: ptrace.StatusCodeOk})))
+	})
+
+	t.Run("rate_limiting", func(t *testing.T) {
+		p := mustNewPolicy(PolicyConfig{Type: policyTypeRateLimiting, TracesPerSecond: 1})
+		assert.True(t, p.Sampled(makeTraces(spanOptions{traceID: t1, service: "svc1"})))
+		assert.True(t, p.Sampled(makeTraces(spanOptions{traceID: t1, service: "svc2"})))
+		assert.False(t, p.Sampled(makeTraces(spanOptions{traceID: t1, service: "svc1"})))
+
+		gcPolicies([]policy{p}, time.Now().Unix()+1)
+		assert.Len(t, p.(*rateLimitingPolicy).services, 0)
+	})
+
+	t.Run("and/or", func(t *testing.T) {
+		and := mustNewPolicy(PolicyConfig{Type: policyTypeAnd, SubPolicies: []PolicyConfig{
+			{Type: policyTypeLatency, Threshold: time.Second},
+			{Type: policyTypeStatusCode, StatusCode: []string{"ERROR"}},
+		}})
+		or := mustNewPolicy(PolicyConfig{Type: policyTypeOr, SubPolicies: []PolicyConfig{
+			{Type: policyTypeLatency, Threshold: time.Second},
+			{Type: policyTypeStatusCode, StatusCode: []string{"ERROR"}},
+		}})
+
+		slowErr := makeTraces(spanOptions{traceID: t1, duration: 2 * time.Second, status: ptrace.StatusCodeError})
+		slowOk := makeTraces(spanOptions{traceID: t1, duration: 2 * time.Second, status: ptrace.StatusCodeOk})
+		fastOk := makeTraces(spanOptions{traceID: t1, status: ptrace.StatusCodeOk})
+
+		assert.True(t, and.Sampled(slowErr))
+		assert.False(t, and.Sampled(slowOk))
+		assert.True(t, or.Sampled(slowOk))
+		assert.False(t, or.Sampled(fastOk))
+		assert.False(t, mustNewPolicy(PolicyConfig{Type: policyTypeOr}).Sampled(slowErr))
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := newPolicy(PolicyConfig{Type: "unknown"})
+		assert.Error(t, err)
+
+		_, err = newPolicy(PolicyConfig{Type: policyTypeAnd, SubPolicies: []PolicyConfig{
+			{Type: policyTypeAlways},
+			{Type: "latancy"},
+		}})
+		assert.Error(t, err)
+
+		_, err = newTailEvaluator(Config{Policies: []PolicyConfig{{Type: "unknown"}}})
+		assert.Error(t, err)
+	})
+}
+
+func TestTailEvaluator(t *testing.T) {
+	var published []*define.Record
+	eval, _ := newTailEvaluator(Config{
+		DecisionWait: time.Minute,
+		Policies: []PolicyConfig{
+			{Name: "errors", Type: policyTypeStatusCode, StatusCode: []string{"ERROR"}},
+		},
+	})
+	eval.publish = func(r *define.Record) { published = append(published, r) }
+	defer eval.Stop()
+
+	t1 := random.TraceID()
+	t2 := random.TraceID()
+	token := define.Token{Original: "token1", TracesDataId: 1001}
+
+	// round1: 所有 spans 均被缓存
+	traces := makeTraces(
+		spanOptions{traceID: t1, status: ptrace.StatusCodeOk},
+		spanOptions{traceID: t2, status: ptrace.StatusCodeOk},
+	)
+	err := eval.Evaluate(&define.Record{RecordType: define.RecordTraces, Token: token, Data: traces})
+	assert.Equal(t, define.ErrEndOfPipeline, err)
+	assert.Equal(t, 0, traces.SpanCount())
+
+	traces = makeTraces(spanOptions{traceID: t1, status: ptrace.StatusCodeError})
+	err = eval.Evaluate(&define.Record{RecordType: define.RecordTraces, Token: token, Data: traces})
+	assert.Equal(t, define.ErrEndOfPipeline, err)
+	assert.Len(t, eval.traces, 2)
+
+	// 未到决策窗口
+	eval.decide(time.Now())
+	assert.Len(t, published, 0)
+
+	// round2: 到达决策窗口 t1 命中 errors 策略
+	eval.decide(time.Now().Add(time.Minute))
+	assert.Len(t, eval.traces, 0)
+	assert.Len(t, published, 1)
+	assert.Equal(t, 2, published[0].Data.(ptrace.Traces).SpanCount())
+	assert.Equal(t, token, published[0].Token)
+
+	// round3: 迟到的 spans 沿用决策
+	traces = makeTraces(
+		spanOptions{traceID: t1, status: ptrace.StatusCodeOk},
+		spanOptions{traceID: t2, status: ptrace.StatusCodeOk},
+	)
+	err = eval.Evaluate(&define.Record{RecordType: define.RecordTraces, Token: token, Data: traces})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, traces.SpanCount())
+	assert.Len(t, eval.traces, 0)
+
+	// round4: 决策缓存过期
+	eval.decide(time.Now().Add(time.Hour))
+	assert.Len(t, eval.decisions, 0)
+}
+
+func TestTailEvaluatorNoPolicies(t *testing.T) {
+	var published []*define.Record
+	eval, _ := newTailEvaluator(Config{DecisionWait: time.Minute})
+	eval.publish = func(r *define.Record) { published = append(published, r) }
+	defer eval.Stop()
+
+	assert.Len(t, eval.policies, 1)
+	assert.Equal(t, policyTypeAlways, eval.policies[0].Name())
+
+	traces := makeTraces(spanOptions{traceID: random.TraceID(), status: ptrace.StatusCodeOk})
+	err := eval.Evaluate(&define.Record{RecordType: define.RecordTraces, Data: traces})
+	assert.Equal(t, define.ErrEndOfPipeline, err)
+
+	eval.decide(time.Now().Add(time.Minute))
+	assert.Len(t, published, 1)
+}
+
+func TestTailEvaluatorStop(t *testing.T) {
+	var published []*define.Record
+	eval, _ := newTailEvaluator(Config{
+		DecisionWait: time.Minute,
+		Policies: []PolicyConfig{
+			{Name: "errors", Type: policyTypeStatusCode, StatusCode: []string{"ERROR"}},
+		},
+	})
+	eval.publish = func(r *define.Record) { published = append(published, r) }
+
+	traces := makeTraces(
+		spanOptions{traceID: random.TraceID(), status: ptrace.StatusCodeError},
+		spanOptions{traceID: random.TraceID(), status: ptrace.StatusCodeOk},
+	)
+	err := eval.Evaluate(&define.Record{RecordType: define.RecordTraces, Data: traces})
+	assert.Equal(t, define.ErrEndOfPipeline, err)
+
+	// 停止时未到决策窗口的 traces 依然执行决策
+	eval.Stop()
+	assert.Len(t, eval.traces, 0)
+	assert.Len(t, published, 1)
+	assert.Equal(t, 1, published[0].Data.(ptrace.Traces).SpanCount())
+}
+
+func TestTailEvaluatorMaxTraces(t *testing.T) {
+	eval, _ := newTailEvaluator(Config{MaxTraces: 1})
+	defer eval.Stop()
+
+	traces := makeTraces(
+		spanOptions{traceID: random.TraceID()},
+		spanOptions{traceID: random.TraceID()},
+	)
+	err := eval.Evaluate(&define.Record{RecordType: define.RecordTraces, Data: traces})
+	assert.Equal(t, define.ErrEndOfPipeline, err)
+	assert.Len(t, eval.traces, 1)
+	assert.Equal(t, evaluatorTypeTail, eval.Type())
+}
@@ -10,6 +10,8 @@
 package sampler
 
 import (
+	"github.com/pkg/errors"
+
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/mapstructure"
@@ -33,7 +35,11 @@ func newFactory(conf map[string]interface{}, customized []processor.SubConfigPro
 	if err := mapstructure.Decode(conf, &c); err != nil {
 		return nil, err
 	}
-	evaluators.SetGlobal(evaluator.New(c))
+	eval, err := evaluator.New(c)
+	if err != nil {
+		return nil, err
+	}
+	evaluators.SetGlobal(eval)
 
 	for _, custom := range customized {
 		var cfg evaluator.Config
@@ -41,7 +47,15 @@ func newFactory(conf map[string]interface{}, customized []processor.SubConfigPro
 			logger.Errorf("failed to decode config: %v", err)
 			continue
 		}
-		evaluators.Set(custom.Token, custom.Type, custom.ID, evaluator.New(cfg))
+		eval, err := evaluator.New(cfg)
+		if err != nil {
+			// 已经创建的 evaluator 可能持有后台任务 需要停止
+			for _, obj := range evaluators.All() {
+				obj.(evaluator.Evaluator).Stop()
+			}
+			return nil, errors.Wrapf(err, "create evaluator of token '%s'", custom.Token)
+		}
+		evaluators.Set(custom.Token, custom.Type, custom.ID, eval)
 	}
 
 	return &sampler{
@@ -64,6 +64,39 @@ processor:
 	factory.Clean()
 }
 
+func TestFactoryInvalidPolicy(t *testing.T) {
+	content := `
+processor:
+  - name: "sampler/tail"
+    config:
+      type: "tail"
+      policies:
+        - name: "errors"
+          type: "status_codes"
+`
+	mainConf := processor.MustLoadConfigs(content)[0].Config
+	_, err := NewFactory(mainConf, nil)
+	assert.Error(t, err)
+
+	// 自定义配置非法时同样拒绝
+	validConf := processor.MustLoadConfigs(`
+processor:
+  - name: "sampler/tail"
+    config:
+      type: "tail"
+`)[0].Config
+	_, err = NewFactory(validConf, []processor.SubConfigProcessor{
+		{
+			Token: "token1",
+			Type:  define.SubConfigFieldDefault,
+			Config: processor.Config{
+				Config: mainConf,
+			},
+		},
+	})
+	assert.Error(t, err)
+}
+
 func TestNoopFactory(t *testing.T) {
 	content := `
 processor:
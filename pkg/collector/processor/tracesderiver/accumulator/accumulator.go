@@ -231,6 +231,11 @@ func (r *recorder) Total() int {
 
 // Set 更新 labels 缓存
 func (r *recorder) Set(dims map[string]string, value float64) bool {
+	return r.SetWithCount(dims, value, 1)
+}
+
+// SetWithCount 更新 labels 缓存 count 表示该数值代表的原始样本数量（如采样后的 adjusted count）
+func (r *recorder) SetWithCount(dims map[string]string, value, count float64) bool {
 	if r.stopped.Load() {
 		return false
 	}
@@ -266,8 +271,8 @@ func (r *recorder) Set(dims map[string]string, value float64) bool {
 		s.buckets = make([]float64, len(r.buckets))
 	}
 
-	s.curr += 1
-	s.currSum += value
+	s.curr += count
+	s.currSum += value * count
 
 	if s.max < value {
 		s.max = value
@@ -278,7 +283,7 @@ func (r *recorder) Set(dims map[string]string, value float64) bool {
 
 	for i := 0; i < len(r.buckets); i++ {
 		if r.buckets[i] >= value {
-			s.buckets[i]++
+			s.buckets[i] += count
 		}
 	}
 
@@ -718,6 +723,11 @@ func (a *Accumulator) Exceeded() map[int32]int {
 }
 
 func (a *Accumulator) Accumulate(dataID int32, dims map[string]string, value float64) bool {
+	return a.AccumulateWithCount(dataID, dims, value, 1)
+}
+
+// AccumulateWithCount 累加 count 个数值为 value 的样本
+func (a *Accumulator) AccumulateWithCount(dataID int32, dims map[string]string, value, count float64) bool {
 	if a.stopped.Load() {
 		return false
 	}
@@ -731,7 +741,7 @@ func (a *Accumulator) Accumulate(dataID int32, dims map[string]string, value flo
 	}
 	a.mut.RUnlock()
 	if r != nil {
-		return r.Set(dims, value)
+		return r.SetWithCount(dims, value, count)
 	}
 
 	// 写锁保护
@@ -754,7 +764,7 @@ func (a *Accumulator) Accumulate(dataID int32, dims map[string]string, value flo
 		a.recorders[dataID] = r
 	}
 	a.mut.Unlock()
-	return r.Set(dims, value)
+	return r.SetWithCount(dims, value, count)
 }
 
 func (a *Accumulator) enableLimitGrowRate() bool {
@@ -72,6 +72,27 @@ func TestCalcStats(t *testing.T) {
 	r.Set(dims1, 10)
 }
 
+func TestCalcStatsWithCount(t *testing.T) {
+	r := newRecorder(recorderOptions{
+		metricName: "test_metric_count",
+		maxSeries:  100,
+		dataID:     1002,
+		buckets:    []float64{2, 5},
+		gcInterval: time.Minute,
+	}, labelstore.GetOrCreateStorage(strconv.Itoa(1002)))
+	defer r.Stop()
+
+	dims := map[string]string{"label1": "value1"}
+	r.SetWithCount(dims, 1e9, 4)
+	r.Set(dims, 3e9)
+
+	for _, stat := range r.statsMap {
+		assert.Equal(t, float64(5), stat.curr)
+		assert.Equal(t, float64(7e9), stat.currSum)
+		assert.Equal(t, []float64{4, 5, 5}, stat.buckets)
+	}
+}
+
 func TestAccumulatorExceeded(t *testing.T) {
 	accumulator := New(&Config{
 		MetricName:      "bk_apm_count",
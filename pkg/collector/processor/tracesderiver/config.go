@@ -16,6 +16,7 @@ import (
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/tracesderiver/accumulator"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/tracesderiver/mapstrings"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/tracesderiver/servicegraph"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
 )
 
@@ -32,6 +33,11 @@ type OperationConfig struct {
 	Buckets             []float64    `config:"buckets" mapstructure:"buckets"`
 	PublishInterval     string       `config:"publish_interval" mapstructure:"publish_interval"`
 	MaxSeriesGrowthRate int          `config:"max_series_growth_rate" mapstructure:"max_series_growth_rate"`
+
+	// service_graph 配置
+	Window         string   `config:"window" mapstructure:"window"`
+	MaxPending     int      `config:"max_pending" mapstructure:"max_pending"`
+	PeerAttributes []string `config:"peer_attributes" mapstructure:"peer_attributes"`
 }
 
 type RuleConfig struct {
@@ -53,8 +59,9 @@ type ConfigHandler struct {
 	attributeKeys *mapstrings.MapStrings  // key:[type+kind+predicateKey]
 	methodKeys    *mapstrings.MapStrings  // key:[type+kind+predicateKey]
 
-	accumulatorConfig *accumulator.Config
-	extractorConfig   *ExtractorConfig
+	accumulatorConfig  *accumulator.Config
+	extractorConfig    *ExtractorConfig
+	serviceGraphConfig *servicegraph.Config
 }
 
 // NewConfigHandler 创建并返回 ConfigHandler 实例 用于管理配置和提取内容
@@ -70,6 +77,7 @@ func NewConfigHandler(config Config) *ConfigHandler {
 	var types []TypeWithName
 	var accumulatorConfig *accumulator.Config
 	var extractorConfig *ExtractorConfig
+	var serviceGraphConfig *servicegraph.Config
 	for i := 0; i < len(config.Operations); i++ {
 		conf := config.Operations[i]
 		// accumulator 类型单独处理
@@ -103,6 +111,24 @@ func NewConfigHandler(config Config) *ConfigHandler {
 			}
 			extractorConfig.Validate()
 
+		// service_graph 不依赖 rules 匹配 因此不需要记录 types
+		case servicegraph.TypeServiceGraph:
+			window, _ := time.ParseDuration(conf.Window)
+			gcInterval, _ := time.ParseDuration(conf.GcInterval)
+			publishInterval, _ := time.ParseDuration(conf.PublishInterval)
+			serviceGraphConfig = &servicegraph.Config{
+				MetricName:      conf.MetricName,
+				Window:          window,
+				MaxPending:      conf.MaxPending,
+				MaxSeries:       conf.MaxSeries,
+				GcInterval:      gcInterval,
+				PublishInterval: publishInterval,
+				Buckets:         conf.Buckets,
+				PeerAttributes:  conf.PeerAttributes,
+			}
+			serviceGraphConfig.Validate()
+			continue
+
 		default:
 			logger.Errorf("invalid extractor type: %s", conf.Type)
 			continue
@@ -135,14 +161,15 @@ func NewConfigHandler(config Config) *ConfigHandler {
 	}
 
 	return &ConfigHandler{
-		types:             types,
-		predicateKeys:     predicateKeys,
-		resourceKeys:      resourceKeys,
-		attributeKeys:     attributeKeys,
-		methodKeys:        methodKeys,
-		kinds:             kinds,
-		accumulatorConfig: accumulatorConfig,
-		extractorConfig:   extractorConfig,
+		types:              types,
+		predicateKeys:      predicateKeys,
+		resourceKeys:       resourceKeys,
+		attributeKeys:      attributeKeys,
+		methodKeys:         methodKeys,
+		kinds:              kinds,
+		accumulatorConfig:  accumulatorConfig,
+		extractorConfig:    extractorConfig,
+		serviceGraphConfig: serviceGraphConfig,
 	}
 }
 
@@ -168,6 +195,10 @@ func (ch *ConfigHandler) GetExtractorConfig() *ExtractorConfig {
 	return ch.extractorConfig
 }
 
+func (ch *ConfigHandler) GetServiceGraphConfig() *servicegraph.Config {
+	return ch.serviceGraphConfig
+}
+
 func (ch *ConfigHandler) GetTypes() []TypeWithName {
 	return ch.types
 }
@@ -11,6 +11,7 @@ package tracesderiver
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -60,3 +61,22 @@ func TestConfigHandler(t *testing.T) {
 	assert.Equal(t, []string{"span_name"}, handler.GetMethods(Type, "SPAN_KIND_RPC", "attributes.rpc.method"))
 	assert.Len(t, handler.GetMethods(Type, "SPAN_KIND_RPC", "attributes.rpc.method.noexsit"), 0)
 }
+
+func TestConfigHandlerServiceGraph(t *testing.T) {
+	handler := NewConfigHandler(Config{
+		Operations: []OperationConfig{{
+			Type:           "service_graph",
+			Window:         "5s",
+			MaxPending:     100,
+			PeerAttributes: []string{"peer.service"},
+		}},
+	})
+	assert.Len(t, handler.GetTypes(), 0)
+
+	conf := handler.GetServiceGraphConfig()
+	assert.NotNil(t, conf)
+	assert.Equal(t, "bk_apm_service_graph", conf.MetricName)
+	assert.Equal(t, 5*time.Second, conf.Window)
+	assert.Equal(t, 100, conf.MaxPending)
+	assert.Equal(t, []string{"peer.service"}, conf.PeerAttributes)
+}
@@ -59,6 +59,25 @@ processor:
                   - "span_name"
                   - "kind"
                   - "status.code"
+
+    - name: "traces_deriver/service_graph"
+      config:
+        operations:
+          - type: "service_graph"
+            metric_name: "bk_apm_service_graph" # 指标前缀
+            window: "2s" # client/server span 配对的等待时间窗口
+            max_pending: 100000 # 等待配对的 edge 数量上限
+            publish_interval: "10s"
+            gc_interval: "1h"
+            max_series: 10000
+            buckets: [0.01, 0.05, 0.1, 0.5, 1, 2, 5]
+            # 未接入探针的下游（数据库/消息队列等）按顺序取以下属性作为虚拟节点名称
+            peer_attributes:
+              - "peer.service"
+              - "db.name"
+              - "db.system"
+              - "messaging.system"
+              - "net.peer.name"
 */
 
 package tracesderiver
@@ -10,6 +10,9 @@
 package tracesderiver
 
 import (
+	"strconv"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
@@ -17,6 +20,7 @@ import (
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/utils"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/tracesderiver/accumulator"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor/tracesderiver/servicegraph"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
 )
 
@@ -40,14 +44,41 @@ func NewTracesOperator(conf Config) Operator {
 	if extractorConfig != nil {
 		to.extractor = NewExtractor(extractorConfig)
 	}
+	serviceGraphConfig := ch.GetServiceGraphConfig()
+	if serviceGraphConfig != nil {
+		to.graph = servicegraph.New(serviceGraphConfig, processor.PublishNonSchedRecords)
+	}
 
 	return to
 }
 
+// adjustedCount 返回 span 经过采样后代表的原始 span 数量 未采样的 span 为 1
+func adjustedCount(span ptrace.Span) float64 {
+	v, ok := span.Attributes().Get(define.AttributeSamplingAdjustedCount)
+	if !ok {
+		return 1
+	}
+
+	var count float64
+	switch v.Type() {
+	case pcommon.ValueTypeDouble:
+		count = v.DoubleVal()
+	case pcommon.ValueTypeInt:
+		count = float64(v.IntVal())
+	case pcommon.ValueTypeString:
+		count, _ = strconv.ParseFloat(v.StringVal(), 64)
+	}
+	if count <= 0 {
+		return 1
+	}
+	return count
+}
+
 type tracesOperator struct {
 	dm          DimensionMatcher
 	accumulator *accumulator.Accumulator
 	extractor   *Extractor
+	graph       *servicegraph.Graph
 }
 
 func (to tracesOperator) Clean() {
@@ -57,6 +88,9 @@ func (to tracesOperator) Clean() {
 	if to.extractor != nil {
 		to.extractor.Stop()
 	}
+	if to.graph != nil {
+		to.graph.Stop()
+	}
 }
 
 func (to tracesOperator) Operate(record *define.Record) *define.Record {
@@ -66,6 +100,10 @@ func (to tracesOperator) Operate(record *define.Record) *define.Record {
 	metricItems := map[string][]metricsbuilder.Metric{}
 	types := to.dm.Types()
 
+	if to.graph != nil {
+		to.graph.Consume(record.Token.MetricsDataId, pdTraces)
+	}
+
 	for i := 0; i < resourceSpansSlice.Len(); i++ {
 		scopeSpansSlice := resourceSpansSlice.At(i).ScopeSpans()
 		resources := to.dm.MatchResource(resourceSpansSlice.At(i))
@@ -89,7 +127,7 @@ func (to tracesOperator) Operate(record *define.Record) *define.Record {
 
 					// extractor 处理
 					if to.extractor != nil {
-						if to.extractor.Set(record.Token.MetricsDataId, dim) {
+						if to.extractor.Set(record.Token.MetricsDataId, t.MetricName, dim) {
 							val := to.extractor.Extract(spans.At(k))
 							metricItems[t.MetricName] = append(metricItems[t.MetricName], metricsbuilder.Metric{
 								Val:        val,
@@ -102,7 +140,8 @@ func (to tracesOperator) Operate(record *define.Record) *define.Record {
 					// accumulator 处理
 					if to.accumulator != nil {
 						val := utils.CalcSpanDuration(spans.At(k))
-						to.accumulator.Accumulate(record.Token.MetricsDataId, dim, val)
+						count := adjustedCount(spans.At(k))
+						to.accumulator.AccumulateWithCount(record.Token.MetricsDataId, dim, val, count)
 					}
 				}
 			}
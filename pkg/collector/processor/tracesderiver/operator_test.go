@@ -14,10 +14,12 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/foreach"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/generator"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/serieslimiter"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/testkits"
 )
 
@@ -136,3 +138,61 @@ func TestOperatorDuration(t *testing.T) {
 		assert.Equal(t, float64(0), metric.Gauge().DataPoints().At(1).DoubleVal())
 	})
 }
+
+func TestOperatorSeriesStats(t *testing.T) {
+	c := Config{
+		Operations: []OperationConfig{
+			{
+				Type:       "duration",
+				MetricName: "test_bk_apm_duration",
+				Rules: []RuleConfig{
+					{
+						Kind:         "SPAN_KIND_CLIENT",
+						PredicateKey: "attributes.http.method",
+						Dimensions:   []string{"kind", "span_name"},
+					},
+				},
+			},
+		},
+	}
+
+	g := generator.NewTracesGenerator(define.TracesOptions{
+		GeneratorOptions: define.GeneratorOptions{
+			Attributes: map[string]string{"http.method": "GET"},
+		},
+		SpanCount: 1,
+		SpanKind:  3,
+	})
+
+	op := NewTracesOperator(c)
+	defer op.Clean()
+	derived := op.Operate(&define.Record{
+		RecordType: define.RecordTraces,
+		Token:      define.Token{MetricsDataId: 1016},
+		Data:       g.Generate(),
+	})
+	assert.NotNil(t, derived)
+
+	// 维度中不包含 __name__ 时依然能统计到指标名
+	stats := serieslimiter.Snapshot(1016, 10)
+	assert.Len(t, stats, 1)
+	assert.Equal(t, 1, stats[0].Series)
+	assert.Equal(t, []serieslimiter.Cardinality{{Name: "test_bk_apm_duration", Count: 1}}, stats[0].TopMetrics)
+}
+
+func TestAdjustedCount(t *testing.T) {
+	span := ptrace.NewSpan()
+	assert.Equal(t, float64(1), adjustedCount(span))
+
+	span.Attributes().UpsertDouble(define.AttributeSamplingAdjustedCount, 8)
+	assert.Equal(t, float64(8), adjustedCount(span))
+
+	span.Attributes().UpsertInt(define.AttributeSamplingAdjustedCount, 4)
+	assert.Equal(t, float64(4), adjustedCount(span))
+
+	span.Attributes().UpsertString(define.AttributeSamplingAdjustedCount, "2")
+	assert.Equal(t, float64(2), adjustedCount(span))
+
+	span.Attributes().UpsertDouble(define.AttributeSamplingAdjustedCount, 0)
+	assert.Equal(t, float64(1), adjustedCount(span))
+}
@@ -0,0 +1,515 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+// Package servicegraph 根据 trace 中的调用关系生成服务拓扑指标
+//
+// client(producer) span 与其子 server(consumer) span 在时间窗口内配对为一条 edge
+// 按 (client, server, connection_type) 维度累计请求数 失败数以及两端耗时分布
+// 对于未接入探针的数据库或消息队列 使用 client span 的 peer 属性生成虚拟节点
+package servicegraph
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	semconv "go.opentelemetry.io/collector/semconv/v1.8.0"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/metricsbuilder"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/utils"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+var (
+	edgesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "service_graph_edges_total",
+			Help:      "Service graph edges total",
+		},
+		[]string{"id", "connection_type"},
+	)
+
+	droppedEdgesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "service_graph_dropped_edges_total",
+			Help:      "Service graph dropped edges total",
+		},
+		[]string{"id", "reason"},
+	)
+
+	pendingEdges = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "service_graph_pending_edges",
+			Help:      "Service graph pending edges",
+		},
+	)
+
+	seriesExceededTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: define.MonitoringNamespace,
+			Name:      "service_graph_series_exceeded_total",
+			Help:      "Service graph series exceeded total",
+		},
+		[]string{"id"},
+	)
+)
+
+var DefaultMetricMonitor = &metricMonitor{}
+
+type metricMonitor struct{}
+
+func (m *metricMonitor) IncEdgesCounter(dataId int32, connectionType string) {
+	edgesTotal.WithLabelValues(strconv.Itoa(int(dataId)), connectionType).Inc()
+}
+
+func (m *metricMonitor) IncDroppedEdgesCounter(dataId int32, reason string) {
+	droppedEdgesTotal.WithLabelValues(strconv.Itoa(int(dataId)), reason).Inc()
+}
+
+func (m *metricMonitor) SetPendingEdges(n int) {
+	pendingEdges.Set(float64(n))
+}
+
+func (m *metricMonitor) IncSeriesExceededCounter(dataId int32) {
+	seriesExceededTotal.WithLabelValues(strconv.Itoa(int(dataId))).Inc()
+}
+
+const (
+	TypeServiceGraph = "service_graph"
+
+	ConnectionTypeNone      = ""
+	ConnectionTypeMessaging = "messaging_system"
+	ConnectionTypeDatabase  = "database"
+	ConnectionTypeVirtual   = "virtual_node"
+
+	// userNode 没有 parent 的 server span 视为由外部用户发起
+	userNode = "user"
+
+	droppedReasonExpired   = "expired"
+	droppedReasonStoreFull = "store_full"
+
+	labelClient         = "client"
+	labelServer         = "server"
+	labelConnectionType = "connection_type"
+
+	expireInterval = time.Second
+)
+
+var defaultPeerAttributes = []string{
+	semconv.AttributePeerService,
+	semconv.AttributeDBName,
+	semconv.AttributeDBSystem,
+	semconv.AttributeMessagingSystem,
+	semconv.AttributeNetPeerName,
+}
+
+type Config struct {
+	MetricName      string
+	Window          time.Duration
+	MaxPending      int
+	MaxSeries       int
+	GcInterval      time.Duration
+	PublishInterval time.Duration
+	Buckets         []float64
+	PeerAttributes  []string
+}
+
+// Validate 验证配置默认值
+func (c *Config) Validate() {
+	if c.MetricName == "" {
+		c.MetricName = "bk_apm_service_graph"
+	}
+	if c.Window <= 0 {
+		c.Window = 2 * time.Second
+	}
+	if c.MaxPending <= 0 {
+		c.MaxPending = 100000 // 100k
+	}
+	if c.MaxSeries <= 0 {
+		c.MaxSeries = 10000 // 10k
+	}
+	if c.GcInterval <= 0 {
+		c.GcInterval = time.Hour
+	}
+	if c.PublishInterval <= 0 {
+		c.PublishInterval = time.Minute
+	}
+	// 排序前复制一份 避免修改 prometheus.DefBuckets 或调用方的切片
+	buckets := c.Buckets
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+	c.Buckets = make([]float64, len(buckets))
+	copy(c.Buckets, buckets)
+	sort.Float64s(c.Buckets)
+	if len(c.PeerAttributes) == 0 {
+		c.PeerAttributes = defaultPeerAttributes
+	}
+}
+
+type seriesKey struct {
+	client         string
+	server         string
+	connectionType string
+}
+
+type seriesStats struct {
+	total         float64
+	failed        float64
+	serverBuckets []float64
+	serverSum     float64
+	serverCount   float64
+	clientBuckets []float64
+	clientSum     float64
+	clientCount   float64
+	updated       time.Time
+}
+
+// Graph 服务拓扑计算器 series 为累计值 按 dataid 隔离
+type Graph struct {
+	conf        *Config
+	store       *store
+	publishFunc func(r *define.Record)
+
+	mut    sync.Mutex
+	series map[int32]map[seriesKey]*seriesStats
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func New(conf *Config, publishFunc func(r *define.Record)) *Graph {
+	logger.Debugf("service graph config: %+v", conf)
+	g := &Graph{
+		conf:        conf,
+		publishFunc: publishFunc,
+		series:      map[int32]map[seriesKey]*seriesStats{},
+		done:        make(chan struct{}),
+	}
+	g.store = newStore(conf.Window, conf.MaxPending, g.onComplete, g.onExpire)
+
+	g.wg.Add(1)
+	go g.loop()
+	return g
+}
+
+func (g *Graph) Stop() {
+	close(g.done)
+	g.wg.Wait()
+}
+
+func (g *Graph) loop() {
+	defer g.wg.Done()
+
+	expireTicker := time.NewTicker(expireInterval)
+	defer expireTicker.Stop()
+
+	publishTicker := time.NewTicker(g.conf.PublishInterval)
+	defer publishTicker.Stop()
+
+	gcTicker := time.NewTicker(g.conf.GcInterval / 2) // 以 0.5*gcInterval 频率进行清理
+	defer gcTicker.Stop()
+
+	for {
+		select {
+		case <-g.done:
+			return
+
+		case now := <-expireTicker.C:
+			g.store.expire(now)
+			DefaultMetricMonitor.SetPendingEdges(g.store.len())
+
+		case <-publishTicker.C:
+			if g.publishFunc != nil {
+				for _, r := range g.buildRecords() {
+					g.publishFunc(r)
+				}
+			}
+
+		case now := <-gcTicker.C:
+			g.gc(now)
+		}
+	}
+}
+
+func isFailed(span ptrace.Span) bool {
+	return span.Status().Code() == ptrace.StatusCodeError
+}
+
+func spanKey(dataID int32, traceID pcommon.TraceID, spanID pcommon.SpanID) string {
+	return strconv.Itoa(int(dataID)) + "/" + traceID.HexString() + "/" + spanID.HexString()
+}
+
+// findPeer 按配置顺序查找 client span 的 peer 属性
+func (g *Graph) findPeer(attrs pcommon.Map) (string, string) {
+	for _, key := range g.conf.PeerAttributes {
+		v, ok := attrs.Get(key)
+		if !ok {
+			continue
+		}
+		if s := v.AsString(); s != "" {
+			return key, s
+		}
+	}
+	return "", ""
+}
+
+// Consume 处理 traces 数据 配对 client/server span
+func (g *Graph) Consume(dataID int32, traces ptrace.Traces) {
+	resourceSpansSlice := traces.ResourceSpans()
+	for i := 0; i < resourceSpansSlice.Len(); i++ {
+		resourceSpans := resourceSpansSlice.At(i)
+		var service string
+		if v, ok := resourceSpans.Resource().Attributes().Get(semconv.AttributeServiceName); ok {
+			service = v.AsString()
+		}
+
+		scopeSpansSlice := resourceSpans.ScopeSpans()
+		for j := 0; j < scopeSpansSlice.Len(); j++ {
+			spans := scopeSpansSlice.At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				g.consumeSpan(dataID, service, spans.At(k))
+			}
+		}
+	}
+}
+
+func (g *Graph) consumeSpan(dataID int32, service string, span ptrace.Span) {
+	latency := utils.CalcSpanDuration(span) / float64(time.Second)
+	failed := isFailed(span)
+
+	switch span.Kind() {
+	case ptrace.SpanKindClient, ptrace.SpanKindProducer:
+		peerKey, peer := g.findPeer(span.Attributes())
+
+		// 数据库通常不会接入探针 直接生成虚拟节点
+		if _, ok := span.Attributes().Get(semconv.AttributeDBSystem); ok && span.Kind() == ptrace.SpanKindClient && peer != "" {
+			g.onComplete(&edge{
+				dataID:         dataID,
+				clientService:  service,
+				serverService:  peer,
+				connectionType: ConnectionTypeDatabase,
+				clientLatency:  latency,
+				failed:         failed,
+				clientDone:     true,
+			})
+			return
+		}
+
+		key := spanKey(dataID, span.TraceID(), span.SpanID())
+		if !g.store.upsert(key, dataID, func(e *edge) {
+			e.clientService = service
+			e.clientLatency = latency
+			e.failed = e.failed || failed
+			e.clientDone = true
+			e.peerKey, e.peer = peerKey, peer
+			if span.Kind() == ptrace.SpanKindProducer {
+				e.connectionType = ConnectionTypeMessaging
+			}
+		}) {
+			DefaultMetricMonitor.IncDroppedEdgesCounter(dataID, droppedReasonStoreFull)
+		}
+
+	case ptrace.SpanKindServer, ptrace.SpanKindConsumer:
+		// 没有 parent 的 server span 为调用链入口 不会有对应的 client span
+		if span.ParentSpanID().IsEmpty() {
+			g.onComplete(&edge{
+				dataID:         dataID,
+				clientService:  userNode,
+				serverService:  service,
+				connectionType: ConnectionTypeVirtual,
+				serverLatency:  latency,
+				failed:         failed,
+				serverDone:     true,
+			})
+			return
+		}
+
+		key := spanKey(dataID, span.TraceID(), span.ParentSpanID())
+		if !g.store.upsert(key, dataID, func(e *edge) {
+			e.serverService = service
+			e.serverLatency = latency
+			e.failed = e.failed || failed
+			e.serverDone = true
+			if span.Kind() == ptrace.SpanKindConsumer {
+				e.connectionType = ConnectionTypeMessaging
+			}
+		}) {
+			DefaultMetricMonitor.IncDroppedEdgesCounter(dataID, droppedReasonStoreFull)
+		}
+	}
+}
+
+// onExpire 未配对的 client edge 如果带有 peer 属性 则以 peer 作为虚拟 server 节点
+func (g *Graph) onExpire(e *edge) {
+	if e.clientDone && !e.serverDone && e.peer != "" {
+		e.serverService = e.peer
+		if e.connectionType == ConnectionTypeNone {
+			e.connectionType = ConnectionTypeVirtual
+			if e.peerKey == semconv.AttributeMessagingSystem {
+				e.connectionType = ConnectionTypeMessaging
+			}
+		}
+		g.onComplete(e)
+		return
+	}
+	DefaultMetricMonitor.IncDroppedEdgesCounter(e.dataID, droppedReasonExpired)
+}
+
+func (g *Graph) newStats() *seriesStats {
+	return &seriesStats{
+		serverBuckets: make([]float64, len(g.conf.Buckets)),
+		clientBuckets: make([]float64, len(g.conf.Buckets)),
+	}
+}
+
+func observe(buckets, bounds []float64, val float64) {
+	for i := 0; i < len(bounds); i++ {
+		if bounds[i] >= val {
+			buckets[i]++
+		}
+	}
+}
+
+func (g *Graph) onComplete(e *edge) {
+	if e.clientService == "" || e.serverService == "" {
+		DefaultMetricMonitor.IncDroppedEdgesCounter(e.dataID, droppedReasonExpired)
+		return
+	}
+
+	g.mut.Lock()
+	defer g.mut.Unlock()
+
+	series, ok := g.series[e.dataID]
+	if !ok {
+		series = map[seriesKey]*seriesStats{}
+		g.series[e.dataID] = series
+	}
+
+	key := seriesKey{client: e.clientService, server: e.serverService, connectionType: e.connectionType}
+	s, ok := series[key]
+	if !ok {
+		if len(series) >= g.conf.MaxSeries {
+			DefaultMetricMonitor.IncSeriesExceededCounter(e.dataID)
+			return
+		}
+		s = g.newStats()
+		series[key] = s
+	}
+
+	s.total++
+	if e.failed {
+		s.failed++
+	}
+	// 虚拟节点一端没有耗时数据
+	if e.serverDone {
+		observe(s.serverBuckets, g.conf.Buckets, e.serverLatency)
+		s.serverSum += e.serverLatency
+		s.serverCount++
+	}
+	if e.clientDone {
+		observe(s.clientBuckets, g.conf.Buckets, e.clientLatency)
+		s.clientSum += e.clientLatency
+		s.clientCount++
+	}
+	s.updated = time.Now()
+	DefaultMetricMonitor.IncEdgesCounter(e.dataID, e.connectionType)
+}
+
+func (g *Graph) gc(now time.Time) {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+
+	for dataID, series := range g.series {
+		for key, s := range series {
+			if now.Sub(s.updated) > g.conf.GcInterval {
+				delete(series, key)
+			}
+		}
+		if len(series) == 0 {
+			delete(g.series, dataID)
+		}
+	}
+}
+
+func (g *Graph) buildHistogram(mb *metricsbuilder.Builder, name string, dims map[string]string, ts pcommon.Timestamp, buckets []float64, sum, count float64) {
+	metrics := make([]metricsbuilder.Metric, 0, len(buckets)+1)
+	for i, bound := range g.conf.Buckets {
+		metrics = append(metrics, metricsbuilder.Metric{
+			Val:        buckets[i],
+			Ts:         ts,
+			Dimensions: withLe(dims, strconv.FormatFloat(bound, 'f', -1, 64)),
+		})
+	}
+	metrics = append(metrics, metricsbuilder.Metric{
+		Val:        count,
+		Ts:         ts,
+		Dimensions: withLe(dims, "+Inf"),
+	})
+	mb.Build(name+"_bucket", metrics...)
+	mb.Build(name+"_sum", metricsbuilder.Metric{Val: sum, Ts: ts, Dimensions: dims})
+	mb.Build(name+"_count", metricsbuilder.Metric{Val: count, Ts: ts, Dimensions: dims})
+}
+
+func withLe(dims map[string]string, le string) map[string]string {
+	cloned := make(map[string]string, len(dims)+1)
+	for k, v := range dims {
+		cloned[k] = v
+	}
+	cloned["le"] = le
+	return cloned
+}
+
+// buildRecords 按 dataid 生成指标数据
+func (g *Graph) buildRecords() []*define.Record {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+
+	ts := pcommon.NewTimestampFromTime(time.Now())
+	name := g.conf.MetricName
+
+	var records []*define.Record
+	for dataID, series := range g.series {
+		if len(series) == 0 {
+			continue
+		}
+		mb := metricsbuilder.New()
+		for key, s := range series {
+			dims := map[string]string{
+				labelClient:         key.client,
+				labelServer:         key.server,
+				labelConnectionType: key.connectionType,
+			}
+			mb.Build(name+"_request_total", metricsbuilder.Metric{Val: s.total, Ts: ts, Dimensions: dims})
+			mb.Build(name+"_request_failed_total", metricsbuilder.Metric{Val: s.failed, Ts: ts, Dimensions: dims})
+			if s.serverCount > 0 {
+				g.buildHistogram(mb, name+"_request_server_seconds", dims, ts, s.serverBuckets, s.serverSum, s.serverCount)
+			}
+			if s.clientCount > 0 {
+				g.buildHistogram(mb, name+"_request_client_seconds", dims, ts, s.clientBuckets, s.clientSum, s.clientCount)
+			}
+		}
+		records = append(records, &define.Record{
+			RecordType:  define.RecordMetrics,
+			RequestType: define.RequestDerived,
+			Token:       define.Token{MetricsDataId: dataID},
+			Data:        mb.Get(),
+		})
+	}
+	return records
+}
@@ -0,0 +1,238 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package servicegraph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+type testSpan struct {
+	service string
+	kind    ptrace.SpanKind
+	spanID  byte
+	parent  byte
+	latency time.Duration
+	failed  bool
+	attrs   map[string]string
+}
+
+func makeTraces(spans ...testSpan) ptrace.Traces {
+	traces := ptrace.NewTraces()
+	traceID := pcommon.NewTraceID([16]byte{1, 2, 3})
+	for _, s := range spans {
+		rs := traces.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().UpsertString("service.name", s.service)
+		span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+		span.SetTraceID(traceID)
+		span.SetSpanID(pcommon.NewSpanID([8]byte{s.spanID}))
+		if s.parent > 0 {
+			span.SetParentSpanID(pcommon.NewSpanID([8]byte{s.parent}))
+		}
+		span.SetKind(s.kind)
+		start := time.Now()
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(s.latency)))
+		if s.failed {
+			span.Status().SetCode(ptrace.StatusCodeError)
+		}
+		for k, v := range s.attrs {
+			span.Attributes().UpsertString(k, v)
+		}
+	}
+	return traces
+}
+
+func newTestGraph(maxSeries int) *Graph {
+	conf := &Config{
+		Window:          time.Minute,
+		MaxSeries:       maxSeries,
+		PublishInterval: time.Hour,
+		Buckets:         []float64{0.1, 1},
+	}
+	conf.Validate()
+	return New(conf, nil)
+}
+
+func (g *Graph) getStats(dataID int32, key seriesKey) *seriesStats {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+	return g.series[dataID][key]
+}
+
+func TestValidateConfig(t *testing.T) {
+	conf := Config{}
+	conf.Validate()
+	assert.Equal(t, "bk_apm_service_graph", conf.MetricName)
+	assert.Equal(t, defaultPeerAttributes, conf.PeerAttributes)
+	assert.Equal(t, 2*time.Second, conf.Window)
+	assert.Equal(t, prometheus.DefBuckets, conf.Buckets)
+}
+
+func TestValidateBucketsCopied(t *testing.T) {
+	buckets := []float64{5, 1, 2}
+	conf := Config{Buckets: buckets}
+	conf.Validate()
+	assert.Equal(t, []float64{1, 2, 5}, conf.Buckets)
+	assert.Equal(t, []float64{5, 1, 2}, buckets)
+
+	conf = Config{}
+	conf.Validate()
+	conf.Buckets[0] = 100
+	assert.NotEqual(t, float64(100), prometheus.DefBuckets[0])
+}
+
+func TestClientServerPaired(t *testing.T) {
+	g := newTestGraph(0)
+	defer g.Stop()
+
+	g.Consume(1001, makeTraces(
+		testSpan{service: "api", kind: ptrace.SpanKindClient, spanID: 1, parent: 9, latency: 500 * time.Millisecond},
+	))
+	assert.Equal(t, 1, g.store.len())
+
+	g.Consume(1001, makeTraces(
+		testSpan{service: "order", kind: ptrace.SpanKindServer, spanID: 2, parent: 1, latency: 50 * time.Millisecond, failed: true},
+	))
+	assert.Equal(t, 0, g.store.len())
+
+	s := g.getStats(1001, seriesKey{client: "api", server: "order"})
+	assert.NotNil(t, s)
+	assert.Equal(t, float64(1), s.total)
+	assert.Equal(t, float64(1), s.failed)
+	assert.Equal(t, []float64{1, 1}, s.serverBuckets)
+	assert.Equal(t, []float64{0, 1}, s.clientBuckets)
+	assert.InDelta(t, 0.5, s.clientSum, 1e-6)
+	assert.InDelta(t, 0.05, s.serverSum, 1e-6)
+}
+
+func TestProducerConsumerPaired(t *testing.T) {
+	g := newTestGraph(0)
+	defer g.Stop()
+
+	g.Consume(1001, makeTraces(
+		testSpan{service: "consumer", kind: ptrace.SpanKindConsumer, spanID: 2, parent: 1},
+		testSpan{service: "producer", kind: ptrace.SpanKindProducer, spanID: 1, parent: 9},
+	))
+
+	s := g.getStats(1001, seriesKey{client: "producer", server: "consumer", connectionType: ConnectionTypeMessaging})
+	assert.NotNil(t, s)
+	assert.Equal(t, float64(1), s.total)
+}
+
+func TestVirtualNodes(t *testing.T) {
+	g := newTestGraph(0)
+	defer g.Stop()
+
+	g.Consume(1001, makeTraces(
+		testSpan{service: "api", kind: ptrace.SpanKindServer, spanID: 1},
+		testSpan{service: "api", kind: ptrace.SpanKindClient, spanID: 2, parent: 1, attrs: map[string]string{
+			"db.system": "mysql",
+			"db.name":   "orders",
+		}},
+		testSpan{service: "api", kind: ptrace.SpanKindProducer, spanID: 3, parent: 1, attrs: map[string]string{
+			"messaging.system": "kafka",
+		}},
+		testSpan{service: "api", kind: ptrace.SpanKindClient, spanID: 4, parent: 1, attrs: map[string]string{
+			"net.peer.name": "payment.example.com",
+		}},
+		testSpan{service: "api", kind: ptrace.SpanKindClient, spanID: 5, parent: 1},
+	))
+
+	assert.NotNil(t, g.getStats(1001, seriesKey{client: userNode, server: "api", connectionType: ConnectionTypeVirtual}))
+	assert.NotNil(t, g.getStats(1001, seriesKey{client: "api", server: "orders", connectionType: ConnectionTypeDatabase}))
+	assert.Equal(t, 3, g.store.len())
+
+	g.store.expire(time.Now().Add(2 * time.Minute))
+	assert.Equal(t, 0, g.store.len())
+	assert.NotNil(t, g.getStats(1001, seriesKey{client: "api", server: "kafka", connectionType: ConnectionTypeMessaging}))
+	s := g.getStats(1001, seriesKey{client: "api", server: "payment.example.com", connectionType: ConnectionTypeVirtual})
+	assert.NotNil(t, s)
+	assert.Equal(t, float64(0), s.serverCount)
+	assert.Equal(t, float64(1), s.clientCount)
+
+	g.mut.Lock()
+	assert.Len(t, g.series[1001], 4)
+	g.mut.Unlock()
+}
+
+func TestStoreFull(t *testing.T) {
+	conf := &Config{Window: time.Minute, MaxPending: 1}
+	conf.Validate()
+	g := New(conf, nil)
+	defer g.Stop()
+
+	g.Consume(1001, makeTraces(
+		testSpan{service: "api", kind: ptrace.SpanKindClient, spanID: 1, parent: 9},
+		testSpan{service: "api", kind: ptrace.SpanKindClient, spanID: 2, parent: 9},
+	))
+	assert.Equal(t, 1, g.store.len())
+}
+
+func TestMaxSeries(t *testing.T) {
+	g := newTestGraph(1)
+	defer g.Stop()
+
+	g.Consume(1001, makeTraces(
+		testSpan{service: "a", kind: ptrace.SpanKindServer, spanID: 1},
+		testSpan{service: "b", kind: ptrace.SpanKindServer, spanID: 2},
+	))
+
+	g.mut.Lock()
+	assert.Len(t, g.series[1001], 1)
+	g.mut.Unlock()
+}
+
+func TestBuildRecords(t *testing.T) {
+	g := newTestGraph(0)
+	defer g.Stop()
+
+	g.Consume(1001, makeTraces(
+		testSpan{service: "api", kind: ptrace.SpanKindClient, spanID: 1, parent: 9, latency: 500 * time.Millisecond},
+		testSpan{service: "order", kind: ptrace.SpanKindServer, spanID: 2, parent: 1, latency: 50 * time.Millisecond},
+	))
+
+	records := g.buildRecords()
+	assert.Len(t, records, 1)
+	assert.Equal(t, int32(1001), records[0].Token.MetricsDataId)
+
+	values := map[string]float64{}
+	metrics := records[0].Data.(pmetric.Metrics).ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := 0; i < metrics.Len(); i++ {
+		m := metrics.At(i)
+		dps := m.Gauge().DataPoints()
+		for j := 0; j < dps.Len(); j++ {
+			dp := dps.At(j)
+			v, _ := dp.Attributes().Get("client")
+			assert.Equal(t, "api", v.StringVal())
+			key := m.Name()
+			if le, ok := dp.Attributes().Get("le"); ok {
+				key += "/" + le.StringVal()
+			}
+			values[key] = dp.DoubleVal()
+		}
+	}
+
+	assert.Equal(t, float64(1), values["bk_apm_service_graph_request_total"])
+	assert.Equal(t, float64(0), values["bk_apm_service_graph_request_failed_total"])
+	assert.Equal(t, float64(0), values["bk_apm_service_graph_request_client_seconds_bucket/0.1"])
+	assert.Equal(t, float64(1), values["bk_apm_service_graph_request_client_seconds_bucket/+Inf"])
+	assert.Equal(t, float64(1), values["bk_apm_service_graph_request_server_seconds_bucket/0.1"])
+	assert.Equal(t, float64(1), values["bk_apm_service_graph_request_server_seconds_count"])
+
+	g.gc(time.Now().Add(2 * time.Hour))
+	assert.Len(t, g.buildRecords(), 0)
+}
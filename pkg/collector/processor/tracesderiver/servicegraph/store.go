@@ -0,0 +1,128 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package servicegraph
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+const (
+	sideClient = iota
+	sideServer
+)
+
+// edge 描述一次 client -> server 的调用 由两端的 span 拼接而成
+type edge struct {
+	key    string
+	dataID int32
+
+	clientService  string
+	serverService  string
+	connectionType string
+	clientLatency  float64 // seconds
+	serverLatency  float64 // seconds
+	failed         bool
+
+	clientDone bool
+	serverDone bool
+	peerKey    string // client 端 span 命中的 peer 属性 用于生成虚拟节点
+	peer       string
+
+	expiration time.Time
+}
+
+func (e *edge) completed() bool {
+	return e.clientDone && e.serverDone
+}
+
+// store 暂存等待配对的 edge 超过 ttl 未配对的 edge 会被淘汰
+//
+// ttl 固定 因此插入顺序即为过期顺序 使用链表即可按序淘汰
+type store struct {
+	mut      sync.Mutex
+	ttl      time.Duration
+	maxItems int
+	l        *list.List
+	m        map[string]*list.Element
+
+	onComplete func(e *edge)
+	onExpire   func(e *edge)
+}
+
+func newStore(ttl time.Duration, maxItems int, onComplete, onExpire func(e *edge)) *store {
+	return &store{
+		ttl:        ttl,
+		maxItems:   maxItems,
+		l:          list.New(),
+		m:          make(map[string]*list.Element),
+		onComplete: onComplete,
+		onExpire:   onExpire,
+	}
+}
+
+// upsert 更新 edge 配对完成时回调 onComplete 并从 store 中移除
+// 返回 false 表示 store 已满 数据被丢弃
+func (s *store) upsert(key string, dataID int32, update func(e *edge)) bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if ele, ok := s.m[key]; ok {
+		e := ele.Value.(*edge)
+		update(e)
+		if e.completed() {
+			s.l.Remove(ele)
+			delete(s.m, key)
+			s.onComplete(e)
+		}
+		return true
+	}
+
+	if len(s.m) >= s.maxItems {
+		return false
+	}
+
+	e := &edge{key: key, dataID: dataID, expiration: time.Now().Add(s.ttl)}
+	update(e)
+	if e.completed() {
+		s.onComplete(e)
+		return true
+	}
+	s.m[key] = s.l.PushBack(e)
+	return true
+}
+
+// expire 淘汰过期的 edge
+func (s *store) expire(now time.Time) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	for {
+		head := s.l.Front()
+		if head == nil {
+			return
+		}
+		e := head.Value.(*edge)
+		if now.Before(e.expiration) {
+			return
+		}
+		s.l.Remove(head)
+		delete(s.m, e.key)
+		s.onExpire(e)
+	}
+}
+
+func (s *store) len() int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return len(s.m)
+}
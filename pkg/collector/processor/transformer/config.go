@@ -0,0 +1,88 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package transformer
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/ottl"
+)
+
+type Config struct {
+	Traces  []StatementsConfig `config:"traces" mapstructure:"traces"`
+	Metrics []StatementsConfig `config:"metrics" mapstructure:"metrics"`
+	Logs    []StatementsConfig `config:"logs" mapstructure:"logs"`
+}
+
+type StatementsConfig struct {
+	Context    string   `config:"context" mapstructure:"context"`
+	Statements []string `config:"statements" mapstructure:"statements"`
+}
+
+type statementGroup struct {
+	context    ottl.Context
+	statements []*ottl.Statement
+}
+
+// programs 编译后的语句集合 按数据类型区分
+type programs struct {
+	traces  []statementGroup
+	metrics []statementGroup
+	logs    []statementGroup
+}
+
+func (p *programs) empty() bool {
+	return len(p.traces) == 0 && len(p.metrics) == 0 && len(p.logs) == 0
+}
+
+func compileGroups(configs []StatementsConfig, allowed ...ottl.Context) ([]statementGroup, error) {
+	groups := make([]statementGroup, 0, len(configs))
+	for _, conf := range configs {
+		ctx, err := ottl.ParseContext(conf.Context)
+		if err != nil {
+			return nil, err
+		}
+
+		var ok bool
+		for _, c := range allowed {
+			if c == ctx {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return nil, errors.Errorf("context '%s' not allowed here, expected %v", ctx, allowed)
+		}
+
+		stmts, err := ottl.ParseStatements(ctx, conf.Statements)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, statementGroup{context: ctx, statements: stmts})
+	}
+	return groups, nil
+}
+
+// compile 编译配置中的所有语句 仅在加载配置时执行一次
+func compile(c Config) (*programs, error) {
+	traces, err := compileGroups(c.Traces, ottl.ContextResource, ottl.ContextSpan)
+	if err != nil {
+		return nil, errors.Wrap(err, "compile traces statements")
+	}
+	metrics, err := compileGroups(c.Metrics, ottl.ContextResource, ottl.ContextDataPoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "compile metrics statements")
+	}
+	logs, err := compileGroups(c.Logs, ottl.ContextResource, ottl.ContextLog)
+	if err != nil {
+		return nil, errors.Wrap(err, "compile logs statements")
+	}
+	return &programs{traces: traces, metrics: metrics, logs: logs}, nil
+}
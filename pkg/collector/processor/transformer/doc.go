@@ -0,0 +1,56 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+/*
+# Transformer: 基于表达式的数据转换器
+
+语句格式: `function(args...) [where condition]` 在配置加载时编译 运行时按顺序执行
+支持的上下文:
+- traces: resource/span
+- metrics: resource/datapoint
+- logs: resource/log
+
+支持的函数:
+- set(path, value): 设置字段 value 为 nil 时删除 attributes 中的 key
+- delete(attributes["key"]): 删除 key
+- keep_keys(attributes, ["key1", "key2"]): 仅保留指定的 keys
+- replace_pattern(path, "regex", "replacement"): 正则替换 path 为 attributes 时作用于所有字符串值
+- truncate(path, limit): 按字符数截断 path 为 attributes 时作用于所有字符串值
+
+条件支持 ==/!=/</<=/>/>= 比较 and/or/not 组合以及 IsMatch(path, "regex") 函数
+
+字段:
+- 通用: attributes/attributes["key"]/resource.attributes["key"]
+- span: name/kind/status.code/status.message/trace_id/span_id/parent_span_id/duration
+- log: body/severity_text/severity_number/trace_id/span_id
+- datapoint: metric.name/metric.unit/metric.type/value
+
+processor:
+    - name: "transformer/common"
+      config:
+        traces:
+          - context: "resource"
+            statements:
+              - 'set(attributes["deployment.environment"], "prod") where attributes["deployment.environment"] == nil'
+          - context: "span"
+            statements:
+              - 'replace_pattern(name, "/\\d+", "/{id}") where kind == "SPAN_KIND_SERVER"'
+              - 'truncate(attributes["db.statement"], 256)'
+              - 'delete(attributes["http.request.header.authorization"])'
+        metrics:
+          - context: "datapoint"
+            statements:
+              - 'keep_keys(attributes, ["service_name", "status_code"]) where metric.name == "bk_apm_count"'
+        logs:
+          - context: "log"
+            statements:
+              - 'set(severity_text, "ERROR") where IsMatch(body, "(?i)exception")'
+*/
+
+package transformer
@@ -0,0 +1,222 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package transformer
+
+import (
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/mapstructure"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/ottl"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+func init() {
+	processor.Register(define.ProcessorTransformer, NewFactory)
+}
+
+func NewFactory(conf map[string]interface{}, customized []processor.SubConfigProcessor) (processor.Processor, error) {
+	return newFactory(conf, customized)
+}
+
+func newFactory(conf map[string]interface{}, customized []processor.SubConfigProcessor) (*transformer, error) {
+	configs := confengine.NewTierConfig()
+
+	var c Config
+	if err := mapstructure.Decode(conf, &c); err != nil {
+		return nil, err
+	}
+	p, err := compile(c)
+	if err != nil {
+		return nil, err
+	}
+	configs.SetGlobal(p)
+
+	for _, custom := range customized {
+		var cfg Config
+		if err := mapstructure.Decode(custom.Config.Config, &cfg); err != nil {
+			logger.Errorf("failed to decode config: %v", err)
+			continue
+		}
+		cp, err := compile(cfg)
+		if err != nil {
+			logger.Errorf("failed to compile config: %v", err)
+			continue
+		}
+		configs.Set(custom.Token, custom.Type, custom.ID, cp)
+	}
+
+	return &transformer{
+		CommonProcessor: processor.NewCommonProcessor(conf, customized),
+		configs:         configs,
+	}, nil
+}
+
+type transformer struct {
+	processor.CommonProcessor
+	configs *confengine.TierConfig // type: *programs
+}
+
+func (p *transformer) Name() string {
+	return define.ProcessorTransformer
+}
+
+func (p *transformer) IsDerived() bool {
+	return false
+}
+
+func (p *transformer) IsPreCheck() bool {
+	return false
+}
+
+func (p *transformer) Reload(config map[string]interface{}, customized []processor.SubConfigProcessor) {
+	f, err := newFactory(config, customized)
+	if err != nil {
+		logger.Errorf("failed to reload processor: %v", err)
+		return
+	}
+
+	p.CommonProcessor = f.CommonProcessor
+	p.configs = f.configs
+}
+
+func (p *transformer) Process(record *define.Record) (*define.Record, error) {
+	progs := p.configs.GetByToken(record.Token.Original).(*programs)
+	if progs.empty() {
+		return nil, nil
+	}
+
+	switch record.RecordType {
+	case define.RecordTraces:
+		if len(progs.traces) > 0 {
+			p.processTraces(record, progs.traces)
+		}
+	case define.RecordMetrics:
+		if len(progs.metrics) > 0 {
+			p.processMetrics(record, progs.metrics)
+		}
+	case define.RecordLogs:
+		if len(progs.logs) > 0 {
+			p.processLogs(record, progs.logs)
+		}
+	}
+	return nil, nil
+}
+
+func execute(token string, stmts []*ottl.Statement, tc *ottl.TransformContext) {
+	for _, stmt := range stmts {
+		if err := stmt.Execute(tc); err != nil {
+			logger.WarnfRate(time.Minute, token+"/"+stmt.String(), "failed to execute statement '%s', token=%s, err: %v", stmt, token, err)
+		}
+	}
+}
+
+func (p *transformer) processTraces(record *define.Record, groups []statementGroup) {
+	resourceSpansSlice := record.Data.(ptrace.Traces).ResourceSpans()
+	for _, group := range groups {
+		for i := 0; i < resourceSpansSlice.Len(); i++ {
+			resourceSpans := resourceSpansSlice.At(i)
+			resource := resourceSpans.Resource()
+			if group.context == ottl.ContextResource {
+				execute(record.Token.Original, group.statements, ottl.NewResourceContext(resource))
+				continue
+			}
+
+			scopeSpansSlice := resourceSpans.ScopeSpans()
+			for j := 0; j < scopeSpansSlice.Len(); j++ {
+				spans := scopeSpansSlice.At(j).Spans()
+				for k := 0; k < spans.Len(); k++ {
+					execute(record.Token.Original, group.statements, ottl.NewSpanContext(resource, spans.At(k)))
+				}
+			}
+		}
+	}
+}
+
+func (p *transformer) processLogs(record *define.Record, groups []statementGroup) {
+	resourceLogsSlice := record.Data.(plog.Logs).ResourceLogs()
+	for _, group := range groups {
+		for i := 0; i < resourceLogsSlice.Len(); i++ {
+			resourceLogs := resourceLogsSlice.At(i)
+			resource := resourceLogs.Resource()
+			if group.context == ottl.ContextResource {
+				execute(record.Token.Original, group.statements, ottl.NewResourceContext(resource))
+				continue
+			}
+
+			scopeLogsSlice := resourceLogs.ScopeLogs()
+			for j := 0; j < scopeLogsSlice.Len(); j++ {
+				logs := scopeLogsSlice.At(j).LogRecords()
+				for k := 0; k < logs.Len(); k++ {
+					execute(record.Token.Original, group.statements, ottl.NewLogContext(resource, logs.At(k)))
+				}
+			}
+		}
+	}
+}
+
+func (p *transformer) processMetrics(record *define.Record, groups []statementGroup) {
+	resourceMetricsSlice := record.Data.(pmetric.Metrics).ResourceMetrics()
+	for _, group := range groups {
+		for i := 0; i < resourceMetricsSlice.Len(); i++ {
+			resourceMetrics := resourceMetricsSlice.At(i)
+			resource := resourceMetrics.Resource()
+			if group.context == ottl.ContextResource {
+				execute(record.Token.Original, group.statements, ottl.NewResourceContext(resource))
+				continue
+			}
+
+			scopeMetricsSlice := resourceMetrics.ScopeMetrics()
+			for j := 0; j < scopeMetricsSlice.Len(); j++ {
+				metrics := scopeMetricsSlice.At(j).Metrics()
+				for k := 0; k < metrics.Len(); k++ {
+					executeDataPoints(record.Token.Original, group.statements, resource, metrics.At(k))
+				}
+			}
+		}
+	}
+}
+
+func executeDataPoints(token string, stmts []*ottl.Statement, resource pcommon.Resource, metric pmetric.Metric) {
+	switch metric.DataType() {
+	case pmetric.MetricDataTypeGauge:
+		dps := metric.Gauge().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			execute(token, stmts, ottl.NewNumberDataPointContext(resource, metric, dps.At(i)))
+		}
+	case pmetric.MetricDataTypeSum:
+		dps := metric.Sum().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			execute(token, stmts, ottl.NewNumberDataPointContext(resource, metric, dps.At(i)))
+		}
+	case pmetric.MetricDataTypeHistogram:
+		dps := metric.Histogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			execute(token, stmts, ottl.NewDataPointContext(resource, metric, dps.At(i).Attributes()))
+		}
+	case pmetric.MetricDataTypeExponentialHistogram:
+		dps := metric.ExponentialHistogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			execute(token, stmts, ottl.NewDataPointContext(resource, metric, dps.At(i).Attributes()))
+		}
+	case pmetric.MetricDataTypeSummary:
+		dps := metric.Summary().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			execute(token, stmts, ottl.NewDataPointContext(resource, metric, dps.At(i).Attributes()))
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package transformer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/generator"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/testkits"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/processor"
+)
+
+func TestFactory(t *testing.T) {
+	content := `
+processor:
+  - name: "transformer/common"
+    config:
+      traces:
+        - context: "span"
+          statements:
+            - 'set(attributes["env"], "prod")'
+`
+	mainConf := processor.MustLoadConfigs(content)[0].Config
+
+	customContent := `
+processor:
+  - name: "transformer/common"
+    config:
+      logs:
+        - context: "log"
+          statements:
+            - 'set(attributes["env"], "test")'
+`
+	customConf := processor.MustLoadConfigs(customContent)[0].Config
+
+	obj, err := NewFactory(mainConf, []processor.SubConfigProcessor{
+		{
+			Token: "token1",
+			Type:  define.SubConfigFieldDefault,
+			Config: processor.Config{
+				Config: customConf,
+			},
+		},
+	})
+	factory := obj.(*transformer)
+	assert.NoError(t, err)
+	assert.Equal(t, mainConf, factory.MainConfig())
+
+	global := factory.configs.GetGlobal().(*programs)
+	assert.Len(t, global.traces, 1)
+	assert.Len(t, global.logs, 0)
+
+	custom := factory.configs.GetByToken("token1").(*programs)
+	assert.Len(t, custom.traces, 0)
+	assert.Len(t, custom.logs, 1)
+
+	assert.Equal(t, define.ProcessorTransformer, factory.Name())
+	assert.False(t, factory.IsDerived())
+	assert.False(t, factory.IsPreCheck())
+
+	factory.Reload(mainConf, nil)
+	assert.Equal(t, mainConf, factory.MainConfig())
+}
+
+func TestFactoryInvalidStatements(t *testing.T) {
+	cases := []string{
+		`
+processor:
+  - name: "transformer/common"
+    config:
+      traces:
+        - context: "log"
+          statements:
+            - 'set(body, "x")'
+`,
+		`
+processor:
+  - name: "transformer/common"
+    config:
+      metrics:
+        - context: "datapoint"
+          statements:
+            - 'set(name, "x")'
+`,
+		`
+processor:
+  - name: "transformer/common"
+    config:
+      logs:
+        - context: "unknown"
+`,
+	}
+
+	for _, content := range cases {
+		_, err := NewFactory(processor.MustLoadConfigs(content)[0].Config, nil)
+		assert.Error(t, err)
+	}
+}
+
+func TestTracesStatements(t *testing.T) {
+	content := `
+processor:
+  - name: "transformer/common"
+    config:
+      traces:
+        - context: "resource"
+          statements:
+            - 'set(attributes["deployment.environment"], "prod") where attributes["deployment.environment"] == nil'
+        - context: "span"
+          statements:
+            - 'set(attributes["env"], resource.attributes["deployment.environment"])'
+            - 'set(name, "server") where kind == "SPAN_KIND_SERVER"'
+            - 'delete(attributes["http.method"])'
+`
+	factory := processor.MustCreateFactory(content, NewFactory)
+
+	g := generator.NewTracesGenerator(define.TracesOptions{
+		GeneratorOptions: define.GeneratorOptions{
+			Attributes: map[string]string{"http.method": "GET"},
+		},
+		SpanCount: 2,
+		SpanKind:  int(ptrace.SpanKindServer),
+	})
+	record := &define.Record{
+		RecordType: define.RecordTraces,
+		Data:       g.Generate(),
+	}
+
+	_, err := factory.Process(record)
+	assert.NoError(t, err)
+
+	traces := record.Data.(ptrace.Traces)
+	rsAttrs := traces.ResourceSpans().At(0).Resource().Attributes()
+	testkits.AssertAttrsFoundStringVal(t, rsAttrs, "deployment.environment", "prod")
+
+	spans := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	for i := 0; i < spans.Len(); i++ {
+		span := spans.At(i)
+		assert.Equal(t, "server", span.Name())
+		testkits.AssertAttrsFoundStringVal(t, span.Attributes(), "env", "prod")
+		testkits.AssertAttrsNotFound(t, span.Attributes(), "http.method")
+	}
+}
+
+func TestMetricsStatements(t *testing.T) {
+	content := `
+processor:
+  - name: "transformer/common"
+    config:
+      metrics:
+        - context: "datapoint"
+          statements:
+            - 'keep_keys(attributes, ["service"])'
+            - 'set(metric.name, "renamed") where metric.name == "my_metrics"'
+`
+	factory := processor.MustCreateFactory(content, NewFactory)
+
+	g := generator.NewMetricsGenerator(define.MetricsOptions{
+		GeneratorOptions: define.GeneratorOptions{
+			Attributes: map[string]string{"service": "api", "instance": "127.0.0.1"},
+		},
+		MetricName: "my_metrics",
+		GaugeCount: 1,
+	})
+	record := &define.Record{
+		RecordType: define.RecordMetrics,
+		Data:       g.Generate(),
+	}
+
+	_, err := factory.Process(record)
+	assert.NoError(t, err)
+
+	metrics := record.Data.(pmetric.Metrics)
+	assert.Equal(t, "renamed", testkits.FirstMetric(metrics).Name())
+	assert.Equal(t, map[string]interface{}{"service": "api"}, testkits.FirstGaugeDataPoint(metrics).Attributes().AsRaw())
+}
+
+func TestLogsStatements(t *testing.T) {
+	content := `
+processor:
+  - name: "transformer/common"
+    config:
+      logs:
+        - context: "log"
+          statements:
+            - 'truncate(body, 4)'
+            - 'set(severity_text, "INFO")'
+`
+	factory := processor.MustCreateFactory(content, NewFactory)
+
+	g := generator.NewLogsGenerator(define.LogsOptions{
+		LogName:   "testlogs",
+		LogCount:  1,
+		LogLength: 10,
+	})
+	record := &define.Record{
+		RecordType: define.RecordLogs,
+		Data:       g.Generate(),
+	}
+
+	_, err := factory.Process(record)
+	assert.NoError(t, err)
+
+	logRecord := testkits.FirstLogRecord(record.Data.(plog.Logs))
+	assert.Len(t, logRecord.Body().StringVal(), 4)
+	assert.Equal(t, "INFO", logRecord.SeverityText())
+}
@@ -10,15 +10,22 @@
 package receiver
 
 import (
+	"crypto/subtle"
+	"encoding/json"
 	"net/http"
 	"net/http/pprof"
 	"runtime/debug"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/serieslimiter"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/tap"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/libgse/beat"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/pprofsnapshot"
@@ -30,6 +37,9 @@ func init() {
 		promhttp.Handler().ServeHTTP(w, r)
 	})
 
+	// 查询 serieslimiter 各 dataid 的基数分布 支持 dataid/top 参数
+	registerAdminHttpGetRoute(statsSource, "/-/cardinality", cardinalityHandler)
+
 	const adminSource = "admin"
 	registerAdminHttpPostRoute(adminSource, "/-/logger", func(w http.ResponseWriter, r *http.Request) {
 		level := r.FormValue("level")
@@ -41,6 +51,9 @@ func init() {
 		w.Write([]byte(`{"status": "success"}`))
 	})
 
+	// 实时查看指定 token/dataid 的数据在各 processor 执行前后的快照 需要鉴权
+	registerAdminHttpGetRoute(adminSource, "/-/tap", tapHandler)
+
 	// debug 专用
 	registerAdminHttpPostRoute(adminSource, "/-/freemem", func(w http.ResponseWriter, r *http.Request) {
 		debug.FreeOSMemory()
@@ -56,6 +69,143 @@ func init() {
 	registerAdminHttpGetRoute(pprofSource, "/debug/pprof/{other}", pprof.Index)
 }
 
+const defaultCardinalityTop = 10
+
+func cardinalityHandler(w http.ResponseWriter, r *http.Request) {
+	var dataID int
+	if s := r.FormValue("dataid"); s != "" {
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status": "failed", "message": "invalid dataid"}`))
+			return
+		}
+		dataID = i
+	}
+
+	top := defaultCardinalityTop
+	if s := r.FormValue("top"); s != "" {
+		i, err := strconv.Atoi(s)
+		if err != nil || i <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status": "failed", "message": "invalid top"}`))
+			return
+		}
+		top = i
+	}
+
+	stats := serieslimiter.Snapshot(int32(dataID), top)
+	if stats == nil {
+		stats = []serieslimiter.Stats{}
+	}
+	b, err := json.Marshal(stats)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+const defaultTapQps = 1
+
+func writeTapFailed(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	b, _ := json.Marshal(map[string]string{"status": "failed", "message": msg})
+	w.Write(b)
+}
+
+func tapAuthorized(r *http.Request, authToken string) bool {
+	s := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(s), []byte(authToken)) == 1
+}
+
+// tapHandler 以 ndjson 格式持续输出采样快照 直至会话过期或客户端断开
+// 参数: token/dataid（至少其一） type（数据类型） qps（每秒采样 record 数） duration（会话时长）
+func tapHandler(w http.ResponseWriter, r *http.Request) {
+	conf := globalConfig.AdminServer.Tap
+	if !conf.Enabled || conf.AuthToken == "" {
+		writeTapFailed(w, http.StatusForbidden, "tap disabled")
+		return
+	}
+	if !tapAuthorized(r, conf.AuthToken) {
+		writeTapFailed(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	filter := tap.Filter{
+		Token:      r.FormValue("token"),
+		RecordType: define.RecordType(r.FormValue("type")),
+	}
+	if s := r.FormValue("dataid"); s != "" {
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			writeTapFailed(w, http.StatusBadRequest, "invalid dataid")
+			return
+		}
+		filter.DataID = int32(i)
+	}
+
+	qps := defaultTapQps
+	if s := r.FormValue("qps"); s != "" {
+		i, err := strconv.Atoi(s)
+		if err != nil || i <= 0 {
+			writeTapFailed(w, http.StatusBadRequest, "invalid qps")
+			return
+		}
+		qps = i
+	}
+
+	var duration time.Duration
+	if s := r.FormValue("duration"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			writeTapFailed(w, http.StatusBadRequest, "invalid duration")
+			return
+		}
+		duration = d
+	}
+
+	session, err := tap.Subscribe(filter, qps, duration)
+	if err != nil {
+		if err == tap.ErrTooManySessions {
+			writeTapFailed(w, http.StatusTooManyRequests, err.Error())
+			return
+		}
+		writeTapFailed(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer session.Close()
+	logger.Infof("tap session started, filter: %+v, qps: %d, expireAt: %v", filter, qps, session.ExpireAt())
+
+	// 会话时长可能超过 admin server 的写超时
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(session.ExpireAt().Add(time.Minute))
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	_ = rc.Flush()
+
+	encoder := json.NewEncoder(w)
+	for {
+		select {
+		case entry, ok := <-session.C():
+			if !ok {
+				logger.Infof("tap session expired, filter: %+v, dropped: %d", filter, session.Dropped())
+				return
+			}
+			if err := encoder.Encode(entry); err != nil {
+				return
+			}
+			_ = rc.Flush()
+
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
 // AdminHttpRouter 返回 Admin mux.Router
 func AdminHttpRouter() *mux.Router {
 	return adminMgr.httpRouter
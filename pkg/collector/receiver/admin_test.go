@@ -0,0 +1,122 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package receiver
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/generator"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/tap"
+)
+
+func TestAdminServerConfig(t *testing.T) {
+	content := `
+receiver:
+  admin_server:
+    enabled: true
+    endpoint: "localhost:4310"
+    tap:
+      enabled: true
+      auth_token: "secret"
+`
+	var c Config
+	assert.NoError(t, confengine.MustLoadConfigContent(content).UnpackChild(define.ConfigFieldReceiver, &c))
+	assert.True(t, c.AdminServer.Enabled)
+	assert.Equal(t, "localhost:4310", c.AdminServer.Endpoint)
+	assert.Equal(t, TapConfig{Enabled: true, AuthToken: "secret"}, c.AdminServer.Tap)
+}
+
+func TestTapHandler(t *testing.T) {
+	defer func() { globalConfig = Config{} }()
+
+	newRequest := func(url, authToken string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if authToken != "" {
+			req.Header.Set("Authorization", "Bearer "+authToken)
+		}
+		return req
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		tapHandler(rw, newRequest("/-/tap?dataid=1001", "secret"))
+		assert.Equal(t, http.StatusForbidden, rw.Code)
+	})
+
+	globalConfig.AdminServer.Tap = TapConfig{Enabled: true, AuthToken: "secret"}
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		tapHandler(rw, newRequest("/-/tap?dataid=1001", "wrong"))
+		assert.Equal(t, http.StatusUnauthorized, rw.Code)
+	})
+
+	t.Run("Invalid Params", func(t *testing.T) {
+		for _, url := range []string{
+			"/-/tap",
+			"/-/tap?dataid=x",
+			"/-/tap?dataid=1001&qps=0",
+			"/-/tap?dataid=1001&duration=x",
+		} {
+			rw := httptest.NewRecorder()
+			tapHandler(rw, newRequest(url, "secret"))
+			assert.Equal(t, http.StatusBadRequest, rw.Code, url)
+		}
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		svr := httptest.NewServer(http.HandlerFunc(tapHandler))
+		defer svr.Close()
+
+		req, err := http.NewRequest(http.MethodGet, svr.URL+"/-/tap?dataid=1001&type=traces&duration=1s", nil)
+		assert.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer secret")
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		g := generator.NewTracesGenerator(define.TracesOptions{SpanCount: 1})
+		record := &define.Record{
+			RecordType: define.RecordTraces,
+			Token:      define.Token{Original: "token1", TracesDataId: 1001},
+			Data:       g.Generate(),
+		}
+		taps := tap.Match("traces_pipeline", record)
+		assert.NotNil(t, taps)
+		taps.Capture("resource_filter/add", tap.PositionBefore, record, nil)
+
+		start := time.Now()
+		var entries []tap.Entry
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var entry tap.Entry
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+			entries = append(entries, entry)
+		}
+
+		// 会话到期后服务端主动结束响应
+		assert.Less(t, time.Since(start), 5*time.Second)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, "resource_filter/add", entries[0].Processor)
+		assert.Equal(t, int32(1001), entries[0].DataID)
+	})
+}
@@ -10,36 +10,75 @@
 package receiver
 
 import (
+	"time"
+
 	"github.com/elastic/beats/libbeat/common/transport/tlscommon"
 
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/confengine"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/mapstructure"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
 )
 
 type ComponentConfig struct {
-	Jaeger      ComponentCommon `config:"jaeger"`
-	Otlp        ComponentCommon `config:"otlp"`
-	PushGateway ComponentCommon `config:"pushgateway"`
-	RemoteWrite ComponentCommon `config:"remotewrite"`
-	Zipkin      ComponentCommon `config:"zipkin"`
-	Skywalking  ComponentCommon `config:"skywalking"`
-	Pyroscope   ComponentCommon `config:"pyroscope"`
-	Fta         ComponentCommon `config:"fta"`
-	Beat        ComponentCommon `config:"beat"`
-	Tars        ComponentCommon `config:"tars"`
+	Jaeger        ComponentCommon     `config:"jaeger"`
+	Otlp          ComponentCommon     `config:"otlp"`
+	PushGateway   ComponentCommon     `config:"pushgateway"`
+	RemoteWrite   RemoteWriteConfig   `config:"remotewrite"`
+	Zipkin        ComponentCommon     `config:"zipkin"`
+	Skywalking    ComponentCommon     `config:"skywalking"`
+	Pyroscope     ComponentCommon     `config:"pyroscope"`
+	Fta           ComponentCommon     `config:"fta"`
+	Beat          ComponentCommon     `config:"beat"`
+	Tars          ComponentCommon     `config:"tars"`
+	Scrape        ComponentCommon     `config:"scrape"`
+	Statsd        StatsdConfig        `config:"statsd"`
+	Loki          ComponentCommon     `config:"loki"`
+	FluentForward FluentForwardConfig `config:"fluentforward"`
 }
 
 type ComponentCommon struct {
 	Enabled bool `config:"enabled"`
 }
 
+// StatsdConfig statsd 协议无法携带 token 因此所有数据统一使用配置的固定 token
+type StatsdConfig struct {
+	Enabled       bool          `config:"enabled"`
+	Transport     string        `config:"transport"` // udp/tcp
+	Endpoint      string        `config:"endpoint"`
+	FlushInterval time.Duration `config:"flush_interval"`
+	Token         string        `config:"token"`
+}
+
+// RemoteWriteConfig 采集器本身不存储数据 配置 ReadEndpoint 后 remote-read 请求会转发至该地址
+// ReadEndpoint 须为兼容 prometheus remote-read 协议的存储 如 prometheus/thanos/victoriametrics
+type RemoteWriteConfig struct {
+	Enabled      bool          `config:"enabled"`
+	ReadEndpoint string        `config:"read_endpoint"`
+	ReadTimeout  time.Duration `config:"read_timeout"`
+}
+
+// FluentForwardConfig 监听 tcp 端口接收 fluent forward 协议数据 上报数据统一归属于 Token 对应的应用
+//
+// 配置 SharedKey 后连接须先完成 HELO/PING/PONG 握手 暂不支持用户名密码认证
+// 未配置 SharedKey 时任何能访问端口的客户端都可以使用该 Token 上报 须将 Endpoint 绑定在可信的地址上
+type FluentForwardConfig struct {
+	Enabled   bool   `config:"enabled"`
+	Endpoint  string `config:"endpoint"`
+	Token     string `config:"token"`
+	SharedKey string `config:"shared_key"`
+	Hostname  string `config:"hostname"` // PONG 响应中的服务端主机名 默认为本机主机名
+
+	// MaxMessageBytes 单条消息的最大字节数 PackedForward 模式按解压后的大小计算
+	MaxMessageBytes int `config:"max_message_bytes"`
+}
+
 type Config struct {
-	RecvServer  HttpServerConfig `config:"http_server"`
-	AdminServer HttpServerConfig `config:"admin_server"`
-	GrpcServer  GrpcServerConfig `config:"grpc_server"`
-	TarsServer  TarsServerConfig `config:"tars_server"`
-	Components  ComponentConfig  `config:"components"`
+	RecvServer  HttpServerConfig  `config:"http_server"`
+	AdminServer AdminServerConfig `config:"admin_server"`
+	GrpcServer  GrpcServerConfig  `config:"grpc_server"`
+	TarsServer  TarsServerConfig  `config:"tars_server"`
+	Components  ComponentConfig   `config:"components"`
 }
 
 type HttpServerConfig struct {
@@ -49,6 +88,17 @@ type HttpServerConfig struct {
 	TLS         *tlscommon.ServerConfig `config:"ssl"`
 }
 
+type AdminServerConfig struct {
+	HttpServerConfig `config:",inline"`
+	Tap              TapConfig `config:"tap"`
+}
+
+// TapConfig 实时查看流水线数据的调试接口配置 请求需携带 `Authorization: Bearer <AuthToken>` 头部
+type TapConfig struct {
+	Enabled   bool   `config:"enabled"`
+	AuthToken string `config:"auth_token"`
+}
+
 type GrpcServerConfig struct {
 	Enabled     bool     `config:"enabled"`
 	Endpoint    string   `config:"endpoint"`
@@ -74,30 +124,64 @@ type SkywalkingRule struct {
 	Field   string `mapstructure:"field"`
 }
 
-// LoadConfigFrom 允许 receiver 加载 skywalking 应用层级自定义参数下发配置
-func LoadConfigFrom(conf *confengine.Config) map[string]SkywalkingConfig {
+// ScrapeConfig 应用层级的 prometheus 拉取配置
+type ScrapeConfig struct {
+	Interval       time.Duration   `mapstructure:"interval"`
+	Timeout        time.Duration   `mapstructure:"timeout"`
+	MaxSeries      int             `mapstructure:"max_series"`
+	GcInterval     time.Duration   `mapstructure:"gc_interval"`
+	Targets        []ScrapeTarget  `mapstructure:"targets"`
+	RelabelConfigs []RelabelConfig `mapstructure:"relabel_configs"`
+}
+
+type ScrapeTarget struct {
+	Url    string            `mapstructure:"url"`
+	Labels map[string]string `mapstructure:"labels"`
+}
+
+// RelabelConfig 字段语义与 prometheus relabel_configs 保持一致
+type RelabelConfig struct {
+	SourceLabels []string `mapstructure:"source_labels"`
+	Separator    string   `mapstructure:"separator"`
+	Regex        string   `mapstructure:"regex"`
+	Modulus      uint64   `mapstructure:"modulus"`
+	TargetLabel  string   `mapstructure:"target_label"`
+	Replacement  string   `mapstructure:"replacement"`
+	Action       string   `mapstructure:"action"`
+}
+
+type subConfigContent struct {
+	Type           string                 `config:"type"`
+	Token          string                 `config:"token"`
+	SkywalkingConf map[string]interface{} `config:"skywalking_agent"`
+	ScrapeConf     map[string]interface{} `config:"scrape"`
+}
+
+func loadSubConfigContents(conf *confengine.Config) []subConfigContent {
 	var apmConf define.ApmConfig
-	batches := make(map[string]SkywalkingConfig)
 	if err := conf.UnpackChild(define.ConfigFieldApmConfig, &apmConf); err != nil {
-		return batches
-	}
-
-	type T struct {
-		Type           string                 `config:"type"`
-		Token          string                 `config:"token"`
-		SkywalkingConf map[string]interface{} `config:"skywalking_agent"`
+		return nil
 	}
 
+	var contents []subConfigContent
 	subConfig := confengine.LoadConfigPatterns(apmConf.Patterns)
 	for _, subConf := range subConfig {
-		var input T
+		var input subConfigContent
 		if err := subConf.Unpack(&input); err != nil {
 			continue
 		}
 		if input.Type != define.ConfigTypeSubConfig {
 			continue
 		}
+		contents = append(contents, input)
+	}
+	return contents
+}
 
+// LoadConfigFrom 允许 receiver 加载 skywalking 应用层级自定义参数下发配置
+func LoadConfigFrom(conf *confengine.Config) map[string]SkywalkingConfig {
+	batches := make(map[string]SkywalkingConfig)
+	for _, input := range loadSubConfigContents(conf) {
 		var swConfig SkywalkingConfig
 		err := mapstructure.Decode(input.SkywalkingConf, &swConfig)
 		if err != nil {
@@ -107,3 +191,24 @@ func LoadConfigFrom(conf *confengine.Config) map[string]SkywalkingConfig {
 	}
 	return batches
 }
+
+// LoadScrapeConfigFrom 加载应用层级的 prometheus 拉取配置 未配置拉取目标的应用将被忽略
+func LoadScrapeConfigFrom(conf *confengine.Config) map[string]ScrapeConfig {
+	batches := make(map[string]ScrapeConfig)
+	for _, input := range loadSubConfigContents(conf) {
+		if len(input.ScrapeConf) == 0 {
+			continue
+		}
+
+		var scrapeConfig ScrapeConfig
+		if err := mapstructure.Decode(input.ScrapeConf, &scrapeConfig); err != nil {
+			logger.Warnf("failed to decode scrape config, token=%s, err: %v", input.Token, err)
+			continue
+		}
+		if len(scrapeConfig.Targets) == 0 {
+			continue
+		}
+		batches[input.Token] = scrapeConfig
+	}
+	return batches
+}
@@ -11,6 +11,7 @@ package receiver
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -54,3 +55,35 @@ apm:
 		},
 	}, subConfig)
 }
+
+func TestLoadScrapeConfig(t *testing.T) {
+	content := `
+apm:
+  patterns:
+    - "../example/fixtures/subconfig.yml"
+`
+	config, err := confengine.LoadConfigContent(content)
+	assert.NoError(t, err)
+
+	subConfigs := LoadScrapeConfigFrom(config)
+	assert.Len(t, subConfigs, 1)
+
+	subConfig, ok := subConfigs["token1"]
+	assert.True(t, ok)
+	assert.Equal(t, ScrapeConfig{
+		Interval: 30 * time.Second,
+		Targets: []ScrapeTarget{
+			{
+				Url:    "http://127.0.0.1:8080/metrics",
+				Labels: map[string]string{"job": "sidecar"},
+			},
+		},
+		RelabelConfigs: []RelabelConfig{
+			{
+				SourceLabels: []string{"__name__"},
+				Regex:        "go_.*",
+				Action:       "drop",
+			},
+		},
+	}, subConfig)
+}
@@ -0,0 +1,335 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package fluentforward
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tinylib/msgp/msgp"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+const (
+	eventTimeExtType = 0
+
+	optionChunk      = "chunk"
+	optionCompressed = "compressed"
+	compressedGzip   = "gzip"
+
+	attrFluentTag = "fluent.tag"
+)
+
+var errMessageTooLarge = errors.New("message too large")
+
+// bodyKeys 命中的字段作为日志内容 其余字段作为日志属性
+var bodyKeys = []string{"log", "message"}
+
+type event struct {
+	time   time.Time
+	record map[string]interface{}
+}
+
+// message 一次 forward 请求 可能包含多条日志
+type message struct {
+	tag    string
+	events []event
+	chunk  string
+}
+
+// decodeMessage 读取一条 forward 协议消息 兼容 Message/Forward/PackedForward/CompressedPackedForward 四种模式
+// 参见 https://github.com/fluent/fluentd/wiki/Forward-Protocol-Specification-v1
+//
+// 消息先以原始字节读出 超过 maxBytes 时立即返回错误 避免按客户端声明的长度分配内存
+func decodeMessage(r *msgp.Reader, maxBytes int) (*message, error) {
+	raw, err := readRaw(r, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	return parseMessage(msgp.NewReader(bytes.NewReader(raw)), maxBytes)
+}
+
+// limitedBuffer 写入超过 max 字节时返回 errMessageTooLarge
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.max {
+		return 0, errMessageTooLarge
+	}
+	return b.Buffer.Write(p)
+}
+
+// readRaw 读取下一个完整的 msgpack 对象
+func readRaw(r *msgp.Reader, maxBytes int) ([]byte, error) {
+	buf := &limitedBuffer{max: maxBytes}
+	if _, err := r.CopyNext(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func parseMessage(r *msgp.Reader, maxBytes int) (*message, error) {
+	size, err := r.ReadArrayHeader()
+	if err != nil {
+		return nil, err
+	}
+	if size < 2 || size > 4 {
+		return nil, errors.Errorf("invalid forward message size %d", size)
+	}
+
+	tag, err := r.ReadString()
+	if err != nil {
+		return nil, errors.Wrap(err, "read tag")
+	}
+	msg := &message{tag: tag}
+
+	typ, err := r.NextType()
+	if err != nil {
+		return nil, err
+	}
+
+	var remain uint32
+	var packed []byte
+	switch typ {
+	case msgp.ArrayType: // Forward 模式
+		n, err := r.ReadArrayHeader()
+		if err != nil {
+			return nil, err
+		}
+		for i := uint32(0); i < n; i++ {
+			ev, err := decodeEntry(r)
+			if err != nil {
+				return nil, err
+			}
+			msg.events = append(msg.events, ev)
+		}
+		remain = size - 2
+
+	case msgp.StrType, msgp.BinType: // PackedForward 模式 entries 在读取 option 后解码
+		if typ == msgp.StrType {
+			s, err := r.ReadString()
+			if err != nil {
+				return nil, err
+			}
+			packed = []byte(s)
+		} else {
+			if packed, err = r.ReadBytes(nil); err != nil {
+				return nil, err
+			}
+		}
+		remain = size - 2
+
+	default: // Message 模式
+		if size < 3 {
+			return nil, errors.Errorf("invalid message mode size %d", size)
+		}
+		ts, err := readTime(r)
+		if err != nil {
+			return nil, err
+		}
+		record, err := readRecord(r)
+		if err != nil {
+			return nil, err
+		}
+		msg.events = append(msg.events, event{time: ts, record: record})
+		remain = size - 3
+	}
+
+	var option map[string]interface{}
+	if remain > 0 {
+		v, err := r.ReadIntf()
+		if err != nil {
+			return nil, errors.Wrap(err, "read option")
+		}
+		option, _ = v.(map[string]interface{})
+	}
+	if chunk, ok := option[optionChunk].(string); ok {
+		msg.chunk = chunk
+	}
+
+	if packed != nil {
+		compressed, _ := option[optionCompressed].(string)
+		events, err := decodePackedEntries(packed, compressed, maxBytes)
+		if err != nil {
+			return nil, err
+		}
+		msg.events = events
+	}
+	return msg, nil
+}
+
+// decodePackedEntries 解压后的数据同样受 maxBytes 限制
+func decodePackedEntries(packed []byte, compressed string, maxBytes int) ([]event, error) {
+	var src io.Reader = bytes.NewReader(packed)
+	switch compressed {
+	case "", "text":
+	case compressedGzip:
+		gr, err := gzip.NewReader(src)
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		src = gr
+	default:
+		return nil, errors.Errorf("unsupported compressed type '%s'", compressed)
+	}
+
+	// 多读取 1 字节用于判断是否超出限制
+	cr := &countingReader{r: io.LimitReader(src, int64(maxBytes)+1)}
+	r := msgp.NewReader(cr)
+
+	var events []event
+	for {
+		raw, err := readRaw(r, maxBytes)
+		if cr.n > maxBytes {
+			return nil, errMessageTooLarge
+		}
+		if errors.Is(err, io.EOF) {
+			return events, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		ev, err := decodeEntry(msgp.NewReader(bytes.NewReader(raw)))
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+}
+
+// decodeEntry 解码 [time, record] 结构
+func decodeEntry(r *msgp.Reader) (event, error) {
+	n, err := r.ReadArrayHeader()
+	if err != nil {
+		return event{}, err
+	}
+	if n != 2 {
+		return event{}, errors.Errorf("invalid entry size %d", n)
+	}
+
+	ts, err := readTime(r)
+	if err != nil {
+		return event{}, err
+	}
+	record, err := readRecord(r)
+	if err != nil {
+		return event{}, err
+	}
+	return event{time: ts, record: record}, nil
+}
+
+// readTime 时间字段可能为秒级整数 也可能为 EventTime 扩展类型（纳秒精度）
+func readTime(r *msgp.Reader) (time.Time, error) {
+	v, err := r.ReadIntf()
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "read time")
+	}
+
+	switch t := v.(type) {
+	case int64:
+		return time.Unix(t, 0), nil
+	case uint64:
+		return time.Unix(int64(t), 0), nil
+	case float64:
+		return time.Unix(0, int64(t*float64(time.Second))), nil
+	case *msgp.RawExtension:
+		if t.Type != eventTimeExtType || len(t.Data) != 8 {
+			return time.Time{}, errors.Errorf("invalid event time extension type=%d, len=%d", t.Type, len(t.Data))
+		}
+		sec := binary.BigEndian.Uint32(t.Data[:4])
+		nsec := binary.BigEndian.Uint32(t.Data[4:])
+		return time.Unix(int64(sec), int64(nsec)), nil
+	}
+	return time.Time{}, errors.Errorf("unsupported time type %T", v)
+}
+
+func readRecord(r *msgp.Reader) (map[string]interface{}, error) {
+	v, err := r.ReadIntf()
+	if err != nil {
+		return nil, errors.Wrap(err, "read record")
+	}
+	record, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("unsupported record type %T", v)
+	}
+	return record, nil
+}
+
+func toValue(v interface{}) pcommon.Value {
+	switch val := v.(type) {
+	case string:
+		return pcommon.NewValueString(val)
+	case []byte:
+		return pcommon.NewValueString(string(val))
+	case bool:
+		return pcommon.NewValueBool(val)
+	case int64:
+		return pcommon.NewValueInt(val)
+	case uint64:
+		return pcommon.NewValueInt(int64(val))
+	case float32:
+		return pcommon.NewValueDouble(float64(val))
+	case float64:
+		return pcommon.NewValueDouble(val)
+	case map[string]interface{}:
+		m := pcommon.NewValueMap()
+		for k, item := range val {
+			m.MapVal().Upsert(k, toValue(item))
+		}
+		return m
+	case []interface{}:
+		s := pcommon.NewValueSlice()
+		for _, item := range val {
+			toValue(item).CopyTo(s.SliceVal().AppendEmpty())
+		}
+		return s
+	}
+	return pcommon.NewValueEmpty()
+}
+
+// toLogs tag 以 fluent.tag 属性写入每条日志
+func (msg *message) toLogs(now time.Time) plog.Logs {
+	logs := plog.NewLogs()
+	logRecords := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	observed := pcommon.NewTimestampFromTime(now)
+
+	for _, ev := range msg.events {
+		lr := logRecords.AppendEmpty()
+		lr.SetTimestamp(pcommon.NewTimestampFromTime(ev.time))
+		lr.SetObservedTimestamp(observed)
+		lr.Attributes().UpsertString(attrFluentTag, msg.tag)
+
+		var bodyKey string
+		for _, key := range bodyKeys {
+			if _, ok := ev.record[key]; ok {
+				bodyKey = key
+				break
+			}
+		}
+		for k, v := range ev.record {
+			if k == bodyKey {
+				toValue(v).CopyTo(lr.Body())
+				continue
+			}
+			lr.Attributes().Upsert(k, toValue(v))
+		}
+	}
+	return logs
+}
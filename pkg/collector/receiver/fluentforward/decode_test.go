@@ -0,0 +1,229 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package fluentforward
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tinylib/msgp/msgp"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+func eventTime(t time.Time) *msgp.RawExtension {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint32(data[:4], uint32(t.Unix()))
+	binary.BigEndian.PutUint32(data[4:], uint32(t.Nanosecond()))
+	return &msgp.RawExtension{Type: eventTimeExtType, Data: data}
+}
+
+func writeEntry(w *msgp.Writer, ts interface{}, record map[string]interface{}) {
+	_ = w.WriteArrayHeader(2)
+	_ = w.WriteIntf(ts)
+	_ = w.WriteIntf(record)
+}
+
+func encodeMessageMode(tag string, ts interface{}, record map[string]interface{}, option map[string]interface{}) []byte {
+	var buf bytes.Buffer
+	w := msgp.NewWriter(&buf)
+	size := uint32(3)
+	if option != nil {
+		size = 4
+	}
+	_ = w.WriteArrayHeader(size)
+	_ = w.WriteString(tag)
+	_ = w.WriteIntf(ts)
+	_ = w.WriteIntf(record)
+	if option != nil {
+		_ = w.WriteIntf(option)
+	}
+	_ = w.Flush()
+	return buf.Bytes()
+}
+
+func encodeForwardMode(tag string, ts interface{}, records []map[string]interface{}, option map[string]interface{}) []byte {
+	var buf bytes.Buffer
+	w := msgp.NewWriter(&buf)
+	_ = w.WriteArrayHeader(3)
+	_ = w.WriteString(tag)
+	_ = w.WriteArrayHeader(uint32(len(records)))
+	for _, record := range records {
+		writeEntry(w, ts, record)
+	}
+	_ = w.WriteIntf(option)
+	_ = w.Flush()
+	return buf.Bytes()
+}
+
+func encodePackedForwardMode(tag string, ts interface{}, records []map[string]interface{}, compressed bool) []byte {
+	var entries bytes.Buffer
+	ew := msgp.NewWriter(&entries)
+	for _, record := range records {
+		writeEntry(ew, ts, record)
+	}
+	_ = ew.Flush()
+
+	packed := entries.Bytes()
+	option := map[string]interface{}{optionChunk: "chunk1"}
+	if compressed {
+		var gz bytes.Buffer
+		gw := gzip.NewWriter(&gz)
+		_, _ = gw.Write(packed)
+		_ = gw.Close()
+		packed = gz.Bytes()
+		option[optionCompressed] = compressedGzip
+	}
+
+	var buf bytes.Buffer
+	w := msgp.NewWriter(&buf)
+	_ = w.WriteArrayHeader(3)
+	_ = w.WriteString(tag)
+	_ = w.WriteBytes(packed)
+	_ = w.WriteIntf(option)
+	_ = w.Flush()
+	return buf.Bytes()
+}
+
+func decodeBytes(b []byte) (*message, error) {
+	return decodeMessage(msgp.NewReader(bytes.NewReader(b)), defaultMaxMessageBytes)
+}
+
+func TestDecodeMessage(t *testing.T) {
+	now := time.Unix(1700000000, 123)
+	records := []map[string]interface{}{
+		{"log": "hello", "level": "info"},
+		{"message": "world", "code": int64(200)},
+	}
+
+	t.Run("message mode", func(t *testing.T) {
+		msg, err := decodeBytes(encodeMessageMode("app.log", int64(1700000000), records[0], nil))
+		assert.NoError(t, err)
+		assert.Equal(t, "app.log", msg.tag)
+		assert.Equal(t, "", msg.chunk)
+		assert.Len(t, msg.events, 1)
+		assert.Equal(t, time.Unix(1700000000, 0), msg.events[0].time)
+		assert.Equal(t, records[0], msg.events[0].record)
+	})
+
+	t.Run("message mode with option", func(t *testing.T) {
+		msg, err := decodeBytes(encodeMessageMode("app.log", eventTime(now), records[0], map[string]interface{}{optionChunk: "c1"}))
+		assert.NoError(t, err)
+		assert.Equal(t, "c1", msg.chunk)
+		assert.True(t, now.Equal(msg.events[0].time))
+	})
+
+	t.Run("forward mode", func(t *testing.T) {
+		msg, err := decodeBytes(encodeForwardMode("app.log", eventTime(now), records, map[string]interface{}{optionChunk: "c2"}))
+		assert.NoError(t, err)
+		assert.Equal(t, "c2", msg.chunk)
+		assert.Len(t, msg.events, 2)
+		assert.Equal(t, records[1], msg.events[1].record)
+	})
+
+	t.Run("packed forward mode", func(t *testing.T) {
+		msg, err := decodeBytes(encodePackedForwardMode("app.log", eventTime(now), records, false))
+		assert.NoError(t, err)
+		assert.Equal(t, "chunk1", msg.chunk)
+		assert.Len(t, msg.events, 2)
+	})
+
+	t.Run("compressed packed forward mode", func(t *testing.T) {
+		msg, err := decodeBytes(encodePackedForwardMode("app.log", eventTime(now), records, true))
+		assert.NoError(t, err)
+		assert.Len(t, msg.events, 2)
+		assert.Equal(t, records[0], msg.events[0].record)
+	})
+
+	t.Run("invalid message", func(t *testing.T) {
+		_, err := decodeBytes(encodeMessageMode("app.log", "now", records[0], nil))
+		assert.Error(t, err)
+
+		_, err = decodeBytes([]byte{0x91, 0xa1, 'a'})
+		assert.Error(t, err)
+	})
+
+	t.Run("message too large", func(t *testing.T) {
+		b := encodePackedForwardMode("app.log", int64(1700000000), records, false)
+		_, err := decodeMessage(msgp.NewReader(bytes.NewReader(b)), len(b)-1)
+		assert.ErrorIs(t, err, errMessageTooLarge)
+
+		// 声明长度远超实际数据的 bin 不会按声明长度分配内存
+		var buf bytes.Buffer
+		w := msgp.NewWriter(&buf)
+		_ = w.WriteArrayHeader(3)
+		_ = w.WriteString("app.log")
+		_ = w.WriteBytesHeader(1 << 30)
+		_ = w.Flush()
+		_, err = decodeMessage(msgp.NewReader(bytes.NewReader(buf.Bytes())), 1024)
+		assert.Error(t, err)
+	})
+
+	t.Run("decompressed too large", func(t *testing.T) {
+		large := []map[string]interface{}{{"log": strings.Repeat("x", 4096)}}
+		b := encodePackedForwardMode("app.log", int64(1700000000), large, true)
+		assert.Less(t, len(b), 1024)
+
+		_, err := decodeMessage(msgp.NewReader(bytes.NewReader(b)), 1024)
+		assert.ErrorIs(t, err, errMessageTooLarge)
+
+		msg, err := decodeMessage(msgp.NewReader(bytes.NewReader(b)), 8192)
+		assert.NoError(t, err)
+		assert.Len(t, msg.events, 1)
+	})
+}
+
+func TestMessageToLogs(t *testing.T) {
+	msg := &message{
+		tag: "app.log",
+		events: []event{
+			{
+				time: time.Unix(1700000000, 0),
+				record: map[string]interface{}{
+					"log":    "hello",
+					"stream": []byte("stdout"),
+					"kubernetes": map[string]interface{}{
+						"pod_name": "pod-1",
+					},
+					"tags": []interface{}{"a", int64(1)},
+				},
+			},
+			{
+				time:   time.Unix(1700000001, 0),
+				record: map[string]interface{}{"message": "world", "ok": true},
+			},
+		},
+	}
+
+	logs := msg.toLogs(time.Now())
+	assert.Equal(t, 2, logs.LogRecordCount())
+	logRecords := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+
+	first := logRecords.At(0)
+	assert.Equal(t, "hello", first.Body().StringVal())
+	assert.Equal(t, map[string]interface{}{
+		attrFluentTag: "app.log",
+		"stream":      "stdout",
+		"kubernetes":  map[string]interface{}{"pod_name": "pod-1"},
+		"tags":        []interface{}{"a", int64(1)},
+	}, first.Attributes().AsRaw())
+
+	second := logRecords.At(1)
+	assert.Equal(t, "world", second.Body().StringVal())
+	assert.Equal(t, time.Unix(1700000001, 0).UnixNano(), int64(second.Timestamp()))
+	v, ok := second.Attributes().Get("ok")
+	assert.True(t, ok)
+	assert.True(t, v.BoolVal())
+	assert.Equal(t, plog.SeverityNumberUNDEFINED, second.SeverityNumber())
+}
@@ -0,0 +1,160 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package fluentforward
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tinylib/msgp/msgp"
+)
+
+const (
+	typeHelo = "HELO"
+	typePing = "PING"
+	typePong = "PONG"
+
+	handshakeTimeout = 10 * time.Second
+)
+
+// sharedKeyDigest 计算 sha512_hex(salt + hostname + nonce + sharedKey)
+func sharedKeyDigest(salt, hostname string, nonce []byte, sharedKey string) string {
+	h := sha512.New()
+	h.Write([]byte(salt))
+	h.Write([]byte(hostname))
+	h.Write(nonce)
+	h.Write([]byte(sharedKey))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func toString(v interface{}) (string, bool) {
+	switch val := v.(type) {
+	case string:
+		return val, true
+	case []byte:
+		return string(val), true
+	}
+	return "", false
+}
+
+// writeHelo 发送 ['HELO', {nonce, auth, keepalive}] 不支持用户名密码认证 auth 为空
+func writeHelo(w *msgp.Writer, nonce []byte) error {
+	if err := w.WriteArrayHeader(2); err != nil {
+		return err
+	}
+	if err := w.WriteString(typeHelo); err != nil {
+		return err
+	}
+	if err := w.WriteMapHeader(3); err != nil {
+		return err
+	}
+	if err := w.WriteString("nonce"); err != nil {
+		return err
+	}
+	if err := w.WriteBytes(nonce); err != nil {
+		return err
+	}
+	if err := w.WriteString("auth"); err != nil {
+		return err
+	}
+	if err := w.WriteString(""); err != nil {
+		return err
+	}
+	if err := w.WriteString("keepalive"); err != nil {
+		return err
+	}
+	if err := w.WriteBool(true); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// ping 客户端响应的 ['PING', hostname, shared_key_salt, shared_key_hexdigest, username, password]
+type ping struct {
+	hostname string
+	salt     string
+	digest   string
+}
+
+func readPing(r *msgp.Reader) (*ping, error) {
+	v, err := r.ReadIntf()
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := v.([]interface{})
+	if !ok || len(arr) < 4 {
+		return nil, errors.New("invalid PING message")
+	}
+
+	var fields [4]string
+	for i := range fields {
+		if fields[i], ok = toString(arr[i]); !ok {
+			return nil, errors.Errorf("invalid PING field %d", i)
+		}
+	}
+	if fields[0] != typePing {
+		return nil, errors.Errorf("expect PING message, got '%s'", fields[0])
+	}
+	return &ping{hostname: fields[1], salt: fields[2], digest: fields[3]}, nil
+}
+
+// writePong 发送 ['PONG', auth_result, reason, hostname, shared_key_hexdigest]
+func writePong(w *msgp.Writer, ok bool, reason, hostname, digest string) error {
+	if err := w.WriteArrayHeader(5); err != nil {
+		return err
+	}
+	if err := w.WriteString(typePong); err != nil {
+		return err
+	}
+	if err := w.WriteBool(ok); err != nil {
+		return err
+	}
+	if err := w.WriteString(reason); err != nil {
+		return err
+	}
+	if err := w.WriteString(hostname); err != nil {
+		return err
+	}
+	if err := w.WriteString(digest); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// handshake 执行 forward 协议的 shared_key 认证
+// 参见 https://github.com/fluent/fluentd/wiki/Forward-Protocol-Specification-v1#handshake-messages
+func handshake(r *msgp.Reader, w *msgp.Writer, sharedKey, hostname string) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	if err := writeHelo(w, nonce); err != nil {
+		return errors.Wrap(err, "write HELO")
+	}
+
+	p, err := readPing(r)
+	if err != nil {
+		return errors.Wrap(err, "read PING")
+	}
+
+	expected := sharedKeyDigest(p.salt, p.hostname, nonce, sharedKey)
+	if subtle.ConstantTimeCompare([]byte(p.digest), []byte(expected)) != 1 {
+		_ = writePong(w, false, "shared_key mismatch", hostname, "")
+		return errors.Errorf("shared_key mismatch from '%s'", p.hostname)
+	}
+	if err := writePong(w, true, "", hostname, sharedKeyDigest(p.salt, hostname, nonce, sharedKey)); err != nil {
+		return errors.Wrap(err, "write PONG")
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package fluentforward
+
+import (
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tinylib/msgp/msgp"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/utils"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+const (
+	defaultEndpoint        = ":24224"
+	defaultMaxMessageBytes = 16 << 20
+	keyAck                 = "ack"
+)
+
+func init() {
+	receiver.RegisterReadyFunc(define.SourceFluentForward, Ready)
+	receiver.RegisterStopFunc(define.SourceFluentForward, Stop)
+}
+
+var (
+	globalMut    sync.Mutex
+	globalServer *Server
+)
+
+// Ready forward 协议不注册路由 而是独立监听 tcp 端口
+func Ready(config receiver.ComponentConfig) {
+	if !config.FluentForward.Enabled {
+		return
+	}
+
+	svr := NewServer(config.FluentForward)
+	if err := svr.Start(); err != nil {
+		logger.Errorf("failed to start fluent forward server, err: %v", err)
+		return
+	}
+
+	globalMut.Lock()
+	globalServer = svr
+	globalMut.Unlock()
+}
+
+// Stop 停止 Ready 中启动的 forward 服务
+func Stop() {
+	globalMut.Lock()
+	svr := globalServer
+	globalServer = nil
+	globalMut.Unlock()
+
+	if svr != nil {
+		svr.Stop()
+	}
+}
+
+var metricMonitor = receiver.DefaultMetricMonitor.Source(define.SourceFluentForward)
+
+type Server struct {
+	receiver.Publisher
+	pipeline.Validator
+
+	config receiver.FluentForwardConfig
+
+	mut      sync.Mutex
+	listener net.Listener
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewServer(config receiver.FluentForwardConfig) *Server {
+	if config.Endpoint == "" {
+		config.Endpoint = defaultEndpoint
+	}
+	if config.Hostname == "" {
+		config.Hostname, _ = os.Hostname()
+	}
+	if config.MaxMessageBytes <= 0 {
+		config.MaxMessageBytes = defaultMaxMessageBytes
+	}
+	return &Server{
+		config: config,
+		done:   make(chan struct{}),
+	}
+}
+
+// Addr 返回实际监听的地址
+func (s *Server) Addr() net.Addr {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.listener != nil {
+		return s.listener.Addr()
+	}
+	return nil
+}
+
+func (s *Server) Start() error {
+	logger.Infof("start to listen fluent forward server at: %v", s.config.Endpoint)
+	if s.config.SharedKey == "" {
+		logger.Warnf("fluent forward server has no shared_key, make sure endpoint '%s' is only reachable by trusted clients", s.config.Endpoint)
+	}
+
+	l, err := net.Listen("tcp", s.config.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	s.mut.Lock()
+	s.listener = l
+	s.mut.Unlock()
+
+	s.wg.Add(1)
+	go s.serve(l)
+	return nil
+}
+
+func (s *Server) Stop() {
+	close(s.done)
+
+	s.mut.Lock()
+	if s.listener != nil {
+		_ = s.listener.Close()
+	}
+	s.mut.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Server) serve(l net.Listener) {
+	defer s.wg.Done()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+			logger.Warnf("fluent forward failed to accept tcp connection, err: %v", err)
+			continue
+		}
+
+		s.wg.Add(1)
+		go s.handleConn(conn)
+	}
+}
+
+// countingReader 统计连接读取的字节数 由于 msgp.Reader 存在缓冲 单条消息的统计值为近似值
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += n
+	return n, err
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer s.wg.Done()
+
+	closed := make(chan struct{})
+	defer func() {
+		close(closed)
+		_ = conn.Close()
+	}()
+
+	go func() {
+		select {
+		case <-s.done:
+			_ = conn.Close()
+		case <-closed:
+		}
+	}()
+
+	ip := utils.ParseRequestIP(conn.RemoteAddr().String())
+	cr := &countingReader{r: conn}
+	reader := msgp.NewReader(cr)
+	writer := msgp.NewWriter(conn)
+
+	if s.config.SharedKey != "" {
+		_ = conn.SetDeadline(time.Now().Add(handshakeTimeout))
+		if err := handshake(reader, writer, s.config.SharedKey, s.config.Hostname); err != nil {
+			logger.Warnf("fluent forward handshake failed, ip=%v, err: %v", ip, err)
+			metricMonitor.IncDroppedCounter(define.RequestTcp, define.RecordLogs)
+			return
+		}
+		_ = conn.SetDeadline(time.Time{})
+	}
+
+	consumed := cr.n
+	for {
+		msg, err := decodeMessage(reader, s.config.MaxMessageBytes)
+		if err != nil {
+			// 连接关闭属于正常退出 其余错误意味着数据流已无法对齐 只能断开连接
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				logger.Warnf("fluent forward failed to decode message, ip=%v, err: %v", ip, err)
+				metricMonitor.IncDroppedCounter(define.RequestTcp, define.RecordLogs)
+			}
+			return
+		}
+
+		size := cr.n - consumed
+		consumed = cr.n
+		s.handle(msg, ip, size)
+
+		// 预检失败的数据重试也无法成功 同样响应 ack 避免客户端无限重发
+		if msg.chunk != "" {
+			if err := writeAck(writer, msg.chunk); err != nil {
+				logger.Warnf("fluent forward failed to write ack, ip=%v, err: %v", ip, err)
+				return
+			}
+		}
+	}
+}
+
+// handle 预检失败时丢弃数据并计入 dropped 指标
+func (s *Server) handle(msg *message, ip string, size int) {
+	defer utils.HandleCrash()
+
+	start := time.Now()
+	r := &define.Record{
+		RecordType:    define.RecordLogs,
+		RequestType:   define.RequestTcp,
+		RequestClient: define.RequestClient{IP: ip},
+		Token:         define.Token{Original: s.config.Token},
+	}
+
+	code, processorName, err := s.Validate(r)
+	if err != nil {
+		logger.WarnfRate(time.Minute, r.Token.Original, "run pre-check failed, code=%d, ip=%s, err: %v", code, ip, err)
+		metricMonitor.IncPreCheckFailedCounter(define.RequestTcp, define.RecordLogs, processorName, r.Token.Original, code)
+		metricMonitor.IncDroppedCounter(define.RequestTcp, define.RecordLogs)
+		return
+	}
+
+	if len(msg.events) > 0 {
+		r.Data = msg.toLogs(start)
+		s.Publish(r)
+	}
+	receiver.RecordHandleMetrics(metricMonitor, r.Token, define.RequestTcp, define.RecordLogs, size, start)
+}
+
+func writeAck(w *msgp.Writer, chunk string) error {
+	if err := w.WriteMapHeader(1); err != nil {
+		return err
+	}
+	if err := w.WriteString(keyAck); err != nil {
+		return err
+	}
+	if err := w.WriteString(chunk); err != nil {
+		return err
+	}
+	return w.Flush()
+}
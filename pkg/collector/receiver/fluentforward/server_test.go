@@ -0,0 +1,182 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package fluentforward
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/tinylib/msgp/msgp"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/atomic"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver"
+)
+
+func TestReady(t *testing.T) {
+	assert.NotPanics(t, func() {
+		Ready(receiver.ComponentConfig{})
+	})
+}
+
+func TestReadyStop(t *testing.T) {
+	Ready(receiver.ComponentConfig{
+		FluentForward: receiver.FluentForwardConfig{Enabled: true, Endpoint: "127.0.0.1:0"},
+	})
+	assert.NotNil(t, globalServer)
+	addr := globalServer.Addr().String()
+
+	Stop()
+	assert.Nil(t, globalServer)
+	_, err := net.Dial("tcp", addr)
+	assert.Error(t, err)
+	assert.NotPanics(t, Stop)
+}
+
+func newTestServer(code define.StatusCode, err error) (*Server, *atomic.Int64) {
+	return newTestServerWithKey(code, err, "")
+}
+
+func newTestServerWithKey(code define.StatusCode, err error, sharedKey string) (*Server, *atomic.Int64) {
+	n := atomic.NewInt64(0)
+	svr := NewServer(receiver.FluentForwardConfig{
+		Endpoint:  "127.0.0.1:0",
+		Token:     "token1",
+		SharedKey: sharedKey,
+		Hostname:  "server",
+	})
+	svr.Publisher = receiver.Publisher{Func: func(r *define.Record) {
+		if r.Token.Original == "token1" && r.RecordType == define.RecordLogs {
+			n.Add(int64(r.Data.(plog.Logs).LogRecordCount()))
+		}
+	}}
+	svr.Validator = pipeline.Validator{Func: func(r *define.Record) (define.StatusCode, string, error) {
+		return code, "", err
+	}}
+	return svr, n
+}
+
+func TestServer(t *testing.T) {
+	records := []map[string]interface{}{{"log": "hello"}, {"log": "world"}}
+
+	t.Run("ack", func(t *testing.T) {
+		svr, n := newTestServer(define.StatusCodeOK, nil)
+		assert.NoError(t, svr.Start())
+		defer svr.Stop()
+
+		conn, err := net.Dial("tcp", svr.Addr().String())
+		assert.NoError(t, err)
+		defer conn.Close()
+
+		_, err = conn.Write(encodeMessageMode("app.log", int64(1700000000), records[0], nil))
+		assert.NoError(t, err)
+		_, err = conn.Write(encodePackedForwardMode("app.log", int64(1700000000), records, true))
+		assert.NoError(t, err)
+
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		resp, err := msgp.NewReader(conn).ReadIntf()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{keyAck: "chunk1"}, resp)
+		assert.Eventually(t, func() bool { return n.Load() == 3 }, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("precheck failed", func(t *testing.T) {
+		svr, n := newTestServer(define.StatusCodeUnauthorized, errors.New("MUST ERROR"))
+		assert.NoError(t, svr.Start())
+
+		conn, err := net.Dial("tcp", svr.Addr().String())
+		assert.NoError(t, err)
+		defer conn.Close()
+
+		_, err = conn.Write(encodePackedForwardMode("app.log", int64(1700000000), records, false))
+		assert.NoError(t, err)
+
+		// 未通过校验的数据同样响应 ack 避免客户端重发
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		resp, err := msgp.NewReader(conn).ReadIntf()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{keyAck: "chunk1"}, resp)
+
+		svr.Stop()
+		assert.Equal(t, int64(0), n.Load())
+	})
+}
+
+// clientHandshake 模拟客户端完成 HELO/PING/PONG 握手 返回 PONG 内容
+func clientHandshake(t *testing.T, conn net.Conn, sharedKey string) []interface{} {
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reader := msgp.NewReader(conn)
+	helo, err := reader.ReadIntf()
+	assert.NoError(t, err)
+	assert.Equal(t, typeHelo, helo.([]interface{})[0])
+	nonce := helo.([]interface{})[1].(map[string]interface{})["nonce"].([]byte)
+
+	w := msgp.NewWriter(conn)
+	assert.NoError(t, w.WriteArrayHeader(6))
+	for _, s := range []string{typePing, "client", "salt", sharedKeyDigest("salt", "client", nonce, sharedKey), "", ""} {
+		assert.NoError(t, w.WriteString(s))
+	}
+	assert.NoError(t, w.Flush())
+
+	pong, err := reader.ReadIntf()
+	assert.NoError(t, err)
+	pongArr := pong.([]interface{})
+	assert.Equal(t, typePong, pongArr[0])
+	if pongArr[1].(bool) {
+		assert.Equal(t, sharedKeyDigest("salt", "server", nonce, sharedKey), pongArr[4])
+	}
+	return pongArr
+}
+
+func TestServerSharedKey(t *testing.T) {
+	records := []map[string]interface{}{{"log": "hello"}}
+
+	t.Run("success", func(t *testing.T) {
+		svr, n := newTestServerWithKey(define.StatusCodeOK, nil, "secret")
+		assert.NoError(t, svr.Start())
+		defer svr.Stop()
+
+		conn, err := net.Dial("tcp", svr.Addr().String())
+		assert.NoError(t, err)
+		defer conn.Close()
+
+		pong := clientHandshake(t, conn, "secret")
+		assert.Equal(t, true, pong[1])
+
+		_, err = conn.Write(encodeForwardMode("app.log", int64(1700000000), records, nil))
+		assert.NoError(t, err)
+		assert.Eventually(t, func() bool { return n.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		svr, n := newTestServerWithKey(define.StatusCodeOK, nil, "secret")
+		assert.NoError(t, svr.Start())
+		defer svr.Stop()
+
+		conn, err := net.Dial("tcp", svr.Addr().String())
+		assert.NoError(t, err)
+		defer conn.Close()
+
+		pong := clientHandshake(t, conn, "wrong")
+		assert.Equal(t, false, pong[1])
+
+		// 握手失败后连接被关闭 数据不会被接收
+		_, _ = conn.Write(encodeForwardMode("app.log", int64(1700000000), records, nil))
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		_, err = msgp.NewReader(conn).ReadIntf()
+		assert.Error(t, err)
+		assert.Equal(t, int64(0), n.Load())
+	})
+}
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/pipeline"
 )
 
 // ResponseHandler 请求响应处理器
@@ -49,4 +50,5 @@ func RecordHandleMetrics(mm *metricMonitor, token define.Token, protocol define.
 	mm.ObserveHandledDuration(t, protocol, rtype, token.Original)
 	mm.IncHandledCounter(protocol, rtype, token.Original)
 	DefaultMetricMonitor.SetTokenInfo(token)
+	pipeline.ConsumeBytes(rtype, token.Original, bs)
 }
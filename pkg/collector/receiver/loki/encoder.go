@@ -0,0 +1,171 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package loki
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/json"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/lokipb"
+)
+
+type logStream struct {
+	labels  []lokipb.LabelPair
+	entries []lokipb.Entry
+}
+
+type logsEncoder interface {
+	Type() string
+	Decode(buf []byte) ([]logStream, error)
+}
+
+// pbEncoder protobuf 格式的请求体必须经过 snappy 压缩
+type pbEncoder struct{}
+
+func (pbEncoder) Type() string {
+	return "protobuf"
+}
+
+func (pbEncoder) Decode(buf []byte) ([]logStream, error) {
+	decoded, err := snappy.Decode(nil, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	var req lokipb.PushRequest
+	if err := lokipb.Unmarshal(decoded, &req); err != nil {
+		return nil, err
+	}
+
+	streams := make([]logStream, 0, len(req.Streams))
+	for _, s := range req.Streams {
+		labels, err := lokipb.ParseLabels(s.Labels)
+		if err != nil {
+			return nil, err
+		}
+		streams = append(streams, logStream{labels: labels, entries: s.Entries})
+	}
+	return streams, nil
+}
+
+type jsonPushRequest struct {
+	Streams []struct {
+		Stream map[string]string `json:"stream"`
+		Values [][]interface{}   `json:"values"`
+	} `json:"streams"`
+}
+
+// jsonEncoder values 元素格式为 [<unix 纳秒时间戳字符串>, <日志内容>, <structured metadata（可选）>]
+type jsonEncoder struct{}
+
+func (jsonEncoder) Type() string {
+	return "json"
+}
+
+func (jsonEncoder) Decode(buf []byte) ([]logStream, error) {
+	var req jsonPushRequest
+	if err := json.Unmarshal(buf, &req); err != nil {
+		return nil, err
+	}
+
+	streams := make([]logStream, 0, len(req.Streams))
+	for _, s := range req.Streams {
+		labels := make([]lokipb.LabelPair, 0, len(s.Stream))
+		for k, v := range s.Stream {
+			labels = append(labels, lokipb.LabelPair{Name: k, Value: v})
+		}
+		sort.Slice(labels, func(i, j int) bool {
+			return labels[i].Name < labels[j].Name
+		})
+
+		entries := make([]lokipb.Entry, 0, len(s.Values))
+		for _, value := range s.Values {
+			entry, err := decodeJsonEntry(value)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		}
+		streams = append(streams, logStream{labels: labels, entries: entries})
+	}
+	return streams, nil
+}
+
+func decodeJsonEntry(value []interface{}) (lokipb.Entry, error) {
+	var entry lokipb.Entry
+	if len(value) < 2 || len(value) > 3 {
+		return entry, errors.Errorf("invalid entry length %d", len(value))
+	}
+
+	ts, ok := value[0].(string)
+	if !ok {
+		return entry, errors.Errorf("invalid entry timestamp type %T", value[0])
+	}
+	nanos, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return entry, errors.Wrapf(err, "invalid entry timestamp '%s'", ts)
+	}
+	entry.Timestamp = nanos
+
+	line, ok := value[1].(string)
+	if !ok {
+		return entry, errors.Errorf("invalid entry line type %T", value[1])
+	}
+	entry.Line = line
+
+	if len(value) == 3 {
+		metadata, ok := value[2].(map[string]interface{})
+		if !ok {
+			return entry, errors.Errorf("invalid entry structured metadata type %T", value[2])
+		}
+		for k, v := range metadata {
+			s, ok := v.(string)
+			if !ok {
+				return entry, errors.Errorf("invalid structured metadata value type %T of '%s'", v, k)
+			}
+			entry.StructuredMetadata = append(entry.StructuredMetadata, lokipb.LabelPair{Name: k, Value: s})
+		}
+		sort.Slice(entry.StructuredMetadata, func(i, j int) bool {
+			return entry.StructuredMetadata[i].Name < entry.StructuredMetadata[j].Name
+		})
+	}
+	return entry, nil
+}
+
+// toLogs 每个 stream 对应一个 ResourceLogs stream labels 作为 resource 属性 structured metadata 作为日志属性
+func toLogs(streams []logStream, now time.Time) plog.Logs {
+	logs := plog.NewLogs()
+	observed := pcommon.NewTimestampFromTime(now)
+	for _, s := range streams {
+		rl := logs.ResourceLogs().AppendEmpty()
+		for _, lb := range s.labels {
+			rl.Resource().Attributes().UpsertString(lb.Name, lb.Value)
+		}
+
+		logRecords := rl.ScopeLogs().AppendEmpty().LogRecords()
+		for _, e := range s.entries {
+			lr := logRecords.AppendEmpty()
+			lr.SetTimestamp(pcommon.Timestamp(e.Timestamp))
+			lr.SetObservedTimestamp(observed)
+			lr.Body().SetStringVal(e.Line)
+			for _, pair := range e.StructuredMetadata {
+				lr.Attributes().UpsertString(pair.Name, pair.Value)
+			}
+		}
+	}
+	return logs
+}
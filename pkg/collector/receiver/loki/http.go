@@ -0,0 +1,171 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package loki
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/utils"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+const (
+	routeLokiPush       = "/loki/api/v1/push"
+	routeLokiLegacyPush = "/api/prom/push"
+
+	headerContentEncoding = "Content-Encoding"
+	headerScopeOrgID      = "X-Scope-OrgID"
+)
+
+func init() {
+	receiver.RegisterReadyFunc(define.SourceLoki, Ready)
+}
+
+func Ready(config receiver.ComponentConfig) {
+	if !config.Loki.Enabled {
+		return
+	}
+	receiver.RegisterRecvHttpRoute(define.SourceLoki, []receiver.RouteWithFunc{
+		{
+			Method:       http.MethodPost,
+			RelativePath: routeLokiPush,
+			HandlerFunc:  httpSvc.Push,
+		},
+		{
+			Method:       http.MethodPost,
+			RelativePath: routeLokiLegacyPush,
+			HandlerFunc:  httpSvc.Push,
+		},
+	})
+}
+
+var metricMonitor = receiver.DefaultMetricMonitor.Source(define.SourceLoki)
+
+type HttpService struct {
+	receiver.Publisher
+	pipeline.Validator
+}
+
+var httpSvc HttpService
+
+// getEncoder 未指定 Content-Type 时按 protobuf 处理 与 loki 行为保持一致
+func getEncoder(ctype string) (logsEncoder, error) {
+	if ctype == "" {
+		return pbEncoder{}, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(ctype)
+	if err != nil {
+		return nil, err
+	}
+	switch mediaType {
+	case define.ContentTypeProtobuf:
+		return pbEncoder{}, nil
+	case define.ContentTypeJson:
+		return jsonEncoder{}, nil
+	}
+	return nil, errors.Errorf("unsupported content type: %v", ctype)
+}
+
+// decompressBody protobuf 请求体自带 snappy 压缩 json 请求体允许使用 gzip 压缩
+func decompressBody(encoding string, body io.Reader) (io.ReadCloser, error) {
+	switch encoding {
+	case "gzip":
+		return gzip.NewReader(body)
+	case "", "identity":
+		return io.NopCloser(body), nil
+	}
+	return nil, errors.Errorf("unsupported content encoding: %v", encoding)
+}
+
+// tokenFromRequest promtail 可通过 tenant_id 配置项设置 X-Scope-OrgID 请求头 作为兜底的 token 来源
+func tokenFromRequest(req *http.Request) string {
+	token := define.TokenFromHttpRequest(req)
+	if token == "" {
+		token = req.Header.Get(headerScopeOrgID)
+	}
+	return token
+}
+
+func (s HttpService) Push(w http.ResponseWriter, req *http.Request) {
+	defer utils.HandleCrash()
+	ip := utils.ParseRequestIP(req.RemoteAddr)
+
+	start := time.Now()
+	defer func() {
+		_ = req.Body.Close()
+	}()
+
+	encoder, err := getEncoder(req.Header.Get(define.ContentType))
+	if err != nil {
+		logger.Warnf("failed to negotiate loki encoder, ip=%v, err: %v", ip, err)
+		metricMonitor.IncDroppedCounter(define.RequestHttp, define.RecordLogs)
+		receiver.WriteErrResponse(w, define.ContentTypeText, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	rc, err := decompressBody(req.Header.Get(headerContentEncoding), req.Body)
+	if err != nil {
+		logger.Warnf("failed to decompress loki body, ip=%v, err: %v", ip, err)
+		metricMonitor.IncDroppedCounter(define.RequestHttp, define.RecordLogs)
+		receiver.WriteErrResponse(w, define.ContentTypeText, http.StatusBadRequest, err)
+		return
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	buf := &bytes.Buffer{}
+	if _, err = io.Copy(buf, rc); err != nil {
+		metricMonitor.IncInternalErrorCounter(define.RequestHttp, define.RecordLogs)
+		receiver.WriteResponse(w, define.ContentTypeText, http.StatusInternalServerError, nil)
+		logger.Errorf("failed to read loki body: %v", err)
+		return
+	}
+
+	r := &define.Record{
+		RequestType:   define.RequestHttp,
+		RequestClient: define.RequestClient{IP: ip},
+		RecordType:    define.RecordLogs,
+		Token:         define.Token{Original: tokenFromRequest(req)},
+	}
+	code, processorName, err := s.Validate(r)
+	if err != nil {
+		err = errors.Wrapf(err, "run pre-check failed, rtype=logs, code=%d, ip=%s", code, ip)
+		logger.WarnRate(time.Minute, r.Token.Original, err)
+		receiver.WriteErrResponse(w, define.ContentTypeText, int(code), err)
+		metricMonitor.IncPreCheckFailedCounter(define.RequestHttp, define.RecordLogs, processorName, r.Token.Original, code)
+		return
+	}
+
+	streams, err := encoder.Decode(buf.Bytes())
+	if err != nil {
+		err = errors.Wrapf(err, "unmarshal %s request body failed", encoder.Type())
+		logger.Warnf("failed to parse loki exported content, ip=%v, err: %v", ip, err)
+		metricMonitor.IncDroppedCounter(define.RequestHttp, define.RecordLogs)
+		receiver.WriteErrResponse(w, define.ContentTypeText, http.StatusBadRequest, err)
+		return
+	}
+
+	r.Data = toLogs(streams, start)
+	s.Publish(r)
+	receiver.RecordHandleMetrics(metricMonitor, r.Token, define.RequestHttp, define.RecordLogs, buf.Len(), start)
+	receiver.WriteResponse(w, define.ContentTypeText, http.StatusNoContent, nil)
+}
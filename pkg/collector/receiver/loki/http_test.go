@@ -0,0 +1,197 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package loki
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/lokipb"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver"
+)
+
+const jsonContent = `{
+  "streams": [
+    {
+      "stream": {"job": "app", "env": "prod"},
+      "values": [
+        ["1700000000000000000", "hello"],
+        ["1700000001000000000", "world", {"trace_id": "abc"}]
+      ]
+    }
+  ]
+}`
+
+func TestReady(t *testing.T) {
+	assert.NotPanics(t, func() {
+		Ready(receiver.ComponentConfig{})
+	})
+}
+
+func newSvc(code define.StatusCode, msg string, err error) (HttpService, *[]*define.Record) {
+	var records []*define.Record
+	svc := HttpService{
+		receiver.Publisher{Func: func(record *define.Record) { records = append(records, record) }},
+		pipeline.Validator{Func: func(record *define.Record) (define.StatusCode, string, error) {
+			return code, msg, err
+		}},
+	}
+	return svc, &records
+}
+
+func newPbContent() []byte {
+	req := &lokipb.PushRequest{
+		Streams: []lokipb.Stream{
+			{
+				Labels: `{job="app", env="prod"}`,
+				Entries: []lokipb.Entry{
+					{Timestamp: 1700000000000000000, Line: "hello"},
+					{
+						Timestamp:          1700000001000000000,
+						Line:               "world",
+						StructuredMetadata: []lokipb.LabelPair{{Name: "trace_id", Value: "abc"}},
+					},
+				},
+			},
+		},
+	}
+	return snappy.Encode(nil, lokipb.Marshal(req))
+}
+
+func assertLogs(t *testing.T, logs plog.Logs) {
+	assert.Equal(t, 1, logs.ResourceLogs().Len())
+	rl := logs.ResourceLogs().At(0)
+	assert.Equal(t, map[string]interface{}{"job": "app", "env": "prod"}, rl.Resource().Attributes().AsRaw())
+
+	logRecords := rl.ScopeLogs().At(0).LogRecords()
+	assert.Equal(t, 2, logRecords.Len())
+	assert.Equal(t, "hello", logRecords.At(0).Body().StringVal())
+	assert.Equal(t, time.Unix(1700000000, 0).UnixNano(), int64(logRecords.At(0).Timestamp()))
+
+	second := logRecords.At(1)
+	assert.Equal(t, "world", second.Body().StringVal())
+	assert.Equal(t, map[string]interface{}{"trace_id": "abc"}, second.Attributes().AsRaw())
+}
+
+func TestGetEncoder(t *testing.T) {
+	encoder, err := getEncoder("")
+	assert.NoError(t, err)
+	assert.Equal(t, "protobuf", encoder.Type())
+
+	encoder, err = getEncoder("application/json; charset=utf-8")
+	assert.NoError(t, err)
+	assert.Equal(t, "json", encoder.Type())
+
+	_, err = getEncoder("text/plain")
+	assert.Error(t, err)
+}
+
+func TestDecodeJsonEntry(t *testing.T) {
+	tests := []struct {
+		name  string
+		value []interface{}
+	}{
+		{name: "too short", value: []interface{}{"1"}},
+		{name: "invalid timestamp", value: []interface{}{"abc", "line"}},
+		{name: "timestamp not string", value: []interface{}{float64(1), "line"}},
+		{name: "line not string", value: []interface{}{"1", float64(1)}},
+		{name: "invalid metadata", value: []interface{}{"1", "line", "meta"}},
+		{name: "invalid metadata value", value: []interface{}{"1", "line", map[string]interface{}{"k": float64(1)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeJsonEntry(tt.value)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestHttpRequest(t *testing.T) {
+	t.Run("protobuf success", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, routeLokiPush, bytes.NewBuffer(newPbContent()))
+		req.Header.Set(define.ContentType, define.ContentTypeProtobuf)
+		req.Header.Set(headerScopeOrgID, "mytoken")
+
+		svc, records := newSvc(define.StatusCodeOK, "", nil)
+		rw := httptest.NewRecorder()
+		svc.Push(rw, req)
+		assert.Equal(t, http.StatusNoContent, rw.Code)
+		assert.Len(t, *records, 1)
+
+		r := (*records)[0]
+		assert.Equal(t, define.RecordLogs, r.RecordType)
+		assert.Equal(t, "mytoken", r.Token.Original)
+		assertLogs(t, r.Data.(plog.Logs))
+	})
+
+	t.Run("json gzip success", func(t *testing.T) {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		_, _ = gw.Write([]byte(jsonContent))
+		_ = gw.Close()
+
+		req := httptest.NewRequest(http.MethodPost, routeLokiPush+"?X-BK-TOKEN=mytoken", &buf)
+		req.Header.Set(define.ContentType, define.ContentTypeJson)
+		req.Header.Set(headerContentEncoding, "gzip")
+
+		svc, records := newSvc(define.StatusCodeOK, "", nil)
+		rw := httptest.NewRecorder()
+		svc.Push(rw, req)
+		assert.Equal(t, http.StatusNoContent, rw.Code)
+		assert.Len(t, *records, 1)
+
+		r := (*records)[0]
+		assert.Equal(t, "mytoken", r.Token.Original)
+		assertLogs(t, r.Data.(plog.Logs))
+	})
+
+	t.Run("unsupported content type", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, routeLokiPush, bytes.NewBufferString("hello"))
+		req.Header.Set(define.ContentType, "text/plain")
+
+		svc, records := newSvc(define.StatusCodeOK, "", nil)
+		rw := httptest.NewRecorder()
+		svc.Push(rw, req)
+		assert.Equal(t, http.StatusUnsupportedMediaType, rw.Code)
+		assert.Len(t, *records, 0)
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, routeLokiPush, bytes.NewBufferString("{-}"))
+		req.Header.Set(define.ContentType, define.ContentTypeJson)
+
+		svc, records := newSvc(define.StatusCodeOK, "", nil)
+		rw := httptest.NewRecorder()
+		svc.Push(rw, req)
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		assert.Len(t, *records, 0)
+	})
+
+	t.Run("validate failed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, routeLokiPush, bytes.NewBuffer(newPbContent()))
+
+		svc, records := newSvc(define.StatusCodeUnauthorized, define.ProcessorTokenChecker, errors.New("MUST ERROR"))
+		rw := httptest.NewRecorder()
+		svc.Push(rw, req)
+		assert.Equal(t, http.StatusUnauthorized, rw.Code)
+		assert.Len(t, *records, 0)
+	})
+}
@@ -28,7 +28,8 @@ type Encoder interface {
 	UnmarshalLogs(b []byte) (plog.Logs, error)
 }
 
-func unmarshalRecordData(encoder Encoder, rtype define.RecordType, b []byte) (interface{}, error) {
+// UnmarshalRecordData 按数据类型解析 Traces/Metrics/Logs 数据
+func UnmarshalRecordData(encoder Encoder, rtype define.RecordType, b []byte) (interface{}, error) {
 	switch rtype {
 	case define.RecordTraces:
 		return encoder.UnmarshalTraces(b)
@@ -69,6 +69,11 @@ func Ready(config receiver.ComponentConfig) {
 			RelativePath: routeV1Logs,
 			HandlerFunc:  httpSvc.ExportLogs,
 		},
+		{
+			Method:       http.MethodPost,
+			RelativePath: routeV1DevelopmentProfiles,
+			HandlerFunc:  httpSvc.ExportProfiles,
+		},
 	})
 
 	receiver.RegisterRecvGrpcRoute(func(s *grpc.Server) {
@@ -223,7 +228,7 @@ func (h httpPbResponseHandler) Response(rtype define.RecordType) ([]byte, error)
 }
 
 func (h httpPbResponseHandler) Unmarshal(rtype define.RecordType, b []byte) (interface{}, error) {
-	return unmarshalRecordData(h.encoder, rtype, b)
+	return UnmarshalRecordData(h.encoder, rtype, b)
 }
 
 func (h httpPbResponseHandler) ErrorStatus(status interface{}) ([]byte, error) {
@@ -266,7 +271,7 @@ func (h httpJsonResponseHandler) Response(rtype define.RecordType) ([]byte, erro
 }
 
 func (h httpJsonResponseHandler) Unmarshal(rtype define.RecordType, b []byte) (interface{}, error) {
-	return unmarshalRecordData(h.encoder, rtype, b)
+	return UnmarshalRecordData(h.encoder, rtype, b)
 }
 
 func (h httpJsonResponseHandler) ErrorStatus(status interface{}) ([]byte, error) {
@@ -0,0 +1,160 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package otlp
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/otlpprofiles"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/utils"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+const (
+	routeV1DevelopmentProfiles = "/v1development/profiles"
+
+	resourceKeyServiceName = "service.name"
+)
+
+// ExportProfiles 接收 OTLP profiles 数据 目前仅支持 protobuf 编码
+func (s HttpService) ExportProfiles(w http.ResponseWriter, req *http.Request) {
+	defer utils.HandleCrash()
+	ip := utils.ParseRequestIP(req.RemoteAddr)
+	rtype := define.RecordProfiles
+	rh := HttpPbResponseHandler()
+
+	start := time.Now()
+	buf := &bytes.Buffer{}
+	_, err := io.Copy(buf, req.Body)
+	if err != nil {
+		metricMonitor.IncInternalErrorCounter(define.RequestHttp, rtype)
+		writeError(w, rh, err, http.StatusInternalServerError)
+		logger.Errorf("failed to read body content, rtype=%s, ip=%v, error: %s", rtype.S(), ip, err)
+		return
+	}
+	defer func() {
+		_ = req.Body.Close()
+	}()
+
+	if contentType := req.Header.Get(define.ContentType); contentType != define.ContentTypeProtobuf {
+		metricMonitor.IncDroppedCounter(define.RequestHttp, rtype)
+		err = errors.Errorf("unsupported content type '%s'", contentType)
+		writeError(w, rh, err, http.StatusUnsupportedMediaType)
+		logger.Warnf("failed to handle profiles, ip=%v, error: %s", ip, err)
+		return
+	}
+
+	var pr otlpprofiles.ExportProfilesServiceRequest
+	if err = otlpprofiles.Unmarshal(buf.Bytes(), &pr); err != nil {
+		metricMonitor.IncDroppedCounter(define.RequestHttp, rtype)
+		writeError(w, rh, err, http.StatusBadRequest)
+		logger.Warnf("failed to unmarshal body, rtype=%s, ip=%v, error: %s", rtype.S(), ip, err)
+		return
+	}
+
+	profiles, err := toProfilesRawData(&pr)
+	if err != nil {
+		metricMonitor.IncDroppedCounter(define.RequestHttp, rtype)
+		writeError(w, rh, err, http.StatusBadRequest)
+		logger.Warnf("failed to convert profiles, ip=%v, error: %s", ip, err)
+		return
+	}
+
+	// 所有 profile 均通过预检后再统一发布 避免部分数据已发布而请求整体返回失败
+	token := define.Token{Original: extractTokenFromHttpHeader(req.Header)}
+	records := make([]*define.Record, 0, len(profiles))
+	for _, profile := range profiles {
+		r := &define.Record{
+			RequestType:   define.RequestHttp,
+			RequestClient: define.RequestClient{IP: ip},
+			RecordType:    rtype,
+			Data:          profile,
+			Token:         token,
+		}
+
+		code, processorName, err := s.Validate(r)
+		if err != nil {
+			writeError(w, rh, err, int(code))
+			logger.Warnf("run pre-check failed, rtype=%s, code=%d, ip=%v, error: %s", rtype.S(), code, ip, err)
+			metricMonitor.IncPreCheckFailedCounter(define.RequestHttp, rtype, processorName, r.Token.Original, code)
+			return
+		}
+		records = append(records, r)
+	}
+	for _, r := range records {
+		s.Publish(r)
+	}
+	receiver.RecordHandleMetrics(metricMonitor, token, define.RequestHttp, rtype, buf.Len(), start)
+
+	// ExportProfilesServiceResponse 不包含 partial_success 时编码结果为空
+	receiver.WriteResponse(w, rh.ContentType(), http.StatusOK, nil)
+}
+
+func attributesToMap(attrs []otlpprofiles.KeyValue, dst map[string]string) {
+	for _, attr := range attrs {
+		dst[attr.Key] = attr.Value
+	}
+}
+
+// toProfilesRawData 将 OTLP profiles 转换为 ProfilesRawData
+// 携带 pprof 原始数据的 profile 直接使用原始数据 其余 profile 在此处转换为 pprof 以便尽早发现非法数据
+func toProfilesRawData(pr *otlpprofiles.ExportProfilesServiceRequest) ([]define.ProfilesRawData, error) {
+	var ret []define.ProfilesRawData
+	for _, rp := range pr.ResourceProfiles {
+		resource := make(map[string]string)
+		attributesToMap(rp.Resource, resource)
+
+		for _, sp := range rp.ScopeProfiles {
+			for i := 0; i < len(sp.Profiles); i++ {
+				p := &sp.Profiles[i]
+
+				tags := utils.CloneMap(resource)
+				attributesToMap(p.Attributes(), tags)
+				startTime := time.Unix(0, p.TimeNanos)
+				meta := define.ProfileMetadata{
+					StartTime: startTime,
+					EndTime:   startTime.Add(time.Duration(p.DurationNanos)),
+					AppName:   resource[resourceKeyServiceName],
+					SpyName:   sp.ScopeName,
+					Tags:      tags,
+				}
+
+				if p.OriginalPayloadFormat == define.FormatPprof && len(p.OriginalPayload) > 0 {
+					meta.Format = define.FormatPprof
+					ret = append(ret, define.ProfilesRawData{
+						Metadata: meta,
+						Data:     define.ProfilePprofFormatOrigin(p.OriginalPayload),
+					})
+					continue
+				}
+
+				pp, err := p.ToPprof()
+				if err != nil {
+					return nil, errors.Wrapf(err, "convert profile %x failed", p.ProfileID)
+				}
+				meta.Format = define.FormatOTLP
+				meta.AggregationType = pp.PeriodType.Type
+				meta.Units = pp.PeriodType.Unit
+				ret = append(ret, define.ProfilesRawData{
+					Metadata: meta,
+					Data:     define.ProfileOtlpFormatOrigin{Profile: pp},
+				})
+			}
+		}
+	}
+	return ret, nil
+}
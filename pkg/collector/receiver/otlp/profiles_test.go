@@ -0,0 +1,169 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package otlp
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/otlpprofiles"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver"
+)
+
+const localV1DevelopmentProfilesURL = "http://localhost/v1development/profiles"
+
+func newProfilesRequest(profiles ...otlpprofiles.Profile) []byte {
+	return otlpprofiles.Marshal(&otlpprofiles.ExportProfilesServiceRequest{
+		ResourceProfiles: []otlpprofiles.ResourceProfiles{
+			{
+				Resource: []otlpprofiles.KeyValue{{Key: "service.name", Value: "app"}, {Key: "host.name", Value: "host1"}},
+				ScopeProfiles: []otlpprofiles.ScopeProfiles{
+					{ScopeName: "otel-ebpf-profiler", Profiles: profiles},
+				},
+			},
+		},
+	})
+}
+
+func newTestOtlpProfile() otlpprofiles.Profile {
+	return otlpprofiles.Profile{
+		StringTable:      []string{"", "cpu", "nanoseconds", "main", "main.go"},
+		SampleType:       []otlpprofiles.ValueType{{TypeStrindex: 1, UnitStrindex: 2}},
+		PeriodType:       otlpprofiles.ValueType{TypeStrindex: 1, UnitStrindex: 2},
+		Period:           10000000,
+		TimeNanos:        1700000000000000000,
+		DurationNanos:    10000000000,
+		FunctionTable:    []otlpprofiles.Function{{NameStrindex: 3, FilenameStrindex: 4}},
+		LocationTable:    []otlpprofiles.Location{{Line: []otlpprofiles.Line{{FunctionIndex: 0, Line: 1}}}},
+		LocationIndices:  []int32{0},
+		AttributeTable:   []otlpprofiles.KeyValue{{Key: "container.id", Value: "abc"}},
+		AttributeIndices: []int32{0},
+		Sample:           []otlpprofiles.Sample{{LocationsLength: 1, Value: []int64{10000000}}},
+	}
+}
+
+func TestExportProfiles(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var records []*define.Record
+		svc := HttpService{
+			receiver.Publisher{Func: func(r *define.Record) { records = append(records, r) }},
+			pipeline.Validator{Func: func(r *define.Record) (define.StatusCode, string, error) {
+				return define.StatusCodeOK, "", nil
+			}},
+		}
+
+		raw := newTestOtlpProfile()
+		raw.OriginalPayloadFormat = "pprof"
+		raw.OriginalPayload = []byte("pprof payload")
+
+		body := newProfilesRequest(newTestOtlpProfile(), raw)
+		req := httptest.NewRequest(http.MethodPost, localV1DevelopmentProfilesURL, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", define.ContentTypeProtobuf)
+		req.Header.Set(define.KeyToken, "token1")
+
+		rw := httptest.NewRecorder()
+		svc.ExportProfiles(rw, req)
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Len(t, records, 2)
+
+		r := records[0]
+		assert.Equal(t, define.RecordProfiles, r.RecordType)
+		assert.Equal(t, "token1", r.Token.Original)
+
+		data := r.Data.(define.ProfilesRawData)
+		assert.Equal(t, define.FormatOTLP, data.Metadata.Format)
+		assert.Equal(t, "app", data.Metadata.AppName)
+		assert.Equal(t, "cpu", data.Metadata.AggregationType)
+		assert.Equal(t, map[string]string{"service.name": "app", "host.name": "host1", "container.id": "abc"}, data.Metadata.Tags)
+		assert.Equal(t, int64(10000000000), data.Metadata.EndTime.Sub(data.Metadata.StartTime).Nanoseconds())
+		assert.Len(t, data.Data.(define.ProfileOtlpFormatOrigin).Profile.Sample, 1)
+
+		data = records[1].Data.(define.ProfilesRawData)
+		assert.Equal(t, define.FormatPprof, data.Metadata.Format)
+		assert.Equal(t, define.ProfilePprofFormatOrigin("pprof payload"), data.Data)
+	})
+
+	t.Run("unsupported content type", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, localV1DevelopmentProfilesURL, bytes.NewBufferString("{}"))
+		req.Header.Set("Content-Type", define.ContentTypeJson)
+
+		svc, n := newSvc(define.StatusCodeOK, "", nil)
+		rw := httptest.NewRecorder()
+		svc.ExportProfiles(rw, req)
+		assert.Equal(t, http.StatusUnsupportedMediaType, rw.Code)
+		assert.Equal(t, int64(0), n.Load())
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, localV1DevelopmentProfilesURL, bytes.NewBuffer([]byte{0x0a, 0xff}))
+		req.Header.Set("Content-Type", define.ContentTypeProtobuf)
+
+		svc, n := newSvc(define.StatusCodeOK, "", nil)
+		rw := httptest.NewRecorder()
+		svc.ExportProfiles(rw, req)
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		assert.Equal(t, int64(0), n.Load())
+	})
+
+	t.Run("invalid profile", func(t *testing.T) {
+		p := newTestOtlpProfile()
+		p.LocationIndices = []int32{1}
+		req := httptest.NewRequest(http.MethodPost, localV1DevelopmentProfilesURL, bytes.NewBuffer(newProfilesRequest(p)))
+		req.Header.Set("Content-Type", define.ContentTypeProtobuf)
+
+		svc, n := newSvc(define.StatusCodeOK, "", nil)
+		rw := httptest.NewRecorder()
+		svc.ExportProfiles(rw, req)
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		assert.Equal(t, int64(0), n.Load())
+	})
+
+	t.Run("validate failed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, localV1DevelopmentProfilesURL, bytes.NewBuffer(newProfilesRequest(newTestOtlpProfile())))
+		req.Header.Set("Content-Type", define.ContentTypeProtobuf)
+
+		svc, n := newSvc(define.StatusCodeUnauthorized, "", errors.New("MUST ERROR"))
+		rw := httptest.NewRecorder()
+		svc.ExportProfiles(rw, req)
+		assert.Equal(t, http.StatusUnauthorized, rw.Code)
+		assert.Equal(t, int64(0), n.Load())
+	})
+
+	t.Run("partial validate failed", func(t *testing.T) {
+		var published, validated int
+		svc := HttpService{
+			receiver.Publisher{Func: func(r *define.Record) { published++ }},
+			pipeline.Validator{Func: func(r *define.Record) (define.StatusCode, string, error) {
+				validated++
+				if validated > 1 {
+					return define.StatusCodeTooManyRequests, "", errors.New("MUST ERROR")
+				}
+				return define.StatusCodeOK, "", nil
+			}},
+		}
+
+		body := newProfilesRequest(newTestOtlpProfile(), newTestOtlpProfile())
+		req := httptest.NewRequest(http.MethodPost, localV1DevelopmentProfilesURL, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", define.ContentTypeProtobuf)
+
+		rw := httptest.NewRecorder()
+		svc.ExportProfiles(rw, req)
+		assert.Equal(t, http.StatusTooManyRequests, rw.Code)
+		assert.Equal(t, 2, validated)
+		assert.Equal(t, 0, published)
+	})
+}
@@ -178,7 +178,7 @@ func (s HttpService) ProfilesIngest(w http.ResponseWriter, req *http.Request) {
 	units := query.Get("units")
 	spyName := query.Get("spyName")
 
-	format := query.Get("format")
+	format := normalizeFormat(query.Get("format"))
 	if format == "" {
 		format = getFormatBySpy(spyName)
 	}
@@ -198,19 +198,26 @@ func (s HttpService) ProfilesIngest(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	f, err := parseForm(req, buf.Bytes())
-	if err != nil {
-		metricMonitor.IncInternalErrorCounter(define.RequestHttp, define.RecordProfiles)
-		logger.Warnf("failed to parse boundary, token=%s, err: %v", token, err)
-		receiver.WriteErrResponse(w, define.ContentTypeJson, http.StatusBadRequest, err)
-		return
-	}
-	defer func() {
-		_ = f.RemoveAll()
-	}()
+	var origin any
+	if isRawBodyFormat(format, req) {
+		// 折叠栈以及 Go trace 数据直接使用请求体上报
+		origin = convertRawToOrigin(format, buf.Bytes())
+	} else {
+		var f *multipart.Form
+		f, err = parseForm(req, buf.Bytes())
+		if err != nil {
+			metricMonitor.IncInternalErrorCounter(define.RequestHttp, define.RecordProfiles)
+			logger.Warnf("failed to parse boundary, token=%s, err: %v", token, err)
+			receiver.WriteErrResponse(w, define.ContentTypeJson, http.StatusBadRequest, err)
+			return
+		}
+		defer func() {
+			_ = f.RemoveAll()
+		}()
 
-	// TODO 处理 prev_profile 字段
-	origin, err := convertToOrigin(spyName, f)
+		// TODO 处理 prev_profile 字段
+		origin, err = convertToOrigin(spyName, format, f)
+	}
 	if err != nil {
 		metricMonitor.IncDroppedCounter(define.RequestHttp, define.RecordProfiles)
 		logger.Warnf("read profile failed, ip=%s, token=%s, err: %v", ip, token, err)
@@ -229,6 +236,7 @@ func (s HttpService) ProfilesIngest(w http.ResponseWriter, req *http.Request) {
 			Format:          format,
 			AggregationType: aggregationType,
 			Units:           units,
+			SampleRate:      getSampleRateFromQuery(query),
 			Tags:            tags,
 			AppName:         appName,
 		},
@@ -312,8 +320,52 @@ func getFormatBySpy(spyName string) string {
 	}
 }
 
+// normalizeFormat 统一 format 参数 pyroscope 将折叠栈格式称为 folded/lines
+func normalizeFormat(format string) string {
+	switch format {
+	case "folded", "lines":
+		return define.FormatCollapsed
+	case "trace":
+		return define.FormatGoTrace
+	default:
+		return format
+	}
+}
+
+func getSampleRateFromQuery(query url.Values) uint32 {
+	rate, err := strconv.ParseUint(query.Get("sampleRate"), 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint32(rate)
+}
+
+// isRawBodyFormat 折叠栈以及 Go trace 数据允许不使用 multipart 表单上报
+func isRawBodyFormat(format string, req *http.Request) bool {
+	if format != define.FormatCollapsed && format != define.FormatGoTrace {
+		return false
+	}
+	return !strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data")
+}
+
+func convertRawToOrigin(format string, b []byte) any {
+	if format == define.FormatGoTrace {
+		return define.ProfileGoTraceFormatOrigin(b)
+	}
+	return define.ProfileCollapsedFormatOrigin(b)
+}
+
 // convertToOrigin 将 Http.Body 转换为 translator 所需的数据格式
-func convertToOrigin(spyName string, form *multipart.Form) (any, error) {
+func convertToOrigin(spyName, format string, form *multipart.Form) (any, error) {
+	switch format {
+	case define.FormatCollapsed, define.FormatGoTrace:
+		profileBytes, err := ReadField(form, formFieldProfile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read profile field, format=%s", format)
+		}
+		return convertRawToOrigin(format, profileBytes), nil
+	}
+
 	switch spyName {
 	case JavaSpy:
 		jfrBytes, err := ReadField(form, "jfr")
@@ -215,6 +215,63 @@ func TestHttpRequest(t *testing.T) {
 	})
 }
 
+func TestHttpRequestRawFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		origin any
+	}{
+		{format: "folded", origin: define.ProfileCollapsedFormatOrigin("main;foo 1")},
+		{format: "collapsed", origin: define.ProfileCollapsedFormatOrigin("main;foo 1")},
+		{format: "trace", origin: define.ProfileGoTraceFormatOrigin("main;foo 1")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var r *define.Record
+			svc := HttpService{
+				receiver.Publisher{Func: func(record *define.Record) { r = record }},
+				pipeline.Validator{Func: func(record *define.Record) (define.StatusCode, string, error) {
+					return define.StatusCodeOK, "", nil
+				}},
+			}
+
+			url := localURL + "?from=1698053090&name=fuxi&sampleRate=100&spyName=pyroscope-rs&units=samples&until=1698053100&format=" + tt.format
+			req := httptest.NewRequest(http.MethodPost, url, bytes.NewBufferString("main;foo 1"))
+			req.Header.Set("Authorization", "Bearer token_instance")
+
+			rw := httptest.NewRecorder()
+			svc.ProfilesIngest(rw, req)
+			assert.Equal(t, http.StatusOK, rw.Code)
+
+			data := r.Data.(define.ProfilesRawData)
+			assert.Equal(t, tt.origin, data.Data)
+			assert.Equal(t, uint32(100), data.Metadata.SampleRate)
+			assert.Equal(t, normalizeFormat(tt.format), data.Metadata.Format)
+		})
+	}
+
+	t.Run("multipart", func(t *testing.T) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		fw, err := writer.CreateFormFile("profile", "profile.folded")
+		assert.NoError(t, err)
+		_, err = fw.Write([]byte("main;foo 1"))
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Close())
+
+		url := localURL + "?from=1698053090&name=fuxi&spyName=pyroscope-rs&until=1698053100&format=folded"
+		req := httptest.NewRequest(http.MethodPost, url, body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer token_instance")
+
+		svc, n := newSvc(define.StatusCodeOK, "", nil)
+		rw := httptest.NewRecorder()
+		svc.ProfilesIngest(rw, req)
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, int64(1), n.Load())
+	})
+}
+
 func TestParseForm(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		body := &bytes.Buffer{}
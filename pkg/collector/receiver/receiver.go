@@ -15,6 +15,7 @@ import (
 	"net"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/TarsCloud/TarsGo/tars"
@@ -48,6 +49,7 @@ var (
 	globalRecords          = define.NewRecordQueue(define.PushModeGuarantee)
 	globalConfig           Config
 	globalSkywalkingConfig map[string]SkywalkingConfig
+	globalScrapeConfig     atomic.Value // map[string]ScrapeConfig
 )
 
 // Records 返回 Receiver 全局消息管道
@@ -88,6 +90,22 @@ func (f SkywalkingConfigFetcher) Fetch(s string) SkywalkingConfig {
 	return globalSkywalkingConfig[s]
 }
 
+type ScrapeConfigFetcher struct {
+	Func func() map[string]ScrapeConfig
+}
+
+// Fetch 返回所有应用的拉取配置 key 为 token
+func (f ScrapeConfigFetcher) Fetch() map[string]ScrapeConfig {
+	if f.Func != nil {
+		return f.Func()
+	}
+	v, ok := globalScrapeConfig.Load().(map[string]ScrapeConfig)
+	if !ok {
+		return nil
+	}
+	return v
+}
+
 // New 返回 Receiver 实例
 func New(conf *confengine.Config) (*Receiver, error) {
 	var c Config
@@ -109,6 +127,7 @@ func New(conf *confengine.Config) (*Receiver, error) {
 	// 全局状态记录
 	globalConfig = c
 	globalSkywalkingConfig = LoadConfigFrom(conf)
+	globalScrapeConfig.Store(LoadScrapeConfigFrom(conf))
 
 	return &Receiver{
 		config:  c,
@@ -134,8 +153,16 @@ func (r *Receiver) ready() {
 	}
 }
 
+func (r *Receiver) stop() {
+	for k, f := range componentsStop {
+		f()
+		logger.Infof("stop '%s' component", k)
+	}
+}
+
 func (r *Receiver) Reload(conf *confengine.Config) {
 	globalSkywalkingConfig = LoadConfigFrom(conf)
+	globalScrapeConfig.Store(LoadScrapeConfigFrom(conf))
 }
 
 func (r *Receiver) startRecvHttpServer() error {
@@ -343,6 +370,7 @@ func (r *Receiver) Stop() error {
 		}
 	}
 
+	r.stop()
 	r.wg.Wait()
 	return nil
 }
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/prompb"
 
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/utils"
@@ -34,13 +35,23 @@ func Ready(config receiver.ComponentConfig) {
 	if !config.RemoteWrite.Enabled {
 		return
 	}
-	receiver.RegisterRecvHttpRoute(define.SourceRemoteWrite, []receiver.RouteWithFunc{
+	routes := []receiver.RouteWithFunc{
 		{
 			Method:       http.MethodPost,
 			RelativePath: routeRemoteWrite,
 			HandlerFunc:  httpSvc.Write,
 		},
-	})
+	}
+
+	// 采集器不存储数据 配置了上游地址才开启 remote-read
+	if config.RemoteWrite.ReadEndpoint != "" {
+		routes = append(routes, receiver.RouteWithFunc{
+			Method:       http.MethodPost,
+			RelativePath: routeRemoteRead,
+			HandlerFunc:  NewReadService(config.RemoteWrite).Read,
+		})
+	}
+	receiver.RegisterRecvHttpRoute(define.SourceRemoteWrite, routes)
 }
 
 var metricMonitor = receiver.DefaultMetricMonitor.Source(define.SourceRemoteWrite)
@@ -58,6 +69,17 @@ func (s HttpService) Write(w http.ResponseWriter, req *http.Request) {
 
 	start := time.Now()
 
+	protoMsg, err := parseProtoMsg(req.Header.Get(define.ContentType))
+	if err == nil {
+		err = checkContentEncoding(req.Header.Get(headerContentEncoding))
+	}
+	if err != nil {
+		receiver.WriteErrResponse(w, define.ContentTypeText, http.StatusUnsupportedMediaType, err)
+		metricMonitor.IncDroppedCounter(define.RequestHttp, define.RecordRemoteWrite)
+		logger.Warnf("failed to negotiate write request, ip=%v, error: %s", ip, err)
+		return
+	}
+
 	token := define.TokenFromHttpRequest(req)
 	r := &define.Record{
 		RecordType:    define.RecordRemoteWrite,
@@ -74,26 +96,44 @@ func (s HttpService) Write(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	writeReq, size, err := utils.DecodeWriteRequest(req.Body)
+	defer func() {
+		_ = req.Body.Close()
+	}()
+
+	var data *define.RemoteWriteData
+	var stats writeStats
+	var size int
+	switch protoMsg {
+	case protoMsgV2:
+		data, stats, size, err = decodeWriteRequestV2(req.Body)
+	default:
+		var writeReq *prompb.WriteRequest
+		writeReq, size, err = utils.DecodeWriteRequest(req.Body)
+		if err == nil {
+			data = &define.RemoteWriteData{Timeseries: writeReq.Timeseries, Metadata: writeReq.Metadata}
+		}
+	}
 	if err != nil {
 		receiver.WriteErrResponse(w, define.ContentTypeText, http.StatusBadRequest, err)
 		metricMonitor.IncDroppedCounter(define.RequestHttp, define.RecordRemoteWrite)
-		logger.Warnf("failed to decode write request, code=%d, ip=%v, error: %s", code, ip, err)
+		logger.Warnf("failed to decode write request, proto=%s, ip=%v, error: %s", protoMsg, ip, err)
 		return
 	}
-	defer func() {
-		_ = req.Body.Close()
-	}()
 
 	s.Publish(&define.Record{
 		RequestType:   define.RequestHttp,
 		RequestClient: define.RequestClient{IP: ip},
 		RecordType:    define.RecordRemoteWrite,
 		Token:         r.Token,
-		Data: &define.RemoteWriteData{
-			Timeseries: writeReq.Timeseries,
-		},
+		Data:          data,
 	})
 	receiver.RecordHandleMetrics(metricMonitor, r.Token, define.RequestHttp, define.RecordRemoteWrite, size, start)
+
+	// v2 协议要求返回实际写入的样本数量
+	if protoMsg == protoMsgV2 {
+		stats.setHeaders(w.Header())
+		receiver.WriteResponse(w, define.ContentTypeText, http.StatusNoContent, nil)
+		return
+	}
 	receiver.WriteResponse(w, define.ContentTypeText, http.StatusOK, nil)
 }
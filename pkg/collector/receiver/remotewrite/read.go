@@ -0,0 +1,148 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/utils"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+const (
+	routeRemoteRead = "/prometheus/read"
+
+	defaultReadTimeout = 30 * time.Second
+
+	headerRemoteReadVersion = "X-Prometheus-Remote-Read-Version"
+	headerAcceptEncoding    = "Accept-Encoding"
+)
+
+// forwardRequestHeaders 转发至上游的请求头 上游据此选择响应类型（samples/streamed chunks）
+var forwardRequestHeaders = []string{
+	define.ContentType,
+	headerContentEncoding,
+	headerAcceptEncoding,
+	headerRemoteReadVersion,
+}
+
+// ReadService 将 remote-read 请求转发至 ReadEndpoint 并原样返回上游响应
+//
+// 请求使用 remote-write 的预检处理器校验 token 查询结果的数据隔离由上游存储负责
+type ReadService struct {
+	pipeline.Validator
+
+	endpoint string
+	client   *http.Client
+}
+
+func NewReadService(config receiver.RemoteWriteConfig) *ReadService {
+	timeout := config.ReadTimeout
+	if timeout <= 0 {
+		timeout = defaultReadTimeout
+	}
+	return &ReadService{
+		endpoint: config.ReadEndpoint,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *ReadService) Read(w http.ResponseWriter, req *http.Request) {
+	defer utils.HandleCrash()
+	ip := utils.ParseRequestIP(req.RemoteAddr)
+
+	start := time.Now()
+
+	token := define.TokenFromHttpRequest(req)
+	r := &define.Record{
+		RecordType:    define.RecordRemoteWrite,
+		RequestType:   define.RequestHttp,
+		RequestClient: define.RequestClient{IP: ip},
+		Token:         define.Token{Original: token},
+	}
+	code, processorName, err := s.Validate(r)
+	if err != nil {
+		err = errors.Wrapf(err, "run pre-check failed, code=%d, ip=%s", code, ip)
+		logger.WarnRate(time.Minute, r.Token.Original, err)
+		receiver.WriteErrResponse(w, define.ContentTypeText, int(code), err)
+		metricMonitor.IncPreCheckFailedCounter(define.RequestHttp, define.RecordRemoteWrite, processorName, r.Token.Original, code)
+		return
+	}
+
+	defer func() {
+		_ = req.Body.Close()
+	}()
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		receiver.WriteErrResponse(w, define.ContentTypeText, http.StatusInternalServerError, err)
+		metricMonitor.IncInternalErrorCounter(define.RequestHttp, define.RecordRemoteWrite)
+		logger.Errorf("failed to read body content, ip=%v, error: %s", ip, err)
+		return
+	}
+
+	// 提前解码以拒绝非法请求 转发时仍使用原始数据
+	readReq, err := utils.DecodeReadRequest(body)
+	if err == nil && len(readReq.Queries) == 0 {
+		err = errors.New("empty read queries")
+	}
+	if err != nil {
+		receiver.WriteErrResponse(w, define.ContentTypeText, http.StatusBadRequest, err)
+		metricMonitor.IncDroppedCounter(define.RequestHttp, define.RecordRemoteWrite)
+		logger.Warnf("failed to decode read request, ip=%v, error: %s", ip, err)
+		return
+	}
+
+	resp, err := s.forward(req.Context(), req.Header, r.Token.Original, body)
+	if err != nil {
+		receiver.WriteErrResponse(w, define.ContentTypeText, http.StatusBadGateway, err)
+		metricMonitor.IncInternalErrorCounter(define.RequestHttp, define.RecordRemoteWrite)
+		logger.Warnf("failed to forward read request, endpoint=%s, ip=%v, error: %s", s.endpoint, ip, err)
+		return
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	for k, values := range resp.Header {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	if _, err = io.Copy(w, resp.Body); err != nil {
+		logger.Warnf("failed to copy read response, endpoint=%s, ip=%v, error: %s", s.endpoint, ip, err)
+		return
+	}
+	logger.Debugf("forward read request, queries=%d, status=%d, ip=%v, cost=%v", len(readReq.Queries), resp.StatusCode, ip, time.Since(start))
+}
+
+func (s *ReadService) forward(ctx context.Context, header http.Header, token string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range forwardRequestHeaders {
+		if v := header.Get(k); v != "" {
+			req.Header.Set(k, v)
+		}
+	}
+	req.Header.Set(define.KeyToken, token)
+	return s.client.Do(req)
+}
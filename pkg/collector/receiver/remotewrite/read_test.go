@@ -0,0 +1,151 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package remotewrite
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/utils"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver"
+)
+
+const (
+	localPromReadURL = "http://localhost/prometheus/read"
+)
+
+func encodeReadRequest(t *testing.T, queries ...*prompb.Query) []byte {
+	b, err := proto.Marshal(&prompb.ReadRequest{Queries: queries})
+	assert.NoError(t, err)
+	return snappy.Encode(nil, b)
+}
+
+func newReadSvc(endpoint string, code define.StatusCode, err error) *ReadService {
+	svc := NewReadService(receiver.RemoteWriteConfig{ReadEndpoint: endpoint})
+	svc.Validator = pipeline.Validator{Func: func(record *define.Record) (define.StatusCode, string, error) {
+		return code, "", err
+	}}
+	return svc
+}
+
+func TestReadyWithReadEndpoint(t *testing.T) {
+	assert.NotPanics(t, func() {
+		Ready(receiver.ComponentConfig{
+			RemoteWrite: receiver.RemoteWriteConfig{
+				Enabled:      true,
+				ReadEndpoint: "http://localhost:9090/api/v1/read",
+			},
+		})
+	})
+}
+
+func TestReadRequest(t *testing.T) {
+	query := &prompb.Query{
+		StartTimestampMs: 1700000000000,
+		EndTimestampMs:   1700000060000,
+		Matchers: []*prompb.LabelMatcher{
+			{Type: prompb.LabelMatcher_EQ, Name: "__name__", Value: "up"},
+		},
+	}
+
+	t.Run("forward success", func(t *testing.T) {
+		respBody, err := proto.Marshal(&prompb.ReadResponse{
+			Results: []*prompb.QueryResult{{Timeseries: []*prompb.TimeSeries{{
+				Labels:  []prompb.Label{{Name: "__name__", Value: "up"}},
+				Samples: []prompb.Sample{{Value: 1, Timestamp: 1700000000000}},
+			}}}},
+		})
+		assert.NoError(t, err)
+
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			assert.Equal(t, "token1", req.Header.Get(define.KeyToken))
+			assert.Equal(t, "0.1.0", req.Header.Get(headerRemoteReadVersion))
+
+			b, err := io.ReadAll(req.Body)
+			assert.NoError(t, err)
+			readReq, err := utils.DecodeReadRequest(b)
+			assert.NoError(t, err)
+			assert.Len(t, readReq.Queries, 1)
+
+			w.Header().Set(define.ContentType, "application/x-protobuf")
+			w.Header().Set(headerContentEncoding, "snappy")
+			_, _ = w.Write(snappy.Encode(nil, respBody))
+		}))
+		defer upstream.Close()
+
+		req := httptest.NewRequest(http.MethodPost, localPromReadURL+"?X-BK-TOKEN=token1", bytes.NewBuffer(encodeReadRequest(t, query)))
+		req.Header.Set(headerRemoteReadVersion, "0.1.0")
+
+		rw := httptest.NewRecorder()
+		newReadSvc(upstream.URL, define.StatusCodeOK, nil).Read(rw, req)
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "snappy", rw.Header().Get(headerContentEncoding))
+
+		b, err := snappy.Decode(nil, rw.Body.Bytes())
+		assert.NoError(t, err)
+		var resp prompb.ReadResponse
+		assert.NoError(t, proto.Unmarshal(b, &resp))
+		assert.Len(t, resp.Results[0].Timeseries, 1)
+	})
+
+	t.Run("upstream error status", func(t *testing.T) {
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer upstream.Close()
+
+		req := httptest.NewRequest(http.MethodPost, localPromReadURL, bytes.NewBuffer(encodeReadRequest(t, query)))
+		rw := httptest.NewRecorder()
+		newReadSvc(upstream.URL, define.StatusCodeOK, nil).Read(rw, req)
+		assert.Equal(t, http.StatusServiceUnavailable, rw.Code)
+	})
+
+	t.Run("upstream unreachable", func(t *testing.T) {
+		upstream := httptest.NewServer(http.NotFoundHandler())
+		upstream.Close()
+
+		req := httptest.NewRequest(http.MethodPost, localPromReadURL, bytes.NewBuffer(encodeReadRequest(t, query)))
+		rw := httptest.NewRecorder()
+		newReadSvc(upstream.URL, define.StatusCodeOK, nil).Read(rw, req)
+		assert.Equal(t, http.StatusBadGateway, rw.Code)
+	})
+
+	t.Run("precheck failed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, localPromReadURL, bytes.NewBuffer(encodeReadRequest(t, query)))
+		rw := httptest.NewRecorder()
+		newReadSvc("http://127.0.0.1:0", define.StatusCodeUnauthorized, errors.New("MUST ERROR")).Read(rw, req)
+		assert.Equal(t, http.StatusUnauthorized, rw.Code)
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, localPromReadURL, bytes.NewBufferString("{-}"))
+		rw := httptest.NewRecorder()
+		newReadSvc("http://127.0.0.1:0", define.StatusCodeOK, nil).Read(rw, req)
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+	})
+
+	t.Run("empty queries", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, localPromReadURL, bytes.NewBuffer(encodeReadRequest(t)))
+		rw := httptest.NewRecorder()
+		newReadSvc("http://127.0.0.1:0", define.StatusCodeOK, nil).Read(rw, req)
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+	})
+}
@@ -0,0 +1,245 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package remotewrite
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/prompb"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/prompbv2"
+)
+
+const (
+	protoMsgV1 = "prometheus.WriteRequest"
+	protoMsgV2 = "io.prometheus.write.v2.Request"
+
+	headerSamplesWritten    = "X-Prometheus-Remote-Write-Samples-Written"
+	headerHistogramsWritten = "X-Prometheus-Remote-Write-Histograms-Written"
+	headerExemplarsWritten  = "X-Prometheus-Remote-Write-Exemplars-Written"
+	headerContentEncoding   = "Content-Encoding"
+
+	contentEncodingSnappy = "snappy"
+	metricNameLabel       = "__name__"
+	createdSuffix         = "_created"
+	counterSuffix         = "_total"
+)
+
+// parseProtoMsg 根据 Content-Type 协商协议版本 未指定 proto 参数时按 v1 处理以兼容旧版本客户端
+func parseProtoMsg(contentType string) (string, error) {
+	if contentType == "" {
+		return protoMsgV1, nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", errors.Wrapf(err, "unsupported remote write content-type '%s'", contentType)
+	}
+	if mediaType != define.ContentTypeProtobuf {
+		return "", errors.Errorf("unsupported remote write content-type '%s'", contentType)
+	}
+
+	switch proto := params["proto"]; proto {
+	case "", protoMsgV1:
+		return protoMsgV1, nil
+	case protoMsgV2:
+		return protoMsgV2, nil
+	default:
+		return "", errors.Errorf("unsupported remote write proto message '%s'", proto)
+	}
+}
+
+// checkContentEncoding remote-write 仅支持 snappy 压缩 为兼容旧版本客户端允许为空
+func checkContentEncoding(encoding string) error {
+	if encoding == "" || strings.EqualFold(encoding, contentEncodingSnappy) {
+		return nil
+	}
+	return errors.Errorf("unsupported remote write content-encoding '%s'", encoding)
+}
+
+type writeStats struct {
+	samples    int
+	histograms int
+	exemplars  int
+}
+
+func (ws writeStats) setHeaders(h http.Header) {
+	h.Set(headerSamplesWritten, strconv.Itoa(ws.samples))
+	h.Set(headerHistogramsWritten, strconv.Itoa(ws.histograms))
+	h.Set(headerExemplarsWritten, strconv.Itoa(ws.exemplars))
+}
+
+func decodeWriteRequestV2(r io.Reader) (*define.RemoteWriteData, writeStats, int, error) {
+	compressed, err := io.ReadAll(r)
+	if err != nil {
+		return nil, writeStats{}, 0, err
+	}
+	size := len(compressed)
+
+	buf, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, writeStats{}, size, err
+	}
+
+	var req prompbv2.Request
+	if err := prompbv2.Unmarshal(buf, &req); err != nil {
+		return nil, writeStats{}, size, err
+	}
+
+	data, stats, err := convertRequestV2(&req)
+	return data, stats, size, err
+}
+
+func toPromLabels(lbs []string) ([]prompb.Label, string) {
+	var name string
+	labels := make([]prompb.Label, 0, len(lbs)/2)
+	for i := 0; i < len(lbs); i += 2 {
+		if lbs[i] == metricNameLabel {
+			name = lbs[i+1]
+		}
+		labels = append(labels, prompb.Label{Name: lbs[i], Value: lbs[i+1]})
+	}
+	return labels, name
+}
+
+// createdLabels created timestamp 按照 OpenMetrics 的约定转换为 `<name>_created` series
+func createdLabels(labels []prompb.Label, name string) []prompb.Label {
+	created := strings.TrimSuffix(name, counterSuffix) + createdSuffix
+	cloned := make([]prompb.Label, 0, len(labels))
+	for _, lb := range labels {
+		if lb.Name == metricNameLabel {
+			lb.Value = created
+		}
+		cloned = append(cloned, lb)
+	}
+	return cloned
+}
+
+func toNativeHistogram(h prompbv2.Histogram) define.NativeHistogram {
+	toSpans := func(spans []prompbv2.BucketSpan) []define.BucketSpan {
+		if len(spans) == 0 {
+			return nil
+		}
+		dst := make([]define.BucketSpan, 0, len(spans))
+		for _, span := range spans {
+			dst = append(dst, define.BucketSpan{Offset: span.Offset, Length: span.Length})
+		}
+		return dst
+	}
+
+	return define.NativeHistogram{
+		Count:          h.Count(),
+		Sum:            h.Sum,
+		Schema:         h.Schema,
+		ZeroThreshold:  h.ZeroThreshold,
+		ZeroCount:      h.ZeroCount(),
+		PositiveSpans:  toSpans(h.PositiveSpans),
+		PositiveCounts: h.PositiveBuckets(),
+		NegativeSpans:  toSpans(h.NegativeSpans),
+		NegativeCounts: h.NegativeBuckets(),
+		CustomValues:   h.CustomValues,
+		Timestamp:      h.Timestamp,
+	}
+}
+
+// convertRequestV2 将 v2 请求还原为 RemoteWriteData
+// 浮点样本以及 exemplars 沿用 v1 的 TimeSeries 结构 native histograms 单独存放
+func convertRequestV2(req *prompbv2.Request) (*define.RemoteWriteData, writeStats, error) {
+	var stats writeStats
+	data := &define.RemoteWriteData{}
+	metadata := make(map[string]struct{})
+
+	for _, ts := range req.Timeseries {
+		lbs, err := req.Labels(ts.LabelsRefs)
+		if err != nil {
+			return nil, stats, err
+		}
+		labels, name := toPromLabels(lbs)
+
+		var lastTimestamp int64
+		if len(ts.Samples) > 0 || len(ts.Exemplars) > 0 {
+			series := prompb.TimeSeries{Labels: labels}
+			for _, s := range ts.Samples {
+				series.Samples = append(series.Samples, prompb.Sample{Value: s.Value, Timestamp: s.Timestamp})
+				if s.Timestamp > lastTimestamp {
+					lastTimestamp = s.Timestamp
+				}
+			}
+			for _, e := range ts.Exemplars {
+				elbs, err := req.Labels(e.LabelsRefs)
+				if err != nil {
+					return nil, stats, err
+				}
+				exemplarLabels, _ := toPromLabels(elbs)
+				series.Exemplars = append(series.Exemplars, prompb.Exemplar{
+					Labels:    exemplarLabels,
+					Value:     e.Value,
+					Timestamp: e.Timestamp,
+				})
+			}
+			data.Timeseries = append(data.Timeseries, series)
+			stats.samples += len(ts.Samples)
+			stats.exemplars += len(ts.Exemplars)
+		}
+
+		if len(ts.Histograms) > 0 {
+			histograms := make([]define.NativeHistogram, 0, len(ts.Histograms))
+			for _, h := range ts.Histograms {
+				histograms = append(histograms, toNativeHistogram(h))
+				if h.Timestamp > lastTimestamp {
+					lastTimestamp = h.Timestamp
+				}
+			}
+			data.Histograms = append(data.Histograms, define.RemoteWriteHistogram{
+				Labels:     labels,
+				Histograms: histograms,
+			})
+			stats.histograms += len(ts.Histograms)
+		}
+
+		if ts.CreatedTimestamp > 0 && lastTimestamp > 0 && name != "" {
+			data.Timeseries = append(data.Timeseries, prompb.TimeSeries{
+				Labels:  createdLabels(labels, name),
+				Samples: []prompb.Sample{{Value: float64(ts.CreatedTimestamp) / 1000, Timestamp: lastTimestamp}},
+			})
+		}
+
+		if _, ok := metadata[name]; ok || name == "" {
+			continue
+		}
+		md := ts.Metadata
+		if md.Type == prompbv2.MetricTypeUnspecified && md.HelpRef == 0 && md.UnitRef == 0 {
+			continue
+		}
+		help, err := req.Symbol(md.HelpRef)
+		if err != nil {
+			return nil, stats, err
+		}
+		unit, err := req.Symbol(md.UnitRef)
+		if err != nil {
+			return nil, stats, err
+		}
+		metadata[name] = struct{}{}
+		data.Metadata = append(data.Metadata, prompb.MetricMetadata{
+			Type:             prompb.MetricMetadata_MetricType(md.Type),
+			MetricFamilyName: name,
+			Help:             help,
+			Unit:             unit,
+		})
+	}
+	return data, stats, nil
+}
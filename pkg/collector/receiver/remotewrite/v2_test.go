@@ -0,0 +1,179 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package remotewrite
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/prompbv2"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver"
+)
+
+const (
+	contentTypeV2 = "application/x-protobuf;proto=io.prometheus.write.v2.Request"
+)
+
+func newRequestV2() *prompbv2.Request {
+	return &prompbv2.Request{
+		Symbols: []string{"", "__name__", "http_requests_total", "code", "200", "trace_id", "abc", "requests count", "rpc_duration_seconds"},
+		Timeseries: []prompbv2.TimeSeries{
+			{
+				LabelsRefs: []uint32{1, 2, 3, 4},
+				Samples:    []prompbv2.Sample{{Value: 10, Timestamp: 1000}, {Value: 12, Timestamp: 2000}},
+				Exemplars:  []prompbv2.Exemplar{{LabelsRefs: []uint32{5, 6}, Value: 1, Timestamp: 1500}},
+				Metadata:   prompbv2.Metadata{Type: prompbv2.MetricTypeCounter, HelpRef: 7},
+
+				CreatedTimestamp: 500,
+			},
+			{
+				LabelsRefs: []uint32{1, 2, 3, 4},
+				Samples:    []prompbv2.Sample{{Value: 13, Timestamp: 3000}},
+				Metadata:   prompbv2.Metadata{Type: prompbv2.MetricTypeCounter, HelpRef: 7},
+			},
+			{
+				LabelsRefs: []uint32{1, 8},
+				Histograms: []prompbv2.Histogram{{
+					CountInt:       3,
+					Sum:            1.5,
+					PositiveSpans:  []prompbv2.BucketSpan{{Offset: 0, Length: 2}},
+					PositiveDeltas: []int64{1, 1},
+					Timestamp:      2000,
+				}},
+			},
+		},
+	}
+}
+
+func encodeRequestV2(req *prompbv2.Request) []byte {
+	return snappy.Encode(nil, prompbv2.Marshal(req))
+}
+
+func TestParseProtoMsg(t *testing.T) {
+	cases := []struct {
+		contentType string
+		proto       string
+		err         bool
+	}{
+		{contentType: "", proto: protoMsgV1},
+		{contentType: "application/x-protobuf", proto: protoMsgV1},
+		{contentType: "application/x-protobuf;proto=prometheus.WriteRequest", proto: protoMsgV1},
+		{contentType: contentTypeV2, proto: protoMsgV2},
+		{contentType: "application/x-protobuf;proto=io.prometheus.write.v3.Request", err: true},
+		{contentType: "application/json", err: true},
+	}
+
+	for _, c := range cases {
+		proto, err := parseProtoMsg(c.contentType)
+		if c.err {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, c.proto, proto)
+	}
+}
+
+func TestConvertRequestV2(t *testing.T) {
+	data, stats, err := convertRequestV2(newRequestV2())
+	assert.NoError(t, err)
+	assert.Equal(t, writeStats{samples: 3, histograms: 1, exemplars: 1}, stats)
+
+	// 2 个浮点 series 以及 1 个 created series
+	assert.Len(t, data.Timeseries, 3)
+	assert.Equal(t, []prompb.Label{{Name: "trace_id", Value: "abc"}}, data.Timeseries[0].Exemplars[0].Labels)
+
+	created := data.Timeseries[1]
+	assert.Equal(t, []prompb.Label{{Name: "__name__", Value: "http_requests_created"}, {Name: "code", Value: "200"}}, created.Labels)
+	assert.Equal(t, []prompb.Sample{{Value: 0.5, Timestamp: 2000}}, created.Samples)
+
+	assert.Len(t, data.Histograms, 1)
+	h := data.Histograms[0].Histograms[0]
+	assert.Equal(t, float64(3), h.Count)
+	assert.Equal(t, []float64{1, 2}, h.PositiveCounts)
+
+	// 同名指标 metadata 只保留一份
+	assert.Equal(t, []prompb.MetricMetadata{{
+		Type:             prompb.MetricMetadata_COUNTER,
+		MetricFamilyName: "http_requests_total",
+		Help:             "requests count",
+	}}, data.Metadata)
+}
+
+func TestHttpRequestV2(t *testing.T) {
+	t.Run("unsupported proto", func(t *testing.T) {
+		buf := bytes.NewBuffer(encodeRequestV2(newRequestV2()))
+		req := httptest.NewRequest(http.MethodPost, localPromWriteURL, buf)
+		req.Header.Set(define.ContentType, "application/x-protobuf;proto=io.prometheus.write.v3.Request")
+
+		svc, n := newSvc(define.StatusCodeOK, "", nil)
+		rw := httptest.NewRecorder()
+		svc.Write(rw, req)
+		assert.Equal(t, http.StatusUnsupportedMediaType, rw.Code)
+		assert.Equal(t, int64(0), n.Load())
+	})
+
+	t.Run("unsupported encoding", func(t *testing.T) {
+		buf := bytes.NewBuffer(encodeRequestV2(newRequestV2()))
+		req := httptest.NewRequest(http.MethodPost, localPromWriteURL, buf)
+		req.Header.Set(define.ContentType, contentTypeV2)
+		req.Header.Set(headerContentEncoding, "gzip")
+
+		svc, n := newSvc(define.StatusCodeOK, "", nil)
+		rw := httptest.NewRecorder()
+		svc.Write(rw, req)
+		assert.Equal(t, http.StatusUnsupportedMediaType, rw.Code)
+		assert.Equal(t, int64(0), n.Load())
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		buf := bytes.NewBufferString("{-}")
+		req := httptest.NewRequest(http.MethodPost, localPromWriteURL, buf)
+		req.Header.Set(define.ContentType, contentTypeV2)
+
+		svc, n := newSvc(define.StatusCodeOK, "", nil)
+		rw := httptest.NewRecorder()
+		svc.Write(rw, req)
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		assert.Equal(t, int64(0), n.Load())
+	})
+
+	t.Run("report success", func(t *testing.T) {
+		buf := bytes.NewBuffer(encodeRequestV2(newRequestV2()))
+		req := httptest.NewRequest(http.MethodPost, localPromWriteURL, buf)
+		req.Header.Set(define.ContentType, contentTypeV2)
+		req.Header.Set(headerContentEncoding, contentEncodingSnappy)
+
+		var data *define.RemoteWriteData
+		svc := HttpService{
+			receiver.Publisher{Func: func(record *define.Record) {
+				data = record.Data.(*define.RemoteWriteData)
+			}},
+			pipeline.Validator{Func: func(record *define.Record) (define.StatusCode, string, error) {
+				return define.StatusCodeOK, "", nil
+			}},
+		}
+		rw := httptest.NewRecorder()
+		svc.Write(rw, req)
+		assert.Equal(t, http.StatusNoContent, rw.Code)
+		assert.Equal(t, "3", rw.Header().Get(headerSamplesWritten))
+		assert.Equal(t, "1", rw.Header().Get(headerHistogramsWritten))
+		assert.Equal(t, "1", rw.Header().Get(headerExemplarsWritten))
+		assert.Len(t, data.Histograms, 1)
+	})
+}
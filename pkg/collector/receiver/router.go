@@ -28,6 +28,15 @@ func RegisterReadyFunc(source string, f Ready) {
 	componentsReady[source] = f
 }
 
+// Stop 组件自行启动的后台服务 在 Receiver 停止时清理
+type Stop func()
+
+var componentsStop = map[string]Stop{}
+
+func RegisterStopFunc(source string, f Stop) {
+	componentsStop[source] = f
+}
+
 type serviceManager struct {
 	httpRoutes   map[string]define.RouteInfo
 	httpRouter   *mux.Router
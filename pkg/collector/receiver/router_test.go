@@ -65,6 +65,10 @@ func TestRoute(t *testing.T) {
 			method: http.MethodPost,
 			path:   "/-/freemem",
 		},
+		{
+			method: http.MethodGet,
+			path:   "/-/cardinality?dataid=1001&top=5",
+		},
 	}
 
 	for _, c := range cases {
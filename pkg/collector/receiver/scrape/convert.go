@@ -0,0 +1,247 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package scrape
+
+import (
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver"
+)
+
+// compileRelabelConfigs 将下发的 relabel 配置转换为 prometheus relabel.Config 未指定的字段沿用 prometheus 默认值
+func compileRelabelConfigs(configs []receiver.RelabelConfig) ([]*relabel.Config, error) {
+	cfgs := make([]*relabel.Config, 0, len(configs))
+	for _, c := range configs {
+		cfg := relabel.DefaultRelabelConfig
+		if c.Action != "" {
+			cfg.Action = relabel.Action(c.Action)
+		}
+		if c.Separator != "" {
+			cfg.Separator = c.Separator
+		}
+		if c.Replacement != "" {
+			cfg.Replacement = c.Replacement
+		}
+		if c.Regex != "" {
+			regex, err := relabel.NewRegexp(c.Regex)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid relabel regex '%s'", c.Regex)
+			}
+			cfg.Regex = regex
+		}
+		for _, name := range c.SourceLabels {
+			cfg.SourceLabels = append(cfg.SourceLabels, model.LabelName(name))
+		}
+		cfg.Modulus = c.Modulus
+		cfg.TargetLabel = c.TargetLabel
+
+		switch cfg.Action {
+		case relabel.Replace, relabel.Lowercase, relabel.Uppercase:
+			if cfg.TargetLabel == "" {
+				return nil, errors.Errorf("relabel action '%s' requires target_label", cfg.Action)
+			}
+		case relabel.HashMod:
+			if cfg.TargetLabel == "" || cfg.Modulus == 0 {
+				return nil, errors.Errorf("relabel action '%s' requires target_label and modulus", cfg.Action)
+			}
+		case relabel.Keep, relabel.Drop, relabel.LabelMap, relabel.LabelDrop, relabel.LabelKeep:
+		default:
+			return nil, errors.Errorf("unsupported relabel action '%s'", cfg.Action)
+		}
+
+		copied := cfg
+		cfgs = append(cfgs, &copied)
+	}
+	return cfgs, nil
+}
+
+// seriesLabels 合并 target 标签与 series 标签并执行 relabel
+// target 标签优先级高于 series 标签 返回 nil 表示该 series 被丢弃
+func seriesLabels(name string, targetLabels map[string]string, pairs []*dto.LabelPair, cfgs []*relabel.Config) labels.Labels {
+	lbs := make(map[string]string, len(targetLabels)+len(pairs)+1)
+	for _, pair := range pairs {
+		lbs[pair.GetName()] = pair.GetValue()
+	}
+	for k, v := range targetLabels {
+		lbs[k] = v
+	}
+	lbs[model.MetricNameLabel] = name
+	return relabel.Process(labels.FromMap(lbs), cfgs...)
+}
+
+type metricKey struct {
+	name string
+	typ  dto.MetricType
+}
+
+type converter struct {
+	targetLabels map[string]string
+	relabels     []*relabel.Config
+	ts           pcommon.Timestamp
+
+	metrics pmetric.MetricSlice
+	index   map[metricKey]pmetric.Metric
+}
+
+// convertMetricFamilies 将 prometheus exposition 数据转换为 pmetric.Metrics
+//
+// counter 转换为单调递增的累积 sum untyped 按 gauge 处理
+// relabel 修改 __name__ 后同名的 series 会被归并至同一个 metric
+func convertMetricFamilies(families []*dto.MetricFamily, targetLabels map[string]string, cfgs []*relabel.Config, t time.Time) pmetric.Metrics {
+	pdMetrics := pmetric.NewMetrics()
+	sm := pdMetrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+
+	c := &converter{
+		targetLabels: targetLabels,
+		relabels:     cfgs,
+		ts:           pcommon.NewTimestampFromTime(t),
+		metrics:      sm.Metrics(),
+		index:        map[metricKey]pmetric.Metric{},
+	}
+	for _, mf := range families {
+		c.convertFamily(mf)
+	}
+	return pdMetrics
+}
+
+func (c *converter) getOrCreateMetric(name string, typ dto.MetricType, help string) pmetric.Metric {
+	key := metricKey{name: name, typ: typ}
+	if m, ok := c.index[key]; ok {
+		return m
+	}
+
+	m := c.metrics.AppendEmpty()
+	m.SetName(name)
+	m.SetDescription(help)
+	switch typ {
+	case dto.MetricType_COUNTER:
+		m.SetDataType(pmetric.MetricDataTypeSum)
+		m.Sum().SetIsMonotonic(true)
+		m.Sum().SetAggregationTemporality(pmetric.MetricAggregationTemporalityCumulative)
+	case dto.MetricType_HISTOGRAM:
+		m.SetDataType(pmetric.MetricDataTypeHistogram)
+		m.Histogram().SetAggregationTemporality(pmetric.MetricAggregationTemporalityCumulative)
+	case dto.MetricType_SUMMARY:
+		m.SetDataType(pmetric.MetricDataTypeSummary)
+	default:
+		m.SetDataType(pmetric.MetricDataTypeGauge)
+	}
+	c.index[key] = m
+	return m
+}
+
+func (c *converter) timestamp(metric *dto.Metric) pcommon.Timestamp {
+	if metric.TimestampMs != nil {
+		return pcommon.NewTimestampFromTime(time.UnixMilli(metric.GetTimestampMs()))
+	}
+	return c.ts
+}
+
+func setAttributes(attrs pcommon.Map, lbs labels.Labels) {
+	for _, lb := range lbs {
+		if lb.Name == model.MetricNameLabel {
+			continue
+		}
+		attrs.UpsertString(lb.Name, lb.Value)
+	}
+}
+
+func (c *converter) convertFamily(mf *dto.MetricFamily) {
+	typ := mf.GetType()
+	for _, metric := range mf.GetMetric() {
+		lbs := seriesLabels(mf.GetName(), c.targetLabels, metric.GetLabel(), c.relabels)
+		if lbs == nil {
+			continue
+		}
+		name := lbs.Get(model.MetricNameLabel)
+		if name == "" {
+			continue
+		}
+
+		m := c.getOrCreateMetric(name, typ, mf.GetHelp())
+		ts := c.timestamp(metric)
+
+		switch typ {
+		case dto.MetricType_COUNTER:
+			dp := m.Sum().DataPoints().AppendEmpty()
+			dp.SetDoubleVal(metric.GetCounter().GetValue())
+			dp.SetTimestamp(ts)
+			setAttributes(dp.Attributes(), lbs)
+
+		case dto.MetricType_HISTOGRAM:
+			dp := m.Histogram().DataPoints().AppendEmpty()
+			convertHistogram(dp, metric.GetHistogram())
+			dp.SetTimestamp(ts)
+			setAttributes(dp.Attributes(), lbs)
+
+		case dto.MetricType_SUMMARY:
+			dp := m.Summary().DataPoints().AppendEmpty()
+			summary := metric.GetSummary()
+			dp.SetCount(summary.GetSampleCount())
+			dp.SetSum(summary.GetSampleSum())
+			for _, q := range summary.GetQuantile() {
+				vq := dp.QuantileValues().AppendEmpty()
+				vq.SetQuantile(q.GetQuantile())
+				vq.SetValue(q.GetValue())
+			}
+			dp.SetTimestamp(ts)
+			setAttributes(dp.Attributes(), lbs)
+
+		default:
+			dp := m.Gauge().DataPoints().AppendEmpty()
+			if typ == dto.MetricType_UNTYPED {
+				dp.SetDoubleVal(metric.GetUntyped().GetValue())
+			} else {
+				dp.SetDoubleVal(metric.GetGauge().GetValue())
+			}
+			dp.SetTimestamp(ts)
+			setAttributes(dp.Attributes(), lbs)
+		}
+	}
+}
+
+// convertHistogram prometheus bucket 为累积计数 需要转换为 otel 的区间计数
+func convertHistogram(dp pmetric.HistogramDataPoint, h *dto.Histogram) {
+	var bounds []float64
+	var counts []uint64
+	var prev uint64
+	for _, bucket := range h.GetBucket() {
+		if math.IsInf(bucket.GetUpperBound(), 1) {
+			continue
+		}
+		bounds = append(bounds, bucket.GetUpperBound())
+		cumulative := bucket.GetCumulativeCount()
+		if cumulative < prev {
+			cumulative = prev
+		}
+		counts = append(counts, cumulative-prev)
+		prev = cumulative
+	}
+
+	total := h.GetSampleCount()
+	if total < prev {
+		total = prev
+	}
+	counts = append(counts, total-prev)
+
+	dp.SetCount(total)
+	dp.SetSum(h.GetSampleSum())
+	dp.SetMExplicitBounds(bounds)
+	dp.SetMBucketCounts(counts)
+}
@@ -0,0 +1,431 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package scrape
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/prometheus/model/relabel"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/serieslimiter"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/utils"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+const (
+	acceptHeader = `application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.7,text/plain;version=0.0.4;q=0.3,*/*;q=0.1`
+
+	labelInstance = "instance"
+	metricUp      = "up"
+	metricLatency = "scrape_duration_seconds"
+
+	defaultInterval     = time.Minute
+	defaultTimeout      = 10 * time.Second
+	defaultMaxSeries    = 100000
+	defaultGcInterval   = time.Hour
+	defaultSyncInterval = 10 * time.Second
+	maxBodySize         = 64 << 20 // 64MB
+)
+
+func init() {
+	receiver.RegisterReadyFunc(define.SourceScrape, Ready)
+	receiver.RegisterStopFunc(define.SourceScrape, Stop)
+}
+
+var (
+	globalMut     sync.Mutex
+	globalManager *Manager
+)
+
+// Ready scrape 为拉取模式 不注册路由 仅启动全局的拉取管理器
+func Ready(config receiver.ComponentConfig) {
+	if !config.Scrape.Enabled {
+		return
+	}
+
+	globalMut.Lock()
+	defer globalMut.Unlock()
+	if globalManager != nil {
+		return
+	}
+	globalManager = NewManager()
+	globalManager.Start()
+}
+
+// Stop 停止 Ready 中启动的拉取管理器及所有拉取任务 之后可再次通过 Ready 启动
+func Stop() {
+	globalMut.Lock()
+	m := globalManager
+	globalManager = nil
+	globalMut.Unlock()
+
+	if m != nil {
+		m.Stop()
+	}
+}
+
+var metricMonitor = receiver.DefaultMetricMonitor.Source(define.SourceScrape)
+
+// Manager 根据应用子配置管理所有 token 的拉取任务
+// 定期与子配置同步 配置变更的 token 会重建拉取任务
+type Manager struct {
+	receiver.Publisher
+	pipeline.Validator
+	fetcher      receiver.ScrapeConfigFetcher
+	client       *http.Client
+	syncInterval time.Duration
+
+	mut   sync.Mutex
+	loops map[string]*scrapeLoop
+
+	once     sync.Once
+	stopOnce sync.Once
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		client:       &http.Client{},
+		syncInterval: defaultSyncInterval,
+		loops:        map[string]*scrapeLoop{},
+		done:         make(chan struct{}),
+	}
+}
+
+func (m *Manager) Start() {
+	m.once.Do(func() {
+		logger.Info("scrape manager start working...")
+		m.wg.Add(1)
+		go m.loopSync()
+	})
+}
+
+func (m *Manager) Stop() {
+	m.stopOnce.Do(func() { close(m.done) })
+	m.wg.Wait()
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	for token, loop := range m.loops {
+		loop.stop()
+		delete(m.loops, token)
+	}
+}
+
+func (m *Manager) loopSync() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.syncInterval)
+	defer ticker.Stop()
+
+	m.sync()
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			m.sync()
+		}
+	}
+}
+
+// sync 对比子配置与运行中的拉取任务 停止已删除或者变更的任务并启动新任务
+func (m *Manager) sync() {
+	configs := m.fetcher.Fetch()
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	for token, loop := range m.loops {
+		conf, ok := configs[token]
+		if ok && reflect.DeepEqual(conf, loop.origin) {
+			continue
+		}
+		loop.stop()
+		delete(m.loops, token)
+		logger.Infof("scrape loop stopped, token=%s", token)
+	}
+
+	for token, conf := range configs {
+		if _, ok := m.loops[token]; ok {
+			continue
+		}
+		loop, err := m.newScrapeLoop(token, conf)
+		if err != nil {
+			logger.Errorf("failed to create scrape loop, token=%s, err: %v", token, err)
+			continue
+		}
+		m.loops[token] = loop
+		loop.start()
+		logger.Infof("scrape loop started, token=%s, targets=%d", token, len(conf.Targets))
+	}
+}
+
+type scrapeLoop struct {
+	mgr      *Manager
+	token    string
+	origin   receiver.ScrapeConfig
+	config   receiver.ScrapeConfig
+	relabels []*relabel.Config
+	limiter  *serieslimiter.Limiter
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func (m *Manager) newScrapeLoop(token string, conf receiver.ScrapeConfig) (*scrapeLoop, error) {
+	relabels, err := compileRelabelConfigs(conf.RelabelConfigs)
+	if err != nil {
+		return nil, err
+	}
+
+	c := conf
+	if c.Interval <= 0 {
+		c.Interval = defaultInterval
+	}
+	if c.Timeout <= 0 || c.Timeout > c.Interval {
+		c.Timeout = minDuration(defaultTimeout, c.Interval)
+	}
+	if c.MaxSeries <= 0 {
+		c.MaxSeries = defaultMaxSeries
+	}
+	if c.GcInterval <= 0 {
+		c.GcInterval = defaultGcInterval
+	}
+
+	return &scrapeLoop{
+		mgr:      m,
+		token:    token,
+		origin:   conf,
+		config:   c,
+		relabels: relabels,
+		limiter:  serieslimiter.New(c.MaxSeries, c.GcInterval),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func (l *scrapeLoop) start() {
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+
+		ticker := time.NewTicker(l.config.Interval)
+		defer ticker.Stop()
+
+		l.scrapeAll()
+		for {
+			select {
+			case <-l.done:
+				return
+			case <-ticker.C:
+				l.scrapeAll()
+			}
+		}
+	}()
+}
+
+func (l *scrapeLoop) stop() {
+	close(l.done)
+	l.wg.Wait()
+	l.limiter.Stop()
+}
+
+func (l *scrapeLoop) scrapeAll() {
+	var wg sync.WaitGroup
+	for _, target := range l.config.Targets {
+		wg.Add(1)
+		go func(target receiver.ScrapeTarget) {
+			defer wg.Done()
+			l.scrapeTarget(target)
+		}(target)
+	}
+	wg.Wait()
+}
+
+// targetLabels 返回附加至所有 series 的标签 未指定 instance 时使用 target 的 host
+func targetLabels(target receiver.ScrapeTarget) map[string]string {
+	lbs := make(map[string]string, len(target.Labels)+1)
+	if u, err := url.Parse(target.Url); err == nil {
+		lbs[labelInstance] = u.Host
+	}
+	for k, v := range target.Labels {
+		lbs[k] = v
+	}
+	return lbs
+}
+
+func (l *scrapeLoop) fetch(target receiver.ScrapeTarget) ([]*dto.MetricFamily, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.Url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Accept", acceptHeader)
+
+	resp, err := l.mgr.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var families []*dto.MetricFamily
+	decoder := expfmt.NewDecoder(bytes.NewReader(body), expfmt.ResponseFormat(resp.Header))
+	for {
+		mf := &dto.MetricFamily{}
+		if err := decoder.Decode(mf); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, len(body), err
+		}
+		families = append(families, mf)
+	}
+	return families, len(body), nil
+}
+
+func (l *scrapeLoop) scrapeTarget(target receiver.ScrapeTarget) {
+	defer utils.HandleCrash()
+
+	start := time.Now()
+	lbs := targetLabels(target)
+	families, size, err := l.fetch(target)
+	if err != nil {
+		logger.WarnfRate(time.Minute, l.token, "failed to scrape target %s, err: %v", target.Url, err)
+		metricMonitor.IncDroppedCounter(define.RequestHttp, define.RecordMetrics)
+	}
+
+	pdMetrics := convertMetricFamilies(families, lbs, l.relabels, start)
+	appendScrapeMetrics(pdMetrics, lbs, err == nil, time.Since(start), start)
+
+	r := &define.Record{
+		RecordType:    define.RecordMetrics,
+		RequestType:   define.RequestHttp,
+		RequestClient: define.RequestClient{IP: utils.ParseRequestIP(lbs[labelInstance])},
+		Token:         define.Token{Original: l.token},
+		Data:          pdMetrics,
+	}
+
+	code, processorName, err := l.mgr.Validate(r)
+	if err != nil {
+		logger.WarnfRate(time.Minute, r.Token.Original, "run pre-check failed, code=%d, target=%s, err: %v", code, target.Url, err)
+		metricMonitor.IncPreCheckFailedCounter(define.RequestHttp, define.RecordMetrics, processorName, r.Token.Original, code)
+		return
+	}
+
+	limitSeries(l.limiter, r.Token.MetricsDataId, pdMetrics)
+	if pdMetrics.DataPointCount() == 0 {
+		metricMonitor.IncSkippedCounter(define.RequestHttp, define.RecordMetrics, r.Token.Original)
+		return
+	}
+
+	l.mgr.Publish(r)
+	receiver.RecordHandleMetrics(metricMonitor, r.Token, define.RequestHttp, define.RecordMetrics, size, start)
+}
+
+// appendScrapeMetrics 追加与 prometheus 语义一致的 up/scrape_duration_seconds 指标
+func appendScrapeMetrics(pdMetrics pmetric.Metrics, lbs map[string]string, up bool, duration time.Duration, t time.Time) {
+	metrics := pdMetrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	ts := pcommon.NewTimestampFromTime(t)
+
+	add := func(name string, value float64) {
+		m := metrics.AppendEmpty()
+		m.SetName(name)
+		m.SetDataType(pmetric.MetricDataTypeGauge)
+		dp := m.Gauge().DataPoints().AppendEmpty()
+		dp.SetDoubleVal(value)
+		dp.SetTimestamp(ts)
+		for k, v := range lbs {
+			dp.Attributes().UpsertString(k, v)
+		}
+	}
+
+	var v float64
+	if up {
+		v = 1
+	}
+	add(metricUp, v)
+	add(metricLatency, duration.Seconds())
+}
+
+// limitSeries 移除超出 series 上限的数据点
+func limitSeries(limiter *serieslimiter.Limiter, dataID int32, pdMetrics pmetric.Metrics) {
+	metrics := pdMetrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+
+	allowed := func(name string, attrs pcommon.Map) bool {
+		dims := make(map[string]string, attrs.Len()+1)
+		attrs.Range(func(k string, v pcommon.Value) bool {
+			dims[k] = v.AsString()
+			return true
+		})
+		dims["__name__"] = name
+		return limiter.Set(dataID, name, dims)
+	}
+
+	metrics.RemoveIf(func(m pmetric.Metric) bool {
+		name := m.Name()
+		switch m.DataType() {
+		case pmetric.MetricDataTypeGauge:
+			m.Gauge().DataPoints().RemoveIf(func(dp pmetric.NumberDataPoint) bool {
+				return !allowed(name, dp.Attributes())
+			})
+			return m.Gauge().DataPoints().Len() == 0
+		case pmetric.MetricDataTypeSum:
+			m.Sum().DataPoints().RemoveIf(func(dp pmetric.NumberDataPoint) bool {
+				return !allowed(name, dp.Attributes())
+			})
+			return m.Sum().DataPoints().Len() == 0
+		case pmetric.MetricDataTypeHistogram:
+			m.Histogram().DataPoints().RemoveIf(func(dp pmetric.HistogramDataPoint) bool {
+				return !allowed(name, dp.Attributes())
+			})
+			return m.Histogram().DataPoints().Len() == 0
+		case pmetric.MetricDataTypeSummary:
+			m.Summary().DataPoints().RemoveIf(func(dp pmetric.SummaryDataPoint) bool {
+				return !allowed(name, dp.Attributes())
+			})
+			return m.Summary().DataPoints().Len() == 0
+		}
+		return false
+	})
+}
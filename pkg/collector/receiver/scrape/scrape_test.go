@@ -0,0 +1,345 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package scrape
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/serieslimiter"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver"
+)
+
+const textContent = `
+# HELP http_requests_total The total number of HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{method="post",code="200"} 1027
+http_requests_total{method="post",code="400"} 3
+# HELP go_goroutines Number of goroutines.
+# TYPE go_goroutines gauge
+go_goroutines 10
+# TYPE rpc_duration_seconds summary
+rpc_duration_seconds{quantile="0.5"} 4773
+rpc_duration_seconds{quantile="0.9"} 9001
+rpc_duration_seconds_sum 1.7560473e+07
+rpc_duration_seconds_count 2693
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{le="0.1"} 10
+http_request_duration_seconds_bucket{le="0.5"} 15
+http_request_duration_seconds_bucket{le="+Inf"} 20
+http_request_duration_seconds_sum 53
+http_request_duration_seconds_count 20
+`
+
+func parseFamilies(t *testing.T, s string) []*dto.MetricFamily {
+	var parser expfmt.TextParser
+	mfs, err := parser.TextToMetricFamilies(strings.NewReader(s))
+	assert.NoError(t, err)
+
+	families := make([]*dto.MetricFamily, 0, len(mfs))
+	for _, mf := range mfs {
+		families = append(families, mf)
+	}
+	return families
+}
+
+func metricsByName(pdMetrics pmetric.Metrics) map[string]pmetric.Metric {
+	result := make(map[string]pmetric.Metric)
+	metrics := pdMetrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := 0; i < metrics.Len(); i++ {
+		result[metrics.At(i).Name()] = metrics.At(i)
+	}
+	return result
+}
+
+func TestReady(t *testing.T) {
+	assert.NotPanics(t, func() {
+		Ready(receiver.ComponentConfig{})
+	})
+}
+
+func TestReadyStop(t *testing.T) {
+	config := receiver.ComponentConfig{Scrape: receiver.ComponentCommon{Enabled: true}}
+
+	Ready(config)
+	first := globalManager
+	assert.NotNil(t, first)
+
+	// 重复 Ready 沿用运行中的管理器
+	Ready(config)
+	assert.True(t, first == globalManager)
+
+	Stop()
+	assert.Nil(t, globalManager)
+
+	// 停止后可以重新启动
+	Ready(config)
+	assert.NotNil(t, globalManager)
+	assert.True(t, first != globalManager)
+
+	Stop()
+	assert.Nil(t, globalManager)
+	assert.NotPanics(t, Stop)
+}
+
+func TestCompileRelabelConfigs(t *testing.T) {
+	tests := []struct {
+		name   string
+		config receiver.RelabelConfig
+		err    bool
+	}{
+		{
+			name:   "drop",
+			config: receiver.RelabelConfig{SourceLabels: []string{"__name__"}, Regex: "go_.*", Action: "drop"},
+		},
+		{
+			name:   "default replace",
+			config: receiver.RelabelConfig{SourceLabels: []string{"code"}, TargetLabel: "status"},
+		},
+		{
+			name:   "replace without target",
+			config: receiver.RelabelConfig{SourceLabels: []string{"code"}, Action: "replace"},
+			err:    true,
+		},
+		{
+			name:   "hashmod without modulus",
+			config: receiver.RelabelConfig{SourceLabels: []string{"code"}, TargetLabel: "shard", Action: "hashmod"},
+			err:    true,
+		},
+		{
+			name:   "invalid regex",
+			config: receiver.RelabelConfig{Regex: "(", Action: "keep"},
+			err:    true,
+		},
+		{
+			name:   "unknown action",
+			config: receiver.RelabelConfig{Action: "unknown"},
+			err:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileRelabelConfigs([]receiver.RelabelConfig{tt.config})
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestConvertMetricFamilies(t *testing.T) {
+	families := parseFamilies(t, textContent)
+	pdMetrics := convertMetricFamilies(families, map[string]string{"instance": "127.0.0.1:8080"}, nil, time.Now())
+	assert.Equal(t, 5, pdMetrics.DataPointCount())
+
+	metrics := metricsByName(pdMetrics)
+	assert.Len(t, metrics, 4)
+
+	counter := metrics["http_requests_total"]
+	assert.Equal(t, pmetric.MetricDataTypeSum, counter.DataType())
+	assert.True(t, counter.Sum().IsMonotonic())
+	assert.Equal(t, 2, counter.Sum().DataPoints().Len())
+
+	gauge := metrics["go_goroutines"]
+	assert.Equal(t, pmetric.MetricDataTypeGauge, gauge.DataType())
+	dp := gauge.Gauge().DataPoints().At(0)
+	assert.Equal(t, float64(10), dp.DoubleVal())
+	v, ok := dp.Attributes().Get("instance")
+	assert.True(t, ok)
+	assert.Equal(t, "127.0.0.1:8080", v.AsString())
+
+	summary := metrics["rpc_duration_seconds"]
+	assert.Equal(t, pmetric.MetricDataTypeSummary, summary.DataType())
+	assert.Equal(t, uint64(2693), summary.Summary().DataPoints().At(0).Count())
+	assert.Equal(t, 2, summary.Summary().DataPoints().At(0).QuantileValues().Len())
+
+	histogram := metrics["http_request_duration_seconds"]
+	assert.Equal(t, pmetric.MetricDataTypeHistogram, histogram.DataType())
+	hdp := histogram.Histogram().DataPoints().At(0)
+	assert.Equal(t, uint64(20), hdp.Count())
+	assert.Equal(t, float64(53), hdp.Sum())
+	assert.Equal(t, []float64{0.1, 0.5}, hdp.MExplicitBounds())
+	assert.Equal(t, []uint64{10, 5, 5}, hdp.MBucketCounts())
+}
+
+func TestConvertWithRelabel(t *testing.T) {
+	relabels, err := compileRelabelConfigs([]receiver.RelabelConfig{
+		{SourceLabels: []string{"__name__"}, Regex: "go_.*", Action: "drop"},
+		{SourceLabels: []string{"code"}, Regex: "400", Action: "drop"},
+		{SourceLabels: []string{"__name__"}, Regex: "http_requests_total", TargetLabel: "__name__", Replacement: "requests_total"},
+	})
+	assert.NoError(t, err)
+
+	families := parseFamilies(t, textContent)
+	pdMetrics := convertMetricFamilies(families, nil, relabels, time.Now())
+
+	metrics := metricsByName(pdMetrics)
+	_, ok := metrics["go_goroutines"]
+	assert.False(t, ok)
+
+	counter, ok := metrics["requests_total"]
+	assert.True(t, ok)
+	assert.Equal(t, 1, counter.Sum().DataPoints().Len())
+	v, _ := counter.Sum().DataPoints().At(0).Attributes().Get("code")
+	assert.Equal(t, "200", v.AsString())
+}
+
+func TestLimitSeries(t *testing.T) {
+	limiter := serieslimiter.New(2, time.Hour)
+	defer limiter.Stop()
+
+	families := parseFamilies(t, textContent)
+	pdMetrics := convertMetricFamilies(families, nil, nil, time.Now())
+	limitSeries(limiter, 1001, pdMetrics)
+	assert.Equal(t, 2, pdMetrics.DataPointCount())
+}
+
+type recordCollector struct {
+	mut     sync.Mutex
+	records []*define.Record
+}
+
+func (c *recordCollector) Push(r *define.Record) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.records = append(c.records, r)
+}
+
+func (c *recordCollector) Len() int {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return len(c.records)
+}
+
+func newTestManager(configs map[string]receiver.ScrapeConfig, code define.StatusCode, err error) (*Manager, *recordCollector) {
+	c := &recordCollector{}
+	m := NewManager()
+	m.Publisher = receiver.Publisher{Func: c.Push}
+	m.Validator = pipeline.Validator{Func: func(r *define.Record) (define.StatusCode, string, error) {
+		r.Token.MetricsDataId = 1001
+		return code, "", err
+	}}
+	m.fetcher = receiver.ScrapeConfigFetcher{Func: func() map[string]receiver.ScrapeConfig {
+		return configs
+	}}
+	return m, c
+}
+
+func TestManagerScrape(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set(define.ContentType, string(expfmt.FmtText))
+		_, _ = w.Write([]byte(textContent))
+	}))
+	defer svr.Close()
+
+	t.Run("success", func(t *testing.T) {
+		m, c := newTestManager(map[string]receiver.ScrapeConfig{
+			"token1": {Interval: time.Hour, Targets: []receiver.ScrapeTarget{{Url: svr.URL + "/metrics"}}},
+		}, define.StatusCodeOK, nil)
+		m.sync()
+		defer m.Stop()
+
+		assert.Eventually(t, func() bool { return c.Len() == 1 }, 5*time.Second, 10*time.Millisecond)
+		r := c.records[0]
+		assert.Equal(t, define.RecordMetrics, r.RecordType)
+		assert.Equal(t, "token1", r.Token.Original)
+
+		metrics := metricsByName(r.Data.(pmetric.Metrics))
+		assert.Equal(t, float64(1), metrics[metricUp].Gauge().DataPoints().At(0).DoubleVal())
+		assert.Equal(t, 7, r.Data.(pmetric.Metrics).DataPointCount())
+	})
+
+	t.Run("target down", func(t *testing.T) {
+		m, c := newTestManager(map[string]receiver.ScrapeConfig{
+			"token1": {Interval: time.Hour, Targets: []receiver.ScrapeTarget{{Url: svr.URL + "/notfound"}}},
+		}, define.StatusCodeOK, nil)
+		m.sync()
+		defer m.Stop()
+
+		assert.Eventually(t, func() bool { return c.Len() == 1 }, 5*time.Second, 10*time.Millisecond)
+		metrics := metricsByName(c.records[0].Data.(pmetric.Metrics))
+		assert.Equal(t, float64(0), metrics[metricUp].Gauge().DataPoints().At(0).DoubleVal())
+	})
+
+	t.Run("precheck failed", func(t *testing.T) {
+		m, c := newTestManager(map[string]receiver.ScrapeConfig{
+			"token1": {Interval: time.Hour, Targets: []receiver.ScrapeTarget{{Url: svr.URL + "/metrics"}}},
+		}, define.StatusCodeUnauthorized, errors.New("MUST ERROR"))
+		m.sync()
+		defer m.Stop()
+
+		time.Sleep(200 * time.Millisecond)
+		assert.Equal(t, 0, c.Len())
+	})
+}
+
+func TestManagerSync(t *testing.T) {
+	configs := map[string]receiver.ScrapeConfig{
+		"token1": {Interval: time.Hour, Targets: []receiver.ScrapeTarget{{Url: "http://127.0.0.1:0/metrics"}}},
+		"token2": {Interval: time.Hour, RelabelConfigs: []receiver.RelabelConfig{{Action: "unknown"}}},
+	}
+	m, _ := newTestManager(configs, define.StatusCodeOK, nil)
+	defer m.Stop()
+
+	m.sync()
+	assert.Len(t, m.loops, 1)
+	loop := m.loops["token1"]
+
+	// 配置未变更 沿用原有任务
+	m.sync()
+	assert.Equal(t, loop, m.loops["token1"])
+
+	// 配置变更 重建任务
+	configs["token1"] = receiver.ScrapeConfig{Interval: time.Minute, Targets: []receiver.ScrapeTarget{{Url: "http://127.0.0.1:0/metrics"}}}
+	m.sync()
+	assert.NotEqual(t, loop, m.loops["token1"])
+	assert.Equal(t, time.Minute, m.loops["token1"].config.Interval)
+
+	delete(configs, "token1")
+	m.sync()
+	assert.Len(t, m.loops, 0)
+}
+
+func TestManagerStartStop(t *testing.T) {
+	m, _ := newTestManager(map[string]receiver.ScrapeConfig{
+		"token1": {Interval: time.Hour, Targets: []receiver.ScrapeTarget{{Url: "http://127.0.0.1:0/metrics"}}},
+	}, define.StatusCodeOK, nil)
+
+	m.Start()
+	assert.Eventually(t, func() bool {
+		m.mut.Lock()
+		defer m.mut.Unlock()
+		return len(m.loops) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// 重复停止不会 panic 且所有拉取任务均已清理
+	m.Stop()
+	m.Stop()
+	assert.Len(t, m.loops, 0)
+}
@@ -0,0 +1,222 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package statsd
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+var summaryQuantiles = []float64{0.5, 0.9, 0.99}
+
+type seriesKey struct {
+	name string
+	typ  metricType
+	tags string
+}
+
+func newSeriesKey(m statsdMetric) seriesKey {
+	var sb strings.Builder
+	for i, t := range m.Tags {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(t.Key)
+		sb.WriteByte(':')
+		sb.WriteString(t.Value)
+	}
+
+	typ := m.Type
+	// timer/histogram/distribution 均按样本分布聚合
+	if typ == typeHistogram || typ == typeDistribution {
+		typ = typeTimer
+	}
+	return seriesKey{name: m.Name, typ: typ, tags: sb.String()}
+}
+
+type series struct {
+	tags []tag
+
+	counter float64
+	gauge   float64
+	samples []float64
+	count   float64 // 按采样率还原后的样本数
+	set     map[string]struct{}
+	dirty   bool
+}
+
+// aggregator 在 flush 周期内聚合 statsd 数据
+//
+// counter: 周期内累加 并按采样率还原 以 delta sum 输出
+// gauge: 保留最新值 增量写法基于上一次的值计算 仅输出周期内有更新的 series 闲置一个周期后清理
+// timer/histogram/distribution: 以 summary 输出 包含 count/sum 以及 min/max/分位数
+// set: 以 gauge 输出周期内去重后的元素个数
+type aggregator struct {
+	mut    sync.Mutex
+	series map[seriesKey]*series
+	start  time.Time
+}
+
+func newAggregator() *aggregator {
+	return &aggregator{
+		series: map[seriesKey]*series{},
+		start:  time.Now(),
+	}
+}
+
+func (a *aggregator) Add(metrics []statsdMetric) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	for _, m := range metrics {
+		key := newSeriesKey(m)
+		s, ok := a.series[key]
+		if !ok {
+			s = &series{tags: m.Tags}
+			a.series[key] = s
+		}
+		s.dirty = true
+
+		switch key.typ {
+		case typeCounter:
+			for _, v := range m.Values {
+				s.counter += v / m.SampleRate
+			}
+		case typeGauge:
+			for _, v := range m.Values {
+				if m.Relative {
+					s.gauge += v
+				} else {
+					s.gauge = v
+				}
+			}
+		case typeTimer:
+			s.samples = append(s.samples, m.Values...)
+			s.count += float64(len(m.Values)) / m.SampleRate
+		case typeSet:
+			if s.set == nil {
+				s.set = map[string]struct{}{}
+			}
+			for _, v := range m.SetValues {
+				s.set[v] = struct{}{}
+			}
+		}
+	}
+}
+
+// Flush 输出周期内的聚合结果并重置状态 gauge 的最新值会被保留
+func (a *aggregator) Flush(now time.Time) pmetric.Metrics {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	pdMetrics := pmetric.NewMetrics()
+	metrics := pdMetrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+	startTs := pcommon.NewTimestampFromTime(a.start)
+	ts := pcommon.NewTimestampFromTime(now)
+
+	keys := make([]seriesKey, 0, len(a.series))
+	for key, s := range a.series {
+		if s.dirty {
+			keys = append(keys, key)
+			continue
+		}
+		// 超过一个周期未更新的 gauge 不再保留
+		delete(a.series, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].tags < keys[j].tags
+	})
+
+	for _, key := range keys {
+		s := a.series[key]
+		m := metrics.AppendEmpty()
+		m.SetName(key.name)
+
+		var attrs pcommon.Map
+		switch key.typ {
+		case typeCounter:
+			m.SetDataType(pmetric.MetricDataTypeSum)
+			m.Sum().SetIsMonotonic(true)
+			m.Sum().SetAggregationTemporality(pmetric.MetricAggregationTemporalityDelta)
+			dp := m.Sum().DataPoints().AppendEmpty()
+			dp.SetDoubleVal(s.counter)
+			dp.SetStartTimestamp(startTs)
+			dp.SetTimestamp(ts)
+			attrs = dp.Attributes()
+			delete(a.series, key)
+
+		case typeGauge:
+			m.SetDataType(pmetric.MetricDataTypeGauge)
+			dp := m.Gauge().DataPoints().AppendEmpty()
+			dp.SetDoubleVal(s.gauge)
+			dp.SetTimestamp(ts)
+			attrs = dp.Attributes()
+			s.dirty = false
+
+		case typeTimer:
+			m.SetDataType(pmetric.MetricDataTypeSummary)
+			dp := m.Summary().DataPoints().AppendEmpty()
+			fillSummary(dp, s)
+			dp.SetStartTimestamp(startTs)
+			dp.SetTimestamp(ts)
+			attrs = dp.Attributes()
+			delete(a.series, key)
+
+		case typeSet:
+			m.SetDataType(pmetric.MetricDataTypeGauge)
+			dp := m.Gauge().DataPoints().AppendEmpty()
+			dp.SetDoubleVal(float64(len(s.set)))
+			dp.SetTimestamp(ts)
+			attrs = dp.Attributes()
+			delete(a.series, key)
+		}
+
+		for _, t := range s.tags {
+			attrs.UpsertString(t.Key, t.Value)
+		}
+	}
+
+	a.start = now
+	return pdMetrics
+}
+
+// fillSummary 分位数使用 nearest-rank 算法 0/1 分位数分别对应 min/max
+func fillSummary(dp pmetric.SummaryDataPoint, s *series) {
+	samples := s.samples
+	sort.Float64s(samples)
+
+	var sum float64
+	for _, v := range samples {
+		sum += v
+	}
+	dp.SetCount(uint64(math.Round(s.count)))
+	dp.SetSum(sum * s.count / float64(len(samples)))
+
+	quantiles := append([]float64{0}, summaryQuantiles...)
+	quantiles = append(quantiles, 1)
+	for _, q := range quantiles {
+		rank := int(math.Ceil(q*float64(len(samples)))) - 1
+		if rank < 0 {
+			rank = 0
+		}
+		vq := dp.QuantileValues().AppendEmpty()
+		vq.SetQuantile(q)
+		vq.SetValue(samples[rank])
+	}
+}
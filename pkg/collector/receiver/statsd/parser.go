@@ -0,0 +1,147 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package statsd
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type metricType string
+
+const (
+	typeCounter      metricType = "c"
+	typeGauge        metricType = "g"
+	typeTimer        metricType = "ms"
+	typeHistogram    metricType = "h"
+	typeDistribution metricType = "d"
+	typeSet          metricType = "s"
+)
+
+type tag struct {
+	Key   string
+	Value string
+}
+
+// statsdMetric 单行 statsd 数据
+//
+// 协议格式: <name>:<value>[:<value>...]|<type>[|@<sample_rate>][|#<tag>[:<value>],...]
+// 其中多值以及 tags 为 DogStatsD 扩展
+type statsdMetric struct {
+	Name       string
+	Type       metricType
+	Values     []float64
+	SetValues  []string // set 类型保留原始字符串
+	Relative   bool     // gauge 类型携带 +/- 符号表示增量
+	SampleRate float64
+	Tags       []tag
+}
+
+func isEventOrServiceCheck(line string) bool {
+	return strings.HasPrefix(line, "_e{") || strings.HasPrefix(line, "_sc|")
+}
+
+// parseLines 解析多行数据 忽略 DogStatsD 的 event/service check 类型
+func parseLines(data []byte) ([]statsdMetric, []error) {
+	var metrics []statsdMetric
+	var errs []error
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || isEventOrServiceCheck(line) {
+			continue
+		}
+
+		m, err := parseLine(line)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, errs
+}
+
+func parseLine(line string) (statsdMetric, error) {
+	m := statsdMetric{SampleRate: 1}
+
+	sections := strings.Split(line, "|")
+	if len(sections) < 2 {
+		return m, errors.Errorf("invalid statsd line '%s'", line)
+	}
+
+	idx := strings.Index(sections[0], ":")
+	if idx <= 0 {
+		return m, errors.Errorf("invalid statsd name/value '%s'", sections[0])
+	}
+	m.Name = sections[0][:idx]
+	rawValues := strings.Split(sections[0][idx+1:], ":")
+
+	m.Type = metricType(sections[1])
+	switch m.Type {
+	case typeCounter, typeGauge, typeTimer, typeHistogram, typeDistribution, typeSet:
+	default:
+		return m, errors.Errorf("unsupported statsd type '%s'", sections[1])
+	}
+
+	for _, section := range sections[2:] {
+		switch {
+		case strings.HasPrefix(section, "@"):
+			rate, err := strconv.ParseFloat(section[1:], 64)
+			if err != nil || rate <= 0 || rate > 1 {
+				return m, errors.Errorf("invalid sample rate '%s'", section)
+			}
+			m.SampleRate = rate
+		case strings.HasPrefix(section, "#"):
+			m.Tags = parseTags(section[1:])
+		}
+	}
+
+	for _, raw := range rawValues {
+		if raw == "" {
+			return m, errors.Errorf("empty statsd value in '%s'", line)
+		}
+		if m.Type == typeSet {
+			m.SetValues = append(m.SetValues, raw)
+			continue
+		}
+		if m.Type == typeGauge && (raw[0] == '+' || raw[0] == '-') {
+			m.Relative = true
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return m, errors.Wrapf(err, "invalid statsd value '%s'", raw)
+		}
+		m.Values = append(m.Values, v)
+	}
+	return m, nil
+}
+
+// parseTags 解析 DogStatsD tags 未携带值的 tag 值为空字符串 结果按 key 排序
+func parseTags(s string) []tag {
+	var tags []tag
+	for _, item := range strings.Split(s, ",") {
+		if item == "" {
+			continue
+		}
+		kv := strings.SplitN(item, ":", 2)
+		t := tag{Key: kv[0]}
+		if len(kv) == 2 {
+			t.Value = kv[1]
+		}
+		tags = append(tags, t)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Key < tags[j].Key
+	})
+	return tags
+}
@@ -0,0 +1,77 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package statsd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		input  string
+		metric statsdMetric
+		err    bool
+	}{
+		{
+			input:  "page.views:1|c",
+			metric: statsdMetric{Name: "page.views", Type: typeCounter, Values: []float64{1}, SampleRate: 1},
+		},
+		{
+			input:  "page.views:2|c|@0.5",
+			metric: statsdMetric{Name: "page.views", Type: typeCounter, Values: []float64{2}, SampleRate: 0.5},
+		},
+		{
+			input:  "fuel.level:-10|g",
+			metric: statsdMetric{Name: "fuel.level", Type: typeGauge, Values: []float64{-10}, Relative: true, SampleRate: 1},
+		},
+		{
+			input: "song.length:240:120|h|@1|#region:cn,env:prod,canary",
+			metric: statsdMetric{
+				Name:       "song.length",
+				Type:       typeHistogram,
+				Values:     []float64{240, 120},
+				SampleRate: 1,
+				Tags:       []tag{{Key: "canary"}, {Key: "env", Value: "prod"}, {Key: "region", Value: "cn"}},
+			},
+		},
+		{
+			input:  "users.uniques:1234|s",
+			metric: statsdMetric{Name: "users.uniques", Type: typeSet, SetValues: []string{"1234"}, SampleRate: 1},
+		},
+		{input: "page.views", err: true},
+		{input: "page.views:1", err: true},
+		{input: ":1|c", err: true},
+		{input: "page.views:1|x", err: true},
+		{input: "page.views:abc|c", err: true},
+		{input: "page.views:|c", err: true},
+		{input: "page.views:1|c|@2", err: true},
+	}
+
+	for _, tt := range tests {
+		m, err := parseLine(tt.input)
+		if tt.err {
+			assert.Error(t, err, tt.input)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, tt.metric, m)
+	}
+}
+
+func TestParseLines(t *testing.T) {
+	data := []byte("a:1|c\n_e{5,4}:title|text\n_sc|check|0\nb:x|g\n\nc:2|ms\n")
+	metrics, errs := parseLines(data)
+	assert.Len(t, metrics, 2)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "a", metrics[0].Name)
+	assert.Equal(t, "c", metrics[1].Name)
+}
@@ -0,0 +1,299 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package statsd
+
+import (
+	"bufio"
+	"net"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/utils"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+const (
+	transportUdp = "udp"
+	transportTcp = "tcp"
+
+	defaultEndpoint      = ":8125"
+	defaultFlushInterval = 10 * time.Second
+	maxPacketSize        = 65535
+)
+
+func init() {
+	receiver.RegisterReadyFunc(define.SourceStatsd, Ready)
+	receiver.RegisterStopFunc(define.SourceStatsd, Stop)
+}
+
+var (
+	globalMut    sync.Mutex
+	globalServer *Server
+)
+
+// Ready statsd 不注册路由 而是独立监听 udp/tcp 端口
+func Ready(config receiver.ComponentConfig) {
+	if !config.Statsd.Enabled {
+		return
+	}
+
+	svr := NewServer(config.Statsd)
+	if err := svr.Start(); err != nil {
+		logger.Errorf("failed to start statsd server, err: %v", err)
+		return
+	}
+
+	globalMut.Lock()
+	globalServer = svr
+	globalMut.Unlock()
+}
+
+// Stop 停止 Ready 中启动的 statsd 服务
+func Stop() {
+	globalMut.Lock()
+	svr := globalServer
+	globalServer = nil
+	globalMut.Unlock()
+
+	if svr != nil {
+		svr.Stop()
+	}
+}
+
+var metricMonitor = receiver.DefaultMetricMonitor.Source(define.SourceStatsd)
+
+type Server struct {
+	receiver.Publisher
+	pipeline.Validator
+
+	config      receiver.StatsdConfig
+	requestType define.RequestType
+	aggregator  *aggregator
+	received    *atomic.Int64 // flush 周期内接收的字节数
+
+	mut      sync.Mutex
+	conn     net.PacketConn
+	listener net.Listener
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewServer(config receiver.StatsdConfig) *Server {
+	if config.Transport == "" {
+		config.Transport = transportUdp
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = defaultEndpoint
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaultFlushInterval
+	}
+
+	requestType := define.RequestUdp
+	if config.Transport == transportTcp {
+		requestType = define.RequestTcp
+	}
+
+	return &Server{
+		config:      config,
+		requestType: requestType,
+		aggregator:  newAggregator(),
+		received:    atomic.NewInt64(0),
+		done:        make(chan struct{}),
+	}
+}
+
+// Addr 返回实际监听的地址
+func (s *Server) Addr() net.Addr {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.conn != nil {
+		return s.conn.LocalAddr()
+	}
+	if s.listener != nil {
+		return s.listener.Addr()
+	}
+	return nil
+}
+
+func (s *Server) Start() error {
+	logger.Infof("start to listen statsd %s server at: %v", s.config.Transport, s.config.Endpoint)
+
+	s.mut.Lock()
+	switch s.config.Transport {
+	case transportTcp:
+		l, err := net.Listen(transportTcp, s.config.Endpoint)
+		if err != nil {
+			s.mut.Unlock()
+			return err
+		}
+		s.listener = l
+		s.wg.Add(1)
+		go s.serveTcp(l)
+
+	default:
+		conn, err := net.ListenPacket(transportUdp, s.config.Endpoint)
+		if err != nil {
+			s.mut.Unlock()
+			return err
+		}
+		s.conn = conn
+		s.wg.Add(1)
+		go s.serveUdp(conn)
+	}
+	s.mut.Unlock()
+
+	s.wg.Add(1)
+	go s.loopFlush()
+	return nil
+}
+
+func (s *Server) Stop() {
+	close(s.done)
+
+	s.mut.Lock()
+	if s.conn != nil {
+		_ = s.conn.Close()
+	}
+	if s.listener != nil {
+		_ = s.listener.Close()
+	}
+	s.mut.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Server) serveUdp(conn net.PacketConn) {
+	defer s.wg.Done()
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+			logger.Warnf("statsd failed to read udp packet, err: %v", err)
+			continue
+		}
+		s.handle(buf[:n])
+	}
+}
+
+func (s *Server) serveTcp(l net.Listener) {
+	defer s.wg.Done()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+			logger.Warnf("statsd failed to accept tcp connection, err: %v", err)
+			continue
+		}
+
+		s.wg.Add(1)
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer s.wg.Done()
+
+	closed := make(chan struct{})
+	defer func() {
+		close(closed)
+		_ = conn.Close()
+	}()
+
+	go func() {
+		select {
+		case <-s.done:
+			_ = conn.Close()
+		case <-closed:
+		}
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 4096), maxPacketSize)
+	for scanner.Scan() {
+		s.handle(scanner.Bytes())
+	}
+}
+
+func (s *Server) handle(data []byte) {
+	defer utils.HandleCrash()
+
+	metrics, errs := parseLines(data)
+	for _, err := range errs {
+		logger.Debugf("statsd failed to parse line, err: %v", err)
+		metricMonitor.IncDroppedCounter(s.requestType, define.RecordMetrics)
+	}
+
+	s.received.Add(int64(len(data)))
+	s.aggregator.Add(metrics)
+}
+
+func (s *Server) loopFlush() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			s.flush(time.Now())
+			return
+		case t := <-ticker.C:
+			s.flush(t)
+		}
+	}
+}
+
+func (s *Server) flush(t time.Time) {
+	defer utils.HandleCrash()
+
+	start := time.Now()
+	pdMetrics := s.aggregator.Flush(t)
+	size := s.received.Swap(0)
+	if pdMetrics.MetricCount() == 0 {
+		return
+	}
+
+	r := &define.Record{
+		RecordType:  define.RecordMetrics,
+		RequestType: s.requestType,
+		Token:       define.Token{Original: s.config.Token},
+		Data:        pdMetrics,
+	}
+
+	code, processorName, err := s.Validate(r)
+	if err != nil {
+		logger.WarnfRate(time.Minute, r.Token.Original, "run pre-check failed, code=%d, err: %v", code, err)
+		metricMonitor.IncPreCheckFailedCounter(s.requestType, define.RecordMetrics, processorName, r.Token.Original, code)
+		return
+	}
+
+	s.Publish(r)
+	receiver.RecordHandleMetrics(metricMonitor, r.Token, s.requestType, define.RecordMetrics, int(size), start)
+}
@@ -0,0 +1,165 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package statsd
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/atomic"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver"
+)
+
+func TestReady(t *testing.T) {
+	assert.NotPanics(t, func() {
+		Ready(receiver.ComponentConfig{})
+	})
+}
+
+func TestReadyStop(t *testing.T) {
+	Ready(receiver.ComponentConfig{
+		Statsd: receiver.StatsdConfig{Enabled: true, Endpoint: "127.0.0.1:0"},
+	})
+	assert.NotNil(t, globalServer)
+	addr := globalServer.Addr().String()
+
+	Stop()
+	assert.Nil(t, globalServer)
+
+	// 端口已释放
+	conn, err := net.ListenPacket(transportUdp, addr)
+	assert.NoError(t, err)
+	_ = conn.Close()
+	assert.NotPanics(t, Stop)
+}
+
+func metricsByName(pdMetrics pmetric.Metrics) map[string]pmetric.Metric {
+	result := make(map[string]pmetric.Metric)
+	metrics := pdMetrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := 0; i < metrics.Len(); i++ {
+		result[metrics.At(i).Name()] = metrics.At(i)
+	}
+	return result
+}
+
+func TestAggregator(t *testing.T) {
+	agg := newAggregator()
+	metrics, errs := parseLines([]byte(`requests:1|c|#code:200
+requests:1|c|@0.5|#code:200
+goroutines:10|g
+goroutines:+5|g
+latency:10|ms
+latency:20|ms
+latency:30|d
+users:alice|s
+users:bob|s
+users:alice|s
+`))
+	assert.Len(t, errs, 0)
+	agg.Add(metrics)
+
+	pdMetrics := agg.Flush(time.Now())
+	result := metricsByName(pdMetrics)
+	assert.Len(t, result, 4)
+
+	counter := result["requests"]
+	assert.Equal(t, pmetric.MetricDataTypeSum, counter.DataType())
+	assert.Equal(t, pmetric.MetricAggregationTemporalityDelta, counter.Sum().AggregationTemporality())
+	dp := counter.Sum().DataPoints().At(0)
+	assert.Equal(t, float64(3), dp.DoubleVal())
+	v, _ := dp.Attributes().Get("code")
+	assert.Equal(t, "200", v.AsString())
+
+	gauge := result["goroutines"]
+	assert.Equal(t, float64(15), gauge.Gauge().DataPoints().At(0).DoubleVal())
+
+	summary := result["latency"].Summary().DataPoints().At(0)
+	assert.Equal(t, uint64(3), summary.Count())
+	assert.Equal(t, float64(60), summary.Sum())
+	assert.Equal(t, 5, summary.QuantileValues().Len())
+	assert.Equal(t, float64(10), summary.QuantileValues().At(0).Value())
+	assert.Equal(t, float64(20), summary.QuantileValues().At(1).Value())
+	assert.Equal(t, float64(30), summary.QuantileValues().At(4).Value())
+
+	set := result["users"]
+	assert.Equal(t, float64(2), set.Gauge().DataPoints().At(0).DoubleVal())
+
+	// gauge 增量基于上一周期的值
+	metrics, _ = parseLines([]byte("goroutines:-3|g"))
+	agg.Add(metrics)
+	pdMetrics = agg.Flush(time.Now())
+	assert.Equal(t, 1, pdMetrics.MetricCount())
+	assert.Equal(t, float64(12), metricsByName(pdMetrics)["goroutines"].Gauge().DataPoints().At(0).DoubleVal())
+
+	// 闲置周期不输出数据
+	assert.Equal(t, 0, agg.Flush(time.Now()).MetricCount())
+	assert.Len(t, agg.series, 0)
+}
+
+func newTestServer(transport string, code define.StatusCode, err error) (*Server, *atomic.Int64) {
+	n := atomic.NewInt64(0)
+	svr := NewServer(receiver.StatsdConfig{
+		Transport:     transport,
+		Endpoint:      "127.0.0.1:0",
+		FlushInterval: 50 * time.Millisecond,
+		Token:         "token1",
+	})
+	svr.Publisher = receiver.Publisher{Func: func(r *define.Record) {
+		if r.Token.Original == "token1" {
+			n.Add(int64(r.Data.(pmetric.Metrics).MetricCount()))
+		}
+	}}
+	svr.Validator = pipeline.Validator{Func: func(r *define.Record) (define.StatusCode, string, error) {
+		return code, "", err
+	}}
+	return svr, n
+}
+
+func TestServer(t *testing.T) {
+	for _, transport := range []string{transportUdp, transportTcp} {
+		t.Run(transport, func(t *testing.T) {
+			svr, n := newTestServer(transport, define.StatusCodeOK, nil)
+			assert.NoError(t, svr.Start())
+			defer svr.Stop()
+
+			conn, err := net.Dial(transport, svr.Addr().String())
+			assert.NoError(t, err)
+			defer conn.Close()
+
+			_, err = conn.Write([]byte("requests:1|c\nlatency:10|ms\n"))
+			assert.NoError(t, err)
+
+			assert.Eventually(t, func() bool { return n.Load() == 2 }, 5*time.Second, 10*time.Millisecond)
+		})
+	}
+
+	t.Run("precheck failed", func(t *testing.T) {
+		svr, n := newTestServer(transportUdp, define.StatusCodeUnauthorized, errors.New("MUST ERROR"))
+		assert.NoError(t, svr.Start())
+
+		conn, err := net.Dial(transportUdp, svr.Addr().String())
+		assert.NoError(t, err)
+		defer conn.Close()
+
+		_, err = conn.Write([]byte("requests:1|c\n"))
+		assert.NoError(t, err)
+
+		time.Sleep(200 * time.Millisecond)
+		svr.Stop()
+		assert.Equal(t, int64(0), n.Load())
+	})
+}
@@ -0,0 +1,218 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package zipkin
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/prettyprint"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/utils"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/utils/logger"
+)
+
+const (
+	routeV1Spans = "/api/v1/spans"
+	routeV2Spans = "/api/v2/spans"
+)
+
+func init() {
+	receiver.RegisterReadyFunc(define.SourceZipkin, Ready)
+}
+
+func Ready(config receiver.ComponentConfig) {
+	if !config.Zipkin.Enabled {
+		return
+	}
+	receiver.RegisterRecvHttpRoute(define.SourceZipkin, []receiver.RouteWithFunc{
+		{
+			Method:       http.MethodPost,
+			RelativePath: routeV1Spans,
+			HandlerFunc:  httpSvc.V1Spans,
+		},
+		{
+			Method:       http.MethodPost,
+			RelativePath: routeV2Spans,
+			HandlerFunc:  httpSvc.V2Spans,
+		},
+	})
+}
+
+var metricMonitor = receiver.DefaultMetricMonitor.Source(define.SourceZipkin)
+
+type HttpService struct {
+	receiver.Publisher
+	pipeline.Validator
+}
+
+var httpSvc HttpService
+
+type apiVersion string
+
+const (
+	apiV1 apiVersion = "v1"
+	apiV2 apiVersion = "v2"
+)
+
+type tracesEncoder interface {
+	Type() string
+	UnmarshalTraces(buf []byte) (ptrace.Traces, error)
+}
+
+var acceptedThriftFormats = map[string]struct{}{
+	"application/x-thrift":                 {},
+	"application/vnd.apache.thrift.binary": {},
+}
+
+// getEncoder 根据 api 版本以及 Content-Type 选择编码器
+// v1: thrift/json（默认 json）
+// v2: protobuf/json（默认 json）
+func getEncoder(version apiVersion, ctype string) (tracesEncoder, error) {
+	contentType := define.ContentTypeJson
+	if ctype != "" {
+		mediaType, _, err := mime.ParseMediaType(ctype)
+		if err != nil {
+			return nil, err
+		}
+		contentType = mediaType
+	}
+
+	switch version {
+	case apiV1:
+		if _, ok := acceptedThriftFormats[contentType]; ok {
+			return newThriftV1Encoder(), nil
+		}
+		if contentType == define.ContentTypeJson {
+			return newJsonV1Encoder(), nil
+		}
+	case apiV2:
+		switch contentType {
+		case define.ContentTypeProtobuf:
+			return newPbV2Encoder(), nil
+		case define.ContentTypeJson:
+			return newJsonV2Encoder(), nil
+		}
+	}
+	return nil, errors.Errorf("unsupported content type: %v", contentType)
+}
+
+// decompressBody 支持 gzip 以及 deflate/zlib 压缩格式
+func decompressBody(encoding string, body io.Reader) (io.ReadCloser, error) {
+	switch encoding {
+	case "gzip":
+		return gzip.NewReader(body)
+	case "deflate", "zlib":
+		return zlib.NewReader(body)
+	case "", "identity":
+		return io.NopCloser(body), nil
+	}
+	return nil, errors.Errorf("unsupported content encoding: %v", encoding)
+}
+
+func (s HttpService) V1Spans(w http.ResponseWriter, req *http.Request) {
+	s.exportSpans(w, req, apiV1)
+}
+
+func (s HttpService) V2Spans(w http.ResponseWriter, req *http.Request) {
+	s.exportSpans(w, req, apiV2)
+}
+
+func (s HttpService) exportSpans(w http.ResponseWriter, req *http.Request, version apiVersion) {
+	defer utils.HandleCrash()
+	ip := utils.ParseRequestIP(req.RemoteAddr)
+
+	start := time.Now()
+	defer func() {
+		_ = req.Body.Close()
+	}()
+
+	rc, err := decompressBody(req.Header.Get("Content-Encoding"), req.Body)
+	if err != nil {
+		logger.Warnf("failed to decompress zipkin body, ip=%v, err: %v", ip, err)
+		metricMonitor.IncDroppedCounter(define.RequestHttp, define.RecordTraces)
+		receiver.WriteErrResponse(w, define.ContentTypeText, http.StatusBadRequest, err)
+		return
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	buf := &bytes.Buffer{}
+	_, err = io.Copy(buf, rc)
+	if err != nil {
+		metricMonitor.IncInternalErrorCounter(define.RequestHttp, define.RecordTraces)
+		receiver.WriteResponse(w, define.ContentTypeText, http.StatusInternalServerError, nil)
+		logger.Errorf("failed to read zipkin body: %v", err)
+		return
+	}
+
+	encoder, err := getEncoder(version, req.Header.Get(define.ContentType))
+	if err != nil {
+		logger.Warnf("failed to negotiate zipkin encoder, ip=%v, err: %v", ip, err)
+		metricMonitor.IncDroppedCounter(define.RequestHttp, define.RecordTraces)
+		receiver.WriteErrResponse(w, define.ContentTypeText, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	traces, err := encoder.UnmarshalTraces(buf.Bytes())
+	if err != nil {
+		err = errors.Wrapf(err, "unmarshal %s request body failed", encoder.Type())
+		logger.Warnf("failed to parse zipkin exported content, ip=%v, err: %v", ip, err)
+		metricMonitor.IncDroppedCounter(define.RequestHttp, define.RecordTraces)
+		receiver.WriteErrResponse(w, define.ContentTypeText, http.StatusBadRequest, err)
+		return
+	}
+
+	r := &define.Record{
+		RequestType:   define.RequestHttp,
+		RequestClient: define.RequestClient{IP: ip},
+		RecordType:    define.RecordTraces,
+		Data:          traces,
+	}
+
+	tk := define.TokenFromHttpRequest(req)
+	if len(tk) > 0 {
+		r.Token = define.Token{Original: tk}
+	}
+	prettyprint.Traces(traces)
+
+	code, processorName, err := s.Validate(r)
+	if err != nil {
+		err = errors.Wrapf(err, "run pre-check failed, rtype=traces, code=%d, ip=%s", code, ip)
+		logger.WarnRate(time.Minute, r.Token.Original, err)
+		metricMonitor.IncPreCheckFailedCounter(define.RequestHttp, define.RecordTraces, processorName, r.Token.Original, code)
+		receiver.WriteErrResponse(w, define.ContentTypeText, int(code), err)
+		return
+	}
+
+	if traces.SpanCount() == 0 {
+		metricMonitor.IncSkippedCounter(define.RequestHttp, define.RecordTraces, r.Token.Original)
+		logger.Debugf("skip empty records, ip=%v, proto=%v, rtype=%v", ip, define.RequestHttp, define.RecordTraces)
+		receiver.WriteResponse(w, define.ContentTypeText, http.StatusAccepted, nil)
+		return
+	}
+
+	s.Publish(r)
+	receiver.RecordHandleMetrics(metricMonitor, r.Token, define.RequestHttp, define.RecordTraces, buf.Len(), start)
+
+	// zipkin 协议约定成功时返回 202
+	receiver.WriteResponse(w, define.ContentTypeText, http.StatusAccepted, nil)
+}
@@ -0,0 +1,209 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package zipkin
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/internal/testkits"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/collector/receiver"
+)
+
+const (
+	localV1SpansURL = "http://localhost/api/v1/spans"
+	localV2SpansURL = "http://localhost/api/v2/spans"
+)
+
+const v2JsonContent = `[{
+  "traceId": "5982fe77008310cc80f1da5e10147517",
+  "id": "90394f6bcffb5d13",
+  "kind": "SERVER",
+  "name": "get /api",
+  "timestamp": 1472470996199000,
+  "duration": 207000,
+  "localEndpoint": {"serviceName": "frontend", "ipv4": "192.168.99.1", "port": 3306},
+  "tags": {"http.method": "GET", "http.path": "/api"}
+}]`
+
+const v1JsonContent = `[{
+  "traceId": "5982fe77008310cc80f1da5e10147517",
+  "name": "get /api",
+  "id": "90394f6bcffb5d13",
+  "timestamp": 1472470996199000,
+  "duration": 207000,
+  "annotations": [
+    {"timestamp": 1472470996199000, "value": "sr", "endpoint": {"serviceName": "frontend", "ipv4": "192.168.99.1"}},
+    {"timestamp": 1472470996406000, "value": "ss", "endpoint": {"serviceName": "frontend", "ipv4": "192.168.99.1"}}
+  ]
+}]`
+
+func TestReady(t *testing.T) {
+	assert.NotPanics(t, func() {
+		Ready(receiver.ComponentConfig{})
+	})
+}
+
+func newSvc(code define.StatusCode, msg string, err error) (HttpService, *atomic.Int64) {
+	n := atomic.NewInt64(0)
+	svc := HttpService{
+		receiver.Publisher{Func: func(record *define.Record) { n.Inc() }},
+		pipeline.Validator{Func: func(record *define.Record) (define.StatusCode, string, error) {
+			return code, msg, err
+		}},
+	}
+	return svc, n
+}
+
+func gzipContent(s string) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	_, _ = w.Write([]byte(s))
+	_ = w.Close()
+	return buf
+}
+
+func TestGetEncoder(t *testing.T) {
+	tests := []struct {
+		version apiVersion
+		ctype   string
+		typ     string
+		err     bool
+	}{
+		{version: apiV1, ctype: "", typ: "json.v1"},
+		{version: apiV1, ctype: "application/json; charset=utf-8", typ: "json.v1"},
+		{version: apiV1, ctype: "application/x-thrift", typ: "thrift.v1"},
+		{version: apiV1, ctype: "application/x-protobuf", err: true},
+		{version: apiV2, ctype: "", typ: "json.v2"},
+		{version: apiV2, ctype: "application/x-protobuf", typ: "pb.v2"},
+		{version: apiV2, ctype: "application/x-thrift", err: true},
+		{version: apiV2, ctype: ";;", err: true},
+	}
+
+	for _, tt := range tests {
+		encoder, err := getEncoder(tt.version, tt.ctype)
+		if tt.err {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, tt.typ, encoder.Type())
+	}
+}
+
+func TestHttpRequest(t *testing.T) {
+	t.Run("v2 json success", func(t *testing.T) {
+		buf := bytes.NewBufferString(v2JsonContent)
+		req := httptest.NewRequest(http.MethodPost, localV2SpansURL, buf)
+		req.Header.Set(define.ContentType, define.ContentTypeJson)
+		req.Header.Set(define.KeyToken, "token1")
+
+		var token string
+		svc := HttpService{
+			receiver.Publisher{Func: func(record *define.Record) { token = record.Token.Original }},
+			pipeline.Validator{Func: func(record *define.Record) (define.StatusCode, string, error) {
+				return define.StatusCodeOK, "", nil
+			}},
+		}
+
+		rw := httptest.NewRecorder()
+		svc.V2Spans(rw, req)
+		assert.Equal(t, http.StatusAccepted, rw.Code)
+		assert.Equal(t, "token1", token)
+	})
+
+	t.Run("v2 json gzip success", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, localV2SpansURL, gzipContent(v2JsonContent))
+		req.Header.Set("Content-Encoding", "gzip")
+
+		svc, n := newSvc(define.StatusCodeOK, "", nil)
+		rw := httptest.NewRecorder()
+		svc.V2Spans(rw, req)
+		assert.Equal(t, http.StatusAccepted, rw.Code)
+		assert.Equal(t, int64(1), n.Load())
+	})
+
+	t.Run("v1 json success", func(t *testing.T) {
+		buf := bytes.NewBufferString(v1JsonContent)
+		req := httptest.NewRequest(http.MethodPost, localV1SpansURL, buf)
+
+		svc, n := newSvc(define.StatusCodeOK, "", nil)
+		rw := httptest.NewRecorder()
+		svc.V1Spans(rw, req)
+		assert.Equal(t, http.StatusAccepted, rw.Code)
+		assert.Equal(t, int64(1), n.Load())
+	})
+
+	t.Run("invalid encoding", func(t *testing.T) {
+		buf := bytes.NewBufferString(v2JsonContent)
+		req := httptest.NewRequest(http.MethodPost, localV2SpansURL, buf)
+		req.Header.Set("Content-Encoding", "gzip")
+
+		svc, n := newSvc(define.StatusCodeOK, "", nil)
+		rw := httptest.NewRecorder()
+		svc.V2Spans(rw, req)
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		assert.Equal(t, int64(0), n.Load())
+	})
+
+	t.Run("unsupported content type", func(t *testing.T) {
+		buf := bytes.NewBufferString(v2JsonContent)
+		req := httptest.NewRequest(http.MethodPost, localV2SpansURL, buf)
+		req.Header.Set(define.ContentType, "application/x-thrift")
+
+		svc, n := newSvc(define.StatusCodeOK, "", nil)
+		rw := httptest.NewRecorder()
+		svc.V2Spans(rw, req)
+		assert.Equal(t, http.StatusUnsupportedMediaType, rw.Code)
+		assert.Equal(t, int64(0), n.Load())
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		buf := bytes.NewBufferString("{-}")
+		req := httptest.NewRequest(http.MethodPost, localV2SpansURL, buf)
+
+		svc, n := newSvc(define.StatusCodeOK, "", nil)
+		rw := httptest.NewRecorder()
+		svc.V2Spans(rw, req)
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		assert.Equal(t, int64(0), n.Load())
+	})
+
+	t.Run("read failed", func(t *testing.T) {
+		buf := testkits.NewBrokenReader()
+		req := httptest.NewRequest(http.MethodPost, localV2SpansURL, buf)
+
+		svc, n := newSvc(define.StatusCodeOK, "", nil)
+		rw := httptest.NewRecorder()
+		svc.V2Spans(rw, req)
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+		assert.Equal(t, int64(0), n.Load())
+	})
+
+	t.Run("precheck failed", func(t *testing.T) {
+		buf := bytes.NewBufferString(v2JsonContent)
+		req := httptest.NewRequest(http.MethodPost, localV2SpansURL, buf)
+
+		svc, n := newSvc(define.StatusCodeTooManyRequests, "", errors.New("MUST ERROR"))
+		rw := httptest.NewRecorder()
+		svc.V2Spans(rw, req)
+		assert.Equal(t, http.StatusTooManyRequests, rw.Code)
+		assert.Equal(t, int64(0), n.Load())
+	})
+}
@@ -281,6 +281,21 @@ func Send(event MapStr) bool {
 	return true
 }
 
+// SendWithPrivate sends a MapStr type event with private data
+// which will be reported back by the pipeline ACK handler
+func SendWithPrivate(event MapStr, private interface{}) bool {
+	if beatNotRunning() {
+		return false
+	}
+	if commonBKBeat.Client == nil {
+		return false
+	}
+	ev := bkEventToEvent(event)
+	ev.Private = private
+	(*commonBKBeat.Client).Publish(ev)
+	return true
+}
+
 // SendEvent sends a Event type event
 func SendEvent(event Event) bool {
 	if beatNotRunning() {
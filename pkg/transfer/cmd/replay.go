@@ -0,0 +1,202 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/consul"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/json"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/logging"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/replay"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/storage"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/utils"
+)
+
+// loadReplayPipelineConfig : 优先使用命令行给出的配置，否则从 consul 读取 data id 配置
+func loadReplayPipelineConfig(conf define.Configuration, dataID int, raw, path string) (*config.PipelineConfig, error) {
+	var data []byte
+	switch {
+	case raw != "":
+		data = []byte(raw)
+	case path != "":
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		data = content
+	default:
+		client, err := consul.NewConsulAPIFromConfig(conf)
+		if err != nil {
+			return nil, err
+		}
+		key := utils.ResolveUnixPaths(conf.GetString(consul.ConfKeyDataIDPath), strconv.Itoa(dataID))
+		pair, _, err := client.KV().Get(key, nil)
+		if err != nil {
+			return nil, err
+		} else if pair == nil {
+			return nil, errors.Wrapf(define.ErrItemNotFound, "consul key %s", key)
+		}
+		data = pair.Value
+	}
+
+	pipe := config.NewPipelineConfig()
+	if err := json.Unmarshal(data, pipe); err != nil {
+		return nil, err
+	}
+	if dataID > 0 && pipe.DataID != dataID {
+		return nil, errors.Wrapf(define.ErrValue, "data id %d mismatch with pipeline config %d", dataID, pipe.DataID)
+	}
+	if pipe.ETLConfig == "" {
+		return nil, errors.Wrapf(define.ErrValue, "etl config is empty")
+	}
+	return pipe, pipe.Clean()
+}
+
+// replayCmd represents the replay command
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Replay kafka offset range or local file through data id pipeline",
+	Example: `./transfer replay -d 1001 --partition 0 --start-offset 1000 --end-offset 2000
+./transfer replay -d 1001 -f ./dead_letter --dead-letter -b '{"cluster_type":"file"}' --rate 1048576`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var (
+			flags = cmd.Flags()
+			opts  replay.Options
+			err   error
+		)
+		dataID, err := flags.GetInt("data-id")
+		checkError(err, -1, "get data id failed")
+		raw, err := flags.GetString("raw")
+		checkError(err, -1, "get raw failed")
+		pipeFile, err := flags.GetString("pipeline-file")
+		checkError(err, -1, "get pipeline file failed")
+		backend, err := flags.GetString("backend")
+		checkError(err, -1, "get backend failed")
+		interval, err := flags.GetDuration("progress-interval")
+		checkError(err, -1, "get progress interval failed")
+		stopTimeout, err := flags.GetDuration("stop-timeout")
+		checkError(err, -1, "get stop timeout failed")
+		opts.File, err = flags.GetString("file")
+		checkError(err, -1, "get file failed")
+		opts.Topic, err = flags.GetString("topic")
+		checkError(err, -1, "get topic failed")
+		opts.Partition, err = flags.GetInt32("partition")
+		checkError(err, -1, "get partition failed")
+		opts.StartOffset, err = flags.GetInt64("start-offset")
+		checkError(err, -1, "get start offset failed")
+		opts.EndOffset, err = flags.GetInt64("end-offset")
+		checkError(err, -1, "get end offset failed")
+		opts.DeadLetter, err = flags.GetBool("dead-letter")
+		checkError(err, -1, "get dead letter failed")
+		opts.Rate, err = flags.GetInt("rate")
+		checkError(err, -1, "get rate failed")
+
+		if dataID <= 0 && raw == "" && pipeFile == "" {
+			exitf(-1, "data id or pipeline config is required")
+		} else if interval <= 0 {
+			exitf(-1, "progress interval must be positive")
+		}
+
+		conf := config.Configuration
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		ctx = config.IntoContext(ctx, conf)
+		ctx = context.WithValue(ctx, define.ContextStartCacheKey, false)
+
+		pipe, err := loadReplayPipelineConfig(conf, dataID, raw, pipeFile)
+		checkError(err, -2, "load pipeline config of %d failed", dataID)
+
+		// 覆盖所有结果表的写入后端，避免直接写入线上存储
+		if backend != "" {
+			shipper := config.NewMetaClusterInfo()
+			checkError(json.Unmarshal([]byte(backend), shipper), -1, "parse backend failed")
+			checkError(shipper.Clean(), -1, "clean backend failed")
+			for _, rt := range pipe.ResultTableList {
+				rt.ShipperList = []*config.MetaClusterInfo{shipper}
+			}
+		}
+		pipe.MQConfig.ClusterType = replay.FrontendType
+
+		storeType := conf.GetString(storage.ConfStorageType)
+		store, err := define.NewStore(ctx, storeType)
+		checkError(err, -3, "create store %s failed", storeType)
+		defer func() {
+			logging.WarnIf("close store error", store.Close())
+		}()
+		ctx = define.StoreIntoContext(ctx, store)
+
+		task := replay.NewReplay(opts)
+		ctx = replay.IntoContext(ctx, task)
+		ctx = config.PipelineConfigIntoContext(ctx, pipe)
+		ctx = config.MQConfigIntoContext(ctx, pipe.MQConfig)
+		pipeline, err := define.NewPipeline(ctx, pipe.ETLConfig)
+		checkError(err, -3, "create pipeline %s of %d failed", pipe.ETLConfig, pipe.DataID)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		killCh := pipeline.Start()
+		fmt.Printf("replaying data id %d with %s\n", pipe.DataID, pipe.ETLConfig)
+
+		var failed error
+	loop:
+		for {
+			select {
+			case err := <-killCh:
+				// 前端读取完毕后会发送 ErrTimeout
+				if errors.Cause(err) != define.ErrTimeout {
+					failed = err
+				}
+				break loop
+			case <-ticker.C:
+				fmt.Printf("progress: %v\n", task.Progress.Snapshot())
+			}
+		}
+
+		go func() {
+			for err := range killCh {
+				logging.Warnf("received error %v when pipeline %v closing", err, pipeline)
+			}
+		}()
+		logging.WarnIf("stop pipeline error", pipeline.Stop(stopTimeout))
+		logging.WarnIf("wait pipeline error", pipeline.Wait())
+
+		fmt.Printf("finished: %v\n", task.Progress.Snapshot())
+		checkError(failed, -4, "replay data id %d failed", pipe.DataID)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(replayCmd)
+	flags := replayCmd.Flags()
+	flags.IntP("data-id", "d", 0, "data id to replay")
+	flags.StringP("raw", "r", "", "pipeline config in json, read from consul if empty")
+	flags.String("pipeline-file", "", "pipeline config file in json, read from consul if empty")
+	flags.StringP("file", "f", "", "replay from local file, one record per line")
+	flags.String("topic", "", "kafka topic, use topic of data id if empty")
+	flags.Int32("partition", 0, "kafka partition")
+	flags.Int64("start-offset", sarama.OffsetOldest, "kafka start offset, -2 means oldest")
+	flags.Int64("end-offset", -1, "kafka end offset (exclusive), -1 means newest")
+	flags.Bool("dead-letter", false, "input is dead letter records, replay their payload")
+	flags.StringP("backend", "b", "", "shipper config in json, override backends of all result tables")
+	flags.Int("rate", 0, "rate limit in bytes per second, use data id default if 0")
+	flags.Duration("progress-interval", 5*time.Second, "progress report interval")
+	flags.Duration("stop-timeout", 10*time.Second, "wait timeout for flushing backends")
+}
@@ -38,6 +38,28 @@ func (c *ElasticSearchMetaClusterInfo) SetVersion(val string) {
 	c.StorageConfigHelper.Set("version", val)
 }
 
+// ElasticSearchDistributionOpenSearch : OpenSearch 的版本号与 ES 重叠，需要通过 distribution 区分
+const ElasticSearchDistributionOpenSearch = "opensearch"
+
+// GetDistribution : 集群发行版，默认为 elasticsearch
+func (c *ElasticSearchMetaClusterInfo) GetDistribution() string {
+	distribution, ok := c.StorageConfigHelper.GetString("distribution")
+	if ok {
+		return distribution
+	}
+	distribution, ok = c.ClusterConfigHelper.GetString("distribution")
+	if ok {
+		return distribution
+	}
+	return "elasticsearch"
+}
+
+// IsDataStream : 写入目标 base_index 是否为 data stream
+func (c *ElasticSearchMetaClusterInfo) IsDataStream() bool {
+	dataStream, _ := c.StorageConfigHelper.GetBool("data_stream")
+	return dataStream
+}
+
 // GetTarget :
 func (c *ElasticSearchMetaClusterInfo) GetTarget() string {
 	return c.GetIndex()
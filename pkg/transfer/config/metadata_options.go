@@ -55,6 +55,8 @@ const (
 	PipelineConfigOptPayloadEncoding = "encoding"
 	// PipelineConfigOptPayloadEncodingStrict : 严格编码模式(bool)
 	PipelineConfigOptPayloadEncodingStrict = "encoding_strict"
+	// PipelineConfigOptPayloadType : 前端使用的 payload 格式，默认为全局配置 pipeline.payload.type
+	PipelineConfigOptPayloadType = "payload_type"
 	// PipelineConfigOptTransformFileNameToAliasName : 字段别名映射
 	PipelineConfigOptTransformFileNameToAliasName = "allow_use_alias_name"
 	// ResultTableListConfigOptEnableFillDefault : 默认值
@@ -76,6 +78,8 @@ const (
 	PipelineConfigOptAllowDynamicMetricsAsFloat = "dynamic_metrics_as_float"
 	// PipelineConfigOptMaxQps 允许后端写入的最大的 QPS
 	PipelineConfigOptMaxQps = "max_qps"
+	// PipelineConfigOptDeadLetter : 死信后端配置，结构同 shipper(cluster_type 支持 kafka/file)
+	PipelineConfigOptDeadLetter = "dead_letter"
 	// PipelineConfigDropEmptyMetrics 是否丢弃空 metrics
 	PipelineConfigDropEmptyMetrics = "drop_empty_metrics"
 	// PipelineConfigDisableMetricsReporter 是否关闭 metrics_reporter 特性
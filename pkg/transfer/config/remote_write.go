@@ -0,0 +1,147 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package config
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cstockton/go-conv"
+)
+
+// RemoteWriteDefaultPath : 未配置 endpoints 时使用的写入路径
+const RemoteWriteDefaultPath = "/api/v1/write"
+
+// RemoteWriteMetaClusterInfo :
+type RemoteWriteMetaClusterInfo struct {
+	*SimpleMetaClusterInfo
+}
+
+func (c *RemoteWriteMetaClusterInfo) getDuration(key string, defaults time.Duration) time.Duration {
+	value, ok := c.StorageConfigHelper.GetString(key)
+	if !ok {
+		return defaults
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return defaults
+	}
+	return duration
+}
+
+// GetPath :
+func (c *RemoteWriteMetaClusterInfo) GetPath() string {
+	path, ok := c.StorageConfigHelper.GetString("path")
+	if ok && path != "" {
+		return path
+	}
+	return RemoteWriteDefaultPath
+}
+
+// GetEndpoints : 写入地址列表，未配置时使用集群地址
+func (c *RemoteWriteMetaClusterInfo) GetEndpoints() []string {
+	values, _ := c.StorageConfigHelper.GetArray("endpoints")
+	endpoints := make([]string, 0, len(values))
+	for _, value := range values {
+		endpoint := conv.String(value)
+		if endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+
+	if len(endpoints) == 0 {
+		endpoints = append(endpoints, c.GetAddress()+c.GetPath())
+	}
+	return endpoints
+}
+
+// SetEndpoints :
+func (c *RemoteWriteMetaClusterInfo) SetEndpoints(endpoints []string) {
+	values := make([]interface{}, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		values = append(values, endpoint)
+	}
+	c.StorageConfigHelper.Set("endpoints", values)
+}
+
+// GetHeaders : 附加的请求头，如多租户场景下的 X-Scope-OrgID
+func (c *RemoteWriteMetaClusterInfo) GetHeaders() map[string]string {
+	headers := make(map[string]string)
+	value, ok := c.StorageConfigHelper.Get("headers")
+	if !ok {
+		return headers
+	}
+
+	switch values := value.(type) {
+	case map[string]interface{}:
+		for key, val := range values {
+			headers[key] = conv.String(val)
+		}
+	case map[string]string:
+		for key, val := range values {
+			headers[key] = val
+		}
+	}
+	return headers
+}
+
+// GetShards : 按序列哈希切分的分片数
+func (c *RemoteWriteMetaClusterInfo) GetShards() int {
+	shards, ok := c.StorageConfigHelper.GetInt("shards")
+	if !ok || shards <= 0 {
+		return 1
+	}
+	return shards
+}
+
+// GetMaxRetries :
+func (c *RemoteWriteMetaClusterInfo) GetMaxRetries() int {
+	retries, ok := c.StorageConfigHelper.GetInt("max_retries")
+	if !ok || retries < 0 {
+		return 3
+	}
+	return retries
+}
+
+// GetMinBackoff :
+func (c *RemoteWriteMetaClusterInfo) GetMinBackoff() time.Duration {
+	return c.getDuration("min_backoff", 30*time.Millisecond)
+}
+
+// GetMaxBackoff :
+func (c *RemoteWriteMetaClusterInfo) GetMaxBackoff() time.Duration {
+	return c.getDuration("max_backoff", 5*time.Second)
+}
+
+// GetTimeout :
+func (c *RemoteWriteMetaClusterInfo) GetTimeout() time.Duration {
+	return c.getDuration("timeout", 30*time.Second)
+}
+
+// GetMetricPrefix : 指标名前缀
+func (c *RemoteWriteMetaClusterInfo) GetMetricPrefix() string {
+	prefix, ok := c.StorageConfigHelper.GetString("metric_prefix")
+	if !ok {
+		return ""
+	}
+	return prefix
+}
+
+// GetTarget :
+func (c *RemoteWriteMetaClusterInfo) GetTarget() string {
+	return strings.Join(c.GetEndpoints(), ",")
+}
+
+// AsRemoteWriteCluster :
+func (c *MetaClusterInfo) AsRemoteWriteCluster() *RemoteWriteMetaClusterInfo {
+	return &RemoteWriteMetaClusterInfo{
+		SimpleMetaClusterInfo: NewSimpleMetaClusterInfo(c),
+	}
+}
@@ -23,6 +23,8 @@ const (
 	ContextETLPluginKey
 	ContextStartCacheKey
 	ContextRuntimeKey
+	ContextDeadLetterKey
+	ContextReplayKey
 )
 
 //go:generate stringer -type=ContextKey -trimprefix Context
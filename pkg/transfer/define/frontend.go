@@ -28,6 +28,11 @@ func NewBaseFrontendWithPayloadCreator(name string, fn PayloadCreatorFunc) *Base
 	}
 }
 
+// SetPayloadCreator :
+func (f *BaseFrontend) SetPayloadCreator(fn PayloadCreatorFunc) {
+	f.PayloadCreator = fn
+}
+
 // String : return frontend name
 func (f *BaseFrontend) String() string {
 	return f.Name
@@ -64,6 +64,11 @@ type Payload interface {
 	Flag() PayloadFlag
 }
 
+// PayloadCreatorSetter : 支持替换 payload 格式的前端
+type PayloadCreatorSetter interface {
+	SetPayloadCreator(fn PayloadCreatorFunc)
+}
+
 type payloadCopier interface {
 	copy() Payload
 }
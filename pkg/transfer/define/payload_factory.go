@@ -12,6 +12,8 @@ package define
 import (
 	"errors"
 	"fmt"
+	"math/rand"
+	"time"
 )
 
 // PayloadCreator : function to create Payload
@@ -37,6 +39,21 @@ var NewPayload = func(name string, sn int) (Payload, error) {
 	return fn(name, sn)
 }
 
+// NewPayloadCreator : 按名称生成 payload 构造函数，供前端替换默认格式
+func NewPayloadCreator(name string) (PayloadCreatorFunc, error) {
+	if _, ok := mapPayload[name]; !ok {
+		return nil, fmt.Errorf("unknown payload %s", name)
+	}
+	return func() Payload {
+		payload, err := NewPayload(name, rand.Int())
+		if err != nil {
+			panic(err)
+		}
+		payload.SetTime(time.Now())
+		return payload
+	}, nil
+}
+
 func init() {
 	RegisterPlugin(&PluginInfo{
 		Name: "Payload",
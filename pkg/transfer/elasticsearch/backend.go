@@ -40,6 +40,8 @@ type BulkHandler struct {
 	writer        BulkWriter
 	indexRender   IndexRenderFn
 	transformers  map[string]etl.TransformFn
+	deadLetter    *pipeline.DeadLetterReporter
+	dataStream    bool
 }
 
 func (b *BulkHandler) makeRecordID(values map[string]interface{}) string {
@@ -65,6 +67,12 @@ func (b *BulkHandler) asRecord(etlRecord *define.ETLRecord) (*Record, error) {
 	if etlRecord.Time != nil {
 		values[define.TimeFieldName] = utils.ParseTimeStamp(*etlRecord.Time)
 	}
+	// data stream 要求文档必须带有 @timestamp
+	if b.dataStream {
+		if _, ok := values[DataStreamTimestampField]; !ok {
+			values[DataStreamTimestampField] = values[define.TimeFieldName]
+		}
+	}
 
 	for name, transformer := range b.transformers {
 		value, ok := values[name]
@@ -96,6 +104,7 @@ func (b *BulkHandler) Handle(ctx context.Context, payload define.Payload, killCh
 		err := payload.To(&etlRecord)
 		if err != nil {
 			logging.Warnf("%v error %v dropped payload %+v", b, err, payload)
+			b.deadLetter.Report(payload, err)
 			return nil, time.Time{}, false
 		}
 	}
@@ -103,7 +112,8 @@ func (b *BulkHandler) Handle(ctx context.Context, payload define.Payload, killCh
 	return &etlRecord, utils.ParseTimeStamp(*etlRecord.Time), true
 }
 
-func (b *BulkHandler) flush(ctx context.Context, index string, records Records) (count int, err error) {
+// flush : sources 为 records 对应的原始数据，与 records 顺序一致
+func (b *BulkHandler) flush(ctx context.Context, index string, records Records, sources []*define.ETLRecord) (count int, err error) {
 	logging.Debugf("backend %v flush %d records", b, len(records))
 
 	errs := utils.NewMultiErrors()
@@ -146,11 +156,18 @@ func (b *BulkHandler) flush(ctx context.Context, index string, records Records)
 		if writeResult.Errors {
 			var total int
 			var resultErrors []*ESWriteResultError
-			for _, item := range writeResult.Items {
+			for i, item := range writeResult.Items {
 				index := item.Index
+				if item.Create != nil {
+					index = *item.Create
+				}
 				if index.Error != nil {
 					total++
 					resultErrors = append(resultErrors, index.Error)
+					// items 与 records 顺序一致，上报原始数据以便重放
+					if i < len(sources) {
+						b.deadLetter.ReportData(sources[i], errors.Errorf("%s: %s", index.Error.Type, index.Error.Reason))
+					}
 				}
 			}
 			if len(resultErrors) > 0 {
@@ -175,6 +192,7 @@ func (b *BulkHandler) Flush(ctx context.Context, results []interface{}) (count i
 	lastIndex := ""
 	errs := utils.NewMultiErrors()
 	records := make(Records, 0, len(results))
+	sources := make([]*define.ETLRecord, 0, len(results))
 	for _, value := range results {
 		payload := value.(*define.ETLRecord)
 		record, err := b.asRecord(payload)
@@ -195,17 +213,19 @@ func (b *BulkHandler) Flush(ctx context.Context, results []interface{}) (count i
 
 		// 处理跨时间间隔
 		if index != lastIndex && lastIndex != "" {
-			cnt, err := b.flush(ctx, lastIndex, records)
+			cnt, err := b.flush(ctx, lastIndex, records, sources)
 			records = records[:0]
+			sources = sources[:0]
 			count += cnt
 			errs.Add(err)
 		}
 		lastIndex = index
 		records = append(records, record)
+		sources = append(sources, payload)
 	}
 
 	if len(records) > 0 {
-		cnt, err := b.flush(ctx, lastIndex, records)
+		cnt, err := b.flush(ctx, lastIndex, records, sources)
 		count += cnt
 		errs.Add(err)
 	}
@@ -213,6 +233,18 @@ func (b *BulkHandler) Flush(ctx context.Context, results []interface{}) (count i
 	return count, errs.AsError()
 }
 
+// SetDeadLetter :
+func (b *BulkHandler) SetDeadLetter(reporter *pipeline.DeadLetterReporter) {
+	b.deadLetter = reporter
+}
+
+// FlushFailed : 整批写入失败时上报原始数据
+func (b *BulkHandler) FlushFailed(results []interface{}, err error) {
+	for _, value := range results {
+		b.deadLetter.ReportData(value.(*define.ETLRecord), err)
+	}
+}
+
 // Close :
 func (b *BulkHandler) Close() error {
 	return b.writer.Close()
@@ -226,14 +258,26 @@ func NewBulkHandler(cluster *config.ElasticSearchMetaClusterInfo, table *config.
 	}
 
 	name := fmt.Sprintf("v%d", ver.Segments()[0])
+	if cluster.GetDistribution() == config.ElasticSearchDistributionOpenSearch {
+		name = config.ElasticSearchDistributionOpenSearch
+	}
 	logging.Infof("create elasticsearch writer %s by version %s", name, ver.String())
 
+	opType := OpTypeIndex
+	if cluster.IsDataStream() {
+		opType = OpTypeCreate
+	}
+
 	authConf := utils.NewMapHelper(cluster.AuthInfo)
+	clusterConf := utils.NewMapHelper(cluster.ClusterConfig)
 	writer, err := NewBulkWriter(name, map[string]interface{}{
-		"Addresses": []string{cluster.GetAddress()},
-		"Username":  authConf.GetOrDefault("username", ""),
-		"Password":  authConf.GetOrDefault("password", ""),
-		"Transport": DefaultTransport,
+		"Addresses":               []string{cluster.GetAddress()},
+		"Username":                authConf.GetOrDefault("username", ""),
+		"Password":                authConf.GetOrDefault("password", ""),
+		"APIKey":                  authConf.GetOrDefault("api_key", ""),
+		"EnableCompatibilityMode": clusterConf.GetOrDefault("compatibility_mode", true),
+		"OpType":                  opType,
+		"Transport":               DefaultTransport,
 	})
 	if err != nil {
 		return nil, err
@@ -263,6 +307,7 @@ func NewBulkHandler(cluster *config.ElasticSearchMetaClusterInfo, table *config.
 		uniqueField:   uniqueFields,
 		indexRender:   indexRender,
 		transformers:  transformers,
+		dataStream:    cluster.IsDataStream(),
 	}
 	return handler, nil
 }
@@ -290,15 +335,25 @@ func NewBackend(ctx context.Context, name string, maxQps int) (define.Backend, e
 	clusterConf := utils.NewMapHelper(cluster.ClusterConfig)
 	clusterConf.SetDefault("version", conf.GetString(ConfKeyDefaultVersion))
 
-	fn, err := ConfigTemplateRender(cluster)
-	if err != nil {
-		return nil, err
+	// data stream 由服务端按时间滚动，直接写入 base_index
+	var (
+		fn  IndexRenderFn
+		err error
+	)
+	if cluster.IsDataStream() {
+		fn = FixedIndexRender(cluster.GetIndex())
+	} else {
+		fn, err = ConfigTemplateRender(cluster)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	bulk, err := NewBulkHandler(cluster, resultTable, flushInterval, uniqueFields, fn)
 	if err != nil {
 		return nil, err
 	}
+	bulk.SetDeadLetter(pipeline.NewDeadLetterReporter(ctx, pipeline.DeadLetterStageBackend, name))
 
 	return pipeline.NewBulkBackendDefaultAdapter(ctx, name, bulk, maxQps), nil
 }
@@ -24,6 +24,7 @@ import (
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/elasticsearch"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/pipeline"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/testsuite"
 )
 
@@ -72,6 +73,85 @@ func (s *BulkHandlerSuite) TestNew() {
 	s.NotNil(handler)
 }
 
+// TestNewOpenSearch
+func (s *BulkHandlerSuite) TestNewOpenSearch() {
+	cluster := s.ShipperConfig.AsElasticSearchCluster()
+	cluster.StorageConfigHelper.Set("distribution", config.ElasticSearchDistributionOpenSearch)
+	defer delete(s.ShipperConfig.StorageConfig, "distribution")
+	cluster.SetVersion("2.11.0")
+
+	elasticsearch.NewBulkWriter = func(version string, config map[string]interface{}) (writer elasticsearch.BulkWriter, e error) {
+		s.Equal(version, "opensearch")
+		s.Equal(elasticsearch.OpTypeIndex, config["OpType"])
+		return s.mockBulkWriter, nil
+	}
+	handler, err := elasticsearch.NewBulkHandler(cluster, s.ResultTableConfig, time.Second, nil, s.indexRender)
+	s.NoError(err)
+	s.NotNil(handler)
+}
+
+// TestDataStream
+func (s *BulkHandlerSuite) TestDataStream() {
+	cluster := s.ShipperConfig.AsElasticSearchCluster()
+	cluster.StorageConfigHelper.Set("data_stream", true)
+	defer delete(s.ShipperConfig.StorageConfig, "data_stream")
+	cluster.SetVersion("8.11.0")
+
+	elasticsearch.NewBulkWriter = func(version string, config map[string]interface{}) (writer elasticsearch.BulkWriter, e error) {
+		s.Equal(version, "v8")
+		s.Equal(elasticsearch.OpTypeCreate, config["OpType"])
+		return s.mockBulkWriter, nil
+	}
+	handler, err := elasticsearch.NewBulkHandler(cluster, s.ResultTableConfig, time.Second, nil, s.indexRender)
+	s.NoError(err)
+
+	s.mockBulkWriter.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, index string, records elasticsearch.Records) (*elasticsearch.Response, error) {
+		s.Len(records, 1)
+		s.Contains(records[0].Document, elasticsearch.DataStreamTimestampField)
+
+		body, err := records.AsBodyWithOpType(elasticsearch.OpTypeCreate)
+		s.NoError(err)
+		var action map[string]interface{}
+		s.NoError(json.NewDecoder(body).Decode(&action))
+		s.Contains(action, elasticsearch.OpTypeCreate)
+
+		esResponse := map[string]interface{}{
+			"took":   1,
+			"errors": true,
+			"items": []map[string]interface{}{{
+				"create": map[string]interface{}{
+					"_index": ".ds-test-2024.01.01-000001",
+					"status": 409,
+					"error": map[string]interface{}{
+						"type":   "version_conflict_engine_exception",
+						"reason": "document already exists",
+					},
+				},
+			}},
+		}
+		data, err := json.Marshal(esResponse)
+		s.NoError(err)
+		return &elasticsearch.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBuffer(data)),
+		}, nil
+	})
+
+	ts := time.Now().Unix()
+	payload := define.NewJSONPayload(0)
+	s.NoError(payload.From(&define.ETLRecord{
+		Time:    &ts,
+		Metrics: map[string]interface{}{"value": 1},
+	}))
+
+	result, _, ok := handler.Handle(s.CTX, payload, s.KillCh)
+	s.True(ok)
+
+	cnt, err := handler.Flush(s.CTX, []interface{}{result})
+	s.NoError(err)
+	s.Equal(0, cnt)
+}
+
 // TestFormatTime
 func (s *BulkHandlerSuite) TestFormatTime() {
 	s.ResultTableConfig.FieldList = append(
@@ -153,6 +233,104 @@ func (s *BulkHandlerSuite) TestFormatTime() {
 	s.Equal(1, cnt)
 }
 
+// TestDeadLetter
+func (s *BulkHandlerSuite) TestDeadLetter() {
+	s.Stubs.Stub(&s.PipelineConfig.Option, map[string]interface{}{
+		config.PipelineConfigOptDeadLetter: map[string]interface{}{
+			"cluster_type": "file",
+		},
+	})
+
+	received := make(chan define.Payload, 2)
+	backend := testsuite.NewMockBackend(s.Ctrl)
+	backend.EXPECT().String().Return("file").AnyTimes()
+	backend.EXPECT().Push(gomock.Any(), gomock.Any()).DoAndReturn(func(d define.Payload, killChan chan<- error) {
+		received <- d
+	}).Times(2)
+	backend.EXPECT().Close().Return(nil)
+	s.Stubs.Stub(&define.NewBackend, func(ctx context.Context, name string) (define.Backend, error) {
+		return backend, nil
+	})
+
+	sink, err := pipeline.NewDeadLetterSink(s.CTX)
+	s.NoError(err)
+	ctx := pipeline.DeadLetterSinkIntoContext(s.CTX, sink)
+	sink.Start(s.KillCh)
+
+	cluster := s.ShipperConfig.AsElasticSearchCluster()
+	handler, err := elasticsearch.NewBulkHandler(cluster, s.ResultTableConfig, time.Second, nil, s.indexRender)
+	s.NoError(err)
+	handler.SetDeadLetter(pipeline.NewDeadLetterReporter(ctx, pipeline.DeadLetterStageBackend, "es"))
+
+	s.mockBulkWriter.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, index string, records elasticsearch.Records) (*elasticsearch.Response, error) {
+		esResponse := map[string]interface{}{
+			"took":   1,
+			"errors": true,
+			"items": []map[string]interface{}{
+				{"index": map[string]interface{}{"status": 201}},
+				{"index": map[string]interface{}{
+					"status": 400,
+					"error": map[string]interface{}{
+						"type":   "mapper_parsing_exception",
+						"reason": "failed to parse",
+					},
+				}},
+			},
+		}
+		data, err := json.Marshal(esResponse)
+		s.NoError(err)
+		return &elasticsearch.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBuffer(data)),
+		}, nil
+	})
+
+	ts := time.Now().Unix()
+	results := make([]interface{}, 0, 2)
+	for _, value := range []string{"ok", "bad"} {
+		payload := define.NewJSONPayload(0)
+		s.NoError(payload.From(&define.ETLRecord{
+			Time:    &ts,
+			Metrics: map[string]interface{}{"value": value},
+		}))
+		result, _, ok := handler.Handle(s.CTX, payload, s.KillCh)
+		s.True(ok)
+		results = append(results, result)
+	}
+
+	receive := func() *define.ETLRecord {
+		select {
+		case payload := <-received:
+			var letter pipeline.DeadLetter
+			s.NoError(payload.To(&letter))
+			s.Equal(pipeline.DeadLetterStageBackend, letter.Stage)
+			s.NotEmpty(letter.Error)
+
+			data, err := letter.GetPayload()
+			s.NoError(err)
+			var record define.ETLRecord
+			s.NoError(json.Unmarshal(data, &record))
+			return &record
+		case <-time.After(time.Second):
+			s.Fail("dead letter not received")
+			return nil
+		}
+	}
+
+	// 单条写入失败时上报该条原始数据
+	cnt, err := handler.Flush(s.CTX, results)
+	s.NoError(err)
+	s.Equal(1, cnt)
+	s.Equal("bad", receive().Metrics["value"])
+
+	// 整批写入失败时上报全部原始数据
+	handler.FlushFailed(results[:1], define.ErrOperationForbidden)
+	s.Equal("ok", receive().Metrics["value"])
+
+	s.NoError(sink.Stop())
+	s.NoError(sink.Wait())
+}
+
 // TestBulkHandlerSuite
 func TestBulkHandlerSuite(t *testing.T) {
 	suite.Run(t, new(BulkHandlerSuite))
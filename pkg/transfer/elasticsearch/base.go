@@ -17,10 +17,18 @@ import (
 // ESWriter :
 type ESWriter struct {
 	transport Transport
+	opType    string
 }
 
 func (w *ESWriter) getBodyByRecords(records Records) (*bytes.Buffer, error) {
-	return records.AsBody()
+	return records.AsBodyWithOpType(w.opType)
+}
+
+// SetOpType :
+func (w *ESWriter) SetOpType(opType string) {
+	if opType != "" {
+		w.opType = opType
+	}
 }
 
 // Close :
@@ -32,6 +40,7 @@ func (w *ESWriter) Close() error {
 func NewESWriter(transport Transport) *ESWriter {
 	return &ESWriter{
 		transport: transport,
+		opType:    OpTypeIndex,
 	}
 }
 
@@ -19,6 +19,15 @@ import (
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
 )
 
+// bulk 操作类型，data stream 只允许 create
+const (
+	OpTypeIndex  = "index"
+	OpTypeCreate = "create"
+)
+
+// DataStreamTimestampField : data stream 必需的时间字段
+const DataStreamTimestampField = "@timestamp"
+
 // IndexRenderFn :
 type IndexRenderFn func(record *Record) (string, error)
 
@@ -79,17 +88,21 @@ type ESWriteResultError struct {
 	} `json:"caused_by"`
 }
 
+// ESWriteResultItem
+type ESWriteResultItem struct {
+	Index  string              `json:"_index"`
+	Type   string              `json:"_type"`
+	ID     string              `json:"_id"`
+	Status int                 `json:"status"`
+	Error  *ESWriteResultError `json:"error"`
+}
+
 // ESWriteResult
 type ESWriteResult struct {
 	Took   int  `json:"took"`
 	Errors bool `json:"errors"`
 	Items  []struct {
-		Index struct {
-			Index  string              `json:"_index"`
-			Type   string              `json:"_type"`
-			ID     string              `json:"_id"`
-			Status int                 `json:"status"`
-			Error  *ESWriteResultError `json:"error"`
-		} `json:"index"`
+		Index  ESWriteResultItem  `json:"index"`
+		Create *ESWriteResultItem `json:"create"`
 	} `json:"items"`
 }
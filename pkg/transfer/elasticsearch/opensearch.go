@@ -0,0 +1,93 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+//go:build opensearch
+// +build opensearch
+
+package elasticsearch
+
+import (
+	"context"
+
+	"github.com/opensearch-project/opensearch-go/v2"
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/bufferpool"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/utils"
+)
+
+// OpenSearchWriter : OpenSearch 1.x/2.x 均使用无 type 的 bulk 接口
+type OpenSearchWriter struct {
+	*ESWriter
+	request opensearchapi.BulkRequest
+}
+
+// NewOpenSearchWriter
+func NewOpenSearchWriter(conf map[string]interface{}) (BulkWriter, error) {
+	var request opensearchapi.BulkRequest
+	err := ApplyFields(&request, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	var c opensearch.Config
+	err = ApplyFields(&c, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := opensearch.NewClient(c)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := NewESWriter(client.Transport)
+	opType, _ := utils.NewMapHelper(conf).GetString("OpType")
+	writer.SetOpType(opType)
+
+	return &OpenSearchWriter{
+		request:  request,
+		ESWriter: writer,
+	}, nil
+}
+
+// Write
+func (w *OpenSearchWriter) Write(ctx context.Context, index string, records Records) (*Response, error) {
+	for index, record := range records {
+		if _, ok := record.Meta["_type"]; ok {
+			delete(records[index].Meta, "_type")
+		}
+	}
+
+	body, err := w.getBodyByRecords(records)
+	if err != nil {
+		return nil, err
+	}
+	defer bufferpool.Put(body)
+
+	request := w.request
+	request.Body = body
+	request.Index = index
+
+	response, err := request.Do(ctx, w.transport)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		StatusCode: response.StatusCode,
+		Header:     response.Header,
+		Body:       response.Body,
+	}, nil
+}
+
+func init() {
+	RegisterBulkWriter(config.ElasticSearchDistributionOpenSearch, NewOpenSearchWriter)
+}
@@ -20,11 +20,16 @@ type Records []*Record
 
 // ASBody
 func (r Records) AsBody() (*bytes.Buffer, error) {
+	return r.AsBodyWithOpType(OpTypeIndex)
+}
+
+// AsBodyWithOpType : 按指定的操作类型生成 bulk 请求体
+func (r Records) AsBodyWithOpType(opType string) (*bytes.Buffer, error) {
 	buffer := bufferpool.Get()
 	encoder := json.NewEncoder(buffer)
 	for _, record := range r {
 		err := encoder.Encode(map[string]interface{}{
-			"index": record.Meta,
+			opType: record.Meta,
 		})
 		if err != nil {
 			return nil, err
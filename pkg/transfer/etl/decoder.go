@@ -30,7 +30,8 @@ func NewPayloadDecoder() *PayloadDecoder {
 
 func (d *PayloadDecoder) decode(payload define.Payload) (Container, error) {
 	container := NewMapContainer()
-	err := payload.To(&container)
+	// 以原生 map 类型解析，二进制格式的 payload 可直接赋值而无需经过 json 转换
+	err := payload.To((*map[string]interface{})(&container))
 	return container, err
 }
 
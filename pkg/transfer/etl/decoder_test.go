@@ -12,10 +12,13 @@ package etl_test
 import (
 	"testing"
 
+	"github.com/golang/snappy"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/etl"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/payload"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/prompb"
 )
 
 // PayloadDecoderSuite
@@ -59,6 +62,43 @@ func (s *PayloadDecoderSuite) TestFissionSplitHandler() {
 	}
 }
 
+// TestDecodeRemoteWrite
+func (s *PayloadDecoderSuite) TestDecodeRemoteWrite() {
+	req := prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{{
+			Labels: []prompb.Label{
+				{Name: "__name__", Value: "usage"},
+				{Name: "host", Value: "a"},
+			},
+			Samples: []prompb.Sample{
+				{Value: 1, Timestamp: 1000},
+				{Value: 2, Timestamp: 2000},
+			},
+		}},
+	}
+	data, err := req.Marshal()
+	s.NoError(err)
+
+	pl, err := define.NewPayload(payload.RemoteWritePayloadType, 0)
+	s.NoError(err)
+	s.NoError(pl.From(snappy.Encode(nil, data)))
+
+	s.decoder.FissionSplitHandler(true, etl.ExtractByJMESPath("data"), "$index", "$value")
+	containers, err := s.decoder.Decode(pl)
+	s.NoError(err)
+	s.Len(containers, 2)
+
+	for i, container := range containers {
+		value, err := container.Get("$value")
+		s.NoError(err)
+		s.Equal(etl.MapContainer{
+			"metrics":   map[string]interface{}{"usage": float64(i + 1)},
+			"dimension": map[string]interface{}{"host": "a"},
+			"timestamp": float64((i + 1) * 1000),
+		}, value)
+	}
+}
+
 // TestFissionMergeHandler
 func (s *PayloadDecoderSuite) TestFissionMergeHandler() {
 	payload := define.NewJSONPayloadFrom([]byte(`{"name":"test","values":[{"value":1},{"value":2}]}`), 0)
@@ -77,6 +77,7 @@ type BulkHandler struct {
 	disabledDimensions    []string
 	mustIncludeDimensions []string
 	isSplitMeasurement    bool
+	deadLetter            *pipeline.DeadLetterReporter
 }
 
 func (b *BulkHandler) cleanRecord(record *Record) bool {
@@ -98,6 +99,7 @@ func (b *BulkHandler) Handle(ctx context.Context, payload define.Payload, killCh
 	err := payload.To(&record)
 	if err != nil {
 		logging.Warnf("%v error %v dropped payload %+v", b, err, payload)
+		b.deadLetter.Report(payload, err)
 		return nil, time.Time{}, false
 	}
 
@@ -137,6 +139,7 @@ func (b *BulkHandler) Handle(ctx context.Context, payload define.Payload, killCh
 			point, err := client.NewPoint(metricName, record.GetDimensions(), metrics, ts)
 			if err != nil {
 				logging.Warnf("%v skipping influx data point %#v with error %v", b, record, err)
+				b.deadLetter.Report(payload, err)
 				return nil, time.Time{}, false
 			}
 			pointList = append(pointList, point)
@@ -151,6 +154,7 @@ func (b *BulkHandler) Handle(ctx context.Context, payload define.Payload, killCh
 		)
 		if err != nil {
 			logging.Warnf("%v skipping influx data point %#v with error %v", b, record, err)
+			b.deadLetter.Report(payload, err)
 			return nil, time.Time{}, false
 		}
 
@@ -282,6 +286,7 @@ func NewBackend(ctx context.Context, name string, maxQps int) (*Backend, error)
 	if err != nil {
 		return nil, err
 	}
+	bulk.deadLetter = pipeline.NewDeadLetterReporter(ctx, pipeline.DeadLetterStageBackend, name)
 
 	return &Backend{
 		BulkBackendAdapter: pipeline.NewBulkBackendDefaultAdapter(ctx, name, bulk, maxQps),
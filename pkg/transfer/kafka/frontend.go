@@ -386,29 +386,16 @@ func (x *XDGSCRAMClient) Step(challenge string) (response string, err error) {
 	return
 }
 
-func (f *Frontend) init() error {
-	var (
-		conf        = config.FromContext(f.ctx)
-		kafkaConfig = config.MQConfigFromContext(f.ctx).AsKafkaCluster()
-		err         error
-		groupPrefix = conf.GetString(ConfKafkaConsumerGroupPrefix)
-	)
-
-	pipeConfig := config.PipelineConfigFromContext(f.ctx)
-	dataID := strconv.Itoa(pipeConfig.DataID)
-
-	// 由于 dataid 归属的 transfer 集群会发生切换
-	// 所以使用时间作为其 values 值，这样查询的时候可以使用 max 语法查询出来
-	define.MonitorFrontendKafka.WithLabelValues(dataID, define.ConfClusterID, kafkaConfig.GetDomain(), kafkaConfig.GetTopic()).Set(float64(time.Now().UnixMilli()))
-
-	pipelineConfig := config.PipelineConfigFromContext(f.ctx)
+// NewConsumerConfigFromContext : 根据上下文中的集群及 pipeline 配置生成消费者配置（含 SASL/TLS）
+func NewConsumerConfigFromContext(ctx context.Context) (*sarama.Config, error) {
+	conf := config.FromContext(ctx)
+	pipelineConfig := config.PipelineConfigFromContext(ctx)
 	c, err := NewKafkaConsumerConfig(conf, pipelineConfig.Option)
 	if err != nil {
-		logging.Errorf("frontend %v make config error %v", f, err)
-		return err
+		return nil, err
 	}
 
-	mqConfig := config.MQConfigFromContext(f.ctx)
+	mqConfig := config.MQConfigFromContext(ctx)
 	auth := config.NewAuthInfo(mqConfig)
 	userName, err := auth.GetUserName()
 	if err != nil {
@@ -420,7 +407,7 @@ func (f *Frontend) init() error {
 	}
 
 	// 能够正确解析出 tls 则配置上
-	tlsConfig, ok := buildTlsConfig(f.ctx)
+	tlsConfig, ok := buildTlsConfig(ctx)
 	if ok {
 		c.Net.TLS.Enable = true
 		c.Net.TLS.Config = tlsConfig
@@ -451,6 +438,30 @@ func (f *Frontend) init() error {
 
 	if err != nil {
 		logging.Warnf("create kafka config error: %v", err)
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (f *Frontend) init() error {
+	var (
+		conf        = config.FromContext(f.ctx)
+		kafkaConfig = config.MQConfigFromContext(f.ctx).AsKafkaCluster()
+		err         error
+		groupPrefix = conf.GetString(ConfKafkaConsumerGroupPrefix)
+	)
+
+	pipeConfig := config.PipelineConfigFromContext(f.ctx)
+	dataID := strconv.Itoa(pipeConfig.DataID)
+
+	// 由于 dataid 归属的 transfer 集群会发生切换
+	// 所以使用时间作为其 values 值，这样查询的时候可以使用 max 语法查询出来
+	define.MonitorFrontendKafka.WithLabelValues(dataID, define.ConfClusterID, kafkaConfig.GetDomain(), kafkaConfig.GetTopic()).Set(float64(time.Now().UnixMilli()))
+
+	c, err := NewConsumerConfigFromContext(f.ctx)
+	if err != nil {
+		logging.Errorf("frontend %v make config error %v", f, err)
 		return err
 	}
 
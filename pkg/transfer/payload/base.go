@@ -0,0 +1,125 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package payload
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/json"
+)
+
+// DecodeFunc : 将二进制数据解析为与 json 解析结果结构一致的文档
+type DecodeFunc func(data []byte) (interface{}, error)
+
+// BinaryPayload : 二进制格式的 payload，派生出的 payload 统一使用 json 格式
+type BinaryPayload struct {
+	*define.JSONPayload
+	name   string
+	decode DecodeFunc
+	isJSON bool
+}
+
+// Type :
+func (p *BinaryPayload) Type() string {
+	return p.name
+}
+
+// To : 解析二进制数据到 v
+func (p *BinaryPayload) To(v interface{}) error {
+	if p.isJSON {
+		return p.JSONPayload.To(v)
+	}
+
+	switch value := v.(type) {
+	case *[]byte:
+		*value = p.Data
+		return nil
+	}
+
+	doc, err := p.decode(p.Data)
+	if err != nil {
+		return errors.Wrapf(err, "decode %s payload", p.name)
+	}
+	return assignDocument(doc, v)
+}
+
+// From : 原始数据直接保存，其他类型按 json 格式保存
+func (p *BinaryPayload) From(v interface{}) error {
+	switch value := v.(type) {
+	case []byte:
+		p.Data = value
+		p.isJSON = false
+		return nil
+	}
+
+	p.isJSON = true
+	return p.JSONPayload.From(v)
+}
+
+// NewBinaryPayload :
+func NewBinaryPayload(name string, sn int, decode DecodeFunc) *BinaryPayload {
+	return &BinaryPayload{
+		JSONPayload: define.NewJSONPayload(sn),
+		name:        name,
+		decode:      decode,
+	}
+}
+
+// assignDocument : 常见的 map 类型直接赋值，其余类型通过 json 转换
+func assignDocument(doc interface{}, v interface{}) error {
+	switch value := v.(type) {
+	case *interface{}:
+		*value = doc
+		return nil
+	case *map[string]interface{}:
+		m, ok := doc.(map[string]interface{})
+		if !ok {
+			return errors.Wrapf(define.ErrType, "document %T is not a map", doc)
+		}
+		*value = m
+		return nil
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// newDocument : 自定义时序格式的文档
+func newDocument(items []interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"data": items,
+	}
+}
+
+// newDocumentItem :
+func newDocumentItem(name string, value float64, dimensions map[string]interface{}, timestamp int64) map[string]interface{} {
+	dims := make(map[string]interface{}, len(dimensions))
+	for key, val := range dimensions {
+		dims[key] = val
+	}
+
+	return map[string]interface{}{
+		"metrics": map[string]interface{}{
+			name: value,
+		},
+		"dimension": dims,
+		"timestamp": float64(timestamp),
+	}
+}
+
+func registerBinaryPayload(name string, decode DecodeFunc) {
+	define.RegisterPayload(name, func(_ string, sn int) (define.Payload, error) {
+		return NewBinaryPayload(name, sn, decode), nil
+	})
+}
@@ -0,0 +1,69 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package payload
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MsgpackPayloadType : msgpack 格式，解析后的结构与 json 一致
+const MsgpackPayloadType = "msgpack"
+
+// normalizeMsgpackValue : 数值统一为 float64，map 的 key 统一为字符串，与 json 解析结果保持一致
+func normalizeMsgpackValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeMsgpackValue(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = normalizeMsgpackValue(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeMsgpackValue(item)
+		}
+		return v
+	case []byte:
+		return string(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	default:
+		return v
+	}
+}
+
+// DecodeMsgpack :
+func DecodeMsgpack(data []byte) (interface{}, error) {
+	decoder := msgpack.NewDecoder(bytes.NewReader(data))
+	decoder.UseLooseInterfaceDecoding(true)
+
+	value, err := decoder.DecodeInterfaceLoose()
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshal msgpack")
+	}
+	return normalizeMsgpackValue(value), nil
+}
+
+func init() {
+	registerBinaryPayload(MsgpackPayloadType, DecodeMsgpack)
+}
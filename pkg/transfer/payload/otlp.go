@@ -0,0 +1,212 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package payload
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/pkg/errors"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	metricsv1 "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/json"
+)
+
+// OTLPMetricsPayloadType : otlp 指标协议，兼容 ExportMetricsServiceRequest
+const OTLPMetricsPayloadType = "otlp_metrics"
+
+type otlpDocumentBuilder struct {
+	items []interface{}
+}
+
+func (b *otlpDocumentBuilder) add(name string, value float64, dimensions map[string]interface{}, timeUnixNano uint64) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return
+	}
+	b.items = append(b.items, newDocumentItem(name, value, dimensions, int64(timeUnixNano/1e6)))
+}
+
+func (b *otlpDocumentBuilder) addNumber(name string, point *metricsv1.NumberDataPoint, dimensions map[string]interface{}) {
+	var value float64
+	switch v := point.GetValue().(type) {
+	case *metricsv1.NumberDataPoint_AsDouble:
+		value = v.AsDouble
+	case *metricsv1.NumberDataPoint_AsInt:
+		value = float64(v.AsInt)
+	default:
+		return
+	}
+	b.add(name, value, mergeAttributes(dimensions, point.GetAttributes()), point.GetTimeUnixNano())
+}
+
+func (b *otlpDocumentBuilder) addHistogram(name string, point *metricsv1.HistogramDataPoint, dimensions map[string]interface{}) {
+	dims := mergeAttributes(dimensions, point.GetAttributes())
+	ts := point.GetTimeUnixNano()
+	if point.Sum != nil {
+		b.add(name+"_sum", point.GetSum(), dims, ts)
+	}
+	b.add(name+"_count", float64(point.GetCount()), dims, ts)
+
+	// bucket 转换为累加值，与 prometheus 保持一致
+	var cumulative uint64
+	counts := point.GetBucketCounts()
+	for i, bound := range point.GetExplicitBounds() {
+		if i < len(counts) {
+			cumulative += counts[i]
+		}
+		b.add(name+"_bucket", float64(cumulative), withLabel(dims, "le", strconv.FormatFloat(bound, 'f', -1, 64)), ts)
+	}
+	b.add(name+"_bucket", float64(point.GetCount()), withLabel(dims, "le", "+Inf"), ts)
+}
+
+func (b *otlpDocumentBuilder) addExponentialHistogram(name string, point *metricsv1.ExponentialHistogramDataPoint, dimensions map[string]interface{}) {
+	dims := mergeAttributes(dimensions, point.GetAttributes())
+	ts := point.GetTimeUnixNano()
+	if point.Sum != nil {
+		b.add(name+"_sum", point.GetSum(), dims, ts)
+	}
+	b.add(name+"_count", float64(point.GetCount()), dims, ts)
+}
+
+func (b *otlpDocumentBuilder) addSummary(name string, point *metricsv1.SummaryDataPoint, dimensions map[string]interface{}) {
+	dims := mergeAttributes(dimensions, point.GetAttributes())
+	ts := point.GetTimeUnixNano()
+	b.add(name+"_sum", point.GetSum(), dims, ts)
+	b.add(name+"_count", float64(point.GetCount()), dims, ts)
+	for _, quantile := range point.GetQuantileValues() {
+		b.add(name, quantile.GetValue(), withLabel(dims, "quantile", strconv.FormatFloat(quantile.GetQuantile(), 'f', -1, 64)), ts)
+	}
+}
+
+func (b *otlpDocumentBuilder) addMetric(metric *metricsv1.Metric, dimensions map[string]interface{}) {
+	name := metric.GetName()
+	switch data := metric.GetData().(type) {
+	case *metricsv1.Metric_Gauge:
+		for _, point := range data.Gauge.GetDataPoints() {
+			b.addNumber(name, point, dimensions)
+		}
+	case *metricsv1.Metric_Sum:
+		for _, point := range data.Sum.GetDataPoints() {
+			b.addNumber(name, point, dimensions)
+		}
+	case *metricsv1.Metric_Histogram:
+		for _, point := range data.Histogram.GetDataPoints() {
+			b.addHistogram(name, point, dimensions)
+		}
+	case *metricsv1.Metric_ExponentialHistogram:
+		for _, point := range data.ExponentialHistogram.GetDataPoints() {
+			b.addExponentialHistogram(name, point, dimensions)
+		}
+	case *metricsv1.Metric_Summary:
+		for _, point := range data.Summary.GetDataPoints() {
+			b.addSummary(name, point, dimensions)
+		}
+	}
+}
+
+func withLabel(dimensions map[string]interface{}, key, value string) map[string]interface{} {
+	dims := make(map[string]interface{}, len(dimensions)+1)
+	for k, v := range dimensions {
+		dims[k] = v
+	}
+	dims[key] = value
+	return dims
+}
+
+func mergeAttributes(dimensions map[string]interface{}, attrs []*commonv1.KeyValue) map[string]interface{} {
+	dims := make(map[string]interface{}, len(dimensions)+len(attrs))
+	for k, v := range dimensions {
+		dims[k] = v
+	}
+	for _, attr := range attrs {
+		dims[attr.GetKey()] = anyValueToString(attr.GetValue())
+	}
+	return dims
+}
+
+func anyValueToNative(value *commonv1.AnyValue) interface{} {
+	switch v := value.GetValue().(type) {
+	case *commonv1.AnyValue_StringValue:
+		return v.StringValue
+	case *commonv1.AnyValue_BoolValue:
+		return v.BoolValue
+	case *commonv1.AnyValue_IntValue:
+		return v.IntValue
+	case *commonv1.AnyValue_DoubleValue:
+		return v.DoubleValue
+	case *commonv1.AnyValue_BytesValue:
+		return fmt.Sprintf("%x", v.BytesValue)
+	case *commonv1.AnyValue_ArrayValue:
+		values := make([]interface{}, 0, len(v.ArrayValue.GetValues()))
+		for _, item := range v.ArrayValue.GetValues() {
+			values = append(values, anyValueToNative(item))
+		}
+		return values
+	case *commonv1.AnyValue_KvlistValue:
+		values := make(map[string]interface{}, len(v.KvlistValue.GetValues()))
+		for _, item := range v.KvlistValue.GetValues() {
+			values[item.GetKey()] = anyValueToNative(item.GetValue())
+		}
+		return values
+	}
+	return nil
+}
+
+// anyValueToString : 维度统一为字符串
+func anyValueToString(value *commonv1.AnyValue) string {
+	switch v := anyValueToNative(value).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// DecodeOTLPMetrics : 按 prometheus 的命名方式展开 otlp 指标
+func DecodeOTLPMetrics(data []byte) (interface{}, error) {
+	var metrics metricsv1.MetricsData
+	err := proto.Unmarshal(data, &metrics)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshal otlp metrics")
+	}
+
+	builder := &otlpDocumentBuilder{
+		items: make([]interface{}, 0),
+	}
+	for _, resourceMetrics := range metrics.GetResourceMetrics() {
+		dimensions := mergeAttributes(nil, resourceMetrics.GetResource().GetAttributes())
+		for _, scopeMetrics := range resourceMetrics.GetScopeMetrics() {
+			for _, metric := range scopeMetrics.GetMetrics() {
+				builder.addMetric(metric, dimensions)
+			}
+		}
+	}
+
+	return newDocument(builder.items), nil
+}
+
+func init() {
+	registerBinaryPayload(OTLPMetricsPayloadType, DecodeOTLPMetrics)
+}
@@ -0,0 +1,254 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package payload_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/suite"
+	"github.com/vmihailenco/msgpack/v5"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	metricsv1 "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/payload"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/prompb"
+)
+
+// PayloadSuite
+type PayloadSuite struct {
+	suite.Suite
+}
+
+func (s *PayloadSuite) newPayload(name string, data []byte) define.Payload {
+	pl, err := define.NewPayload(name, 1)
+	s.NoError(err)
+	s.NoError(pl.From(data))
+	return pl
+}
+
+func (s *PayloadSuite) stringValue(key, value string) *commonv1.KeyValue {
+	return &commonv1.KeyValue{
+		Key:   key,
+		Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: value}},
+	}
+}
+
+func (s *PayloadSuite) writeRequest() []byte {
+	req := prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels: []prompb.Label{
+					{Name: "__name__", Value: "cpu_usage"},
+					{Name: "host", Value: "127.0.0.1"},
+				},
+				Samples: []prompb.Sample{
+					{Value: 0.5, Timestamp: 1600000000000},
+					{Value: math.NaN(), Timestamp: 1600000001000},
+				},
+			},
+			{
+				Labels:  []prompb.Label{{Name: "host", Value: "127.0.0.1"}},
+				Samples: []prompb.Sample{{Value: 1, Timestamp: 1600000000000}},
+			},
+		},
+	}
+	data, err := req.Marshal()
+	s.NoError(err)
+	return data
+}
+
+// TestRemoteWrite
+func (s *PayloadSuite) TestRemoteWrite() {
+	data := s.writeRequest()
+	expected := map[string]interface{}{
+		"data": []interface{}{
+			map[string]interface{}{
+				"metrics":   map[string]interface{}{"cpu_usage": 0.5},
+				"dimension": map[string]interface{}{"host": "127.0.0.1"},
+				"timestamp": float64(1600000000000),
+			},
+		},
+	}
+
+	for _, raw := range [][]byte{data, snappy.Encode(nil, data)} {
+		pl := s.newPayload(payload.RemoteWritePayloadType, raw)
+		s.Equal(payload.RemoteWritePayloadType, pl.Type())
+
+		var doc map[string]interface{}
+		s.NoError(pl.To(&doc))
+		s.Equal(expected, doc)
+	}
+
+	pl := s.newPayload(payload.RemoteWritePayloadType, []byte("invalid"))
+	var doc map[string]interface{}
+	s.Error(pl.To(&doc))
+}
+
+// TestRemoteWriteToStruct
+func (s *PayloadSuite) TestRemoteWriteToStruct() {
+	pl := s.newPayload(payload.RemoteWritePayloadType, snappy.Encode(nil, s.writeRequest()))
+
+	var records struct {
+		Data []struct {
+			Metrics   map[string]float64 `json:"metrics"`
+			Dimension map[string]string  `json:"dimension"`
+			Timestamp *int64             `json:"timestamp"`
+		} `json:"data"`
+	}
+	s.NoError(pl.To(&records))
+	s.Len(records.Data, 1)
+	s.Equal(0.5, records.Data[0].Metrics["cpu_usage"])
+	s.Equal("127.0.0.1", records.Data[0].Dimension["host"])
+	s.Equal(int64(1600000000000), *records.Data[0].Timestamp)
+
+	var raw []byte
+	s.NoError(pl.To(&raw))
+	s.Equal(snappy.Encode(nil, s.writeRequest()), raw)
+}
+
+// TestOTLPMetrics
+func (s *PayloadSuite) TestOTLPMetrics() {
+	ts := uint64(1600000000000 * 1e6)
+	sum := 6.0
+	metrics := &metricsv1.MetricsData{
+		ResourceMetrics: []*metricsv1.ResourceMetrics{{
+			Resource: &resourcev1.Resource{
+				Attributes: []*commonv1.KeyValue{s.stringValue("service.name", "api")},
+			},
+			ScopeMetrics: []*metricsv1.ScopeMetrics{{
+				Metrics: []*metricsv1.Metric{
+					{
+						Name: "requests",
+						Data: &metricsv1.Metric_Sum{Sum: &metricsv1.Sum{
+							DataPoints: []*metricsv1.NumberDataPoint{{
+								Attributes:   []*commonv1.KeyValue{s.stringValue("code", "200")},
+								TimeUnixNano: ts,
+								Value:        &metricsv1.NumberDataPoint_AsInt{AsInt: 3},
+							}},
+						}},
+					},
+					{
+						Name: "latency",
+						Data: &metricsv1.Metric_Histogram{Histogram: &metricsv1.Histogram{
+							DataPoints: []*metricsv1.HistogramDataPoint{{
+								TimeUnixNano:   ts,
+								Count:          3,
+								Sum:            &sum,
+								BucketCounts:   []uint64{1, 1, 1},
+								ExplicitBounds: []float64{1, 2.5},
+							}},
+						}},
+					},
+				},
+			}},
+		}},
+	}
+	data, err := proto.Marshal(metrics)
+	s.NoError(err)
+
+	pl := s.newPayload(payload.OTLPMetricsPayloadType, data)
+	var doc map[string]interface{}
+	s.NoError(pl.To(&doc))
+
+	items := doc["data"].([]interface{})
+	s.Len(items, 6)
+
+	type series struct {
+		value float64
+		le    interface{}
+	}
+	results := make(map[string][]series)
+	for _, value := range items {
+		item := value.(map[string]interface{})
+		s.Equal(float64(1600000000000), item["timestamp"])
+		dimension := item["dimension"].(map[string]interface{})
+		s.Equal("api", dimension["service.name"])
+		for name, v := range item["metrics"].(map[string]interface{}) {
+			results[name] = append(results[name], series{value: v.(float64), le: dimension["le"]})
+		}
+	}
+
+	s.Equal([]series{{value: 3}}, results["requests"])
+	s.Equal([]series{{value: 6}}, results["latency_sum"])
+	s.Equal([]series{{value: 3}}, results["latency_count"])
+	s.Equal([]series{{value: 1, le: "1"}, {value: 2, le: "2.5"}, {value: 3, le: "+Inf"}}, results["latency_bucket"])
+}
+
+// TestMsgpack
+func (s *PayloadSuite) TestMsgpack() {
+	data, err := msgpack.Marshal(map[string]interface{}{
+		"data": []interface{}{
+			map[string]interface{}{
+				"metrics":   map[string]interface{}{"usage": 1},
+				"timestamp": int64(1600000000000),
+			},
+		},
+		"target": "127.0.0.1",
+	})
+	s.NoError(err)
+
+	pl := s.newPayload(payload.MsgpackPayloadType, data)
+	var doc map[string]interface{}
+	s.NoError(pl.To(&doc))
+	s.Equal(map[string]interface{}{
+		"data": []interface{}{
+			map[string]interface{}{
+				"metrics":   map[string]interface{}{"usage": float64(1)},
+				"timestamp": float64(1600000000000),
+			},
+		},
+		"target": "127.0.0.1",
+	}, doc)
+}
+
+// TestDerivePayload
+func (s *PayloadSuite) TestDerivePayload() {
+	pl := s.newPayload(payload.RemoteWritePayloadType, s.writeRequest())
+	pl.Meta().Store("key", "value")
+
+	record := &define.ETLRecord{
+		Metrics: map[string]interface{}{"usage": 1.0},
+	}
+	derived, err := define.DerivePayload(pl, record)
+	s.NoError(err)
+	s.Equal("json", derived.Type())
+
+	value, ok := derived.Meta().Load("key")
+	s.True(ok)
+	s.Equal("value", value)
+
+	var result define.ETLRecord
+	s.NoError(derived.To(&result))
+	s.Equal(record.Metrics, result.Metrics)
+
+	s.NoError(pl.From(record))
+	s.NoError(pl.To(&result))
+	s.Equal(record.Metrics, result.Metrics)
+}
+
+// TestNewPayloadCreator
+func (s *PayloadSuite) TestNewPayloadCreator() {
+	creator, err := define.NewPayloadCreator(payload.MsgpackPayloadType)
+	s.NoError(err)
+	s.Equal(payload.MsgpackPayloadType, creator().Type())
+
+	_, err = define.NewPayloadCreator("unknown")
+	s.Error(err)
+}
+
+// TestPayloadSuite
+func TestPayloadSuite(t *testing.T) {
+	suite.Run(t, new(PayloadSuite))
+}
@@ -0,0 +1,77 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package payload
+
+import (
+	"math"
+
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/prompb"
+)
+
+// RemoteWritePayloadType : prometheus remote write 协议，支持 snappy 压缩及未压缩数据
+const RemoteWritePayloadType = "remote_write"
+
+const metricNameLabel = "__name__"
+
+func unmarshalWriteRequest(data []byte) (*prompb.WriteRequest, error) {
+	var req prompb.WriteRequest
+	buf, err := snappy.Decode(nil, data)
+	if err == nil && req.Unmarshal(buf) == nil {
+		return &req, nil
+	}
+
+	req = prompb.WriteRequest{}
+	err = req.Unmarshal(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshal write request")
+	}
+	return &req, nil
+}
+
+// DecodeRemoteWrite : 每个采样点转换为一条时序数据
+func DecodeRemoteWrite(data []byte) (interface{}, error) {
+	req, err := unmarshalWriteRequest(data)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]interface{}, 0, len(req.Timeseries))
+	for _, series := range req.Timeseries {
+		var name string
+		dimensions := make(map[string]interface{}, len(series.Labels))
+		for _, label := range series.Labels {
+			if label.Name == metricNameLabel {
+				name = label.Value
+				continue
+			}
+			dimensions[label.Name] = label.Value
+		}
+		if name == "" {
+			continue
+		}
+
+		for _, sample := range series.Samples {
+			// stale 标记等无法入库的数值直接忽略
+			if math.IsNaN(sample.Value) || math.IsInf(sample.Value, 0) {
+				continue
+			}
+			items = append(items, newDocumentItem(name, sample.Value, dimensions, sample.Timestamp))
+		}
+	}
+
+	return newDocument(items), nil
+}
+
+func init() {
+	registerBinaryPayload(RemoteWritePayloadType, DecodeRemoteWrite)
+}
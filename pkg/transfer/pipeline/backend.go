@@ -94,6 +94,11 @@ type BulkHandler interface {
 	Close() error
 }
 
+// BulkFailedHandler : BulkHandler 可选实现，重试耗尽后仍写入失败时回调
+type BulkFailedHandler interface {
+	FlushFailed(results []interface{}, err error)
+}
+
 // BulkManager
 type BulkManager interface {
 	define.Stringer
@@ -250,12 +255,14 @@ func (b *BulkBackendAdapter) flushWithRetries(buffer []interface{}) int {
 	ctx := b.context
 	flushRetries := b.flushRetries
 	interval := b.flushInterval / time.Duration(flushRetries)
+	var lastErr error
 	for i := 0; i <= flushRetries; i++ {
 		n, err := b.handler.Flush(ctx, buffer)
 		if err == nil {
 			logging.Debugf("backend %v flushed %d results", b, n)
 			return n
 		}
+		lastErr = err
 
 		if i < flushRetries {
 			logging.Errorf("backend %v retry after %v because of error %v", b, interval, err)
@@ -269,6 +276,9 @@ func (b *BulkBackendAdapter) flushWithRetries(buffer []interface{}) int {
 		}
 	}
 
+	if handler, ok := b.handler.(BulkFailedHandler); ok {
+		handler.FlushFailed(buffer, lastErr)
+	}
 	return 0
 }
 
@@ -434,11 +434,43 @@ func (b *ConfigBuilder) FrontendProcessor(ctx context.Context) (Node, error) {
 	if err != nil {
 		return nil, errors.Wrapf(err, "create frontend by type %v", mqConf.ClusterType)
 	}
+
+	err = b.setupPayloadCreator(ctx, frontend)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 	frontendProcessor := NewFrontendNode(ctx, cancel, frontend, b.frontendWaitDelay)
 	return frontendProcessor, nil
 }
 
+// setupPayloadCreator : 按 pipeline 配置替换前端的 payload 格式
+func (b *ConfigBuilder) setupPayloadCreator(ctx context.Context, frontend define.Frontend) error {
+	pipeConfig := config.PipelineConfigFromContext(ctx)
+	if pipeConfig == nil {
+		return nil
+	}
+
+	helper := utils.NewMapHelper(pipeConfig.Option)
+	payloadType, ok := helper.GetString(config.PipelineConfigOptPayloadType)
+	if !ok || payloadType == "" {
+		return nil
+	}
+
+	setter, ok := frontend.(define.PayloadCreatorSetter)
+	if !ok {
+		return errors.Wrapf(define.ErrNotImplemented, "frontend %v not support payload type %s", frontend, payloadType)
+	}
+
+	creator, err := define.NewPayloadCreator(payloadType)
+	if err != nil {
+		return errors.Wrapf(err, "create payload creator by type %s", payloadType)
+	}
+	setter.SetPayloadCreator(creator)
+	return nil
+}
+
 // ConnectFrontend
 func (b *ConfigBuilder) ConnectFrontend(to Node) *ConfigBuilder {
 	if b.frontend == nil {
@@ -496,6 +528,9 @@ func (b *ConfigBuilder) DataProcessor(ctx context.Context, name string) (Node, e
 	if err != nil {
 		return nil, err
 	}
+	if setter, ok := processor.(DeadLetterSetter); ok {
+		setter.SetDeadLetter(NewDeadLetterReporter(ctx, DeadLetterStageETL, processor.String()))
+	}
 	ctx, cancel := context.WithCancel(ctx)
 	return NewProcessNode(ctx, cancel, processor), nil
 }
@@ -543,6 +578,19 @@ func (b *ConfigBuilder) BuildBranching(from Node, allowGluttonous bool, callback
 		b.PipeConfigInitFn(pipeConfig)
 	}
 
+	// 死信后端需要在处理器及写入后端创建前放入context中
+	sink, err := NewDeadLetterSink(ctx)
+	if err != nil {
+		if strictMode {
+			return nil, errors.Wrapf(err, "create dead letter sink failed")
+		}
+		logging.Warnf("pipeline %d create dead letter sink error %v", pipeConfig.DataID, err)
+	}
+	if sink != nil {
+		ctx = DeadLetterSinkIntoContext(ctx, sink)
+		b.ctx = ctx
+	}
+
 	// 遍历rtTable
 	for _, rt := range pipeConfig.ResultTableList {
 		if rt.ResultTable == "" {
@@ -612,5 +660,17 @@ func (b *ConfigBuilder) BuildBranching(from Node, allowGluttonous bool, callback
 	}
 
 	logging.Debugf("pipeline %v layout: %v", pipeConfig.DataID, b)
-	return b.Finish() // 返回一个 NewPipeline(b.context, b.name, exists)
+	pipe, err := b.Finish() // 返回一个 NewPipeline(b.context, b.name, exists)
+	if err != nil {
+		if sink != nil {
+			sink.discard()
+		}
+		return nil, err
+	}
+
+	// 死信后端放在最后，保证在其它节点停止后才停止
+	if sink != nil {
+		pipe.nodes = append(pipe.nodes, sink)
+	}
+	return pipe, nil
 }
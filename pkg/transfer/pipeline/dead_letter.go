@@ -0,0 +1,308 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package pipeline
+
+import (
+	"context"
+	"encoding/base64"
+	stdjson "encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/json"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/logging"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/utils"
+)
+
+// 死信产生的阶段
+const (
+	DeadLetterStageETL     = "etl"
+	DeadLetterStageBackend = "backend"
+)
+
+// DeadLetterResultTable : 死信后端使用的结果表名，file 后端会以此作为文件名
+const DeadLetterResultTable = "dead_letter"
+
+// DeadLetterBufferSize : 死信缓冲区大小，满了之后直接丢弃，不阻塞主流程
+var DeadLetterBufferSize = 1000
+
+// 死信 Payload 的存储格式
+const (
+	DeadLetterPayloadJSON   = "json"
+	DeadLetterPayloadBase64 = "base64"
+)
+
+// DeadLetter : 死信记录，Payload 为原始数据，可直接用于重放
+// JSON 数据原样保存，其余二进制数据(如 remote write/otlp/msgpack)以 base64 保存，由 PayloadType 区分
+type DeadLetter struct {
+	DataID      int    `json:"dataid"`
+	ResultTable string `json:"result_table"`
+	Stage       string `json:"stage"`
+	Name        string `json:"name"`
+	Error       string `json:"error"`
+	Time        int64  `json:"time"`
+	Payload     string `json:"payload"`
+	PayloadType string `json:"payload_type,omitempty"`
+}
+
+// SetPayload : 非 JSON 数据直接转为 string 会破坏其中非法的 UTF-8 字节，需要 base64 编码
+func (l *DeadLetter) SetPayload(data []byte) {
+	if utf8.Valid(data) && stdjson.Valid(data) {
+		l.Payload = string(data)
+		l.PayloadType = DeadLetterPayloadJSON
+		return
+	}
+	l.Payload = base64.StdEncoding.EncodeToString(data)
+	l.PayloadType = DeadLetterPayloadBase64
+}
+
+// GetPayload : 还原原始数据，未记录 PayloadType 的旧死信按 JSON 处理
+func (l *DeadLetter) GetPayload() ([]byte, error) {
+	switch l.PayloadType {
+	case "", DeadLetterPayloadJSON:
+		return []byte(l.Payload), nil
+	case DeadLetterPayloadBase64:
+		return base64.StdEncoding.DecodeString(l.Payload)
+	default:
+		return nil, errors.Errorf("unknown dead letter payload type %s", l.PayloadType)
+	}
+}
+
+// DeadLetterSink : 流水线级别的死信后端，所有结果表共用
+type DeadLetterSink struct {
+	*BackendNode
+	dataID  int
+	inputCh chan define.Payload
+}
+
+// Start : 死信后端的错误不应导致流水线重启，这里使用独立的 killChan
+func (s *DeadLetterSink) Start(killChan chan<- error) {
+	killCh := make(chan error, 1)
+	s.BackendNode.Start(killCh)
+
+	s.waitGroup.Add(1)
+	go func() {
+		defer s.waitGroup.Done()
+		for {
+			select {
+			case err := <-killCh:
+				logging.Errorf("dead letter %v error %v", s, err)
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Send : 非阻塞发送，缓冲区满或已退出时丢弃
+func (s *DeadLetterSink) Send(letter *DeadLetter) bool {
+	data, err := json.Marshal(letter)
+	if err != nil {
+		logging.Warnf("dead letter %v marshal %#v error %v", s, letter, err)
+		return false
+	}
+
+	payload := define.NewJSONPayloadFrom(data, 0)
+	payload.SetTime(time.Now())
+
+	select {
+	case <-s.ctx.Done():
+		return false
+	default:
+	}
+
+	select {
+	case s.inputCh <- payload:
+		return true
+	default:
+		return false
+	}
+}
+
+// discard : 流水线构建失败时释放已创建的后端
+func (s *DeadLetterSink) discard() {
+	s.cancelFn()
+	if err := s.backend.Close(); err != nil {
+		logging.Warnf("dead letter %v close error %v", s, err)
+	}
+}
+
+// NewDeadLetterSink : 根据流水线 option 中的 dead_letter 配置创建死信后端，未配置时返回 nil
+func NewDeadLetterSink(ctx context.Context) (*DeadLetterSink, error) {
+	pipe := config.PipelineConfigFromContext(ctx)
+	if pipe == nil {
+		return nil, errors.Wrapf(define.ErrOperationForbidden, "pipeline config is empty")
+	}
+
+	value, ok := pipe.Option[config.PipelineConfigOptDeadLetter]
+	if !ok || value == nil {
+		return nil, nil
+	}
+
+	shipper := config.NewMetaClusterInfo()
+	if err := mapstructure.Decode(value, shipper); err != nil {
+		return nil, errors.Wrapf(err, "decode dead letter config of %d", pipe.DataID)
+	}
+	if shipper.ClusterType == "" {
+		return nil, errors.Wrapf(define.ErrOperationForbidden, "dead letter cluster type of %d is empty", pipe.DataID)
+	}
+	logging.PanicIf(shipper.Clean())
+
+	table := &config.MetaResultTableConfig{
+		ResultTable: DeadLetterResultTable,
+		Option:      map[string]interface{}{},
+		MultiNum:    1,
+	}
+	ctx = config.ResultTableConfigIntoContext(ctx, table)
+	ctx = config.ShipperConfigIntoContext(ctx, shipper)
+
+	backend, err := newDeadLetterBackend(ctx, shipper.ClusterType)
+	if err != nil {
+		return nil, errors.Wrapf(err, "create dead letter backend by type %v", shipper.ClusterType)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	node := NewBackendNode(ctx, cancel, backend)
+	inputCh := make(chan define.Payload, DeadLetterBufferSize)
+	if err = node.ConnectFrom(inputCh); err != nil {
+		cancel()
+		return nil, err
+	}
+	node.name = fmt.Sprintf("!:%v", backend)
+
+	return &DeadLetterSink{
+		BackendNode: node,
+		dataID:      pipe.DataID,
+		inputCh:     inputCh,
+	}, nil
+}
+
+// newDeadLetterBackend : 部分后端(如 file)创建失败时会 panic，这里统一转为错误
+func newDeadLetterBackend(ctx context.Context, clusterType string) (backend define.Backend, err error) {
+	defer utils.RecoverError(func(e error) {
+		err = e
+	})
+	return define.NewBackend(ctx, clusterType)
+}
+
+// DeadLetterSinkIntoContext :
+func DeadLetterSinkIntoContext(ctx context.Context, sink *DeadLetterSink) context.Context {
+	return context.WithValue(ctx, define.ContextDeadLetterKey, sink)
+}
+
+// DeadLetterSinkFromContext :
+func DeadLetterSinkFromContext(ctx context.Context) *DeadLetterSink {
+	sink, _ := ctx.Value(define.ContextDeadLetterKey).(*DeadLetterSink)
+	return sink
+}
+
+// DeadLetterReporter : 绑定了阶段及结果表的死信上报器，为 nil 时所有操作均为空操作
+type DeadLetterReporter struct {
+	sink        *DeadLetterSink
+	stage       string
+	name        string
+	resultTable string
+	sent        prometheus.Counter
+	dropped     prometheus.Counter
+}
+
+// NewDeadLetterReporter : 流水线未配置死信后端时返回 nil
+func NewDeadLetterReporter(ctx context.Context, stage, name string) *DeadLetterReporter {
+	sink := DeadLetterSinkFromContext(ctx)
+	if sink == nil {
+		return nil
+	}
+
+	resultTable := ""
+	if rt := config.ResultTableConfigFromContext(ctx); rt != nil {
+		resultTable = rt.ResultTable
+	}
+
+	labels := prometheus.Labels{
+		"id":    strconv.Itoa(sink.dataID),
+		"stage": stage,
+	}
+	return &DeadLetterReporter{
+		sink:        sink,
+		stage:       stage,
+		name:        name,
+		resultTable: resultTable,
+		sent:        MonitorDeadLetterSent.With(labels),
+		dropped:     MonitorDeadLetterDropped.With(labels),
+	}
+}
+
+// Report : 上报原始 payload
+func (r *DeadLetterReporter) Report(d define.Payload, err error) {
+	if r == nil || d == nil {
+		return
+	}
+
+	var data []byte
+	if e := d.To(&data); e != nil {
+		logging.Warnf("dead letter %s load %#v error %v", r.name, d, e)
+		r.dropped.Inc()
+		return
+	}
+	r.ReportBytes(data, err)
+}
+
+// ReportData : 原始 payload 已不可得时，上报处理中的数据
+func (r *DeadLetterReporter) ReportData(v interface{}, err error) {
+	if r == nil {
+		return
+	}
+
+	data, e := json.Marshal(v)
+	if e != nil {
+		logging.Warnf("dead letter %s marshal %#v error %v", r.name, v, e)
+		r.dropped.Inc()
+		return
+	}
+	r.ReportBytes(data, err)
+}
+
+// ReportBytes :
+func (r *DeadLetterReporter) ReportBytes(data []byte, err error) {
+	if r == nil {
+		return
+	}
+
+	letter := &DeadLetter{
+		DataID:      r.sink.dataID,
+		ResultTable: r.resultTable,
+		Stage:       r.stage,
+		Name:        r.name,
+		Time:        time.Now().Unix(),
+	}
+	letter.SetPayload(data)
+	if err != nil {
+		letter.Error = err.Error()
+	}
+
+	if r.sink.Send(letter) {
+		r.sent.Inc()
+	} else {
+		r.dropped.Inc()
+	}
+}
+
+// DeadLetterSetter : 需要上报死信的处理器实现该接口，由 ConfigBuilder 注入
+type DeadLetterSetter interface {
+	SetDeadLetter(reporter *DeadLetterReporter)
+}
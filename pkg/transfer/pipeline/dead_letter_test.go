@@ -0,0 +1,146 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package pipeline_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/json"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/pipeline"
+	. "github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/testsuite"
+)
+
+// DeadLetterSuite
+type DeadLetterSuite struct {
+	ETLSuite
+}
+
+// SetupTest
+func (s *DeadLetterSuite) SetupTest() {
+	s.ETLSuite.SetupTest()
+	delete(s.PipelineConfig.Option, config.PipelineConfigOptDeadLetter)
+}
+
+// TestNotConfigured
+func (s *DeadLetterSuite) TestNotConfigured() {
+	sink, err := pipeline.NewDeadLetterSink(s.CTX)
+	s.NoError(err)
+	s.Nil(sink)
+
+	reporter := pipeline.NewDeadLetterReporter(s.CTX, pipeline.DeadLetterStageETL, "x")
+	s.Nil(reporter)
+	reporter.Report(define.NewJSONPayloadFrom([]byte(`{}`), 0), define.ErrType)
+}
+
+// TestEmptyClusterType
+func (s *DeadLetterSuite) TestEmptyClusterType() {
+	s.PipelineConfig.Option[config.PipelineConfigOptDeadLetter] = map[string]interface{}{}
+	_, err := pipeline.NewDeadLetterSink(s.CTX)
+	s.Error(err)
+}
+
+// TestReport
+func (s *DeadLetterSuite) TestReport() {
+	s.PipelineConfig.Option[config.PipelineConfigOptDeadLetter] = map[string]interface{}{
+		"cluster_type": "kafka",
+		"storage_config": map[string]interface{}{
+			"topic": "dead_letter",
+		},
+	}
+
+	received := make(chan define.Payload, 1)
+	backend := NewMockBackend(s.Ctrl)
+	backend.EXPECT().String().Return("mock").AnyTimes()
+	backend.EXPECT().Push(gomock.Any(), gomock.Any()).DoAndReturn(func(d define.Payload, killChan chan<- error) {
+		received <- d
+	})
+	backend.EXPECT().Close().Return(nil)
+
+	newBackend := define.NewBackend
+	defer func() { define.NewBackend = newBackend }()
+	define.NewBackend = func(ctx context.Context, name string) (define.Backend, error) {
+		s.Equal("kafka", name)
+		s.Equal(pipeline.DeadLetterResultTable, config.ResultTableConfigFromContext(ctx).ResultTable)
+		s.Equal("dead_letter", config.ShipperConfigFromContext(ctx).AsKafkaCluster().GetTopic())
+		return backend, nil
+	}
+
+	sink, err := pipeline.NewDeadLetterSink(s.CTX)
+	s.NoError(err)
+	s.NotNil(sink)
+
+	ctx := pipeline.DeadLetterSinkIntoContext(s.CTX, sink)
+	s.Equal(sink, pipeline.DeadLetterSinkFromContext(ctx))
+	reporter := pipeline.NewDeadLetterReporter(ctx, pipeline.DeadLetterStageETL, "flat:1")
+	s.NotNil(reporter)
+
+	sink.Start(s.KillCh)
+	reporter.Report(define.NewJSONPayloadFrom([]byte(`{"key":"x"}`), 0), errors.New("bad record"))
+
+	select {
+	case payload := <-received:
+		var letter pipeline.DeadLetter
+		s.NoError(payload.To(&letter))
+		s.Equal(s.PipelineConfig.DataID, letter.DataID)
+		s.Equal(s.ResultTableConfig.ResultTable, letter.ResultTable)
+		s.Equal(pipeline.DeadLetterStageETL, letter.Stage)
+		s.Equal("flat:1", letter.Name)
+		s.Equal("bad record", letter.Error)
+		s.Equal(`{"key":"x"}`, letter.Payload)
+	case <-time.After(time.Second):
+		s.Fail("dead letter not received")
+	}
+
+	s.NoError(sink.Stop())
+	s.NoError(sink.Wait())
+}
+
+// TestPayload : 二进制数据需要 base64 编码才能无损往返
+func (s *DeadLetterSuite) TestPayload() {
+	cases := []struct {
+		data        []byte
+		payloadType string
+	}{
+		{[]byte(`{"key":"x"}`), pipeline.DeadLetterPayloadJSON},
+		{[]byte{0x0a, 0xff, 0xfe, 0x00}, pipeline.DeadLetterPayloadBase64},
+		{[]byte("not json"), pipeline.DeadLetterPayloadBase64},
+	}
+	for _, c := range cases {
+		var letter pipeline.DeadLetter
+		letter.SetPayload(c.data)
+		s.Equal(c.payloadType, letter.PayloadType)
+
+		data, err := json.Marshal(letter)
+		s.NoError(err)
+		var decoded pipeline.DeadLetter
+		s.NoError(json.Unmarshal(data, &decoded))
+		payload, err := decoded.GetPayload()
+		s.NoError(err)
+		s.Equal(c.data, payload)
+	}
+
+	// 旧版死信没有 payload_type
+	payload, err := (&pipeline.DeadLetter{Payload: `{"key":"x"}`}).GetPayload()
+	s.NoError(err)
+	s.Equal([]byte(`{"key":"x"}`), payload)
+}
+
+// TestDeadLetterSuite
+func TestDeadLetterSuite(t *testing.T) {
+	suite.Run(t, new(DeadLetterSuite))
+}
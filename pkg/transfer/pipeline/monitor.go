@@ -43,6 +43,20 @@ var (
 		Help:      "Pipeline process elapsed seconds",
 		Buckets:   monitor.DefBuckets,
 	}, []string{"id", "cluster"})
+
+	// MonitorDeadLetterSent 写入死信后端的记录数
+	MonitorDeadLetterSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: define.AppName,
+		Name:      "dead_letter_sent_total",
+		Help:      "Dead letter records sent total",
+	}, []string{"id", "stage"})
+
+	// MonitorDeadLetterDropped 死信缓冲区满或流水线退出时丢弃的记录数
+	MonitorDeadLetterDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: define.AppName,
+		Name:      "dead_letter_dropped_total",
+		Help:      "Dead letter records dropped total",
+	}, []string{"id", "stage"})
 )
 
 func NewFrontendProcessorMonitor(pipe *config.PipelineConfig) *define.ProcessorMonitor {
@@ -111,6 +125,8 @@ func NewBackendProcessorMonitor(pipe *config.PipelineConfig, shipper *config.Met
 		labels["target"] = "kafka"
 	} else if shipper.ClusterType == "redis" {
 		labels["target"] = "redis"
+	} else if shipper.ClusterType == "remote_write" {
+		labels["target"] = "remote_write"
 	} else {
 		labels["target"] = "influxdb"
 	}
@@ -128,5 +144,7 @@ func init() {
 		MonitorBulkBackendBufferUsage,
 		MonitorBulkBackendSendDuration,
 		MonitorProcessElapsedDuration,
+		MonitorDeadLetterSent,
+		MonitorDeadLetterDropped,
 	)
 }
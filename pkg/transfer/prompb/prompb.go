@@ -0,0 +1,225 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+// Package prompb : prometheus remote write 协议(prometheus.WriteRequest)的最小子集
+// 仅包含 transfer 需要的 labels/samples 字段，其余字段(metadata/exemplars/histograms)解码时忽略，
+// 避免为了几个消息类型引入整个 prometheus/prometheus 模块
+package prompb
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Label :
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample : Timestamp 为毫秒时间戳
+type Sample struct {
+	Value     float64
+	Timestamp int64
+}
+
+// TimeSeries :
+type TimeSeries struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+// WriteRequest :
+type WriteRequest struct {
+	Timeseries []TimeSeries
+}
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func (l *Label) marshal(b []byte) []byte {
+	if l.Name != "" {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendString(b, l.Name)
+	}
+	if l.Value != "" {
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendString(b, l.Value)
+	}
+	return b
+}
+
+func (s *Sample) marshal(b []byte) []byte {
+	if s.Value != 0 {
+		b = protowire.AppendTag(b, 1, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(s.Value))
+	}
+	if s.Timestamp != 0 {
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(s.Timestamp))
+	}
+	return b
+}
+
+func (ts *TimeSeries) marshal(b []byte) []byte {
+	for i := range ts.Labels {
+		b = appendMessage(b, 1, ts.Labels[i].marshal(nil))
+	}
+	for i := range ts.Samples {
+		b = appendMessage(b, 2, ts.Samples[i].marshal(nil))
+	}
+	return b
+}
+
+// Marshal :
+func (r *WriteRequest) Marshal() ([]byte, error) {
+	var b []byte
+	for i := range r.Timeseries {
+		b = appendMessage(b, 1, r.Timeseries[i].marshal(nil))
+	}
+	return b, nil
+}
+
+// fieldFunc : 处理单个字段，返回 false 表示字段未被识别，需要跳过
+type fieldFunc func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error)
+
+func consumeMessage(b []byte, f fieldFunc) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		n, ok, err := f(num, typ, b)
+		if err != nil {
+			return errors.Wrapf(err, "field %d", num)
+		}
+		if !ok {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+func consumeBytes(typ protowire.Type, b []byte) ([]byte, int, error) {
+	if typ != protowire.BytesType {
+		return nil, 0, errors.Errorf("unexpected wire type %d", typ)
+	}
+	v, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return nil, 0, protowire.ParseError(n)
+	}
+	return v, n, nil
+}
+
+func (l *Label) unmarshal(b []byte) error {
+	return consumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1, 2:
+			v, n, err := consumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			if num == 1 {
+				l.Name = string(v)
+			} else {
+				l.Value = string(v)
+			}
+			return n, true, nil
+		}
+		return 0, false, nil
+	})
+}
+
+func (s *Sample) unmarshal(b []byte) error {
+	return consumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			if typ != protowire.Fixed64Type {
+				return 0, false, errors.Errorf("unexpected wire type %d", typ)
+			}
+			v, n := protowire.ConsumeFixed64(b)
+			if n < 0 {
+				return 0, false, protowire.ParseError(n)
+			}
+			s.Value = math.Float64frombits(v)
+			return n, true, nil
+		case 2:
+			if typ != protowire.VarintType {
+				return 0, false, errors.Errorf("unexpected wire type %d", typ)
+			}
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return 0, false, protowire.ParseError(n)
+			}
+			s.Timestamp = int64(v)
+			return n, true, nil
+		}
+		return 0, false, nil
+	})
+}
+
+func (ts *TimeSeries) unmarshal(b []byte) error {
+	return consumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		switch num {
+		case 1:
+			v, n, err := consumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			var label Label
+			if err := label.unmarshal(v); err != nil {
+				return 0, false, err
+			}
+			ts.Labels = append(ts.Labels, label)
+			return n, true, nil
+		case 2:
+			v, n, err := consumeBytes(typ, b)
+			if err != nil {
+				return 0, false, err
+			}
+			var sample Sample
+			if err := sample.unmarshal(v); err != nil {
+				return 0, false, err
+			}
+			ts.Samples = append(ts.Samples, sample)
+			return n, true, nil
+		}
+		return 0, false, nil
+	})
+}
+
+// Unmarshal :
+func (r *WriteRequest) Unmarshal(b []byte) error {
+	r.Timeseries = r.Timeseries[:0]
+	return consumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, bool, error) {
+		if num != 1 {
+			return 0, false, nil
+		}
+		v, n, err := consumeBytes(typ, b)
+		if err != nil {
+			return 0, false, err
+		}
+		var ts TimeSeries
+		if err := ts.unmarshal(v); err != nil {
+			return 0, false, err
+		}
+		r.Timeseries = append(r.Timeseries, ts)
+		return n, true, nil
+	})
+}
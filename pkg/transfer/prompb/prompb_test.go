@@ -0,0 +1,72 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package prompb_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/prompb"
+)
+
+// PrompbSuite :
+type PrompbSuite struct {
+	suite.Suite
+}
+
+// TestMarshalUnmarshal :
+func (s *PrompbSuite) TestMarshalUnmarshal() {
+	req := prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels:  []prompb.Label{{Name: "__name__", Value: "usage"}, {Name: "host", Value: "127.0.0.1"}},
+				Samples: []prompb.Sample{{Value: 1.5, Timestamp: 1600000000000}, {Value: -1, Timestamp: -1}},
+			},
+			{
+				Labels:  []prompb.Label{{Name: "__name__", Value: "up"}},
+				Samples: []prompb.Sample{{Value: math.Inf(1), Timestamp: 1600000000000}},
+			},
+		},
+	}
+	data, err := req.Marshal()
+	s.NoError(err)
+
+	var decoded prompb.WriteRequest
+	s.NoError(decoded.Unmarshal(data))
+	s.Equal(req, decoded)
+}
+
+// TestUnknownFields : metadata 等未支持的字段会被跳过
+func (s *PrompbSuite) TestUnknownFields() {
+	req := prompb.WriteRequest{Timeseries: []prompb.TimeSeries{{Labels: []prompb.Label{{Name: "a", Value: "b"}}}}}
+	data, err := req.Marshal()
+	s.NoError(err)
+	data = protowire.AppendTag(data, 3, protowire.BytesType)
+	data = protowire.AppendString(data, "metadata")
+
+	var decoded prompb.WriteRequest
+	s.NoError(decoded.Unmarshal(data))
+	s.Equal(req, decoded)
+}
+
+// TestInvalid :
+func (s *PrompbSuite) TestInvalid() {
+	var req prompb.WriteRequest
+	s.Error(req.Unmarshal([]byte{0x0a, 0xff}))
+	s.Error(req.Unmarshal([]byte(`{"a": 1}`)))
+}
+
+// TestPrompbSuite :
+func TestPrompbSuite(t *testing.T) {
+	suite.Run(t, new(PrompbSuite))
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/logging"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/pipeline"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/utils"
 )
 
@@ -77,6 +78,8 @@ type Backend struct {
 	flushInterval   time.Duration // 发送间隔
 	pushOnce        sync.Once
 	payloadListChan chan define.Payload
+	deadLetter      *pipeline.DeadLetterReporter
+	pending         []define.Payload // 未提交的数据，仅在开启死信时记录
 
 	batchSize float64 // 批次最大值
 	count     float64 // 数据条数
@@ -119,6 +122,7 @@ func NewBackend(ctx context.Context, name string) (*Backend, error) {
 		flushInterval:    conf.GetDuration(PayloadRedisFlushInterval),
 		flushRetries:     conf.GetInt(PayloadRedisFlushRetries),
 		count:            float64(0),
+		deadLetter:       pipeline.NewDeadLetterReporter(ctx, pipeline.DeadLetterStageBackend, name),
 	}, err
 }
 
@@ -198,6 +202,7 @@ func (b *Backend) BatchFull() bool {
 func (b *Backend) SendMsg() error {
 	defer func() {
 		b.count = 0
+		b.pending = b.pending[:0]
 	}()
 	var err error
 	// 队列未满
@@ -206,6 +211,7 @@ func (b *Backend) SendMsg() error {
 		res, err := b.pipe.Exec()
 		if err != nil {
 			logging.Errorf("%v push err: %v", b, err)
+			b.reportPending(err)
 			return err
 		}
 		logging.Debugf("%v : %d data had been pushed to %v totally", b, len(res), b.key)
@@ -213,7 +219,9 @@ func (b *Backend) SendMsg() error {
 		// 队列满且超过最大重试次数
 		logging.Warnf("%v is full, pipeline close after %v times", b.key, b.flushRetries)
 		b.CounterFails.Add(b.count)
-		return errors.New("redis is full")
+		err = errors.New("redis is full")
+		b.reportPending(err)
+		return err
 	}
 	// 处理插入错误
 	if err != nil {
@@ -232,6 +240,7 @@ func (b *Backend) handlePayload(payload define.Payload) error {
 	err := payload.To(&message)
 	if err != nil {
 		logging.Warnf("%v load %#v error %v", b, payload, err)
+		b.deadLetter.Report(payload, err)
 		return err
 	}
 	_, err = b.pipe.LPush(b.key, message).Result()
@@ -239,13 +248,24 @@ func (b *Backend) handlePayload(payload define.Payload) error {
 	if err != nil {
 		logging.Errorf("%v lpush %#v to %v error %v", b, payload, b.key, err)
 		b.CounterFails.Add(1)
+		b.deadLetter.Report(payload, err)
 		return err
 	}
 	b.count++
+	if b.deadLetter != nil {
+		b.pending = append(b.pending, payload)
+	}
 
 	return nil
 }
 
+// reportPending : 批次提交失败时，将该批次数据写入死信
+func (b *Backend) reportPending(err error) {
+	for _, p := range b.pending {
+		b.deadLetter.Report(p, err)
+	}
+}
+
 // CleanUp : 清空缓存队列数据
 func (b *Backend) CleanUp() {
 	for p := range b.payloadListChan {
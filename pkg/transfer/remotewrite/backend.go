@@ -0,0 +1,184 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package remotewrite
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/logging"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/prompb"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/utils"
+)
+
+// BackendName :
+const BackendName = "remote_write"
+
+// handleResult : 转换后的序列及其原始 payload，写入失败时用于上报死信
+type handleResult struct {
+	payload define.Payload
+	series  []prompb.TimeSeries
+}
+
+// shardBatch : 分片内的序列及其所属的结果下标
+type shardBatch struct {
+	series []prompb.TimeSeries
+	owners []int
+	err    error
+}
+
+// BulkHandler
+type BulkHandler struct {
+	pipeline.BaseBulkHandler
+	client       *Client
+	shards       int
+	metricPrefix string
+	deadLetter   *pipeline.DeadLetterReporter
+}
+
+// Handle : 将 payload 转换为时序序列
+func (b *BulkHandler) Handle(ctx context.Context, payload define.Payload, killChan chan<- error) (result interface{}, at time.Time, ok bool) {
+	var record Record
+	err := payload.To(&record)
+	if err != nil {
+		logging.Warnf("%v error %v dropped payload %+v", b, err, payload)
+		b.deadLetter.Report(payload, err)
+		return nil, time.Time{}, false
+	}
+
+	series := record.AsTimeSeries(b.metricPrefix)
+	if len(series) == 0 {
+		logging.Warnf("%v dropped payload %+v for metric is empty", b, payload)
+		return nil, time.Time{}, false
+	}
+
+	return &handleResult{payload: payload, series: series}, utils.ParseTimeStamp(record.Time), true
+}
+
+func (b *BulkHandler) split(results []interface{}) []*shardBatch {
+	batches := make([]*shardBatch, b.shards)
+	for i := range batches {
+		batches[i] = &shardBatch{}
+	}
+
+	for index, value := range results {
+		for _, series := range value.(*handleResult).series {
+			batch := batches[seriesHash(series.Labels)%uint64(b.shards)]
+			batch.series = append(batch.series, series)
+			batch.owners = append(batch.owners, index)
+		}
+	}
+	return batches
+}
+
+// Flush : 按序列哈希分片并发写入，可重试错误已由客户端按指数退避重试
+// 永久失败或重试耗尽的分片不再交由上层重试，其序列所属的 payload 上报死信，避免已写入的分片重复写入
+func (b *BulkHandler) Flush(ctx context.Context, results []interface{}) (int, error) {
+	var wg sync.WaitGroup
+
+	batches := b.split(results)
+	for shard, batch := range batches {
+		if len(batch.series) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func(shard int, batch *shardBatch) {
+			defer wg.Done()
+			batch.err = b.client.Write(ctx, shard, batch.series)
+			if batch.err != nil {
+				logging.Errorf("%v write %d series to shard %d failed: %v", b, len(batch.series), shard, batch.err)
+			}
+		}(shard, batch)
+	}
+	wg.Wait()
+
+	// 同一 payload 的序列可能分布在多个分片，只上报一次
+	failed := make(map[int]error)
+	for _, batch := range batches {
+		if batch.err == nil {
+			continue
+		}
+		for _, index := range batch.owners {
+			if _, ok := failed[index]; !ok {
+				failed[index] = batch.err
+			}
+		}
+	}
+
+	for index, err := range failed {
+		b.deadLetter.Report(results[index].(*handleResult).payload, err)
+	}
+	return len(results) - len(failed), nil
+}
+
+// SetDeadLetter :
+func (b *BulkHandler) SetDeadLetter(reporter *pipeline.DeadLetterReporter) {
+	b.deadLetter = reporter
+}
+
+// Close :
+func (b *BulkHandler) Close() error {
+	b.client.client.CloseIdleConnections()
+	return nil
+}
+
+// NewBulkHandler :
+func NewBulkHandler(shipper *config.MetaClusterInfo) *BulkHandler {
+	cluster := shipper.AsRemoteWriteCluster()
+	client := NewClient(shipper)
+	logging.Infof("remote write connect to %v with %d shards", cluster.GetEndpoints(), cluster.GetShards())
+
+	return &BulkHandler{
+		client:       client,
+		shards:       cluster.GetShards(),
+		metricPrefix: cluster.GetMetricPrefix(),
+	}
+}
+
+// Backend :
+type Backend struct {
+	*pipeline.BulkBackendAdapter
+}
+
+// NewBackend :
+func NewBackend(ctx context.Context, name string, maxQps int) (*Backend, error) {
+	bulk := NewBulkHandler(config.ShipperConfigFromContext(ctx))
+	bulk.SetDeadLetter(pipeline.NewDeadLetterReporter(ctx, pipeline.DeadLetterStageBackend, name))
+
+	return &Backend{
+		BulkBackendAdapter: pipeline.NewBulkBackendDefaultAdapter(ctx, name, bulk, maxQps),
+	}, nil
+}
+
+func init() {
+	define.RegisterBackend(BackendName, func(ctx context.Context, name string) (define.Backend, error) {
+		if config.FromContext(ctx) == nil {
+			return nil, errors.Wrapf(define.ErrOperationForbidden, "config is empty")
+		}
+		if config.ShipperConfigFromContext(ctx) == nil {
+			return nil, errors.Wrapf(define.ErrOperationForbidden, "shipper config is empty")
+		}
+		pipeConfig := config.PipelineConfigFromContext(ctx)
+		if pipeConfig == nil {
+			return nil, errors.Wrapf(define.ErrOperationForbidden, "pipeline config is empty")
+		}
+
+		options := utils.NewMapHelper(pipeConfig.Option)
+		maxQps, _ := options.GetInt(config.PipelineConfigOptMaxQps)
+		return NewBackend(ctx, pipeConfig.FormatName(name), maxQps)
+	})
+}
@@ -0,0 +1,392 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package remotewrite_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/prompb"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/remotewrite"
+	. "github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/testsuite"
+)
+
+// receiver : 记录收到的 remote write 请求
+type receiver struct {
+	*httptest.Server
+	lock     sync.Mutex
+	status   []int
+	requests int32
+	series   []prompb.TimeSeries
+	headers  []http.Header
+}
+
+func (r *receiver) handle(w http.ResponseWriter, req *http.Request) {
+	index := int(atomic.AddInt32(&r.requests, 1)) - 1
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.headers = append(r.headers, req.Header.Clone())
+
+	if index < len(r.status) && r.status[index] != http.StatusNoContent {
+		w.WriteHeader(r.status[index])
+		return
+	}
+
+	compressed, err := io.ReadAll(req.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var wr prompb.WriteRequest
+	if err = wr.Unmarshal(data); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.series = append(r.series, wr.Timeseries...)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newReceiver(status ...int) *receiver {
+	r := &receiver{status: status}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.handle))
+	return r
+}
+
+// BackendSuite
+type BackendSuite struct {
+	ETLSuite
+	storageConfig map[string]interface{}
+	authInfo      map[string]interface{}
+}
+
+// SetupTest :
+func (s *BackendSuite) SetupTest() {
+	s.ETLSuite.SetupTest()
+	s.storageConfig = s.ShipperConfig.StorageConfig
+	s.authInfo = s.ShipperConfig.AuthInfo
+	s.ShipperConfig.StorageConfig = map[string]interface{}{
+		"min_backoff": "1ms",
+		"max_backoff": "2ms",
+	}
+	s.ShipperConfig.AuthInfo = map[string]interface{}{}
+}
+
+// TearDownTest :
+func (s *BackendSuite) TearDownTest() {
+	s.ShipperConfig.StorageConfig = s.storageConfig
+	s.ShipperConfig.AuthInfo = s.authInfo
+	s.ETLSuite.TearDownTest()
+}
+
+func (s *BackendSuite) setEndpoints(receivers ...*receiver) {
+	endpoints := make([]string, 0, len(receivers))
+	for _, r := range receivers {
+		endpoints = append(endpoints, r.URL+"/api/v1/write")
+	}
+	s.ShipperConfig.AsRemoteWriteCluster().SetEndpoints(endpoints)
+}
+
+func (s *BackendSuite) handle(handler *remotewrite.BulkHandler, data ...string) []interface{} {
+	results := make([]interface{}, 0, len(data))
+	for _, d := range data {
+		result, _, ok := handler.Handle(s.CTX, define.NewJSONPayloadFrom([]byte(d), 0), s.KillCh)
+		if ok {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// deadLetters : 启动死信 sink，返回绑定到 backend 阶段的上报器及收到的死信
+func (s *BackendSuite) deadLetters() (*pipeline.DeadLetterReporter, <-chan pipeline.DeadLetter, func()) {
+	s.Stubs.Stub(&s.PipelineConfig.Option, map[string]interface{}{
+		config.PipelineConfigOptDeadLetter: map[string]interface{}{
+			"cluster_type": "file",
+		},
+	})
+
+	received := make(chan pipeline.DeadLetter, 10)
+	backend := NewMockBackend(s.Ctrl)
+	backend.EXPECT().String().Return("file").AnyTimes()
+	backend.EXPECT().Push(gomock.Any(), gomock.Any()).DoAndReturn(func(d define.Payload, killChan chan<- error) {
+		var letter pipeline.DeadLetter
+		s.NoError(d.To(&letter))
+		received <- letter
+	}).AnyTimes()
+	backend.EXPECT().Close().Return(nil)
+	s.Stubs.Stub(&define.NewBackend, func(ctx context.Context, name string) (define.Backend, error) {
+		return backend, nil
+	})
+
+	sink, err := pipeline.NewDeadLetterSink(s.CTX)
+	s.NoError(err)
+	sink.Start(s.KillCh)
+
+	ctx := pipeline.DeadLetterSinkIntoContext(s.CTX, sink)
+	return pipeline.NewDeadLetterReporter(ctx, pipeline.DeadLetterStageBackend, "remote_write"), received, func() {
+		s.NoError(sink.Stop())
+		s.NoError(sink.Wait())
+	}
+}
+
+// TestRecord
+func (s *BackendSuite) TestRecord() {
+	record := remotewrite.Record{
+		Time: 1547616480,
+		Dimensions: map[string]interface{}{
+			"bk_biz_id": 2,
+			"0ip":       "127.0.0.1",
+			"empty":     "",
+			"device.id": nil,
+		},
+		Metrics: map[string]interface{}{
+			"cpu.usage": 1.5,
+			"string":    "4",
+			"invalid":   "x",
+			"null":      nil,
+		},
+	}
+
+	series := record.AsTimeSeries("bk_")
+	s.Len(series, 2)
+
+	values := make(map[string]float64)
+	for _, ts := range series {
+		s.Len(ts.Labels, 3)
+		s.Equal(prompb.Label{Name: "_0ip", Value: "127.0.0.1"}, ts.Labels[0])
+		s.Equal("__name__", ts.Labels[1].Name)
+		s.Equal(prompb.Label{Name: "bk_biz_id", Value: "2"}, ts.Labels[2])
+		s.Len(ts.Samples, 1)
+		s.Equal(int64(1547616480000), ts.Samples[0].Timestamp)
+		values[ts.Labels[1].Value] = ts.Samples[0].Value
+	}
+	s.Equal(map[string]float64{"bk_cpu_usage": 1.5, "bk_string": 4}, values)
+}
+
+// TestRecordLabelsConflict
+func (s *BackendSuite) TestRecordLabelsConflict() {
+	record := remotewrite.Record{
+		Time: 1547616480,
+		Dimensions: map[string]interface{}{
+			"a.b":      "1",
+			"a_b":      "2",
+			"a-b":      "3",
+			"c.d":      "4",
+			"c-d":      "5",
+			"__name__": "x",
+		},
+		Metrics: map[string]interface{}{
+			"usage": 1,
+		},
+	}
+
+	for i := 0; i < 10; i++ {
+		series := record.AsTimeSeries("")
+		s.Len(series, 1)
+		s.Equal([]prompb.Label{
+			{Name: "__name__", Value: "usage"},
+			{Name: "a_b", Value: "2"},
+			{Name: "c_d", Value: "5"},
+		}, series[0].Labels)
+	}
+}
+
+// TestFlushSharding
+func (s *BackendSuite) TestFlushSharding() {
+	r1, r2 := newReceiver(), newReceiver()
+	defer r1.Close()
+	defer r2.Close()
+	s.setEndpoints(r1, r2)
+
+	cluster := s.ShipperConfig.AsRemoteWriteCluster()
+	cluster.StorageConfigHelper.Set("shards", 4)
+	cluster.StorageConfigHelper.Set("headers", map[string]interface{}{"X-Scope-OrgID": "tenant"})
+	cluster.AuthInfoHelper.Set("username", "user")
+	cluster.AuthInfoHelper.Set("password", "pass")
+
+	handler := remotewrite.NewBulkHandler(s.ShipperConfig)
+	data := make([]string, 0)
+	for i := 0; i < 20; i++ {
+		data = append(data, fmt.Sprintf(`{"time":1547616480,"dimensions":{"index":"%d"},"metrics":{"usage":%d}}`, i, i))
+	}
+
+	for round := 0; round < 2; round++ {
+		n, err := handler.Flush(s.CTX, s.handle(handler, data...))
+		s.NoError(err)
+		s.Equal(len(data), n)
+	}
+	s.NoError(handler.Close())
+
+	s.Equal(2*len(data), len(r1.series)+len(r2.series))
+	s.NotEmpty(r1.series)
+	s.NotEmpty(r2.series)
+
+	// 相同序列总是写入同一个 endpoint
+	seen := make(map[string]int)
+	for i, r := range []*receiver{r1, r2} {
+		for _, ts := range r.series {
+			key := fmt.Sprint(ts.Labels)
+			if owner, ok := seen[key]; ok {
+				s.Equal(owner, i)
+			}
+			seen[key] = i
+		}
+	}
+	s.Len(seen, len(data))
+
+	for _, header := range append(r1.headers, r2.headers...) {
+		s.Equal("snappy", header.Get("Content-Encoding"))
+		s.Equal("application/x-protobuf", header.Get("Content-Type"))
+		s.Equal("0.1.0", header.Get("X-Prometheus-Remote-Write-Version"))
+		s.Equal("tenant", header.Get("X-Scope-OrgID"))
+		s.NotEmpty(header.Get("Authorization"))
+	}
+}
+
+// TestFlushRetry
+func (s *BackendSuite) TestFlushRetry() {
+	r := newReceiver(http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	defer r.Close()
+	s.setEndpoints(r)
+
+	handler := remotewrite.NewBulkHandler(s.ShipperConfig)
+	n, err := handler.Flush(s.CTX, s.handle(handler, `{"time":1547616480,"dimensions":{},"metrics":{"usage":1}}`))
+	s.NoError(err)
+	s.Equal(1, n)
+	s.Equal(int32(3), r.requests)
+	s.Len(r.series, 1)
+}
+
+// TestFlushFailed : 永久失败及重试耗尽的数据均上报死信
+func (s *BackendSuite) TestFlushFailed() {
+	reporter, received, stop := s.deadLetters()
+	defer stop()
+
+	cases := []struct {
+		status   int
+		requests int32
+	}{
+		{http.StatusBadRequest, 1},
+		{http.StatusInternalServerError, 3},
+	}
+
+	data := `{"time":1547616480,"dimensions":{},"metrics":{"usage":1,"count":2}}`
+	for _, c := range cases {
+		r := newReceiver(c.status, c.status, c.status)
+		s.setEndpoints(r)
+		s.ShipperConfig.AsRemoteWriteCluster().StorageConfigHelper.Set("max_retries", 2)
+
+		handler := remotewrite.NewBulkHandler(s.ShipperConfig)
+		handler.SetDeadLetter(reporter)
+		n, err := handler.Flush(s.CTX, s.handle(handler, data))
+		s.Equal(0, n)
+		s.NoError(err)
+		s.Equal(c.requests, r.requests)
+		r.Close()
+
+		select {
+		case letter := <-received:
+			s.Equal(pipeline.DeadLetterStageBackend, letter.Stage)
+			s.Contains(letter.Error, fmt.Sprint(c.status))
+			payload, err := letter.GetPayload()
+			s.NoError(err)
+			s.JSONEq(data, string(payload))
+		case <-time.After(time.Second):
+			s.Fail("dead letter not received")
+		}
+		s.Len(received, 0)
+	}
+}
+
+// TestFlushPartialFailed : 只有写入失败分片所属的数据上报死信
+func (s *BackendSuite) TestFlushPartialFailed() {
+	reporter, received, stop := s.deadLetters()
+	defer stop()
+
+	ok, failed := newReceiver(), newReceiver(http.StatusBadRequest)
+	defer ok.Close()
+	defer failed.Close()
+	s.setEndpoints(ok, failed)
+	s.ShipperConfig.AsRemoteWriteCluster().StorageConfigHelper.Set("shards", 2)
+
+	handler := remotewrite.NewBulkHandler(s.ShipperConfig)
+	handler.SetDeadLetter(reporter)
+	data := make([]string, 0)
+	for i := 0; i < 20; i++ {
+		data = append(data, fmt.Sprintf(`{"time":1547616480,"dimensions":{"index":"%d"},"metrics":{"usage":%d}}`, i, i))
+	}
+
+	n, err := handler.Flush(s.CTX, s.handle(handler, data...))
+	s.NoError(err)
+	s.Equal(len(ok.series), n)
+	s.True(n > 0 && n < len(data))
+	s.Equal(int32(1), failed.requests)
+
+	s.Eventually(func() bool {
+		return len(received) == len(data)-n
+	}, time.Second, time.Millisecond)
+}
+
+// TestBackend
+func (s *BackendSuite) TestBackend() {
+	r := newReceiver()
+	defer r.Close()
+	s.setEndpoints(r)
+
+	s.Stubs.Stub(&pipeline.BulkDefaultBufferSize, 2)
+	s.Stubs.Stub(&pipeline.BulkDefaultFlushInterval, time.Hour)
+
+	backend, err := define.NewBackend(s.CTX, remotewrite.BackendName)
+	s.NoError(err)
+	s.CheckKillChan(s.KillCh)
+
+	cases := []string{
+		`{"time":1547616480,"dimensions":{"index":"0"},"metrics":{"int":1,"float":2.3}}`,
+		`{"time":1547616481,"dimensions":{"index":"1"},"metrics":{}}`,
+		`{"time":1547616482,"dimensions":{"index":"2"},"metrics":{"int":1}}`,
+	}
+	for _, c := range cases {
+		backend.Push(define.NewJSONPayloadFrom([]byte(c), 0), s.KillCh)
+	}
+
+	s.Eventually(func() bool {
+		return atomic.LoadInt32(&r.requests) == 1
+	}, time.Second, time.Millisecond)
+	s.NoError(backend.Close())
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	s.Len(r.series, 3)
+}
+
+// TestBackendSuite :
+func TestBackendSuite(t *testing.T) {
+	suite.Run(t, new(BackendSuite))
+}
@@ -0,0 +1,139 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/prompb"
+)
+
+const maxErrorBodySize = 512
+
+// recoverableError : 可重试的错误，如 5xx 及 429
+type recoverableError struct {
+	error
+}
+
+// Client : remote write 客户端，分片按顺序映射到各个 endpoint
+type Client struct {
+	endpoints  []string
+	headers    map[string]string
+	username   string
+	password   string
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	client     *http.Client
+}
+
+// String :
+func (c *Client) String() string {
+	return fmt.Sprintf("remote_write%v", c.endpoints)
+}
+
+func (c *Client) endpoint(shard int) string {
+	return c.endpoints[shard%len(c.endpoints)]
+}
+
+func (c *Client) send(ctx context.Context, endpoint string, data []byte) error {
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+	req = req.WithContext(ctx)
+
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", fmt.Sprintf("bkmonitor-transfer/%s", define.Version))
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return recoverableError{err}
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	err = errors.Errorf("server %s returned HTTP status %s: %s", endpoint, resp.Status, bytes.TrimSpace(body))
+	if resp.StatusCode/100 == 5 || resp.StatusCode == http.StatusTooManyRequests {
+		return recoverableError{err}
+	}
+	return backoff.Permanent(err)
+}
+
+// Write : 将序列编码为 snappy 压缩的 WriteRequest 写入分片对应的 endpoint，可重试错误按指数退避重试
+func (c *Client) Write(ctx context.Context, shard int, series []prompb.TimeSeries) error {
+	req := &prompb.WriteRequest{Timeseries: series}
+	data, err := req.Marshal()
+	if err != nil {
+		return errors.Wrapf(err, "marshal write request")
+	}
+	compressed := snappy.Encode(nil, data)
+	endpoint := c.endpoint(shard)
+
+	bf := backoff.NewExponentialBackOff()
+	bf.InitialInterval = c.minBackoff
+	bf.MaxInterval = c.maxBackoff
+	bf.MaxElapsedTime = 0
+
+	return backoff.Retry(func() error {
+		return c.send(ctx, endpoint, compressed)
+	}, backoff.WithContext(backoff.WithMaxRetries(bf, uint64(c.maxRetries)), ctx))
+}
+
+// NewClient :
+func NewClient(shipper *config.MetaClusterInfo) *Client {
+	cluster := shipper.AsRemoteWriteCluster()
+	var username, password string
+	if value, ok := cluster.AuthInfoHelper.GetString("username"); ok {
+		username = value
+	}
+	if value, ok := cluster.AuthInfoHelper.GetString("password"); ok {
+		password = value
+	}
+
+	return &Client{
+		endpoints:  cluster.GetEndpoints(),
+		headers:    cluster.GetHeaders(),
+		username:   username,
+		password:   password,
+		maxRetries: cluster.GetMaxRetries(),
+		minBackoff: cluster.GetMinBackoff(),
+		maxBackoff: cluster.GetMaxBackoff(),
+		client: &http.Client{
+			Timeout: cluster.GetTimeout(),
+		},
+	}
+}
@@ -0,0 +1,133 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package remotewrite
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/cstockton/go-conv"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/prompb"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/utils"
+)
+
+const metricNameLabel = "__name__"
+
+// Record :
+type Record struct {
+	Time       int64                  `json:"time"`
+	Dimensions map[string]interface{} `json:"dimensions"`
+	Metrics    map[string]interface{} `json:"metrics"`
+}
+
+// GetLabels : 维度转换为标签，空值维度及与指标名同名的维度会被忽略
+// 不同维度清洗后可能同名(如 a.b 与 a_b)，优先保留原名即为合法标签名的维度，其次保留原名较小的维度，保证结果稳定
+func (r *Record) GetLabels() []prompb.Label {
+	labels := make([]prompb.Label, 0, len(r.Dimensions)+1)
+	origins := make(map[string]string, len(r.Dimensions))
+	positions := make(map[string]int, len(r.Dimensions))
+	for key, value := range r.Dimensions {
+		if value == nil {
+			continue
+		}
+		val := conv.String(value)
+		if val == "" {
+			continue
+		}
+		name := sanitizeName(key, false)
+		if name == metricNameLabel {
+			continue
+		}
+
+		origin, ok := origins[name]
+		if !ok {
+			origins[name] = key
+			positions[name] = len(labels)
+			labels = append(labels, prompb.Label{Name: name, Value: val})
+			continue
+		}
+		if origin == name || (key != name && key > origin) {
+			continue
+		}
+		origins[name] = key
+		labels[positions[name]].Value = val
+	}
+	return labels
+}
+
+// AsTimeSeries : 每个指标转换为一条序列，时间戳精度为毫秒
+func (r *Record) AsTimeSeries(prefix string) []prompb.TimeSeries {
+	ts := utils.ParseTimeStamp(r.Time).UnixNano() / 1e6
+	labels := r.GetLabels()
+
+	series := make([]prompb.TimeSeries, 0, len(r.Metrics))
+	for name, value := range r.Metrics {
+		if value == nil {
+			continue
+		}
+		val, err := conv.DefaultConv.Float64(value)
+		if err != nil || math.IsNaN(val) || math.IsInf(val, 0) {
+			continue
+		}
+
+		seriesLabels := make([]prompb.Label, 0, len(labels)+1)
+		seriesLabels = append(seriesLabels, prompb.Label{
+			Name:  metricNameLabel,
+			Value: sanitizeName(prefix+name, true),
+		})
+		seriesLabels = append(seriesLabels, labels...)
+		sort.Slice(seriesLabels, func(i, j int) bool {
+			return seriesLabels[i].Name < seriesLabels[j].Name
+		})
+
+		series = append(series, prompb.TimeSeries{
+			Labels:  seriesLabels,
+			Samples: []prompb.Sample{{Value: val, Timestamp: ts}},
+		})
+	}
+	return series
+}
+
+// sanitizeName : 非法字符替换为下划线，指标名允许使用冒号
+func sanitizeName(name string, isMetric bool) string {
+	var builder strings.Builder
+	builder.Grow(len(name) + 1)
+	for i, c := range name {
+		valid := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (isMetric && c == ':')
+		if i > 0 {
+			valid = valid || (c >= '0' && c <= '9')
+		} else if c >= '0' && c <= '9' {
+			builder.WriteByte('_')
+			valid = true
+		}
+
+		if valid {
+			builder.WriteRune(c)
+		} else {
+			builder.WriteByte('_')
+		}
+	}
+	return builder.String()
+}
+
+// seriesHash : 根据标签计算序列哈希，相同序列总是落在同一分片
+func seriesHash(labels []prompb.Label) uint64 {
+	digest := xxhash.New()
+	for _, label := range labels {
+		_, _ = digest.WriteString(label.Name)
+		_, _ = digest.Write([]byte{0xff})
+		_, _ = digest.WriteString(label.Value)
+		_, _ = digest.Write([]byte{0xff})
+	}
+	return digest.Sum64()
+}
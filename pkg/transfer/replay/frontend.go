@@ -0,0 +1,297 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/pkg/errors"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/json"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/kafka"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/logging"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/pipeline"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/utils"
+)
+
+// FrontendType : 回放使用的前端类型
+const FrontendType = "replay"
+
+// NewKafkaClient : 便于测试替换
+var NewKafkaClient = sarama.NewClient
+
+// KafkaIdleTimeout : 分区高水位已超过结束位点且该时间内未收到消息时结束读取
+// 被压缩或事务标记占用的位点不会被消费到，不能只依赖读到结束位点前一条消息来结束
+var KafkaIdleTimeout = 10 * time.Second
+
+// Frontend : 从本地文件或 kafka 分区位点区间读取数据，读完即结束
+type Frontend struct {
+	*define.BaseFrontend
+	*define.ProcessorMonitor
+	ctx        context.Context
+	cancelFunc context.CancelFunc
+	replay     *Replay
+	fl         *define.FlowLimiter
+}
+
+// NewFrontend :
+func NewFrontend(rootCtx context.Context, name string) (*Frontend, error) {
+	r := FromContext(rootCtx)
+	if r == nil {
+		return nil, errors.Wrapf(define.ErrOperationForbidden, "replay options not found")
+	}
+
+	rate := r.Rate
+	if rate <= 0 {
+		rate = define.DataIdFlowBytes()
+	}
+	ctx, cancelFunc := context.WithCancel(rootCtx)
+	return &Frontend{
+		BaseFrontend:     define.NewBaseFrontend(name),
+		ProcessorMonitor: pipeline.NewFrontendProcessorMonitor(config.PipelineConfigFromContext(ctx)),
+		ctx:              ctx,
+		cancelFunc:       cancelFunc,
+		replay:           r,
+		fl:               define.NewFlowLimiter(name, rate),
+	}, nil
+}
+
+func (f *Frontend) closeWithLog(what string, fn func() error) {
+	if err := fn(); err != nil {
+		logging.Warnf("%v close %s error: %v", f, what, err)
+	}
+}
+
+// send : 返回 false 表示已关闭，不需要继续读取
+func (f *Frontend) send(data []byte, outputChan chan<- define.Payload) bool {
+	progress := &f.replay.Progress
+	if f.replay.DeadLetter {
+		var letter pipeline.DeadLetter
+		if err := json.Unmarshal(data, &letter); err != nil || letter.Payload == "" {
+			logging.Warnf("%v skip invalid dead letter %s: %v", f, data, err)
+			atomic.AddInt64(&progress.Skipped, 1)
+			return true
+		}
+		payload, err := letter.GetPayload()
+		if err != nil {
+			logging.Warnf("%v skip invalid dead letter payload %s: %v", f, data, err)
+			atomic.AddInt64(&progress.Skipped, 1)
+			return true
+		}
+		data = payload
+	}
+
+	length := len(data)
+	define.LimitRate(length)
+	f.fl.Consume(length)
+
+	payload := f.PayloadCreator()
+	if err := payload.From(data); err != nil {
+		f.CounterFails.Inc()
+		logging.Warnf("%v skip invalid data %s: %v", f, data, err)
+		atomic.AddInt64(&progress.Skipped, 1)
+		return true
+	}
+
+	select {
+	case <-f.ctx.Done():
+		return false
+	case outputChan <- payload:
+	}
+	f.CounterSuccesses.Inc()
+	atomic.AddInt64(&progress.Records, 1)
+	atomic.AddInt64(&progress.Bytes, int64(length))
+	return true
+}
+
+func (f *Frontend) pullFile(outputChan chan<- define.Payload) error {
+	file, err := os.Open(f.replay.File)
+	if err != nil {
+		return errors.Wrapf(err, "open replay file %s failed", f.replay.File)
+	}
+	defer f.closeWithLog("file", file.Close)
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	progress := &f.replay.Progress
+	atomic.StoreInt64(&progress.Total, info.Size())
+	logging.Infof("%v replaying file %s with size %d", f, f.replay.File, info.Size())
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		atomic.AddInt64(&progress.Position, int64(len(line)))
+		data := bytes.TrimSpace(line)
+		if len(data) > 0 && !f.send(data, outputChan) {
+			return nil
+		}
+
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (f *Frontend) pullKafka(outputChan chan<- define.Payload) error {
+	c, err := kafka.NewConsumerConfigFromContext(f.ctx)
+	if err != nil {
+		return errors.WithMessage(err, "make kafka config failed")
+	}
+
+	kafkaConfig := config.MQConfigFromContext(f.ctx).AsKafkaCluster()
+	topic := f.replay.Topic
+	if topic == "" {
+		topic = kafkaConfig.GetTopic()
+	}
+	partition := f.replay.Partition
+	cluster := fmt.Sprintf("%s:%d", kafkaConfig.GetDomain(), kafkaConfig.GetPort())
+
+	client, err := NewKafkaClient([]string{cluster}, c)
+	if err != nil {
+		return errors.Wrapf(err, "connect to kafka %s failed", cluster)
+	}
+	defer f.closeWithLog("kafka client", client.Close)
+
+	start := f.replay.StartOffset
+	if start < 0 {
+		start, err = client.GetOffset(topic, partition, start)
+		if err != nil {
+			return errors.Wrapf(err, "get start offset of %s[%d] failed", topic, partition)
+		}
+	}
+	end := f.replay.EndOffset
+	if end < 0 {
+		end, err = client.GetOffset(topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return errors.Wrapf(err, "get end offset of %s[%d] failed", topic, partition)
+		}
+	}
+
+	progress := &f.replay.Progress
+	logging.Infof("%v replaying kafka %s topic %s[%d] from %d to %d", f, cluster, topic, partition, start, end)
+	if start >= end {
+		return nil
+	}
+	atomic.StoreInt64(&progress.Total, end-start)
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		return err
+	}
+	defer f.closeWithLog("kafka consumer", consumer.Close)
+
+	pc, err := consumer.ConsumePartition(topic, partition, start)
+	if err != nil {
+		return errors.Wrapf(err, "consume %s[%d] from %d failed", topic, partition, start)
+	}
+	defer f.closeWithLog("partition consumer", pc.Close)
+
+	idle := time.NewTimer(KafkaIdleTimeout)
+	defer idle.Stop()
+
+	next := start
+	for {
+		select {
+		case <-f.ctx.Done():
+			return nil
+		case <-idle.C:
+			hwm := pc.HighWaterMarkOffset()
+			if hwm < end {
+				logging.Infof("%v waiting for %s[%d] offset %d, high water mark %d", f, topic, partition, next, hwm)
+				idle.Reset(KafkaIdleTimeout)
+				continue
+			}
+			f.skipOffsets(topic, partition, next, end)
+			return nil
+		case msg, ok := <-pc.Messages():
+			if !ok {
+				return nil
+			}
+			if msg.Offset >= end {
+				f.skipOffsets(topic, partition, next, end)
+				return nil
+			}
+			f.skipOffsets(topic, partition, next, msg.Offset)
+			next = msg.Offset + 1
+			if !f.send(msg.Value, outputChan) {
+				return nil
+			}
+			atomic.StoreInt64(&progress.Position, next-start)
+			if next >= end {
+				return nil
+			}
+			if !idle.Stop() {
+				<-idle.C
+			}
+			idle.Reset(KafkaIdleTimeout)
+		}
+	}
+}
+
+// skipOffsets : 记录 [from, to) 区间内未消费到的位点，如被压缩的消息或事务标记
+func (f *Frontend) skipOffsets(topic string, partition int32, from, to int64) {
+	if from >= to {
+		return
+	}
+	logging.Warnf("%v offsets [%d, %d) of %s[%d] not consumed, maybe compacted or transaction markers", f, from, to, topic, partition)
+	progress := &f.replay.Progress
+	atomic.AddInt64(&progress.Missing, to-from)
+	atomic.AddInt64(&progress.Position, to-from)
+}
+
+// Pull : 读取完毕后直接返回，由流水线在等待一段时间后停止
+func (f *Frontend) Pull(outputChan chan<- define.Payload, killChan chan<- error) {
+	defer utils.RecoverError(func(err error) {
+		logging.Errorf("frontend %v panic by error: %v", f, err)
+	})
+
+	var err error
+	if f.replay.File != "" {
+		err = f.pullFile(outputChan)
+	} else {
+		err = f.pullKafka(outputChan)
+	}
+	if err != nil {
+		logging.Errorf("frontend %v kill by error %v", f, err)
+		killChan <- err
+		return
+	}
+	logging.Infof("frontend %v replay finished: %v", f, f.replay.Progress.Snapshot())
+}
+
+// Close :
+func (f *Frontend) Close() error {
+	f.cancelFunc()
+	return nil
+}
+
+func init() {
+	define.RegisterFrontend(FrontendType, func(ctx context.Context, name string) (define.Frontend, error) {
+		pipeConfig := config.PipelineConfigFromContext(ctx)
+		if pipeConfig == nil {
+			return nil, errors.Wrapf(define.ErrOperationForbidden, "pipeline config is nil")
+		}
+		return NewFrontend(ctx, pipeConfig.FormatName(name))
+	})
+}
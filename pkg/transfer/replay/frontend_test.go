@@ -0,0 +1,165 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package replay_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/kafka"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/replay"
+)
+
+// FrontendSuite :
+type FrontendSuite struct {
+	suite.Suite
+	dir string
+}
+
+// SetupTest :
+func (s *FrontendSuite) SetupTest() {
+	s.dir = s.T().TempDir()
+}
+
+func (s *FrontendSuite) pull(opts replay.Options, content string) ([]map[string]interface{}, *replay.Replay) {
+	opts.File = filepath.Join(s.dir, "input")
+	s.NoError(os.WriteFile(opts.File, []byte(content), 0o644))
+	return s.run(opts, nil)
+}
+
+func (s *FrontendSuite) run(opts replay.Options, mqConfig *config.MetaClusterInfo) ([]map[string]interface{}, *replay.Replay) {
+	pipeConfig := config.NewPipelineConfig()
+	pipeConfig.DataID = 1
+	task := replay.NewReplay(opts)
+	ctx := config.IntoContext(context.Background(), config.NewConfiguration())
+	ctx = config.PipelineConfigIntoContext(ctx, pipeConfig)
+	if mqConfig != nil {
+		ctx = config.MQConfigIntoContext(ctx, mqConfig)
+	}
+	ctx = replay.IntoContext(ctx, task)
+
+	frontend, err := define.NewFrontend(ctx, replay.FrontendType)
+	s.NoError(err)
+
+	outCh := make(chan define.Payload)
+	killCh := make(chan error, 1)
+	go func() {
+		frontend.Pull(outCh, killCh)
+		s.NoError(frontend.Close())
+		close(outCh)
+	}()
+
+	results := make([]map[string]interface{}, 0)
+	for out := range outCh {
+		value := make(map[string]interface{})
+		s.NoError(out.To(&value))
+		results = append(results, value)
+	}
+	s.Len(killCh, 0)
+	return results, task
+}
+
+// TestFile :
+func (s *FrontendSuite) TestFile() {
+	content := "{\"v\": 1}\n\n{\"v\": 2}\n{\"v\": 3}"
+	results, task := s.pull(replay.Options{}, content)
+
+	s.Equal([]map[string]interface{}{{"v": 1.0}, {"v": 2.0}, {"v": 3.0}}, results)
+	progress := task.Progress.Snapshot()
+	s.Equal(int64(3), progress.Records)
+	s.Equal(int64(len(content)), progress.Total)
+	s.Equal(progress.Total, progress.Position)
+	s.Equal(100.0, progress.Percent())
+}
+
+// TestDeadLetter :
+func (s *FrontendSuite) TestDeadLetter() {
+	content := `{"dataid":1,"stage":"etl","error":"x","payload":"{\"v\": 1}"}
+{"dataid":1,"stage":"etl","error":"x"}
+not a dead letter
+{"dataid":1,"stage":"backend","error":"x","payload":"{\"v\": 2}"}
+{"dataid":1,"stage":"backend","error":"x","payload":"eyJ2IjogM30=","payload_type":"base64"}
+{"dataid":1,"stage":"backend","error":"x","payload":"x","payload_type":"unknown"}
+`
+	results, task := s.pull(replay.Options{DeadLetter: true}, content)
+
+	s.Equal([]map[string]interface{}{{"v": 1.0}, {"v": 2.0}, {"v": 3.0}}, results)
+	progress := task.Progress.Snapshot()
+	s.Equal(int64(3), progress.Records)
+	s.Equal(int64(3), progress.Skipped)
+}
+
+// TestKafkaMissingOffsets : 区间内存在无法消费的位点时，高水位超过结束位点后空闲超时结束
+func (s *FrontendSuite) TestKafkaMissingOffsets() {
+	const topic = "replay"
+	broker := sarama.NewMockBroker(s.T(), 1)
+	defer broker.Close()
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(s.T()).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader(topic, 0, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(s.T()).
+			SetOffset(topic, 0, sarama.OffsetOldest, 0).
+			SetOffset(topic, 0, sarama.OffsetNewest, 6),
+		// 位点 2 被压缩，位点 4、5 为事务标记
+		"FetchRequest": sarama.NewMockFetchResponse(s.T(), 1).
+			SetMessage(topic, 0, 0, sarama.StringEncoder(`{"v": 1}`)).
+			SetMessage(topic, 0, 1, sarama.StringEncoder(`{"v": 2}`)).
+			SetMessage(topic, 0, 3, sarama.StringEncoder(`{"v": 3}`)).
+			SetHighWaterMark(topic, 0, 6),
+	})
+
+	newConfig, idleTimeout := kafka.NewKafkaConsumerConfig, replay.KafkaIdleTimeout
+	defer func() {
+		kafka.NewKafkaConsumerConfig, replay.KafkaIdleTimeout = newConfig, idleTimeout
+	}()
+	kafka.NewKafkaConsumerConfig = func(_ define.Configuration, _ map[string]interface{}) (*sarama.Config, error) {
+		return sarama.NewConfig(), nil
+	}
+	replay.KafkaIdleTimeout = 100 * time.Millisecond
+
+	host, port, err := net.SplitHostPort(broker.Addr())
+	s.NoError(err)
+	mqConfig := config.NewMetaClusterInfo()
+	mqConfig.ClusterConfig = map[string]interface{}{"domain_name": host, "port": port}
+	mqConfig.AuthInfo = map[string]interface{}{"username": "", "password": ""}
+
+	results, task := s.run(replay.Options{Topic: topic, EndOffset: 6}, mqConfig)
+
+	s.Equal([]map[string]interface{}{{"v": 1.0}, {"v": 2.0}, {"v": 3.0}}, results)
+	progress := task.Progress.Snapshot()
+	s.Equal(int64(3), progress.Records)
+	s.Equal(int64(3), progress.Missing)
+	s.Equal(int64(6), progress.Total)
+	s.Equal(100.0, progress.Percent())
+}
+
+// TestWithoutOptions :
+func (s *FrontendSuite) TestWithoutOptions() {
+	pipeConfig := config.NewPipelineConfig()
+	ctx := config.PipelineConfigIntoContext(context.Background(), pipeConfig)
+	_, err := define.NewFrontend(ctx, replay.FrontendType)
+	s.Error(err)
+}
+
+// TestFrontend :
+func TestFrontend(t *testing.T) {
+	suite.Run(t, new(FrontendSuite))
+}
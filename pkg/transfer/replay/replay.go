@@ -0,0 +1,89 @@
+// Tencent is pleased to support the open source community by making
+// 蓝鲸智云 - 监控平台 (BlueKing - Monitor) available.
+// Copyright (C) 2022 THL A29 Limited, a Tencent company. All rights reserved.
+// Licensed under the MIT License (the "License"); you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at http://opensource.org/licenses/MIT
+// Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+// an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+// specific language governing permissions and limitations under the License.
+
+package replay
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+)
+
+// Options : 回放参数，File 不为空时从本地文件回放，否则按 kafka 分区位点区间回放
+type Options struct {
+	File        string
+	Topic       string // 为空时使用 data id 配置中的 topic
+	Partition   int32
+	StartOffset int64 // 支持 sarama.OffsetOldest / sarama.OffsetNewest
+	EndOffset   int64 // 不包含该位点，小于 0 时使用启动时分区的最新位点
+	DeadLetter  bool  // 输入为死信记录，回放其中的原始数据
+	Rate        int   // 字节/秒，小于等于 0 时使用 dataid 默认流控
+}
+
+// Progress : 回放进度，所有字段均通过原子操作读写
+type Progress struct {
+	Records  int64 // 已发送的记录数
+	Bytes    int64 // 已发送的字节数
+	Skipped  int64 // 解析失败被跳过的记录数
+	Missing  int64 // kafka 区间内未消费到的位点数，如被压缩的消息或事务标记
+	Position int64 // 已读取的位置，文件为字节数，kafka 为位点数
+	Total    int64 // 需读取的总量，单位同 Position
+}
+
+// Snapshot : 获取当前进度的副本
+func (p *Progress) Snapshot() Progress {
+	return Progress{
+		Records:  atomic.LoadInt64(&p.Records),
+		Bytes:    atomic.LoadInt64(&p.Bytes),
+		Skipped:  atomic.LoadInt64(&p.Skipped),
+		Missing:  atomic.LoadInt64(&p.Missing),
+		Position: atomic.LoadInt64(&p.Position),
+		Total:    atomic.LoadInt64(&p.Total),
+	}
+}
+
+// Percent : 已读取的百分比，总量未知时返回 0
+func (p Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+	return float64(p.Position) * 100 / float64(p.Total)
+}
+
+// String :
+func (p Progress) String() string {
+	return fmt.Sprintf("%.2f%% (%d/%d), records: %d, bytes: %d, skipped: %d, missing: %d",
+		p.Percent(), p.Position, p.Total, p.Records, p.Bytes, p.Skipped, p.Missing)
+}
+
+// Replay : 回放任务，Progress 在流水线运行期间由前端更新
+type Replay struct {
+	Options
+	Progress Progress
+}
+
+// NewReplay :
+func NewReplay(opts Options) *Replay {
+	return &Replay{
+		Options: opts,
+	}
+}
+
+// IntoContext :
+func IntoContext(ctx context.Context, r *Replay) context.Context {
+	return context.WithValue(ctx, define.ContextReplayKey, r)
+}
+
+// FromContext :
+func FromContext(ctx context.Context) *Replay {
+	r, _ := ctx.Value(define.ContextReplayKey).(*Replay)
+	return r
+}
@@ -25,8 +25,14 @@ import (
 type EncodingHandler struct {
 	*define.BaseDataProcessor
 	*define.ProcessorMonitor
-	decoder define.CharSetDecoder
-	strict  bool
+	decoder    define.CharSetDecoder
+	strict     bool
+	deadLetter *pipeline.DeadLetterReporter
+}
+
+// SetDeadLetter :
+func (p *EncodingHandler) SetDeadLetter(reporter *pipeline.DeadLetterReporter) {
+	p.deadLetter = reporter
 }
 
 // Process : process json data
@@ -43,6 +49,7 @@ func (p *EncodingHandler) Process(d define.Payload, outputChan chan<- define.Pay
 	if err != nil && p.strict {
 		logging.Warnf("%v decode %#v error %v", p, d, err)
 		p.CounterFails.Inc()
+		p.deadLetter.Report(d, err)
 		return
 	}
 
@@ -26,8 +26,14 @@ type FlatBatchHandler struct {
 	*define.BaseDataProcessor
 	*define.ProcessorMonitor
 
-	ctx    context.Context
-	schema etl.Schema
+	ctx        context.Context
+	schema     etl.Schema
+	deadLetter *pipeline.DeadLetterReporter
+}
+
+// SetDeadLetter :
+func (p *FlatBatchHandler) SetDeadLetter(reporter *pipeline.DeadLetterReporter) {
+	p.deadLetter = reporter
 }
 
 const (
@@ -87,6 +93,7 @@ func (p *FlatBatchHandler) Process(d define.Payload, outputChan chan<- define.Pa
 	if err != nil {
 		p.CounterFails.Inc()
 		logging.MinuteErrorfSampling(p.String(), "%v convert payload %#v error %v", p, d, err)
+		p.deadLetter.Report(d, err)
 		return
 	}
 
@@ -98,6 +105,7 @@ func (p *FlatBatchHandler) Process(d define.Payload, outputChan chan<- define.Pa
 	}
 
 	handled := 0
+	var failed []failedContainer
 	for _, from := range containers {
 		if bizID, err := from.Get(define.RecordBizID); err == nil {
 			if _, ok := p.DisabledBizIDs[conv.String(bizID)]; ok {
@@ -109,12 +117,14 @@ func (p *FlatBatchHandler) Process(d define.Payload, outputChan chan<- define.Pa
 		err = p.schema.Transform(from, to)
 		if err != nil {
 			logging.MinuteErrorfSampling(p.String(), "%v transform %v error %v", p, d, err)
+			failed = append(failed, failedContainer{container: from, err: err})
 			continue
 		}
 
 		output, err := define.DerivePayload(d, &to)
 		if err != nil {
 			logging.Errorf("%v create payload from %v error: %+v", p, d, err)
+			failed = append(failed, failedContainer{container: from, err: err})
 			continue
 		}
 
@@ -125,9 +135,18 @@ func (p *FlatBatchHandler) Process(d define.Payload, outputChan chan<- define.Pa
 	if handled == 0 {
 		logging.Warnf("%v handle %#v failed", p, d)
 		p.CounterFails.Inc()
+		if len(failed) > 0 {
+			p.deadLetter.Report(d, failed[len(failed)-1].err)
+		}
 	} else {
 		logging.Debugf("%v push %d items from %v", p, handled, d)
 		p.CounterSuccesses.Inc()
+		// 部分成功时只上报失败的条目，去掉 items 后可按原始 map 直接重放
+		for _, f := range failed {
+			values := etl.ContainerToMap(f.container)
+			delete(values, batchKey)
+			p.deadLetter.ReportData(values, f.err)
+		}
 	}
 }
 
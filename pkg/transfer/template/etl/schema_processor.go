@@ -28,8 +28,14 @@ type Decoder func(d define.Payload) ([]etl.Container, error)
 type RecordProcessor struct {
 	*define.BaseDataProcessor
 	*define.ProcessorMonitor
-	Decode Decoder
-	schema etl.Transformer
+	Decode     Decoder
+	schema     etl.Transformer
+	deadLetter *pipeline.DeadLetterReporter
+}
+
+// SetDeadLetter :
+func (p *RecordProcessor) SetDeadLetter(reporter *pipeline.DeadLetterReporter) {
+	p.deadLetter = reporter
 }
 
 // Process : process json data
@@ -38,6 +44,7 @@ func (p *RecordProcessor) Process(d define.Payload, outputChan chan<- define.Pay
 	if err != nil {
 		logging.MinuteErrorfSampling(p.String(), "%v load %#v error %v", p, d, err)
 		p.CounterFails.Inc()
+		p.deadLetter.Report(d, err)
 		return
 	}
 	if len(containers) == 0 {
@@ -47,6 +54,7 @@ func (p *RecordProcessor) Process(d define.Payload, outputChan chan<- define.Pay
 	}
 
 	handled := 0
+	var failed []failedContainer
 	for _, from := range containers {
 		if bizID, err := from.Get(define.RecordBizID); err == nil {
 			if _, ok := p.DisabledBizIDs[conv.String(bizID)]; ok {
@@ -58,12 +66,14 @@ func (p *RecordProcessor) Process(d define.Payload, outputChan chan<- define.Pay
 		err = p.schema.Transform(from, to)
 		if err != nil {
 			logging.MinuteErrorfSampling(p.String(), "%v transform %v error %v", p, d, err)
+			failed = append(failed, failedContainer{container: from, err: err})
 			continue
 		}
 
 		output, err := define.DerivePayload(d, &to)
 		if err != nil {
 			logging.Errorf("%v create payload from %v error: %+v", p, d, err)
+			failed = append(failed, failedContainer{container: from, err: err})
 			continue
 		}
 
@@ -74,12 +84,25 @@ func (p *RecordProcessor) Process(d define.Payload, outputChan chan<- define.Pay
 	if handled == 0 {
 		logging.Warnf("%v handle %#v failed", p, d)
 		p.CounterFails.Inc()
+		if len(failed) > 0 {
+			p.deadLetter.Report(d, failed[len(failed)-1].err)
+		}
 	} else {
 		logging.Debugf("%v push %d items from %v", p, handled, d)
 		p.CounterSuccesses.Inc()
+		// 部分成功时只上报失败的 container，避免重放时重复写入
+		for _, f := range failed {
+			p.deadLetter.ReportData(etl.ContainerToMap(f.container), f.err)
+		}
 	}
 }
 
+// failedContainer : 处理失败的 container 及其错误
+type failedContainer struct {
+	container etl.Container
+	err       error
+}
+
 // NewRecordProcessor :
 func NewRecordProcessor(name string, pipeConfig *config.PipelineConfig, schema etl.Transformer) *RecordProcessor {
 	return NewRecordProcessorWithDecoderFn(name, pipeConfig, schema, etl.NewPayloadDecoder().Decode)
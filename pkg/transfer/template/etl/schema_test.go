@@ -10,12 +10,18 @@
 package etl_test
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/config"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/define"
+	etlpkg "github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/etl"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/json"
+	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/pipeline"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/template/etl"
 	"github.com/TencentBlueKing/bkmonitor-datalink/pkg/transfer/testsuite"
 )
@@ -162,6 +168,129 @@ func (s *ProcessorSuite) TestPreparePlugin() {
 	})
 }
 
+// TestDeadLetter
+func (s *ProcessorSuite) TestDeadLetter() {
+	s.Stubs.Stub(&s.PipelineConfig.Option, map[string]interface{}{
+		config.PipelineConfigOptDeadLetter: map[string]interface{}{
+			"cluster_type": "file",
+		},
+	})
+
+	received := make(chan define.Payload, 1)
+	backend := testsuite.NewMockBackend(s.Ctrl)
+	backend.EXPECT().String().Return("file").AnyTimes()
+	backend.EXPECT().Push(gomock.Any(), gomock.Any()).DoAndReturn(func(d define.Payload, killChan chan<- error) {
+		received <- d
+	})
+	backend.EXPECT().Close().Return(nil)
+	s.Stubs.Stub(&define.NewBackend, func(ctx context.Context, name string) (define.Backend, error) {
+		return backend, nil
+	})
+
+	sink, err := pipeline.NewDeadLetterSink(s.CTX)
+	s.NoError(err)
+	ctx := pipeline.DeadLetterSinkIntoContext(s.CTX, sink)
+	sink.Start(s.KillCh)
+
+	schema, err := etl.NewSchema(ctx)
+	s.NoError(err)
+	processor := etl.NewRecordProcessor("x", s.PipelineConfig, schema)
+	processor.SetDeadLetter(pipeline.NewDeadLetterReporter(ctx, pipeline.DeadLetterStageETL, processor.String()))
+
+	outputCh := make(chan define.Payload, 1)
+	processor.Process(define.NewJSONPayloadFrom([]byte(`{"time": `), 0), outputCh, s.KillCh)
+
+	select {
+	case payload := <-received:
+		var letter pipeline.DeadLetter
+		s.NoError(payload.To(&letter))
+		s.Equal(pipeline.DeadLetterStageETL, letter.Stage)
+		s.Equal(s.ResultTableConfig.ResultTable, letter.ResultTable)
+		s.Equal(pipeline.DeadLetterPayloadBase64, letter.PayloadType)
+		data, err := letter.GetPayload()
+		s.NoError(err)
+		s.Equal(`{"time": `, string(data))
+		s.NotEmpty(letter.Error)
+	case <-time.After(time.Second):
+		s.Fail("dead letter not received")
+	}
+	s.Len(outputCh, 0)
+
+	s.NoError(sink.Stop())
+	s.NoError(sink.Wait())
+}
+
+// keyRejectedTransformer : key 字段等于指定值时返回错误
+type keyRejectedTransformer struct {
+	etlpkg.Transformer
+	key string
+}
+
+// Transform
+func (t *keyRejectedTransformer) Transform(from etlpkg.Container, to etlpkg.Container) error {
+	if key, err := from.Get("key"); err == nil && key == t.key {
+		return define.ErrValue
+	}
+	return t.Transformer.Transform(from, to)
+}
+
+// TestDeadLetterPartial
+func (s *ProcessorSuite) TestDeadLetterPartial() {
+	s.Stubs.Stub(&s.PipelineConfig.Option, map[string]interface{}{
+		config.PipelineConfigOptDeadLetter: map[string]interface{}{
+			"cluster_type": "file",
+		},
+	})
+
+	received := make(chan define.Payload, 1)
+	backend := testsuite.NewMockBackend(s.Ctrl)
+	backend.EXPECT().String().Return("file").AnyTimes()
+	backend.EXPECT().Push(gomock.Any(), gomock.Any()).DoAndReturn(func(d define.Payload, killChan chan<- error) {
+		received <- d
+	})
+	backend.EXPECT().Close().Return(nil)
+	s.Stubs.Stub(&define.NewBackend, func(ctx context.Context, name string) (define.Backend, error) {
+		return backend, nil
+	})
+
+	sink, err := pipeline.NewDeadLetterSink(s.CTX)
+	s.NoError(err)
+	ctx := pipeline.DeadLetterSinkIntoContext(s.CTX, sink)
+	sink.Start(s.KillCh)
+
+	schema, err := etl.NewSchema(ctx)
+	s.NoError(err)
+	transformer := &keyRejectedTransformer{Transformer: schema, key: "y"}
+	processor := etl.NewRecordProcessorWithDecoderFn("x", s.PipelineConfig, transformer, func(d define.Payload) ([]etlpkg.Container, error) {
+		return []etlpkg.Container{
+			etlpkg.MapContainer{"time": 1574233401, "key": "x", "value": 1},
+			etlpkg.MapContainer{"time": 1574233402, "key": "y", "value": 2},
+		}, nil
+	})
+	processor.SetDeadLetter(pipeline.NewDeadLetterReporter(ctx, pipeline.DeadLetterStageETL, processor.String()))
+
+	outputCh := make(chan define.Payload, 2)
+	processor.Process(define.NewJSONPayloadFrom([]byte(`{}`), 0), outputCh, s.KillCh)
+	s.Len(outputCh, 1)
+
+	// 只上报处理失败的 container
+	select {
+	case payload := <-received:
+		var letter pipeline.DeadLetter
+		s.NoError(payload.To(&letter))
+		s.NotEmpty(letter.Error)
+
+		var values map[string]interface{}
+		s.NoError(json.Unmarshal([]byte(letter.Payload), &values))
+		s.Equal("y", values["key"])
+	case <-time.After(time.Second):
+		s.Fail("dead letter not received")
+	}
+
+	s.NoError(sink.Stop())
+	s.NoError(sink.Wait())
+}
+
 // TestProcessorSuite
 func TestProcessorSuite(t *testing.T) {
 	suite.Run(t, new(ProcessorSuite))